@@ -0,0 +1,164 @@
+package sturdyc
+
+import (
+	"container/list"
+	"sync/atomic"
+)
+
+// arcAccessBufferSize is the number of reads that are buffered between the
+// writes to a shard. Once the buffer is full, new reads overwrite the oldest
+// ones, which only makes the recency of the index slightly less accurate.
+const arcAccessBufferSize = 64
+
+// arcEntry is an element of one of the lists of the arcIndex.
+type arcEntry struct {
+	key  string
+	list *list.List
+}
+
+// arcIndex implements the Adaptive Replacement Cache algorithm. Keys that
+// have been read once are kept in t1, and keys that have been read again are
+// moved to t2. Evicted keys are remembered in the ghost lists b1 and b2, and
+// writing one of them again shifts the target size p of t1 towards the list
+// that it was evicted from. The reads happen while the shard only holds a read
+// lock, so they're written to a lossy ring buffer, which is applied to the
+// lists once the shard holds its write lock. This keeps the reads from
+// serializing on a mutex.
+type arcIndex struct {
+	capacity func() int
+	p        int
+	t1       *list.List
+	t2       *list.List
+	b1       *list.List
+	b2       *list.List
+	elements map[string]*list.Element
+	// accesses holds the keys of the reads that haven't been applied yet.
+	accesses   [arcAccessBufferSize]atomic.Pointer[string]
+	nextAccess atomic.Uint64
+}
+
+func newARCIndex(capacity func() int) *arcIndex {
+	return &arcIndex{
+		capacity: capacity,
+		t1:       list.New(),
+		t2:       list.New(),
+		b1:       list.New(),
+		b2:       list.New(),
+		elements: make(map[string]*list.Element),
+	}
+}
+
+// move makes the key the most recently used element of the list.
+func (a *arcIndex) move(element *list.Element, to *list.List) {
+	entry := element.Value.(*arcEntry)
+	entry.list.Remove(element)
+	entry.list = to
+	a.elements[entry.key] = to.PushFront(entry)
+}
+
+func (a *arcIndex) add(key string) {
+	a.drain()
+
+	element, ok := a.elements[key]
+	if !ok {
+		a.elements[key] = a.t1.PushFront(&arcEntry{key: key, list: a.t1})
+		a.trimGhosts()
+		return
+	}
+
+	switch element.Value.(*arcEntry).list {
+	case a.b1:
+		a.p = min(a.p+max(a.b2.Len()/max(a.b1.Len(), 1), 1), a.capacity())
+	case a.b2:
+		a.p = max(a.p-max(a.b1.Len()/max(a.b2.Len(), 1), 1), 0)
+	}
+	a.move(element, a.t2)
+}
+
+func (a *arcIndex) access(key *string) {
+	slot := (a.nextAccess.Add(1) - 1) % arcAccessBufferSize
+	a.accesses[slot].Store(key)
+}
+
+// drain applies the buffered reads, starting with the oldest one. Should be
+// called with the write lock of the shard.
+func (a *arcIndex) drain() {
+	next := a.nextAccess.Load()
+	for i := uint64(0); i < arcAccessBufferSize; i++ {
+		key := a.accesses[(next+i)%arcAccessBufferSize].Swap(nil)
+		if key == nil {
+			continue
+		}
+		element, ok := a.elements[*key]
+		if !ok {
+			continue
+		}
+		if current := element.Value.(*arcEntry).list; current == a.t1 || current == a.t2 {
+			a.move(element, a.t2)
+		}
+	}
+}
+
+func (a *arcIndex) remove(key string) {
+	a.drain()
+
+	element, ok := a.elements[key]
+	if !ok {
+		return
+	}
+	switch element.Value.(*arcEntry).list {
+	case a.t1:
+		a.move(element, a.b1)
+	case a.t2:
+		a.move(element, a.b2)
+	}
+	a.trimGhosts()
+}
+
+// trimGhosts keeps t1 and b1 within the capacity, and all of the
+// lists within twice the capacity. Should be called with the write lock of
+// the shard.
+func (a *arcIndex) trimGhosts() {
+	capacity := a.capacity()
+	for a.b1.Len() > 0 && a.t1.Len()+a.b1.Len() > capacity {
+		a.drop(a.b1.Back())
+	}
+	for a.b2.Len() > 0 && a.t1.Len()+a.t2.Len()+a.b1.Len()+a.b2.Len() > 2*capacity {
+		a.drop(a.b2.Back())
+	}
+}
+
+func (a *arcIndex) drop(element *list.Element) {
+	entry := element.Value.(*arcEntry)
+	entry.list.Remove(element)
+	delete(a.elements, entry.key)
+}
+
+func (a *arcIndex) victims(n int, skip func(key string) bool) []string {
+	a.drain()
+
+	// next returns the least recently used element, starting
+	// at the given one, that shouldn't be skipped.
+	next := func(element *list.Element) *list.Element {
+		for element != nil && skip(element.Value.(*arcEntry).key) {
+			element = element.Prev()
+		}
+		return element
+	}
+
+	keys := make([]string, 0, n)
+	t1Len := a.t1.Len()
+	fromT1, fromT2 := next(a.t1.Back()), next(a.t2.Back())
+	for len(keys) < n && (fromT1 != nil || fromT2 != nil) {
+		// Evict from t1 while it exceeds its target size.
+		if fromT1 != nil && (t1Len > a.p || fromT2 == nil) {
+			keys = append(keys, fromT1.Value.(*arcEntry).key)
+			t1Len--
+			fromT1 = next(fromT1.Prev())
+			continue
+		}
+		keys = append(keys, fromT2.Value.(*arcEntry).key)
+		fromT2 = next(fromT2.Prev())
+	}
+	return keys
+}
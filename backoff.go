@@ -0,0 +1,71 @@
+package sturdyc
+
+import (
+	"math"
+	"time"
+)
+
+// BackoffStrategy determines how the delay grows between the refreshes of a
+// record whose background refresh keeps failing.
+type BackoffStrategy int
+
+const (
+	// ExponentialBackoff doubles the delay for every failed refresh.
+	ExponentialBackoff BackoffStrategy = iota
+	// LinearBackoff adds the base delay for every failed refresh.
+	LinearBackoff
+	// ConstantBackoff always uses the base delay.
+	ConstantBackoff
+)
+
+// ExhaustedPolicy determines what happens to a record once
+// its background refreshes have failed too many times.
+type ExhaustedPolicy int
+
+const (
+	// ExhaustedDelete deletes the record from the cache.
+	ExhaustedDelete ExhaustedPolicy = iota
+	// ExhaustedMarkMissing turns the record into a missing record.
+	ExhaustedMarkMissing
+)
+
+// backoff returns the delay for the given number of retries. The delay is
+// never going to exceed the max, and the calculations can't overflow.
+func (s BackoffStrategy) backoff(baseDelay, maxDelay time.Duration, retries int) time.Duration {
+	switch s {
+	case LinearBackoff:
+		if retries >= int(maxDelay/max(baseDelay, 1)) {
+			return maxDelay
+		}
+		return min(baseDelay*time.Duration(retries+1), maxDelay)
+	case ConstantBackoff:
+		return min(baseDelay, maxDelay)
+	case ExponentialBackoff:
+	}
+	return exponentialBackoff(baseDelay, maxDelay, retries)
+}
+
+// exponentialBackoff doubles the base delay n times without exceeding the max
+// delay. It stops doubling once the max is reached, which prevents overflows.
+func exponentialBackoff(baseDelay, maxDelay time.Duration, n int) time.Duration {
+	delay := baseDelay
+	for i := 0; i < n && delay < maxDelay; i++ {
+		if delay > maxDelay/2 {
+			return maxDelay
+		}
+		delay *= 2
+	}
+	return min(delay, maxDelay)
+}
+
+// refreshRetryDelay returns the delay before a record whose background
+// refresh has failed the given number of times is refreshed again.
+func (c *Config) refreshRetryDelay(retries int) time.Duration {
+	if c.refreshBackoff {
+		return c.refreshBackoffStrategy.backoff(c.refreshBackoffBase, c.refreshBackoffMax, retries)
+	}
+	if c.retryPolicy != nil {
+		return c.retryPolicy.Backoff(retries + 1)
+	}
+	return exponentialBackoff(c.retryBaseDelay, math.MaxInt64, retries)
+}
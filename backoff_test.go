@@ -0,0 +1,154 @@
+package sturdyc_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/viccon/sturdyc"
+)
+
+// assertRefreshDelays makes the refreshes fail, and asserts that the next
+// refresh happens once each of the delays has passed, but not before.
+func assertRefreshDelays(t *testing.T, strategy sturdyc.BackoffStrategy, delays []time.Duration) {
+	t.Helper()
+
+	ctx := context.Background()
+	clock := sturdyc.NewTestClock(time.Now())
+	refreshDelay := time.Second
+	c := sturdyc.New[string](100, 1, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithEarlyRefreshes(refreshDelay, refreshDelay, time.Millisecond),
+		sturdyc.WithRefreshBackoff(time.Second, 4*time.Second, strategy),
+		sturdyc.WithClock(clock),
+	)
+
+	fetchObserver := NewFetchObserver(len(delays) + 2)
+	fetchObserver.Response("1")
+	c.GetOrFetch(ctx, "1", fetchObserver.Fetch)
+	<-fetchObserver.FetchCompleted
+
+	fetchObserver.Err(errors.New("error"))
+	clock.Add(refreshDelay + 1)
+	c.GetOrFetch(ctx, "1", fetchObserver.Fetch)
+	<-fetchObserver.FetchCompleted
+
+	for i, delay := range delays {
+		clock.Add(delay)
+		c.GetOrFetch(ctx, "1", fetchObserver.Fetch)
+		time.Sleep(5 * time.Millisecond)
+		fetchObserver.AssertFetchCount(t, i+2)
+
+		clock.Add(1)
+		c.GetOrFetch(ctx, "1", fetchObserver.Fetch)
+		<-fetchObserver.FetchCompleted
+		fetchObserver.AssertFetchCount(t, i+3)
+	}
+}
+
+func TestExponentialRefreshBackoffIsCapped(t *testing.T) {
+	t.Parallel()
+	assertRefreshDelays(t, sturdyc.ExponentialBackoff, []time.Duration{
+		time.Second, 2 * time.Second, 4 * time.Second, 4 * time.Second,
+	})
+}
+
+func TestLinearRefreshBackoff(t *testing.T) {
+	t.Parallel()
+	assertRefreshDelays(t, sturdyc.LinearBackoff, []time.Duration{
+		time.Second, 2 * time.Second, 3 * time.Second, 4 * time.Second, 4 * time.Second,
+	})
+}
+
+func TestConstantRefreshBackoff(t *testing.T) {
+	t.Parallel()
+	assertRefreshDelays(t, sturdyc.ConstantBackoff, []time.Duration{
+		time.Second, time.Second, time.Second,
+	})
+}
+
+func TestRecordsAreDeletedWhenTheRefreshRetriesAreExhausted(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	clock := sturdyc.NewTestClock(time.Now())
+	refreshDelay := time.Second
+	retryDelay := time.Millisecond
+	maxRetries := 3
+	c := sturdyc.New[string](100, 1, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithEarlyRefreshes(refreshDelay, refreshDelay, retryDelay),
+		sturdyc.WithMaxRefreshRetries(maxRetries, sturdyc.ExhaustedDelete),
+		sturdyc.WithClock(clock),
+	)
+
+	fetchObserver := NewFetchObserver(maxRetries + 2)
+	fetchObserver.Response("1")
+	c.GetOrFetch(ctx, "1", fetchObserver.Fetch)
+	<-fetchObserver.FetchCompleted
+
+	fetchObserver.Err(errors.New("error"))
+	clock.Add(refreshDelay + 1)
+	for i := 0; i < maxRetries; i++ {
+		res, err := c.GetOrFetch(ctx, "1", fetchObserver.Fetch)
+		if err != nil || res != "value1" {
+			t.Fatalf("expected the record to be served while the refreshes are failing, got %q %v", res, err)
+		}
+		<-fetchObserver.FetchCompleted
+		clock.Add(time.Minute)
+	}
+	fetchObserver.AssertFetchCount(t, maxRetries+1)
+
+	if _, ok := c.Get("1"); ok {
+		t.Error("expected the record to be deleted once the refresh retries were exhausted")
+	}
+	if c.Size() != 0 {
+		t.Errorf("expected the cache to be empty, got size %d", c.Size())
+	}
+}
+
+func TestRecordsAreMarkedAsMissingWhenTheRefreshRetriesAreExhausted(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	clock := sturdyc.NewTestClock(time.Now())
+	refreshDelay := time.Second
+	retryDelay := time.Millisecond
+	maxRetries := 2
+	c := sturdyc.New[string](100, 1, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithMissingRecordStorage(),
+		sturdyc.WithEarlyRefreshes(refreshDelay, refreshDelay, retryDelay),
+		sturdyc.WithMaxRefreshRetries(maxRetries, sturdyc.ExhaustedMarkMissing),
+		sturdyc.WithClock(clock),
+	)
+
+	fetchObserver := NewFetchObserver(maxRetries + 2)
+	fetchObserver.Response("1")
+	c.GetOrFetch(ctx, "1", fetchObserver.Fetch)
+	<-fetchObserver.FetchCompleted
+
+	fetchObserver.Err(errors.New("error"))
+	clock.Add(refreshDelay + 1)
+	for i := 0; i < maxRetries; i++ {
+		c.GetOrFetch(ctx, "1", fetchObserver.Fetch)
+		<-fetchObserver.FetchCompleted
+		clock.Add(time.Minute)
+	}
+
+	// The record is marked as missing, but missing records are still refreshed
+	// which means that the record is able to recover.
+	fetchObserver.Err(nil)
+	_, err := c.GetOrFetch(ctx, "1", fetchObserver.Fetch)
+	if !errors.Is(err, sturdyc.ErrMissingRecord) {
+		t.Errorf("expected ErrMissingRecord, got %v", err)
+	}
+	<-fetchObserver.FetchCompleted
+	time.Sleep(10 * time.Millisecond)
+
+	res, err := c.GetOrFetch(ctx, "1", fetchObserver.Fetch)
+	if err != nil || res != "value1" {
+		t.Errorf("expected the record to recover, got %q %v", res, err)
+	}
+}
@@ -0,0 +1,156 @@
+package sturdyc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"sync"
+)
+
+// chunkIDs splits the IDs into chunks that are no larger than the size.
+func chunkIDs(ids []string, size int) [][]string {
+	chunks := make([][]string, 0, (len(ids)+size-1)/size)
+	for size < len(ids) {
+		chunks = append(chunks, ids[:size:size])
+		ids = ids[size:]
+	}
+	return append(chunks, ids)
+}
+
+// chunkedBatchFetch wraps the fetchFn so that the IDs are split into chunks
+// that don't exceed the max batch size. The responses are then merged into a
+// single map. The IDs of the chunks that fail are returned in a BatchError
+// along with the records of the chunks that succeeded. If every chunk fails,
+// the errors from the chunks are joined together instead.
+func chunkedBatchFetch[V, T any](c *Client[T], fetchFn BatchFetchFn[V]) BatchFetchFn[V] {
+	if c.maxBatchSize < 1 {
+		return fetchFn
+	}
+
+	return func(ctx context.Context, ids []string) (map[string]V, error) {
+		if len(ids) <= c.maxBatchSize {
+			return fetchFn(ctx, ids)
+		}
+
+		chunks := chunkIDs(ids, c.maxBatchSize)
+		if c.batchConcurrency > 1 {
+			return fetchChunksConcurrently(ctx, c, chunks, fetchFn)
+		}
+
+		var errs []error
+		response := make(map[string]V, len(ids))
+		idErrors := make(map[string]error)
+		for _, chunk := range chunks {
+			chunkResponse, err := fetchFn(ctx, chunk)
+			if batchErr, ok := asBatchError(err); ok {
+				maps.Copy(idErrors, batchErr.Errors)
+			} else if err != nil {
+				errs = append(errs, err)
+				failChunk(idErrors, chunk, err)
+				continue
+			}
+			maps.Copy(response, chunkResponse)
+		}
+		return mergeChunks(response, idErrors, errs, len(chunks))
+	}
+}
+
+// failChunk records the error for every ID of a chunk that failed.
+func failChunk(idErrors map[string]error, chunk []string, err error) {
+	for _, id := range chunk {
+		idErrors[id] = err
+	}
+}
+
+// mergeChunks returns the records of the chunks that succeeded, along with a
+// BatchError for the IDs that failed. If every chunk failed with an error,
+// the errors are joined together, just like for a batch that isn't chunked.
+func mergeChunks[V any](response map[string]V, idErrors map[string]error, errs []error, numChunks int) (map[string]V, error) {
+	if len(errs) == numChunks {
+		return map[string]V{}, errors.Join(errs...)
+	}
+	if len(idErrors) > 0 {
+		return response, &BatchError{Errors: idErrors}
+	}
+	return response, nil
+}
+
+// fetchChunksConcurrently fetches the chunks using at most
+// batchConcurrency goroutines at once.
+func fetchChunksConcurrently[V, T any](ctx context.Context, c *Client[T], chunks [][]string, fetchFn BatchFetchFn[V]) (map[string]V, error) {
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var errs []error
+	response := make(map[string]V)
+	idErrors := make(map[string]error)
+	semaphore := make(chan struct{}, c.batchConcurrency)
+
+	for _, chunk := range chunks {
+		semaphore <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				if err := recover(); err != nil {
+					mu.Lock()
+					panicErr := fmt.Errorf("sturdyc: panic recovered: %v", err)
+					errs = append(errs, panicErr)
+					failChunk(idErrors, chunk, panicErr)
+					mu.Unlock()
+				}
+				<-semaphore
+				wg.Done()
+			}()
+
+			chunkResponse, err := fetchFn(ctx, chunk)
+			mu.Lock()
+			defer mu.Unlock()
+			if batchErr, ok := asBatchError(err); ok {
+				maps.Copy(idErrors, batchErr.Errors)
+			} else if err != nil {
+				errs = append(errs, err)
+				failChunk(idErrors, chunk, err)
+				return
+			}
+			maps.Copy(response, chunkResponse)
+		}()
+	}
+	wg.Wait()
+	return mergeChunks(response, idErrors, errs, len(chunks))
+}
+
+// asBatchError returns the BatchError if that is what the error is.
+func asBatchError(err error) (*BatchError, bool) {
+	var batchErr *BatchError
+	if errors.As(err, &batchErr) {
+		return batchErr, true
+	}
+	return nil, false
+}
+
+// withReturnedRecords is applied to the error that a batch returns to the
+// caller. A BatchError only unwraps to ErrOnlyCachedRecords if records are
+// returned along with it, as the IDs may have failed in calls that were made
+// by other batches.
+func withReturnedRecords(returned int, err error) error {
+	batchErr, ok := asBatchError(err)
+	if !ok || returned > 0 {
+		return err
+	}
+	return &BatchError{Errors: batchErr.Errors, noRecords: true}
+}
+
+// failedIDsError returns a BatchError for the IDs that failed with an error
+// other than ErrNotFound. If none of them did, it returns nil.
+func failedIDsError(idErrors map[string]error, ids []string) error {
+	failed := make(map[string]error)
+	for _, id := range ids {
+		if err, ok := idErrors[id]; ok && !errors.Is(err, ErrNotFound) {
+			failed[id] = err
+		}
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+	return &BatchError{Errors: failed}
+}
@@ -0,0 +1,408 @@
+package sturdyc_test
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/viccon/sturdyc"
+)
+
+type chunkObserver struct {
+	sync.Mutex
+	sizes []int
+	err   error
+}
+
+func (o *chunkObserver) FetchBatch(_ context.Context, ids []string) (map[string]string, error) {
+	o.Lock()
+	defer o.Unlock()
+	o.sizes = append(o.sizes, len(ids))
+	if o.err != nil {
+		return nil, o.err
+	}
+
+	response := make(map[string]string, len(ids))
+	for _, id := range ids {
+		response[id] = "value" + id
+	}
+	return response, nil
+}
+
+func (o *chunkObserver) assertSizes(t *testing.T, sizes []int) {
+	t.Helper()
+	o.Lock()
+	defer o.Unlock()
+
+	sort.Ints(sizes)
+	sort.Ints(o.sizes)
+	if !cmp.Equal(sizes, o.sizes) {
+		t.Error(cmp.Diff(sizes, o.sizes))
+	}
+}
+
+func createIDs(n int) []string {
+	ids := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		ids = append(ids, strconv.Itoa(i))
+	}
+	return ids
+}
+
+func TestGetOrFetchBatchSplitsMissesIntoChunks(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	c := sturdyc.New[string](100, 2, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithMaxBatchSize(10),
+	)
+
+	ids := createIDs(25)
+	observer := &chunkObserver{}
+	res, err := c.GetOrFetchBatch(ctx, ids, c.BatchKeyFn("item"), observer.FetchBatch)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(res) != len(ids) {
+		t.Errorf("expected %d records, got %d", len(ids), len(res))
+	}
+	for _, id := range ids {
+		if res[id] != "value"+id {
+			t.Errorf("expected value%s, got %s", id, res[id])
+		}
+	}
+	observer.assertSizes(t, []int{10, 10, 5})
+
+	if c.Size() != len(ids) {
+		t.Errorf("expected cache to have %d records, got %d", len(ids), c.Size())
+	}
+}
+
+func TestChunkedBatchFailsIfEveryChunkFails(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	c := sturdyc.New[string](100, 2, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithMaxBatchSize(10),
+	)
+
+	observer := &chunkObserver{err: errors.New("boom")}
+	_, err := c.GetOrFetchBatch(ctx, createIDs(25), c.BatchKeyFn("item"), observer.FetchBatch)
+	if err == nil {
+		t.Fatal("expected an error, got nil")
+	}
+	if c.Size() != 0 {
+		t.Errorf("expected cache to be empty, got %d records", c.Size())
+	}
+}
+
+func TestPassthroughBatchSplitsIDsIntoChunks(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	c := sturdyc.New[string](100, 2, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithMaxBatchSize(4),
+	)
+
+	observer := &chunkObserver{}
+	res, err := c.PassthroughBatch(ctx, createIDs(10), c.BatchKeyFn("item"), observer.FetchBatch)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(res) != 10 {
+		t.Errorf("expected 10 records, got %d", len(res))
+	}
+	observer.assertSizes(t, []int{4, 4, 2})
+}
+
+func TestChunksAreFetchedWithBoundedConcurrency(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	c := sturdyc.New[string](1000, 2, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithMaxBatchSize(5),
+		sturdyc.WithBatchConcurrency(3),
+	)
+
+	var mu sync.Mutex
+	var current, peak, calls int
+	fetchFn := func(_ context.Context, ids []string) (map[string]string, error) {
+		mu.Lock()
+		calls++
+		current++
+		peak = max(peak, current)
+		mu.Unlock()
+
+		time.Sleep(10 * time.Millisecond)
+
+		mu.Lock()
+		current--
+		mu.Unlock()
+
+		response := make(map[string]string, len(ids))
+		for _, id := range ids {
+			response[id] = "value" + id
+		}
+		return response, nil
+	}
+
+	ids := createIDs(50)
+	res, err := c.GetOrFetchBatch(ctx, ids, c.BatchKeyFn("item"), fetchFn)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(res) != len(ids) {
+		t.Errorf("expected %d records, got %d", len(ids), len(res))
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if calls != 10 {
+		t.Errorf("expected 10 calls, got %d", calls)
+	}
+	if peak > 3 {
+		t.Errorf("expected at most 3 concurrent calls, got %d", peak)
+	}
+}
+
+func TestChunkedBatchReturnsTheChunksThatSucceeded(t *testing.T) {
+	t.Parallel()
+
+	for name, concurrency := range map[string]int{"sequential": 1, "concurrent": 2} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			c := sturdyc.New[string](1000, 2, time.Hour, 10,
+				sturdyc.WithNoContinuousEvictions(),
+				sturdyc.WithMaxBatchSize(5),
+				sturdyc.WithBatchConcurrency(concurrency),
+			)
+
+			errFirstChunk := errors.New("first chunk")
+			errLastChunk := errors.New("last chunk")
+			fetchFn := func(_ context.Context, ids []string) (map[string]string, error) {
+				switch ids[0] {
+				case "0":
+					return nil, errFirstChunk
+				case "15":
+					return nil, errLastChunk
+				}
+				response := make(map[string]string, len(ids))
+				for _, id := range ids {
+					response[id] = "value" + id
+				}
+				return response, nil
+			}
+
+			res, err := c.GetOrFetchBatch(ctx, createIDs(20), c.BatchKeyFn("item"), fetchFn)
+			var batchErr *sturdyc.BatchError
+			if !errors.As(err, &batchErr) {
+				t.Fatalf("expected a BatchError, got %v", err)
+			}
+			if len(batchErr.Errors) != 10 || !errors.Is(batchErr.Errors["0"], errFirstChunk) || !errors.Is(batchErr.Errors["19"], errLastChunk) {
+				t.Errorf("expected the IDs of the failed chunks, got %v", batchErr.Errors)
+			}
+			if len(res) != 10 || res["5"] != "value5" {
+				t.Errorf("expected the records of the chunks that succeeded, got %v", res)
+			}
+			if c.Size() != 10 {
+				t.Errorf("expected the records of the chunks that succeeded to be cached, got %d records", c.Size())
+			}
+		})
+	}
+}
+
+func TestConcurrentChunkErrorsAreJoinedIfEveryChunkFails(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	c := sturdyc.New[string](1000, 2, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithMaxBatchSize(10),
+		sturdyc.WithBatchConcurrency(2),
+	)
+
+	errFirstChunk := errors.New("first chunk")
+	errLastChunk := errors.New("last chunk")
+	fetchFn := func(_ context.Context, ids []string) (map[string]string, error) {
+		if ids[0] == "0" {
+			return nil, errFirstChunk
+		}
+		return nil, errLastChunk
+	}
+
+	_, err := c.GetOrFetchBatch(ctx, createIDs(20), c.BatchKeyFn("item"), fetchFn)
+	if !errors.Is(err, errFirstChunk) || !errors.Is(err, errLastChunk) {
+		t.Errorf("expected the errors from both chunks, got %v", err)
+	}
+	if c.Size() != 0 {
+		t.Errorf("expected cache to be empty, got %d records", c.Size())
+	}
+}
+
+func TestBatchErrorStoresTheRecordsThatSucceeded(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	c := sturdyc.New[string](100, 2, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithMissingRecordStorage(),
+	)
+
+	errBadID := errors.New("bad id")
+	fetchFn := func(_ context.Context, ids []string) (map[string]string, error) {
+		response := make(map[string]string, len(ids))
+		for _, id := range ids {
+			response[id] = "value" + id
+		}
+		delete(response, "2")
+		delete(response, "3")
+		return response, &sturdyc.BatchError{Errors: map[string]error{
+			"2": errBadID,
+			"3": sturdyc.ErrNotFound,
+		}}
+	}
+
+	keyFn := c.BatchKeyFn("item")
+	res, err := c.GetOrFetchBatch(ctx, []string{"1", "2", "3", "4"}, keyFn, fetchFn)
+	if !errors.Is(err, sturdyc.ErrOnlyCachedRecords) {
+		t.Errorf("expected ErrOnlyCachedRecords, got %v", err)
+	}
+
+	var batchErr *sturdyc.BatchError
+	if !errors.As(err, &batchErr) {
+		t.Fatalf("expected a BatchError, got %v", err)
+	}
+	if len(batchErr.Errors) != 1 || !errors.Is(batchErr.Errors["2"], errBadID) {
+		t.Errorf("expected only ID 2 to have failed, got %v", batchErr.Errors)
+	}
+
+	if len(res) != 2 || res["1"] != "value1" || res["4"] != "value4" {
+		t.Errorf("expected records 1 and 4, got %v", res)
+	}
+
+	// The records that succeeded, and the one that was
+	// not found, should have been written to the cache.
+	if c.Size() != 3 {
+		t.Errorf("expected cache to have 3 records, got %d", c.Size())
+	}
+	if _, ok := c.Get(keyFn("2")); ok {
+		t.Error("expected the record that failed to not be in the cache")
+	}
+}
+
+func TestBatchErrorWithoutRecordsDoesNotClaimCachedRecords(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	c := sturdyc.New[string](100, 2, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+	)
+
+	errBadID := errors.New("bad id")
+	release := make(chan struct{})
+	fetchFn := func(_ context.Context, _ []string) (map[string]string, error) {
+		<-release
+		return map[string]string{"1": "value1"}, &sturdyc.BatchError{Errors: map[string]error{"2": errBadID}}
+	}
+
+	keyFn := c.BatchKeyFn("item")
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		c.GetOrFetchBatch(ctx, []string{"1", "2"}, keyFn, fetchFn)
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	// ID 2 fails in the call that is owned by the first batch.
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		close(release)
+	}()
+	res, err := c.GetOrFetchBatch(ctx, []string{"2"}, keyFn, fetchFn)
+	wg.Wait()
+
+	var batchErr *sturdyc.BatchError
+	if !errors.As(err, &batchErr) || !errors.Is(batchErr.Errors["2"], errBadID) {
+		t.Fatalf("expected a BatchError for ID 2, got %v", err)
+	}
+	if errors.Is(err, sturdyc.ErrOnlyCachedRecords) {
+		t.Error("expected the error to not claim that cached records were returned")
+	}
+	if len(res) != 0 {
+		t.Errorf("expected no records, got %v", res)
+	}
+}
+
+func TestBatchErrorWithOnlyNotFoundIDsIsNotReturned(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	c := sturdyc.New[string](100, 2, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithMaxBatchSize(1),
+	)
+
+	fetchFn := func(_ context.Context, ids []string) (map[string]string, error) {
+		if ids[0] == "2" {
+			return nil, &sturdyc.BatchError{Errors: map[string]error{"2": sturdyc.ErrNotFound}}
+		}
+		return map[string]string{ids[0]: "value" + ids[0]}, nil
+	}
+
+	res, err := c.GetOrFetchBatch(ctx, []string{"1", "2"}, c.BatchKeyFn("item"), fetchFn)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(res) != 1 || res["1"] != "value1" {
+		t.Errorf("expected record 1, got %v", res)
+	}
+}
+
+func TestBatchErrorDoesNotDeleteRecordsThatFailedToRefresh(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	clock := sturdyc.NewTestClock(time.Now())
+	c := sturdyc.New[string](100, 2, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithEarlyRefreshes(time.Minute, time.Minute, time.Second),
+		sturdyc.WithClock(clock),
+	)
+
+	ids := []string{"1", "2"}
+	keyFn := c.BatchKeyFn("item")
+	observer := &chunkObserver{}
+	c.GetOrFetchBatch(ctx, ids, keyFn, observer.FetchBatch)
+
+	refreshed := make(chan struct{})
+	fetchFn := func(_ context.Context, _ []string) (map[string]string, error) {
+		defer close(refreshed)
+		return map[string]string{"1": "refreshed1"}, &sturdyc.BatchError{Errors: map[string]error{
+			"2": errors.New("bad id"),
+		}}
+	}
+
+	clock.Add(time.Minute + 1)
+	c.GetOrFetchBatch(ctx, ids, keyFn, fetchFn)
+	<-refreshed
+	time.Sleep(10 * time.Millisecond)
+
+	if v, _ := c.Get(keyFn("1")); v != "refreshed1" {
+		t.Errorf("expected refreshed1, got %s", v)
+	}
+	if v, _ := c.Get(keyFn("2")); v != "value2" {
+		t.Errorf("expected value2 to still be in the cache, got %s", v)
+	}
+}
@@ -1,6 +1,8 @@
 package sturdyc_test
 
 import (
+	"fmt"
+	"sync"
 	"testing"
 	"time"
 
@@ -74,6 +76,43 @@ func BenchmarkGetConcurrent(b *testing.B) {
 	b.ReportMetric(metrics.hitRate())
 }
 
+// BenchmarkGetConcurrentStripedLocks reads a single hot key from a lot of
+// goroutines. One stripe per shard is the default, and serves as the baseline
+// for the lock contention that the additional stripes are meant to reduce:
+//
+//	go test -run NONE -bench GetConcurrentStripedLocks -cpu 1,8,32
+func BenchmarkGetConcurrentStripedLocks(b *testing.B) {
+	for _, stripes := range []int{1, 4, 16} {
+		b.Run(fmt.Sprintf("stripes=%d", stripes), func(b *testing.B) {
+			cacheKey := "key"
+			capacity := 1_000_000
+			numShards := 100
+			ttl := time.Hour
+			evictionPercentage := 5
+			c := sturdyc.New[string](capacity, numShards, ttl, evictionPercentage,
+				sturdyc.WithNoContinuousEvictions(),
+				sturdyc.WithStripedLocks(stripes),
+			)
+			c.Set(cacheKey, "value")
+
+			var mu sync.Mutex
+			metrics := make(benchmarkMetrics[string], 0)
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				var metric benchmarkMetric[string]
+				for pb.Next() {
+					metric.recordGet(c, cacheKey)
+				}
+				mu.Lock()
+				metrics = append(metrics, metric)
+				mu.Unlock()
+			})
+			b.StopTimer()
+			b.ReportMetric(metrics.hitRate())
+		})
+	}
+}
+
 func BenchmarkSetConcurrent(b *testing.B) {
 	capacity := 10_000_000
 	numShards := 10_000
@@ -1,6 +1,8 @@
 package sturdyc
 
 import (
+	"context"
+	"sync"
 	"time"
 )
 
@@ -8,17 +10,46 @@ import (
 type buffer struct {
 	channel chan []string
 	ids     []string
+	// refresh performs the batch refresh of the IDs, and is used when the
+	// buffer is flushed by client.FlushRefreshBuffers.
+	refresh func(ids []string)
+	// flush is closed when the buffer has been flushed, which
+	// makes the goroutine that is managing it return.
+	flush   chan struct{}
+	flushed bool
+	// createdAt is used to report how long the IDs were buffered for.
+	createdAt time.Time
+}
+
+// bufferSettings returns the size and timeout of the buffer for the permutation.
+func (c *Client[T]) bufferSettings(permutation string) (int, time.Duration) {
+	if c.bufferFn == nil {
+		return c.bufferSize, c.bufferTimeout
+	}
+	size, timeout := c.bufferFn(permutation)
+	if size < 1 {
+		size = c.bufferSize
+	}
+	if timeout < 1 {
+		timeout = c.bufferTimeout
+	}
+	return size, timeout
 }
 
 // createBuffer should be called WITH a lock when a refresh buffer is created.
-func (c *Client[T]) createBuffer(permutation string, ids []string) {
-	bufferIDs := make([]string, 0, c.bufferSize)
+func (c *Client[T]) createBuffer(permutation string, ids []string, size int, refresh func(ids []string)) *buffer {
+	bufferIDs := make([]string, 0, size)
 	bufferIDs = append(bufferIDs, ids...)
 	buf := &buffer{
-		channel: make(chan []string),
-		ids:     bufferIDs,
+		channel:   make(chan []string),
+		ids:       bufferIDs,
+		refresh:   refresh,
+		flush:     make(chan struct{}),
+		createdAt: c.clock.Now(),
 	}
 	c.permutationBufferMap[permutation] = buf
+	c.reportRefreshBufferSize(permutation, len(bufferIDs))
+	return buf
 }
 
 // deleteBuffer should be called WITH a lock when a buffer has been processed.
@@ -27,41 +58,45 @@ func (c *Client[T]) deleteBuffer(permutation string) {
 }
 
 // bufferBatchRefresh will buffer the batch of IDs until the batch size is reached or the buffer duration is exceeded.
-func bufferBatchRefresh[T any](c *Client[T], ids []string, keyFn KeyFn, fetchFn BatchFetchFn[T]) {
+func bufferBatchRefresh[T any](ctx context.Context, c *Client[T], ids []string, keyFn KeyFn, fetchFn BatchFetchFn[T], opts callOptions) {
 	if len(ids) == 0 {
 		return
 	}
 
+	// Extract the permutation string from the ids, and use it to
+	// determine the size and timeout of the buffer.
+	permutationString := extractPermutation(keyFn(ids[0]))
+	bufferSize, bufferTimeout := c.bufferSettings(permutationString)
+
 	// If we got a perfect batch size, we can refresh the records immediately.
-	if len(ids) == c.bufferSize {
-		c.refreshBatch(ids, keyFn, fetchFn)
+	if len(ids) == bufferSize {
+		c.scheduleRefresh(func() {
+			c.refreshBatch(ctx, ids, keyFn, fetchFn, opts)
+		})
 		return
 	}
 
 	c.batchMutex.Lock()
 
 	// If the ids are greater than our batch size we'll have to chunk them.
-	if len(ids) > c.bufferSize {
-		idsToRefresh := ids[:c.bufferSize]
-		overflowingIDs := ids[c.bufferSize:]
+	if len(ids) > bufferSize {
+		idsToRefresh := ids[:bufferSize]
+		overflowingIDs := ids[bufferSize:]
 		c.batchMutex.Unlock()
 
 		// These IDs are the size we want, so we'll refresh them immediately.
-		c.safeGo(func() {
-			c.refreshBatch(idsToRefresh, keyFn, fetchFn)
+		c.scheduleRefresh(func() {
+			c.refreshBatch(ctx, idsToRefresh, keyFn, fetchFn, opts)
 		})
 
 		// We'll continue to process the remaining IDs recursively.
-		c.safeGo(func() {
-			bufferBatchRefresh(c, overflowingIDs, keyFn, fetchFn)
+		c.trackedGo(func() {
+			bufferBatchRefresh(ctx, c, overflowingIDs, keyFn, fetchFn, opts)
 		})
 
 		return
 	}
 
-	// Extract the permutation string from the ids.
-	permutationString := extractPermutation(keyFn(ids[0]))
-
 	// Check if we already have a batch for this set of options.
 	if buf, ok := c.permutationBufferMap[permutationString]; ok {
 		// There is a small chance that another goroutine manages to write to the channel
@@ -72,9 +107,10 @@ func bufferBatchRefresh[T any](c *Client[T], ids []string, keyFn KeyFn, fetchFn
 		select {
 		case buf.channel <- ids:
 			stop()
+			c.emitBatchRefreshEvent(RefreshBuffered, ids, keyFn)
 		case <-timer:
-			c.safeGo(func() {
-				bufferBatchRefresh(c, ids, keyFn, fetchFn)
+			c.trackedGo(func() {
+				bufferBatchRefresh(ctx, c, ids, keyFn, fetchFn, opts)
 			})
 		}
 		return
@@ -82,17 +118,23 @@ func bufferBatchRefresh[T any](c *Client[T], ids []string, keyFn KeyFn, fetchFn
 
 	// There is no existing batch buffering for this permutation
 	// of options. Hence, we'll create a new one.
-	c.createBuffer(permutationString, ids)
+	buffer := c.createBuffer(permutationString, ids, bufferSize, func(ids []string) {
+		c.refreshBatch(ctx, ids, keyFn, fetchFn, opts)
+	})
 	c.batchMutex.Unlock()
+	c.emitBatchRefreshEvent(RefreshBuffered, ids, keyFn)
 
-	c.safeGo(func() {
-		timer, stop := c.clock.NewTimer(c.bufferTimeout)
-		c.batchMutex.Lock()
-		idStream := c.permutationBufferMap[permutationString].channel
-		c.batchMutex.Unlock()
+	c.trackedGo(func() {
+		timer, stop := c.clock.NewTimer(bufferTimeout)
+		defer stop()
+		idStream := buffer.channel
 
 		for {
 			select {
+			// The IDs have already been refreshed by client.FlushRefreshBuffers.
+			case <-buffer.flush:
+				return
+
 			// If the buffer times out, we'll refresh the records regardless of the buffer size.
 			case _, ok := <-timer:
 				if !ok {
@@ -101,12 +143,16 @@ func bufferBatchRefresh[T any](c *Client[T], ids []string, keyFn KeyFn, fetchFn
 
 				// We reached the deadline for this batch.
 				c.batchMutex.Lock()
-				buffer := c.permutationBufferMap[permutationString]
+				if buffer.flushed {
+					c.batchMutex.Unlock()
+					return
+				}
 				c.deleteBuffer(permutationString)
 				c.batchMutex.Unlock()
+				c.reportRefreshBufferFlushed(permutationString, BufferFlushTimeout, buffer)
 
-				c.safeGo(func() {
-					c.refreshBatch(buffer.ids, keyFn, fetchFn)
+				c.scheduleRefresh(func() {
+					c.refreshBatch(ctx, buffer.ids, keyFn, fetchFn, opts)
 				})
 				return
 
@@ -115,13 +161,21 @@ func bufferBatchRefresh[T any](c *Client[T], ids []string, keyFn KeyFn, fetchFn
 					return
 				}
 
-				// Lock the mutex, and add the additional IDs to the buffer.
+				// Lock the mutex, and add the additional IDs to the buffer. If the
+				// buffer was flushed in the meantime, they'll be buffered again.
 				c.batchMutex.Lock()
-				buffer := c.permutationBufferMap[permutationString]
+				if buffer.flushed {
+					c.batchMutex.Unlock()
+					c.trackedGo(func() {
+						bufferBatchRefresh(ctx, c, additionalIDs, keyFn, fetchFn, opts)
+					})
+					return
+				}
 				buffer.ids = append(buffer.ids, additionalIDs...)
 
 				// If we haven't reached the batch size yet, we'll wait for more ids.
-				if len(buffer.ids) < c.bufferSize {
+				if len(buffer.ids) < bufferSize {
+					c.reportRefreshBufferSize(permutationString, len(buffer.ids))
 					c.batchMutex.Unlock()
 					continue
 				}
@@ -135,19 +189,20 @@ func bufferBatchRefresh[T any](c *Client[T], ids []string, keyFn KeyFn, fetchFn
 				permIDs := buffer.ids
 				c.deleteBuffer(permutationString)
 				c.batchMutex.Unlock()
+				c.reportRefreshBufferFlushed(permutationString, BufferFlushSize, buffer)
 
-				idsToRefresh := permIDs[:c.bufferSize]
-				overflowingIDs := permIDs[c.bufferSize:]
+				idsToRefresh := permIDs[:bufferSize]
+				overflowingIDs := permIDs[bufferSize:]
 
 				// Refresh the first batch of IDs immediately.
-				c.safeGo(func() {
-					c.refreshBatch(idsToRefresh, keyFn, fetchFn)
+				c.scheduleRefresh(func() {
+					c.refreshBatch(ctx, idsToRefresh, keyFn, fetchFn, opts)
 				})
 
 				// If we exceeded the batch size, we'll continue to process the remaining IDs recursively.
 				if len(overflowingIDs) > 0 {
-					c.safeGo(func() {
-						bufferBatchRefresh(c, overflowingIDs, keyFn, fetchFn)
+					c.trackedGo(func() {
+						bufferBatchRefresh(ctx, c, overflowingIDs, keyFn, fetchFn, opts)
 					})
 				}
 				return
@@ -155,3 +210,39 @@ func bufferBatchRefresh[T any](c *Client[T], ids []string, keyFn KeyFn, fetchFn
 		}
 	})
 }
+
+// FlushRefreshBuffers refreshes the IDs that are waiting in the refresh buffers
+// right away, rather than waiting for the buffers to fill up or time out. It
+// blocks until the refreshes have completed, which makes it suitable for
+// shutdown hooks and tests. The buffers are flushed by Close as well.
+func (c *Client[T]) FlushRefreshBuffers() {
+	if !c.bufferRefreshes {
+		return
+	}
+
+	c.batchMutex.Lock()
+	buffers := make(map[string]*buffer, len(c.permutationBufferMap))
+	for permutation, buf := range c.permutationBufferMap {
+		buf.flushed = true
+		close(buf.flush)
+		buffers[permutation] = buf
+		c.deleteBuffer(permutation)
+	}
+	c.batchMutex.Unlock()
+
+	// The metrics are reported once the lock has been released, just
+	// like they are when the buffers are flushed by their goroutines.
+	for permutation, buf := range buffers {
+		c.reportRefreshBufferFlushed(permutation, BufferFlushManual, buf)
+	}
+
+	var wg sync.WaitGroup
+	for _, buf := range buffers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			buf.refresh(buf.ids)
+		}()
+	}
+	wg.Wait()
+}
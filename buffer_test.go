@@ -3,6 +3,7 @@ package sturdyc_test
 import (
 	"context"
 	"strconv"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -76,6 +77,88 @@ func TestBatchIsRefreshedWhenTheTimeoutExpires(t *testing.T) {
 	fetchObserver.AssertRequestedRecords(t, recordsToRequest)
 }
 
+func TestBatchIsRefreshedWhenTheBuffersAreFlushed(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	refreshDelay := time.Minute
+	batchBufferTimeout := time.Minute
+	clock := sturdyc.NewTestClock(time.Now())
+	client := sturdyc.New[string](1000, 10, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithEarlyRefreshes(refreshDelay, refreshDelay, time.Millisecond*10),
+		sturdyc.WithRefreshCoalescing(10, batchBufferTimeout),
+		sturdyc.WithClock(clock),
+	)
+
+	ids := []string{"1", "2", "3"}
+	fetchObserver := NewFetchObserver(2)
+	fetchObserver.BatchResponse(ids)
+	sturdyc.GetOrFetchBatch(ctx, client, ids, client.BatchKeyFn("item"), fetchObserver.FetchBatch)
+	<-fetchObserver.FetchCompleted
+	fetchObserver.Clear()
+
+	// The refresh is buffered, as the batch is smaller than the buffer size.
+	clock.Add(refreshDelay + time.Second)
+	fetchObserver.BatchResponse(ids)
+	sturdyc.GetOrFetchBatch(ctx, client, ids, client.BatchKeyFn("item"), fetchObserver.FetchBatch)
+	time.Sleep(10 * time.Millisecond)
+	fetchObserver.AssertFetchCount(t, 1)
+
+	// Flushing the buffers should refresh the IDs straight away, and
+	// prevent them from being refreshed again once the timeout expires.
+	client.FlushRefreshBuffers()
+	<-fetchObserver.FetchCompleted
+	fetchObserver.AssertFetchCount(t, 2)
+	fetchObserver.AssertRequestedRecords(t, ids)
+
+	clock.Add(batchBufferTimeout + 1)
+	time.Sleep(10 * time.Millisecond)
+	fetchObserver.AssertFetchCount(t, 2)
+}
+
+func TestBatchIsRefreshedWhenTheClientIsClosed(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	refreshDelay := time.Minute
+	clock := sturdyc.NewTestClock(time.Now())
+	recorder := newTestMetricsRecorder(10)
+	client := sturdyc.New[string](1000, 10, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithEarlyRefreshes(refreshDelay, refreshDelay, time.Millisecond*10),
+		sturdyc.WithRefreshCoalescing(10, time.Minute),
+		sturdyc.WithMetrics(recorder),
+		sturdyc.WithClock(clock),
+	)
+
+	ids := []string{"1", "2", "3"}
+	fetchObserver := NewFetchObserver(2)
+	fetchObserver.BatchResponse(ids)
+	sturdyc.GetOrFetchBatch(ctx, client, ids, client.BatchKeyFn("item"), fetchObserver.FetchBatch)
+	<-fetchObserver.FetchCompleted
+	fetchObserver.Clear()
+
+	clock.Add(refreshDelay + time.Second)
+	fetchObserver.BatchResponse(ids)
+	sturdyc.GetOrFetchBatch(ctx, client, ids, client.BatchKeyFn("item"), fetchObserver.FetchBatch)
+	time.Sleep(10 * time.Millisecond)
+	fetchObserver.AssertFetchCount(t, 1)
+
+	if err := client.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+	<-fetchObserver.FetchCompleted
+	fetchObserver.AssertFetchCount(t, 2)
+	fetchObserver.AssertRequestedRecords(t, ids)
+
+	recorder.Lock()
+	defer recorder.Unlock()
+	if recorder.bufferFlushes[sturdyc.BufferFlushManual] != 1 {
+		t.Errorf("expected 1 manual flush, got %d", recorder.bufferFlushes[sturdyc.BufferFlushManual])
+	}
+}
+
 func TestBatchIsRefreshedWhenTheBufferSizeIsReached(t *testing.T) {
 	t.Parallel()
 
@@ -468,3 +551,122 @@ func TestValuesAreUpdatedCorrectly(t *testing.T) {
 		t.Errorf("expected 'foo2-1', got '%s'", resTwo["1"].Value)
 	}
 }
+
+func TestBufferSizeCanBeConfiguredPerPermutation(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	refreshDelay := time.Minute
+	batchBufferTimeout := time.Minute
+	clock := sturdyc.NewTestClock(time.Now())
+	client := sturdyc.New[string](1000, 10, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithEarlyRefreshes(refreshDelay, refreshDelay, time.Millisecond*10),
+		sturdyc.WithRefreshCoalescing(10, batchBufferTimeout),
+		sturdyc.WithRefreshCoalescingFn(func(permutation string) (int, time.Duration) {
+			if strings.HasPrefix(permutation, "small") {
+				return 2, 0
+			}
+			return 0, 0
+		}),
+		sturdyc.WithClock(clock),
+	)
+
+	ids := []string{"1", "2"}
+	smallObserver, largeObserver := NewFetchObserver(2), NewFetchObserver(2)
+	smallObserver.BatchResponse(ids)
+	largeObserver.BatchResponse(ids)
+	sturdyc.GetOrFetchBatch(ctx, client, ids, client.BatchKeyFn("small"), smallObserver.FetchBatch)
+	sturdyc.GetOrFetchBatch(ctx, client, ids, client.BatchKeyFn("large"), largeObserver.FetchBatch)
+	<-smallObserver.FetchCompleted
+	<-largeObserver.FetchCompleted
+	smallObserver.Clear()
+	largeObserver.Clear()
+
+	// The two IDs fill the buffer of the small permutation, which means that
+	// they should be refreshed right away, while the large one keeps waiting.
+	clock.Add(refreshDelay + time.Second)
+	smallObserver.BatchResponse(ids)
+	largeObserver.BatchResponse(ids)
+	sturdyc.GetOrFetchBatch(ctx, client, ids, client.BatchKeyFn("small"), smallObserver.FetchBatch)
+	sturdyc.GetOrFetchBatch(ctx, client, ids, client.BatchKeyFn("large"), largeObserver.FetchBatch)
+	<-smallObserver.FetchCompleted
+	smallObserver.AssertFetchCount(t, 2)
+	smallObserver.AssertRequestedRecords(t, ids)
+	time.Sleep(10 * time.Millisecond)
+	largeObserver.AssertFetchCount(t, 1)
+
+	// The large permutation falls back to the timeout of the client.
+	clock.Add(batchBufferTimeout + 1)
+	<-largeObserver.FetchCompleted
+	largeObserver.AssertFetchCount(t, 2)
+	largeObserver.AssertRequestedRecords(t, ids)
+}
+
+func TestRefreshBuffersReportMetrics(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	refreshDelay := time.Minute
+	batchBufferTimeout := time.Minute
+	clock := sturdyc.NewTestClock(time.Now())
+	recorder := newTestMetricsRecorder(10)
+	client := sturdyc.New[string](1000, 10, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithEarlyRefreshes(refreshDelay, refreshDelay, time.Millisecond*10),
+		sturdyc.WithRefreshCoalescing(3, batchBufferTimeout),
+		sturdyc.WithMetrics(recorder),
+		sturdyc.WithClock(clock),
+	)
+
+	ids := []string{"1", "2", "3", "4"}
+	fetchObserver := NewFetchObserver(4)
+	fetchObserver.BatchResponse(ids)
+	sturdyc.GetOrFetchBatch(ctx, client, ids, client.BatchKeyFn("item"), fetchObserver.FetchBatch)
+	<-fetchObserver.FetchCompleted
+	fetchObserver.Clear()
+
+	// Two IDs are buffered, and the gauge should report them.
+	clock.Add(refreshDelay + time.Second)
+	fetchObserver.BatchResponse(ids)
+	sturdyc.GetOrFetchBatch(ctx, client, ids[:2], client.BatchKeyFn("item"), fetchObserver.FetchBatch)
+	time.Sleep(10 * time.Millisecond)
+	recorder.Lock()
+	if len(recorder.bufferSizes) != 1 {
+		t.Fatalf("expected one buffer to be reported, got %d", len(recorder.bufferSizes))
+	}
+	for permutation, size := range recorder.bufferSizes {
+		if size != 2 {
+			t.Errorf("expected permutation %s to have 2 buffered IDs, got %d", permutation, size)
+		}
+	}
+	recorder.Unlock()
+
+	// Filling the buffer should flush it because of the size.
+	clock.Add(time.Second)
+	sturdyc.GetOrFetchBatch(ctx, client, ids[2:3], client.BatchKeyFn("item"), fetchObserver.FetchBatch)
+	<-fetchObserver.FetchCompleted
+
+	// The last ID is buffered until the timeout expires.
+	sturdyc.GetOrFetchBatch(ctx, client, ids[3:], client.BatchKeyFn("item"), fetchObserver.FetchBatch)
+	time.Sleep(10 * time.Millisecond)
+	clock.Add(batchBufferTimeout + 1)
+	<-fetchObserver.FetchCompleted
+
+	recorder.Lock()
+	defer recorder.Unlock()
+	if recorder.bufferFlushes[sturdyc.BufferFlushSize] != 1 {
+		t.Errorf("expected 1 flush because of the size, got %d", recorder.bufferFlushes[sturdyc.BufferFlushSize])
+	}
+	if recorder.bufferFlushes[sturdyc.BufferFlushTimeout] != 1 {
+		t.Errorf("expected 1 flush because of the timeout, got %d", recorder.bufferFlushes[sturdyc.BufferFlushTimeout])
+	}
+	if len(recorder.timeInBuffer) != 2 || recorder.timeInBuffer[0] != time.Second || recorder.timeInBuffer[1] != batchBufferTimeout+1 {
+		t.Errorf("unexpected time in buffer: %v", recorder.timeInBuffer)
+	}
+	for permutation, size := range recorder.bufferSizes {
+		if size != 0 {
+			t.Errorf("expected the buffer of permutation %s to be empty, got %d", permutation, size)
+		}
+	}
+}
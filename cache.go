@@ -3,7 +3,9 @@ package sturdyc
 import (
 	"context"
 	"log/slog"
+	"reflect"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	xxhash "github.com/cespare/xxhash/v2"
@@ -26,26 +28,112 @@ type Config struct {
 	clock                      Clock
 	evictionInterval           time.Duration
 	disableContinuousEvictions bool
+	adaptiveEvictions          bool
+	evictionPolicy             EvictionPolicy
+	doorkeeperKeys             int
+	doorkeeperWindow           time.Duration
+	doorkeeper                 *doorkeeper
+	minEvictionInterval        time.Duration
+	maxEvictionInterval        time.Duration
 	metricsRecorder            DistributedMetricsRecorder
+	bufferRecorder             BufferMetricsRecorder
+	coalescingRecorder         CoalescingMetricsRecorder
+	latencyRecorder            LatencyMetricsRecorder
+	evictionRecorder           EvictionMetricsRecorder
+	missingRecordRecorder      MissingRecordMetricsRecorder
+	name                       string
 	log                        Logger
+	logLevels                  map[LogSubsystem]slog.Level
+	lockStripes                int
+	parallelGetManyKeys        int
 
 	refreshInBackground bool
+	refreshesPaused     atomic.Int32
+	minRefreshAccesses  int
+	refreshTimeout      time.Duration
+	refreshWorkers      int
+	refreshQueueSize    int
+	refreshOverflow     OverflowPolicy
+	refreshPool         *refreshPool
 	minRefreshTime      time.Duration
 	maxRefreshTime      time.Duration
+	refreshAtFraction   float64
+	refreshAtJitter     float64
+	ttlJitter           float64
+	rand                *lockedRand
 	retryBaseDelay      time.Duration
 	storeMissingRecords bool
+	missingRecordTTL    time.Duration
+
+	refreshBackoff         bool
+	refreshBackoffBase     time.Duration
+	refreshBackoffMax      time.Duration
+	refreshBackoffStrategy BackoffStrategy
+
+	maxRefreshRetries        int
+	refreshesExhaustedPolicy ExhaustedPolicy
+	onRefreshSuccess         RefreshSuccessFn
+	onRefreshFailure         RefreshFailureFn
+	refreshEvents            *refreshEvents
+	stats                    *cacheStats
+	statsWindow              time.Duration
+	statsBucket              time.Duration
+	keyHasher                func(key string) uint64
+	validator                any
+	transform                any
+	costFn                   any
+	useExpvar                bool
+	shardSkewThreshold       float64
+	shardsSkewed             atomic.Bool
+	expvarPrefix             string
+
+	serveStaleOnError    bool
+	staleOnErrorDuration time.Duration
+	errorCache           *errorCache
+
+	fetchTimeout          time.Duration
+	originRateLimiter     RateLimiter
+	retryPolicy           RetryPolicy
+	circuitBreakers       *circuitBreakers
+	circuitBreakerGroupFn func(key string) string
+	hedging               *hedging
+	maxBatchSize          int
+	batchConcurrency      int
 
 	bufferRefreshes      bool
 	batchMutex           sync.Mutex
 	bufferSize           int
 	bufferTimeout        time.Duration
+	bufferFn             func(permutation string) (int, time.Duration)
 	permutationBufferMap map[string]*buffer
 
 	useRelativeTimeKeyFormat bool
 	keyTruncation            time.Duration
+	timeKeyFormatter         func(now, t time.Time) string
 	getSize                  func() int
 
 	distributedStorage              DistributedStorageWithDeletions
+	distributedKeyPrefix            string
+	distributedCompressor           Compressor
+	distributedCompressionThreshold int
+	distributedWriteWindow          time.Duration
+	distributedWriteMaxBatchSize    int
+	distributedBreakers             *circuitBreakers
+	distributedStorageTimeout       time.Duration
+	distributedWriteThrough         bool
+	writeBehindWorkers              int
+	writeBehindQueueSize            int
+	writeBehindOverflow             OverflowPolicy
+	writeBehindTimeout              time.Duration
+	writeBehindRetryPolicy          RetryPolicy
+	writeBehindQueue                WriteBehindQueue
+	writeBehind                     *writeBehindStorage
+	invalidationBus                 InvalidationBus
+	codecs                          map[reflect.Type]any
+	distributedScannerStorage       DistributedStorageScanner
+	distributedLocker               DistributedLocker
+	distributedLockTTL              time.Duration
+	distributedLockWait             time.Duration
 	distributedEarlyRefreshes       bool
 	distributedRefreshAfterDuration time.Duration
 }
@@ -53,12 +141,18 @@ type Config struct {
 // Client represents a cache client that can be used to store and retrieve values.
 type Client[T any] struct {
 	*Config
-	shards             []*shard[T]
-	nextShard          int
-	inFlightMutex      sync.Mutex
-	inFlightBatchMutex sync.Mutex
-	inFlightMap        map[string]*inFlightCall[T]
-	inFlightBatchMap   map[string]*inFlightCall[map[string]T]
+	shards    atomic.Pointer[[]*shard[T]]
+	reshardMu sync.Mutex
+	nextShard int
+	inFlight  []*inFlightShard[T]
+	// pendingWork is the number of background tasks, such as refreshes and
+	// writes to the distributed storage, that have yet to complete.
+	pendingWork atomic.Int64
+	validator   func(key string, value T) error
+	transform   func(key string, value T) T
+	// closed is closed by Close to stop the continuous evictions.
+	closed    chan struct{}
+	closeOnce sync.Once
 }
 
 // New creates a new Client instance with the specified configuration.
@@ -69,10 +163,7 @@ type Client[T any] struct {
 //	`evictionPercentage` Percentage of items to evict when the cache exceeds its capacity.
 //	`opts` allows for additional configurations to be applied to the cache client.
 func New[T any](capacity, numShards int, ttl time.Duration, evictionPercentage int, opts ...Option) *Client[T] {
-	client := &Client[T]{
-		inFlightMap:      make(map[string]*inFlightCall[T]),
-		inFlightBatchMap: make(map[string]*inFlightCall[map[string]T]),
-	}
+	client := &Client[T]{closed: make(chan struct{})}
 
 	// Create a default configuration, and then apply the options.
 	cfg := &Config{
@@ -80,6 +171,10 @@ func New[T any](capacity, numShards int, ttl time.Duration, evictionPercentage i
 		evictionInterval: ttl / time.Duration(numShards),
 		getSize:          client.Size,
 		log:              slog.Default(),
+		lockStripes:      1,
+		refreshEvents:    newRefreshEvents(),
+		stats:            newCacheStats(),
+		keyHasher:        xxhash.Sum64String,
 	}
 	// Apply the options to the configuration.
 	client.Config = cfg
@@ -87,14 +182,55 @@ func New[T any](capacity, numShards int, ttl time.Duration, evictionPercentage i
 		opt(cfg)
 	}
 	validateConfig(capacity, numShards, ttl, evictionPercentage, cfg)
+	if cfg.validator != nil {
+		validator, ok := cfg.validator.(func(key string, value T) error)
+		if !ok {
+			panic("the validator must accept values of the type that the cache stores")
+		}
+		client.validator = validator
+	}
+	if cfg.transform != nil {
+		transform, ok := cfg.transform.(func(key string, value T) T)
+		if !ok {
+			panic("the transform must accept and return values of the type that the cache stores")
+		}
+		client.transform = transform
+	}
+	if cfg.costFn != nil {
+		if _, ok := cfg.costFn.(func(key string, value T) int); !ok {
+			panic("the cost function must accept values of the type that the cache stores")
+		}
+	}
+	cfg.setupMetricsRecorder()
+	cfg.decorateDistributedStorage()
+	if cfg.doorkeeperKeys > 0 {
+		cfg.doorkeeper = newDoorkeeper(cfg.doorkeeperKeys, cfg.doorkeeperWindow, cfg.clock)
+	}
+	if cfg.statsWindow > 0 {
+		cfg.stats.window = newStatsWindow(cfg.statsWindow, cfg.statsBucket, cfg.clock)
+	}
 
 	shardSize := capacity / numShards
 	shards := make([]*shard[T], numShards)
 	for i := 0; i < numShards; i++ {
 		shards[i] = newShard[T](shardSize, ttl, evictionPercentage, cfg)
 	}
-	client.shards = shards
+	client.shards.Store(&shards)
 	client.nextShard = 0
+	client.inFlight = newInFlightShards[T](numShards)
+
+	if cfg.refreshWorkers > 0 {
+		cfg.refreshPool = newRefreshPool(cfg.refreshWorkers, cfg.refreshQueueSize, cfg.refreshOverflow)
+		client.startRefreshWorkers()
+	}
+
+	if cfg.invalidationBus != nil {
+		client.subscribeToInvalidations()
+	}
+
+	if cfg.useExpvar {
+		client.publishExpvars()
+	}
 
 	// Run evictions on the shards in a separate goroutine.
 	if !cfg.disableContinuousEvictions {
@@ -104,36 +240,172 @@ func New[T any](capacity, numShards int, ttl time.Duration, evictionPercentage i
 	return client
 }
 
-// performContinuousEvictions is going to be running in a separate goroutine that we're going to prevent from ever exiting.
+// performContinuousEvictions runs the evictions in a separate goroutine until the client is closed.
 func (c *Client[T]) performContinuousEvictions() {
+	if c.adaptiveEvictions {
+		go c.performAdaptiveEvictions()
+		return
+	}
+
 	go func() {
 		ticker, stop := c.clock.NewTicker(c.evictionInterval)
 		defer stop()
-		for range ticker {
-			c.shards[c.nextShard].evictExpired()
-			c.nextShard = (c.nextShard + 1) % len(c.shards)
+		for {
+			select {
+			case <-c.closed:
+				return
+			case <-ticker:
+				c.evictNextShard()
+			}
 		}
 	}()
 }
 
+// performAdaptiveEvictions evicts the shards one at a time, and adjusts the
+// interval between them based on how much pressure the last shard was under.
+func (c *Client[T]) performAdaptiveEvictions() {
+	interval := min(max(c.evictionInterval, c.minEvictionInterval), c.maxEvictionInterval)
+	for {
+		timer, stop := c.clock.NewTimer(interval)
+		select {
+		case <-c.closed:
+			stop()
+			return
+		case <-timer:
+		}
+		stop()
+		evicted, remaining, capacity := c.evictNextShard()
+		interval = c.nextEvictionInterval(interval, evicted, remaining, capacity)
+	}
+}
+
+// Close flushes the refresh buffers, waits for the writes of the
+// write-behind queue to be flushed, and stops the continuous evictions.
+// The client can still be used once it has been closed, but its expired
+// records are no longer evicted in the background.
+func (c *Client[T]) Close(ctx context.Context) error {
+	err := c.Drain(ctx)
+	c.closeOnce.Do(func() {
+		close(c.closed)
+	})
+	return err
+}
+
+// evictNextShard evicts the expired entries of the next shard, and returns the
+// number of entries that were evicted, kept, and the capacity of the shard.
+func (c *Client[T]) evictNextShard() (evicted, remaining, capacity int) {
+	shards := c.getShards()
+	c.nextShard %= len(shards)
+	shard := shards[c.nextShard]
+	evicted = shard.evictExpired()
+	remaining, capacity = shard.size(), shard.getCapacity()
+
+	c.nextShard = (c.nextShard + 1) % len(shards)
+	if c.errorCache != nil && c.nextShard == 0 {
+		c.errorCache.evictExpired(c.clock.Now())
+	}
+	if c.nextShard == 0 {
+		c.checkShardSkew()
+	}
+	return evicted, remaining, capacity
+}
+
+// nextEvictionInterval halves the interval if the shard was close to its
+// capacity, or if at least a quarter of its entries had expired. If the shard
+// had nothing to evict and is less than half full, the interval is doubled.
+func (c *Config) nextEvictionInterval(interval time.Duration, evicted, remaining, capacity int) time.Duration {
+	nearCapacity := remaining*10 >= capacity*9
+	mostlyExpired := evicted > 0 && evicted*4 >= evicted+remaining
+	if nearCapacity || mostlyExpired {
+		return max(interval/2, c.minEvictionInterval)
+	}
+	if evicted == 0 && remaining*2 < capacity {
+		return min(interval*2, c.maxEvictionInterval)
+	}
+	return interval
+}
+
+// getShards returns the current shards of the cache.
+func (c *Client[T]) getShards() []*shard[T] {
+	return *c.shards.Load()
+}
+
 // getShard returns the shard that should be used for the specified key.
 func (c *Client[T]) getShard(key string) *shard[T] {
-	hash := xxhash.Sum64String(key)
-	shardIndex := hash % uint64(len(c.shards))
+	shards := c.getShards()
+	hash := c.keyHasher(key)
+	shardIndex := hash % uint64(len(shards))
 	c.reportShardIndex(int(shardIndex))
-	return c.shards[shardIndex]
+	return shards[shardIndex]
 }
 
 // getWithState retrieves a single value from the cache and returns additional
 // information about the state of the record. The state includes whether the record
 // exists, if it has been marked as missing, and if it is due for a refresh.
-func (c *Client[T]) getWithState(key string) (value T, exists, markedAsMissing, refresh bool) {
+func (c *Client[T]) getWithState(key string, allowRefresh bool) (value T, exists, markedAsMissing, refresh bool) {
 	shard := c.getShard(key)
-	val, exists, markedAsMissing, refresh := shard.get(key)
+	val, exists, markedAsMissing, refresh := shard.get(key, allowRefresh)
 	c.reportCacheHits(exists, markedAsMissing, refresh)
 	return val, exists, markedAsMissing, refresh
 }
 
+// getMany retrieves multiple values from the cache. The keys are grouped by
+// shard so that the lock of each shard only has to be acquired once, and the
+// shards are read from in parallel if there are enough keys. The results are
+// returned in the same order as the keys.
+func (c *Client[T]) getMany(keys []string) []lookup[T] {
+	shards := c.getShards()
+	groups := make([][]string, len(shards))
+	positions := make([][]int, len(shards))
+	for i, key := range keys {
+		shardIndex := int(c.keyHasher(key) % uint64(len(shards)))
+		c.reportShardIndex(shardIndex)
+		groups[shardIndex] = append(groups[shardIndex], key)
+		positions[shardIndex] = append(positions[shardIndex], i)
+	}
+
+	results := make([]lookup[T], len(keys))
+	if c.parallelGetManyKeys > 0 && len(keys) >= c.parallelGetManyKeys {
+		var wg sync.WaitGroup
+		for shardIndex, group := range groups {
+			if len(group) == 0 {
+				continue
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				shards[shardIndex].getMany(group, positions[shardIndex], true, results)
+			}()
+		}
+		wg.Wait()
+	} else {
+		for shardIndex, group := range groups {
+			if len(group) > 0 {
+				shards[shardIndex].getMany(group, positions[shardIndex], true, results)
+			}
+		}
+	}
+
+	for _, r := range results {
+		c.reportCacheHits(r.exists, r.markedAsMissing, r.refresh)
+	}
+	return results
+}
+
+// getStale retrieves a value that is allowed to be served
+// if the underlying data source fails.
+func (c *Client[T]) getStale(key string) (T, bool) {
+	if !c.serveStaleOnError {
+		var zero T
+		return zero, false
+	}
+	value, ok := c.getShard(key).getStale(key)
+	if ok {
+		c.stats.staleHits.Add(1)
+	}
+	return value, ok
+}
+
 // Get retrieves a single value from the cache.
 //
 // Parameters:
@@ -145,11 +417,57 @@ func (c *Client[T]) getWithState(key string) (value T, exists, markedAsMissing,
 //	The value corresponding to the key and a boolean indicating if the value was found.
 func (c *Client[T]) Get(key string) (T, bool) {
 	shard := c.getShard(key)
-	val, ok, markedAsMissing, refresh := shard.get(key)
+	val, ok, markedAsMissing, refresh := shard.get(key, true)
 	c.reportCacheHits(ok, markedAsMissing, refresh)
 	return val, ok && !markedAsMissing
 }
 
+// bypassKey is the context key that marks the requests that should skip the
+// in-memory shards.
+type bypassKey struct{}
+
+// WithCacheBypass returns a context that makes GetCtx report a miss without
+// reading the cache. It can be used for requests that must see fresh data,
+// such as those that were sent with a Cache-Control: no-cache header. Writes
+// made with SetCtx are unaffected.
+func WithCacheBypass(ctx context.Context) context.Context {
+	return context.WithValue(ctx, bypassKey{}, true)
+}
+
+func bypassCache(ctx context.Context) bool {
+	bypass, _ := ctx.Value(bypassKey{}).(bool)
+	return bypass
+}
+
+// GetCtx is the same as Get, but it accepts the context of the request. The
+// read is skipped if the context was created by WithCacheBypass, and the
+// context is passed to the logger if it implements ContextLogger.
+//
+// Parameters:
+//
+//	ctx - The context of the request.
+//	key - The key to be retrieved.
+//
+// Returns:
+//
+//	The value corresponding to the key and a boolean indicating if the value was found.
+func (c *Client[T]) GetCtx(ctx context.Context, key string) (T, bool) {
+	logger := c.logger(LogCache)
+	if bypassCache(ctx) {
+		if logger.Enabled(slog.LevelDebug) {
+			logger.withContext(ctx).Debug("sturdyc: bypassed the cache", "key", key)
+		}
+		var zero T
+		return zero, false
+	}
+
+	val, ok := c.Get(key)
+	if logger.Enabled(slog.LevelDebug) {
+		logger.withContext(ctx).Debug("sturdyc: read key", "key", key, "found", ok)
+	}
+	return val, ok
+}
+
 // GetMany retrieves multiple values from the cache.
 //
 // Parameters:
@@ -161,9 +479,9 @@ func (c *Client[T]) Get(key string) (T, bool) {
 //	A map of keys to their corresponding values.
 func (c *Client[T]) GetMany(keys []string) map[string]T {
 	records := make(map[string]T, len(keys))
-	for _, key := range keys {
-		if value, ok := c.Get(key); ok {
-			records[key] = value
+	for i, r := range c.getMany(keys) {
+		if r.exists && !r.markedAsMissing {
+			records[keys[i]] = r.val
 		}
 	}
 	return records
@@ -184,10 +502,15 @@ func (c *Client[T]) GetMany(keys []string) map[string]T {
 //
 //	A map of IDs to their corresponding values.
 func (c *Client[T]) GetManyKeyFn(ids []string, keyFn KeyFn) map[string]T {
-	records := make(map[string]T, len(ids))
+	keys := make([]string, 0, len(ids))
 	for _, id := range ids {
-		if value, ok := c.Get(keyFn(id)); ok {
-			records[id] = value
+		keys = append(keys, keyFn(id))
+	}
+
+	records := make(map[string]T, len(ids))
+	for i, r := range c.getMany(keys) {
+		if r.exists && !r.markedAsMissing {
+			records[ids[i]] = r.val
 		}
 	}
 	return records
@@ -204,15 +527,39 @@ func (c *Client[T]) GetManyKeyFn(ids []string, keyFn KeyFn) map[string]T {
 //
 //	A boolean indicating if the set operation triggered an eviction.
 func (c *Client[T]) Set(key string, value T) bool {
+	return c.SetCtx(context.Background(), key, value)
+}
+
+// SetCtx is the same as Set, but it accepts the context of the request, which
+// is passed to the logger if it implements ContextLogger, and to the
+// distributed storage when the value is written through.
+//
+// Parameters:
+//
+//	ctx - The context of the request.
+//	key - The key to be set.
+//	value - The value to be associated with the key.
+//
+// Returns:
+//
+//	A boolean indicating if the set operation triggered an eviction.
+func (c *Client[T]) SetCtx(ctx context.Context, key string, value T) bool {
 	shard := c.getShard(key)
-	return shard.set(key, value, false)
+	evicted := shard.set(key, value, false, 0)
+	if logger := c.logger(LogCache); logger.Enabled(slog.LevelDebug) {
+		logger.withContext(ctx).Debug("sturdyc: wrote key", "key", key, "evicted", evicted)
+	}
+	c.writeRecordThrough(ctx, key, value)
+	return evicted
 }
 
 // StoreMissingRecord writes a single value to the cache. Returns true if it triggered an eviction.
 func (c *Client[T]) StoreMissingRecord(key string) bool {
 	shard := c.getShard(key)
 	var zero T
-	return shard.set(key, zero, true)
+	evicted := shard.set(key, zero, true, c.missingRecordTTL)
+	c.writeMissingRecordThrough(context.Background(), key)
+	return evicted
 }
 
 // SetMany writes a map of key-value pairs to the cache.
@@ -227,11 +574,12 @@ func (c *Client[T]) StoreMissingRecord(key string) bool {
 func (c *Client[T]) SetMany(records map[string]T) bool {
 	var triggeredEviction bool
 	for key, value := range records {
-		evicted := c.Set(key, value)
+		evicted := c.getShard(key).set(key, value, false, 0)
 		if evicted {
 			triggeredEviction = true
 		}
 	}
+	c.writeThrough(context.Background(), records)
 	return triggeredEviction
 }
 
@@ -248,14 +596,11 @@ func (c *Client[T]) SetMany(records map[string]T) bool {
 //
 //	A boolean indicating if any of the set operations triggered an eviction.
 func (c *Client[T]) SetManyKeyFn(records map[string]T, cacheKeyFn KeyFn) bool {
-	var triggeredEviction bool
+	keyedRecords := make(map[string]T, len(records))
 	for id, value := range records {
-		evicted := c.Set(cacheKeyFn(id), value)
-		if evicted {
-			triggeredEviction = true
-		}
+		keyedRecords[cacheKeyFn(id)] = value
 	}
-	return triggeredEviction
+	return c.SetMany(keyedRecords)
 }
 
 // ScanKeys returns a list of all keys in the cache.
@@ -265,7 +610,7 @@ func (c *Client[T]) SetManyKeyFn(records map[string]T, cacheKeyFn KeyFn) bool {
 //	A slice of strings representing all the keys in the cache.
 func (c *Client[T]) ScanKeys() []string {
 	keys := make([]string, 0, c.Size())
-	for _, shard := range c.shards {
+	for _, shard := range c.getShards() {
 		keys = append(keys, shard.keys()...)
 	}
 	return keys
@@ -278,12 +623,69 @@ func (c *Client[T]) ScanKeys() []string {
 //	An integer representing the total number of entries in the cache.
 func (c *Client[T]) Size() int {
 	var sum int
-	for _, shard := range c.shards {
+	for _, shard := range c.getShards() {
 		sum += shard.size()
 	}
 	return sum
 }
 
+// Pin exempts the entry from every form of eviction, and keeps it from
+// expiring. The entry stays pinned when it's overwritten or refreshed, until
+// it's either unpinned or deleted. This is meant for small sets of records
+// that must never disappear, such as configuration. Returns false if the key
+// isn't in the cache.
+func (c *Client[T]) Pin(key string) bool {
+	return c.getShard(key).setPinned(key, true)
+}
+
+// Unpin makes the entry subject to expiration and eviction again. Returns false
+// if the key isn't in the cache.
+func (c *Client[T]) Unpin(key string) bool {
+	return c.getShard(key).setPinned(key, false)
+}
+
+// EvictExpired evicts the expired entries of every shard, and returns the
+// number of entries that were evicted. This allows the entries to be
+// reclaimed explicitly when WithNoContinuousEvictions is used.
+func (c *Client[T]) EvictExpired() int {
+	var evicted int
+	for _, shard := range c.getShards() {
+		evicted += shard.evictExpired()
+	}
+	if c.errorCache != nil {
+		c.errorCache.evictExpired(c.clock.Now())
+	}
+	return evicted
+}
+
+// EvictExpiredShard evicts the expired entries of the shard with the given
+// index, and returns the number of entries that were evicted. The index has
+// to be less than the number of shards.
+func (c *Client[T]) EvictExpiredShard(index int) int {
+	shards := c.getShards()
+	if index < 0 || index >= len(shards) {
+		panic("index must be greater than or equal to 0 and less than the number of shards")
+	}
+	return shards[index].evictExpired()
+}
+
+// Resize changes the capacity of the cache without losing its entries. The
+// capacity is divided evenly between the shards, and has to be at least the
+// number of shards. If the cache is shrunk, each shard evicts the entries
+// that are closest to expiring until it fits within its new capacity.
+func (c *Client[T]) Resize(capacity int) {
+	c.reshardMu.Lock()
+	defer c.reshardMu.Unlock()
+
+	shards := c.getShards()
+	if capacity < len(shards) {
+		panic("capacity must be greater than or equal to the number of shards")
+	}
+	for _, shard := range shards {
+		shard.resize(capacity / len(shards))
+	}
+}
+
 // Delete removes a single entry from the cache.
 //
 // Parameters:
@@ -292,6 +694,21 @@ func (c *Client[T]) Size() int {
 func (c *Client[T]) Delete(key string) {
 	shard := c.getShard(key)
 	shard.delete(key)
+	c.deleteThrough(context.Background(), key)
+	c.publishInvalidation(context.Background(), key)
+}
+
+// deleteLocal removes the entry from the in-memory cache only. It's used when
+// a refresh finds that a record has been deleted at the data source, which
+// must not cause deletes in the distributed storage or invalidations across
+// the other instances.
+func (c *Client[T]) deleteLocal(key string) {
+	c.getShard(key).delete(key)
+}
+
+// Name returns the name that the cache was given with WithName.
+func (c *Client[T]) Name() string {
+	return c.name
 }
 
 // NumKeysInflight returns the number of keys that are currently being fetched.
@@ -300,9 +717,53 @@ func (c *Client[T]) Delete(key string) {
 //
 //	An integer representing the total number of keys that are currently being fetched.
 func (c *Client[T]) NumKeysInflight() int {
-	c.inFlightMutex.Lock()
-	defer c.inFlightMutex.Unlock()
-	c.inFlightBatchMutex.Lock()
-	defer c.inFlightBatchMutex.Unlock()
-	return len(c.inFlightMap) + len(c.inFlightBatchMap)
+	var sum int
+	for _, shard := range c.inFlight {
+		shard.Lock()
+		sum += len(shard.calls)
+		shard.Unlock()
+	}
+	return sum
+}
+
+// InflightKeys returns the keys that are currently being fetched.
+//
+// Returns:
+//
+//	A slice of strings representing the keys that are currently being fetched.
+func (c *Client[T]) InflightKeys() []string {
+	durations := c.InflightDurations()
+	keys := make([]string, 0, len(durations))
+	for key := range durations {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// IsInflight checks if a key is currently being fetched.
+//
+// Parameters:
+//
+//	key - The key to check.
+//
+// Returns:
+//
+//	A boolean indicating if the key is currently being fetched.
+func (c *Client[T]) IsInflight(key string) bool {
+	return c.inFlight[c.inFlightShardIndex(key)].contains(key)
+}
+
+// InflightDurations returns how long each of the keys that are
+// currently being fetched has been outstanding.
+//
+// Returns:
+//
+//	A map of keys to the duration that they have been in flight.
+func (c *Client[T]) InflightDurations() map[string]time.Duration {
+	now := c.clock.Now()
+	durations := make(map[string]time.Duration)
+	for _, shard := range c.inFlight {
+		shard.durations(now, durations)
+	}
+	return durations
 }
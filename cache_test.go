@@ -1,11 +1,17 @@
 package sturdyc_test
 
 import (
+	"context"
+	"math"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/cespare/xxhash/v2"
 	"github.com/viccon/sturdyc"
 )
 
@@ -292,6 +298,78 @@ func TestGetMany(t *testing.T) {
 	}
 }
 
+func TestGetManyReadsFromTheShardsInParallel(t *testing.T) {
+	t.Parallel()
+
+	c := sturdyc.New[int](10000, 10, time.Hour, 5,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithMissingRecordStorage(),
+		sturdyc.WithParallelGetMany(100),
+	)
+
+	keys := make([]string, 0, 1000)
+	for i := 0; i < 1000; i++ {
+		key := strconv.Itoa(i)
+		keys = append(keys, key)
+		if i%2 == 0 {
+			c.Set(key, i)
+		}
+	}
+	c.StoreMissingRecord("1")
+
+	ids := append([]string{"unknown"}, keys...)
+	records := c.GetManyKeyFn(ids, func(id string) string { return id })
+	if len(records) != 500 {
+		t.Fatalf("expected 500 records, got %d", len(records))
+	}
+	for i := 0; i < 1000; i += 2 {
+		if records[strconv.Itoa(i)] != i {
+			t.Errorf("expected key %d to have the value %d, got %d", i, i, records[strconv.Itoa(i)])
+		}
+	}
+	if _, ok := records["1"]; ok {
+		t.Error("expected the missing record to be left out")
+	}
+}
+
+type requestIDKey struct{}
+
+// contextStorage keeps the request IDs of the contexts that it's written with.
+type contextStorage struct {
+	*mockStorage
+	mu         sync.Mutex
+	requestIDs []any
+}
+
+func (s *contextStorage) Set(ctx context.Context, key string, value []byte) {
+	s.mu.Lock()
+	s.requestIDs = append(s.requestIDs, ctx.Value(requestIDKey{}))
+	s.mu.Unlock()
+	s.mockStorage.Set(ctx, key, value)
+}
+
+func TestSetCtxPassesTheContextToTheDistributedStorage(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.WithValue(context.Background(), requestIDKey{}, "abc")
+	storage := &contextStorage{mockStorage: &mockStorage{}}
+	c := sturdyc.New[int](1000, 10, time.Hour, 5,
+		sturdyc.WithDistributedStorage(storage),
+		sturdyc.WithDistributedWriteThrough(),
+	)
+
+	c.SetCtx(ctx, "1", 1)
+	if value, ok := c.Get("1"); !ok || value != 1 {
+		t.Errorf("expected the value 1 to be in the cache, got %d %v", value, ok)
+	}
+
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+	if len(storage.requestIDs) != 1 || storage.requestIDs[0] != "abc" {
+		t.Errorf("expected the context to be passed to the storage, got %v", storage.requestIDs)
+	}
+}
+
 func TestEvictsAndReturnsTheCorrectSize(t *testing.T) {
 	t.Parallel()
 
@@ -355,3 +433,752 @@ func TestReportsMetricsForHitsAndMisses(t *testing.T) {
 		t.Errorf("expected 1 cache miss, got %d", metricsRecorder.cacheMisses)
 	}
 }
+
+// baselineMetricsRecorder only implements the methods that are required by
+// the recorder interfaces, and none of the optional ones.
+type baselineMetricsRecorder struct {
+	hits atomic.Int32
+}
+
+func (r *baselineMetricsRecorder) CacheHit()                   { r.hits.Add(1) }
+func (r *baselineMetricsRecorder) CacheMiss()                  {}
+func (r *baselineMetricsRecorder) Refresh()                    {}
+func (r *baselineMetricsRecorder) MissingRecord()              {}
+func (r *baselineMetricsRecorder) ForcedEviction()             {}
+func (r *baselineMetricsRecorder) EntriesEvicted(int)          {}
+func (r *baselineMetricsRecorder) ShardIndex(int)              {}
+func (r *baselineMetricsRecorder) CacheBatchRefreshSize(int)   {}
+func (r *baselineMetricsRecorder) ObserveCacheSize(func() int) {}
+func (r *baselineMetricsRecorder) DistributedCacheHit()        {}
+func (r *baselineMetricsRecorder) DistributedCacheMiss()       {}
+func (r *baselineMetricsRecorder) DistributedRefresh()         {}
+func (r *baselineMetricsRecorder) DistributedMissingRecord()   {}
+func (r *baselineMetricsRecorder) DistributedFallback()        {}
+
+func TestRecordersWithoutTheOptionalInterfaces(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	metricsRecorder := &baselineMetricsRecorder{}
+	client := sturdyc.New[string](100, 10, time.Hour, 5,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithMissingRecordStorage(),
+		sturdyc.WithDistributedStorage(&mockStorage{}),
+		sturdyc.WithDistributedMetrics(metricsRecorder),
+	)
+
+	// Promote a missing record, and coalesce a fetch onto one that is in flight.
+	client.StoreMissingRecord("1")
+	client.Set("1", "value")
+	fetchObserver := NewFetchObserver(1)
+	fetchObserver.Response("2")
+	var wg sync.WaitGroup
+	wg.Add(2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			defer wg.Done()
+			client.GetOrFetch(ctx, "2", fetchObserver.Fetch)
+		}()
+	}
+	<-fetchObserver.FetchCompleted
+	wg.Wait()
+	client.Get("1")
+
+	if metricsRecorder.hits.Load() == 0 {
+		t.Error("expected the cache hits to be reported")
+	}
+}
+
+// namedMetricsRecorder hands out a recorder for each cache name.
+type namedMetricsRecorder struct {
+	*TestMetricsRecorder
+	mu        sync.Mutex
+	recorders map[string]*TestMetricsRecorder
+}
+
+func (r *namedMetricsRecorder) ForCache(name string) sturdyc.MetricsRecorder {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	recorder := newTestMetricsRecorder(10)
+	r.recorders[name] = recorder
+	return recorder
+}
+
+func TestNamedCachesReportMetricsToTheirOwnRecorder(t *testing.T) {
+	t.Parallel()
+
+	metricsRecorder := &namedMetricsRecorder{
+		TestMetricsRecorder: newTestMetricsRecorder(10),
+		recorders:           make(map[string]*TestMetricsRecorder),
+	}
+	users := sturdyc.New[string](100, 10, time.Hour, 5,
+		sturdyc.WithName("user-cache"),
+		sturdyc.WithMetrics(metricsRecorder),
+	)
+	orders := sturdyc.New[string](100, 10, time.Hour, 5,
+		sturdyc.WithName("order-cache"),
+		sturdyc.WithMetrics(metricsRecorder),
+	)
+
+	users.Set("existing-key", "value")
+	users.Get("existing-key")
+	orders.Get("non-existent-key")
+
+	if metricsRecorder.cacheHits != 0 || metricsRecorder.cacheMisses != 0 {
+		t.Errorf("expected the shared recorder to be unused, got %d hits and %d misses", metricsRecorder.cacheHits, metricsRecorder.cacheMisses)
+	}
+	if r := metricsRecorder.recorders["user-cache"]; r.cacheHits != 1 || r.cacheMisses != 0 {
+		t.Errorf("expected 1 hit for the user cache, got %d hits and %d misses", r.cacheHits, r.cacheMisses)
+	}
+	if r := metricsRecorder.recorders["order-cache"]; r.cacheHits != 0 || r.cacheMisses != 1 {
+		t.Errorf("expected 1 miss for the order cache, got %d hits and %d misses", r.cacheHits, r.cacheMisses)
+	}
+}
+
+// latencyMetricsRecorder keeps the durations that the cache reports.
+type latencyMetricsRecorder struct {
+	*TestMetricsRecorder
+	mu                   sync.Mutex
+	fetchDurations       []time.Duration
+	refreshDurations     []time.Duration
+	distributedDurations map[sturdyc.DistributedOperation][]time.Duration
+}
+
+func (r *latencyMetricsRecorder) ObserveFetchDuration(d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.fetchDurations = append(r.fetchDurations, d)
+}
+
+func (r *latencyMetricsRecorder) ObserveRefreshDuration(d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.refreshDurations = append(r.refreshDurations, d)
+}
+
+func (r *latencyMetricsRecorder) ObserveDistributedDuration(operation sturdyc.DistributedOperation, d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.distributedDurations[operation] = append(r.distributedDurations[operation], d)
+}
+
+func TestReportsLatencyMetrics(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	refreshDelay := time.Minute
+	clock := sturdyc.NewTestClock(time.Now())
+	metricsRecorder := &latencyMetricsRecorder{
+		TestMetricsRecorder:  newTestMetricsRecorder(1),
+		distributedDurations: make(map[sturdyc.DistributedOperation][]time.Duration),
+	}
+	client := sturdyc.New[string](100, 1, time.Hour, 5,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithEarlyRefreshes(refreshDelay, refreshDelay, time.Second),
+		sturdyc.WithMetrics(metricsRecorder),
+		sturdyc.WithClock(clock),
+	)
+
+	fetchFn := func(context.Context) (string, error) {
+		clock.Add(time.Second)
+		return "value", nil
+	}
+	if _, err := client.GetOrFetch(ctx, "1", fetchFn); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.WaitForIdle(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	clock.Add(refreshDelay + time.Second)
+	if _, err := client.GetOrFetch(ctx, "1", fetchFn); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.WaitForIdle(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	metricsRecorder.mu.Lock()
+	defer metricsRecorder.mu.Unlock()
+	if !slices.Equal(metricsRecorder.fetchDurations, []time.Duration{time.Second}) {
+		t.Errorf("expected a fetch of 1s, got %v", metricsRecorder.fetchDurations)
+	}
+	if !slices.Equal(metricsRecorder.refreshDurations, []time.Duration{time.Second}) {
+		t.Errorf("expected a refresh of 1s, got %v", metricsRecorder.refreshDurations)
+	}
+}
+
+// evictionMetricsRecorder keeps the ages and sizes of the evicted entries by reason.
+type evictionMetricsRecorder struct {
+	*TestMetricsRecorder
+	mu    sync.Mutex
+	ages  map[sturdyc.EvictionReason][]time.Duration
+	sizes map[sturdyc.EvictionReason]int
+}
+
+func (r *evictionMetricsRecorder) EntryEvicted(reason sturdyc.EvictionReason, age time.Duration, size int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ages[reason] = append(r.ages[reason], age)
+	r.sizes[reason] += size
+}
+
+func TestReportsEvictionsByReason(t *testing.T) {
+	t.Parallel()
+
+	ttl := time.Hour
+	clock := sturdyc.NewTestClock(time.Now())
+	metricsRecorder := &evictionMetricsRecorder{
+		TestMetricsRecorder: newTestMetricsRecorder(1),
+		ages:                make(map[sturdyc.EvictionReason][]time.Duration),
+		sizes:               make(map[sturdyc.EvictionReason]int),
+	}
+	client := sturdyc.New[string](2, 1, ttl, 50,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithMetrics(metricsRecorder),
+		sturdyc.WithClock(clock),
+	)
+
+	// The third write has to evict the oldest entry to make room.
+	client.Set("1", "value")
+	clock.Add(10 * time.Second)
+	client.Set("2", "value")
+	clock.Add(10 * time.Second)
+	client.Set("3", "value")
+
+	clock.Add(ttl + time.Second)
+	client.EvictExpired()
+
+	metricsRecorder.mu.Lock()
+	defer metricsRecorder.mu.Unlock()
+	capacity := metricsRecorder.ages[sturdyc.EvictionCapacity]
+	if !slices.Equal(capacity, []time.Duration{20 * time.Second}) {
+		t.Errorf("expected the first entry to be evicted for capacity at 20s, got %v", capacity)
+	}
+	expired := metricsRecorder.ages[sturdyc.EvictionExpired]
+	slices.Sort(expired)
+	if !slices.Equal(expired, []time.Duration{ttl + time.Second, ttl + 11*time.Second}) {
+		t.Errorf("expected the remaining entries to be evicted as expired, got %v", expired)
+	}
+}
+
+func TestReportsTheSizeOfTheEvictedEntries(t *testing.T) {
+	t.Parallel()
+
+	ttl := time.Hour
+	clock := sturdyc.NewTestClock(time.Now())
+	metricsRecorder := &evictionMetricsRecorder{
+		TestMetricsRecorder: newTestMetricsRecorder(1),
+		ages:                make(map[sturdyc.EvictionReason][]time.Duration),
+		sizes:               make(map[sturdyc.EvictionReason]int),
+	}
+	client := sturdyc.New[string](2, 1, ttl, 50,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithMetrics(metricsRecorder),
+		sturdyc.WithCostFunc(func(key, value string) int {
+			return len(key) + len(value)
+		}),
+		sturdyc.WithClock(clock),
+	)
+
+	client.Set("1", "a")
+	clock.Add(time.Second)
+	client.Set("2", "bb")
+	clock.Add(time.Second)
+	client.Set("3", "ccc")
+
+	clock.Add(ttl + time.Second)
+	client.EvictExpired()
+
+	metricsRecorder.mu.Lock()
+	defer metricsRecorder.mu.Unlock()
+	if got := metricsRecorder.sizes[sturdyc.EvictionCapacity]; got != 2 {
+		t.Errorf("expected 2 bytes to be evicted for capacity, got %d", got)
+	}
+	if got := metricsRecorder.sizes[sturdyc.EvictionExpired]; got != 7 {
+		t.Errorf("expected 7 bytes to be evicted as expired, got %d", got)
+	}
+}
+
+func TestStripedLocksConcurrentReadsAndWrites(t *testing.T) {
+	t.Parallel()
+
+	client := sturdyc.New[int](1000, 2, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithStripedLocks(8),
+	)
+
+	numGoroutines := 50
+	var wg sync.WaitGroup
+	wg.Add(numGoroutines)
+	for i := 0; i < numGoroutines; i++ {
+		go func(i int) {
+			defer wg.Done()
+			key := strconv.Itoa(i % 10)
+			for j := 0; j < 100; j++ {
+				client.Set(key, j)
+				client.Get(key)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if client.Size() != 10 {
+		t.Errorf("expected cache size to be 10, got %d", client.Size())
+	}
+}
+
+func TestResizeEvictsTheEntriesThatAreClosestToExpiring(t *testing.T) {
+	t.Parallel()
+
+	clock := sturdyc.NewTestClock(time.Now())
+	client := sturdyc.New[int](100, 1, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithClock(clock),
+	)
+	for i := 0; i < 50; i++ {
+		client.Set(strconv.Itoa(i), i)
+		clock.Add(time.Second)
+	}
+
+	client.Resize(20)
+	if client.Size() != 20 {
+		t.Fatalf("expected the cache size to be 20, got %d", client.Size())
+	}
+	if _, ok := client.Get("29"); ok {
+		t.Error("expected the older entries to have been evicted")
+	}
+	if _, ok := client.Get("30"); !ok {
+		t.Error("expected the newer entries to have been kept")
+	}
+
+	client.Resize(200)
+	for i := 50; i < 200; i++ {
+		client.Set(strconv.Itoa(i), i)
+	}
+	if client.Size() != 170 {
+		t.Errorf("expected the cache to grow to 170 entries, got %d", client.Size())
+	}
+}
+
+func TestReshardKeepsTheEntries(t *testing.T) {
+	t.Parallel()
+
+	client := sturdyc.New[int](1000, 2, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+	)
+	for i := 0; i < 100; i++ {
+		client.Set(strconv.Itoa(i), i)
+	}
+
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				client.Set(strconv.Itoa(i), i)
+				client.Get(strconv.Itoa(i))
+			}
+		}()
+	}
+	client.Reshard(8)
+	wg.Wait()
+
+	stats := client.ShardStats()
+	if len(stats) != 8 {
+		t.Fatalf("expected 8 shards, got %d", len(stats))
+	}
+	if stats[0].Capacity != 125 {
+		t.Errorf("expected the capacity to be spread across the shards, got %d", stats[0].Capacity)
+	}
+	for i := 0; i < 100; i++ {
+		if value, ok := client.Get(strconv.Itoa(i)); !ok || value != i {
+			t.Errorf("expected key %d to have been kept, got %d", i, value)
+		}
+	}
+
+	client.Delete("1")
+	client.Reshard(3)
+	if client.Size() != 99 {
+		t.Errorf("expected 99 entries after resharding, got %d", client.Size())
+	}
+	if _, ok := client.Get("1"); ok {
+		t.Error("expected the deleted key to stay deleted")
+	}
+}
+
+// maxSource is a random source that always returns its largest value.
+type maxSource struct{}
+
+func (maxSource) Uint64() uint64 { return math.MaxUint64 }
+
+func TestWritesThatAreForwardedDuringAReshardAreOnlyJitteredOnce(t *testing.T) {
+	t.Parallel()
+
+	ttl := time.Hour
+	clock := sturdyc.NewTestClock(time.Now())
+	var client *sturdyc.Client[int]
+	var resharded atomic.Bool
+	client = sturdyc.New[int](100, 1, ttl, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithTTLJitter(0.5),
+		sturdyc.WithRandSource(maxSource{}),
+		sturdyc.WithClock(clock),
+		// Reshard after the write has picked its shard, so that it has to be
+		// forwarded to the successor.
+		sturdyc.WithKeyHasher(func(key string) uint64 {
+			if key == "key" && resharded.CompareAndSwap(false, true) {
+				client.Reshard(2)
+			}
+			return xxhash.Sum64String(key)
+		}),
+	)
+
+	client.Set("key", 1)
+	info, ok := client.EntryInfo("key")
+	if !ok {
+		t.Fatal("expected the key to have been written to the new shards")
+	}
+	if maxExpiry := clock.Now().Add(ttl + ttl/2); info.ExpiresAt.After(maxExpiry) {
+		t.Errorf("expected the TTL to be jittered once, expires at %v which is after %v", info.ExpiresAt, maxExpiry)
+	}
+}
+
+func TestAdaptiveEvictionsSpeedUpWhenTheShardsAreFull(t *testing.T) {
+	t.Parallel()
+
+	start := time.Now()
+	clock := sturdyc.NewTestClock(start)
+	ttl := 10 * time.Minute
+	client := sturdyc.New[int](10, 1, ttl, 10,
+		sturdyc.WithEvictionInterval(time.Minute),
+		sturdyc.WithAdaptiveEvictions(time.Second, time.Hour),
+		sturdyc.WithClock(clock),
+	)
+	for i := 0; i < 10; i++ {
+		client.Set(strconv.Itoa(i), i)
+	}
+
+	// The shard is at its capacity, which should make the interval shrink to a
+	// second. With the fixed interval, the entries would be evicted after 11 minutes.
+	for client.Size() > 0 {
+		clock.Add(time.Second)
+		time.Sleep(time.Millisecond)
+	}
+	if elapsed := clock.Since(start); elapsed > ttl+10*time.Second {
+		t.Errorf("expected the entries to be evicted shortly after they expired, took %v", elapsed)
+	}
+}
+
+func TestEvictExpiredReclaimsTheExpiredEntries(t *testing.T) {
+	t.Parallel()
+
+	clock := sturdyc.NewTestClock(time.Now())
+	client := sturdyc.New[int](100, 2, time.Minute, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithClock(clock),
+	)
+	for i := 0; i < 10; i++ {
+		client.Set(strconv.Itoa(i), i)
+	}
+	clock.Add(time.Minute + time.Second)
+	client.Set("fresh", 10)
+
+	var shardIndex, shardEntries int
+	for _, s := range client.ShardStats() {
+		if s.Entries > shardEntries {
+			shardIndex, shardEntries = s.Index, s.Entries
+		}
+	}
+	evicted := client.EvictExpiredShard(shardIndex)
+	if evicted == 0 || client.Size() != 11-evicted {
+		t.Errorf("expected the expired entries of shard %d to be evicted, evicted %d with a size of %d", shardIndex, evicted, client.Size())
+	}
+
+	client.EvictExpired()
+	if client.Size() != 1 {
+		t.Errorf("expected only the fresh entry to remain, got a size of %d", client.Size())
+	}
+	if _, ok := client.Get("fresh"); !ok {
+		t.Error("expected the fresh entry to be kept")
+	}
+}
+
+func TestPinnedEntriesAreNeverEvicted(t *testing.T) {
+	t.Parallel()
+
+	clock := sturdyc.NewTestClock(time.Now())
+	client := sturdyc.New[int](10, 1, time.Minute, 50,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithClock(clock),
+	)
+	client.Set("config", 1)
+	if !client.Pin("config") {
+		t.Fatal("expected the key to be pinned")
+	}
+	if client.Pin("missing") {
+		t.Error("expected keys that aren't in the cache not to be pinned")
+	}
+
+	// Fill the cache past its capacity, and let every entry expire.
+	for i := 0; i < 20; i++ {
+		client.Set(strconv.Itoa(i), i)
+	}
+	clock.Add(time.Hour)
+	client.EvictExpired()
+	client.Resize(1)
+	if value, ok := client.Get("config"); !ok || value != 1 {
+		t.Fatalf("expected the pinned entry to be kept, got %d", value)
+	}
+
+	// The pin survives the entry being overwritten.
+	client.Set("config", 2)
+	clock.Add(time.Hour)
+	if value, ok := client.Get("config"); !ok || value != 2 {
+		t.Errorf("expected the overwritten entry to still be pinned, got %d", value)
+	}
+
+	client.Unpin("config")
+	clock.Add(time.Hour)
+	if _, ok := client.Get("config"); ok {
+		t.Error("expected the unpinned entry to expire")
+	}
+}
+
+func TestFIFOEvictsTheOldestEntries(t *testing.T) {
+	t.Parallel()
+
+	clock := sturdyc.NewTestClock(time.Now())
+	client := sturdyc.New[int](10, 1, time.Hour, 20,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithEvictionPolicy(sturdyc.EvictFIFO),
+		sturdyc.WithClock(clock),
+	)
+	for i := 0; i < 9; i++ {
+		client.Set(strconv.Itoa(i), i)
+		clock.Add(time.Second)
+	}
+	// Overwriting the first entry gives it the latest expiration time, which means
+	// that the default policy would have kept it. FIFO goes by the first write.
+	client.Set("0", 100)
+	client.Set("9", 9)
+	client.Set("10", 10)
+
+	if client.Size() != 9 {
+		t.Fatalf("expected 2 entries to be evicted, got a size of %d", client.Size())
+	}
+	for _, key := range []string{"0", "1"} {
+		if _, ok := client.Get(key); ok {
+			t.Errorf("expected key %s to have been evicted first", key)
+		}
+	}
+	for _, key := range []string{"2", "9", "10"} {
+		if _, ok := client.Get(key); !ok {
+			t.Errorf("expected key %s to have been kept", key)
+		}
+	}
+}
+
+func TestARCKeepsTheFrequentlyReadEntriesDuringScans(t *testing.T) {
+	t.Parallel()
+
+	clock := sturdyc.NewTestClock(time.Now())
+	client := sturdyc.New[int](10, 1, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithEvictionPolicy(sturdyc.EvictARC),
+		sturdyc.WithClock(clock),
+	)
+	hotKeys := []string{"hot-1", "hot-2", "hot-3", "hot-4", "hot-5"}
+	for _, key := range hotKeys {
+		client.Set(key, 1)
+		client.Get(key)
+	}
+
+	// The hot keys have the earliest expiration times, which means that the
+	// default policy would have evicted them first. ARC evicts the scanned keys.
+	for i := 0; i < 50; i++ {
+		clock.Add(time.Second)
+		client.Set("scan-"+strconv.Itoa(i), i)
+	}
+
+	for _, key := range hotKeys {
+		if _, ok := client.Get(key); !ok {
+			t.Errorf("expected %s to survive the scan", key)
+		}
+	}
+	if client.Size() > 10 {
+		t.Errorf("expected the cache to stay within its capacity, got %d", client.Size())
+	}
+}
+
+func TestEntryInfoTracksTheLastAccess(t *testing.T) {
+	t.Parallel()
+
+	start := time.Now()
+	clock := sturdyc.NewTestClock(start)
+	client := sturdyc.New[int](100, 1, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithClock(clock),
+	)
+	client.Set("1", 1)
+	clock.Add(time.Minute)
+	client.Get("1")
+	// Reads within the granularity don't move the access time.
+	clock.Add(time.Millisecond)
+	client.Get("1")
+
+	info, ok := client.EntryInfo("1")
+	if !ok {
+		t.Fatal("expected the entry to exist")
+	}
+	if !info.CachedAt.Equal(start) || !info.ExpiresAt.Equal(start.Add(time.Hour)) {
+		t.Errorf("expected the entry to have been cached at %v, got %v", start, info.CachedAt)
+	}
+	if want := start.Add(time.Minute); !info.LastAccessedAt.Equal(want) {
+		t.Errorf("expected the last access to be %v, got %v", want, info.LastAccessedAt)
+	}
+	if info.Accesses != 2 {
+		t.Errorf("expected 2 accesses, got %d", info.Accesses)
+	}
+	if _, ok := client.EntryInfo("2"); ok {
+		t.Error("expected no info for a key that isn't in the cache")
+	}
+}
+
+func TestLRUEvictsTheLeastRecentlyReadEntries(t *testing.T) {
+	t.Parallel()
+
+	clock := sturdyc.NewTestClock(time.Now())
+	client := sturdyc.New[int](10, 1, time.Hour, 20,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithEvictionPolicy(sturdyc.EvictLRU),
+		sturdyc.WithClock(clock),
+	)
+	for i := 0; i < 10; i++ {
+		client.Set(strconv.Itoa(i), i)
+		clock.Add(time.Second)
+	}
+	// Reading the oldest entries makes the ones that follow them the least recently used.
+	client.Get("0")
+	client.Get("1")
+	clock.Add(time.Second)
+	client.Set("10", 10)
+
+	for _, key := range []string{"2", "3"} {
+		if _, ok := client.Get(key); ok {
+			t.Errorf("expected key %s to have been evicted", key)
+		}
+	}
+	for _, key := range []string{"0", "1", "4", "10"} {
+		if _, ok := client.Get(key); !ok {
+			t.Errorf("expected key %s to have been kept", key)
+		}
+	}
+}
+
+func TestLFUEvictsTheLeastFrequentlyReadEntries(t *testing.T) {
+	t.Parallel()
+
+	clock := sturdyc.NewTestClock(time.Now())
+	client := sturdyc.New[int](10, 1, time.Hour, 20,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithEvictionPolicy(sturdyc.EvictLFU),
+		sturdyc.WithClock(clock),
+	)
+	for i := 0; i < 10; i++ {
+		client.Set(strconv.Itoa(i), i)
+	}
+	for i := 2; i < 10; i++ {
+		client.Get(strconv.Itoa(i))
+		client.Get(strconv.Itoa(i))
+	}
+	// Overwriting a key, as a refresh would, keeps the reads that it has had.
+	client.Set("9", 9)
+	client.Set("10", 10)
+
+	for _, key := range []string{"0", "1"} {
+		if _, ok := client.Get(key); ok {
+			t.Errorf("expected key %s to have been evicted", key)
+		}
+	}
+	for _, key := range []string{"2", "9", "10"} {
+		if _, ok := client.Get(key); !ok {
+			t.Errorf("expected key %s to have been kept", key)
+		}
+	}
+}
+
+func TestARCConcurrentReadsAndWrites(t *testing.T) {
+	t.Parallel()
+
+	client := sturdyc.New[int](100, 2, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithEvictionPolicy(sturdyc.EvictARC),
+		sturdyc.WithStripedLocks(8),
+	)
+
+	numGoroutines := 20
+	var wg sync.WaitGroup
+	wg.Add(numGoroutines)
+	for i := 0; i < numGoroutines; i++ {
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 500; j++ {
+				key := strconv.Itoa((i*j + j) % 300)
+				if j%4 == 0 {
+					client.Set(key, j)
+					continue
+				}
+				client.Get(key)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if client.Size() > 100 {
+		t.Errorf("expected the cache to stay within its capacity, got %d", client.Size())
+	}
+}
+
+func TestListEntriesPaginatesOverEveryEntry(t *testing.T) {
+	t.Parallel()
+
+	client := sturdyc.New[int](1000, 4, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+	)
+	for i := 0; i < 95; i++ {
+		client.Set(strconv.Itoa(i), i)
+	}
+
+	seen := make(map[string]bool)
+	var pages int
+	cursor := ""
+	for {
+		infos, next := client.ListEntries(cursor, 10)
+		pages++
+		if len(infos) > 10 {
+			t.Fatalf("expected at most 10 entries per page, got %d", len(infos))
+		}
+		for _, info := range infos {
+			if seen[info.Key] {
+				t.Errorf("expected %s to only be listed once", info.Key)
+			}
+			seen[info.Key] = true
+		}
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+
+	if len(seen) != 95 {
+		t.Errorf("expected every entry to be listed, got %d", len(seen))
+	}
+	if pages < 10 || pages > 14 {
+		t.Errorf("expected about 10 pages, got %d", pages)
+	}
+	if infos, next := client.ListEntries("invalid", 10); infos != nil || next != "" {
+		t.Errorf("expected an invalid cursor to end the listing, got %v and %q", infos, next)
+	}
+}
@@ -0,0 +1,120 @@
+package sturdyc
+
+import "time"
+
+// CallOption overrides the configuration of the cache for a single call to
+// GetOrFetch, GetOrFetchBatch, Passthrough or PassthroughBatch. If the call
+// is deduplicated with a call that is already in flight, the options of the
+// call in flight are used.
+type CallOption func(*callOptions)
+
+// callOptions holds the configuration that is used for a single call.
+type callOptions struct {
+	// ttl is the TTL of the records that the call writes. A
+	// value of 0 means that the TTL of the cache is used.
+	ttl time.Duration
+	// missingRecordTTL is the TTL of the missing records that the call
+	// writes. A value of 0 falls back to the TTL of the missing records of
+	// the cache, and then to ttl.
+	missingRecordTTL    time.Duration
+	noRefresh           bool
+	storeMissingRecords bool
+	// cacheHits is called with the number of records that the call was able
+	// to read from the cache. The namespaces use it to keep their statistics.
+	cacheHits func(n int)
+	// onStale is called with the error of the data source when the call
+	// serves a stale record.
+	onStale func(err error)
+}
+
+// CallTTL overrides the TTL of the records that are written by the call. A TTL
+// that is less than or equal to 0 leaves the TTL of the cache in place.
+func CallTTL(ttl time.Duration) CallOption {
+	return func(o *callOptions) {
+		o.ttl = max(ttl, 0)
+	}
+}
+
+// CallMissingRecordTTL overrides the TTL of the missing records that are
+// written by the call. A TTL that is less than or equal to 0 leaves the TTL
+// of the cache in place.
+func CallMissingRecordTTL(ttl time.Duration) CallOption {
+	return func(o *callOptions) {
+		o.missingRecordTTL = max(ttl, 0)
+	}
+}
+
+// CallNoRefresh prevents the call from scheduling background refreshes for
+// the records that it reads from the cache.
+func CallNoRefresh() CallOption {
+	return func(o *callOptions) {
+		o.noRefresh = true
+	}
+}
+
+// CallMissingRecordStorage overrides whether the records that the underlying
+// data source reports as missing are stored as missing records by the call.
+func CallMissingRecordStorage(store bool) CallOption {
+	return func(o *callOptions) {
+		o.storeMissingRecords = store
+	}
+}
+
+// CallOnStale registers a function that is called when GetOrFetch serves a
+// stale record because the underlying data source failed. The function is
+// passed the error of the data source, while the call itself returns the stale
+// value without an error.
+//
+// NOTE: This requires the WithStaleOnError functionality to be enabled.
+func CallOnStale(fn func(err error)) CallOption {
+	return func(o *callOptions) {
+		o.onStale = fn
+	}
+}
+
+// newCallOptions applies the options on top of the configuration of the cache.
+func (c *Config) newCallOptions(opts []CallOption) callOptions {
+	options := callOptions{storeMissingRecords: c.storeMissingRecords}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
+
+// reportCacheHits passes the number of records that were read from the cache
+// to the callback of the call, if it has one.
+func (o callOptions) reportCacheHits(n int) {
+	if o.cacheHits != nil {
+		o.cacheHits(n)
+	}
+}
+
+// reportStale passes the error that made the call
+// serve a stale record to the callback of the call.
+func (o callOptions) reportStale(err error) {
+	if o.onStale != nil {
+		o.onStale(err)
+	}
+}
+
+// set writes a record to the cache using the options of the call.
+func (c *Client[T]) set(key string, value T, opts callOptions) bool {
+	return c.getShard(key).set(key, value, false, opts.ttl)
+}
+
+// storeMissingRecord writes a missing record to the cache using the options of
+// the call. Keys that have been given a value since the data source was called
+// are left untouched, as the record was created after the fetch started.
+func (c *Client[T]) storeMissingRecord(key string, opts callOptions, fetchedAt time.Time) bool {
+	ttl := opts.missingRecordTTL
+	if ttl == 0 {
+		ttl = c.missingRecordTTL
+	}
+	if ttl == 0 {
+		ttl = opts.ttl
+	}
+	var zero T
+	return c.getShard(key).setIf(key, zero, true, ttl, func(existing *entry[T]) bool {
+		return existing == nil || existing.isMissingRecord || !existing.cachedAt.After(fetchedAt)
+	})
+}
@@ -0,0 +1,178 @@
+package sturdyc_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/viccon/sturdyc"
+)
+
+func TestCallTTLOverridesTheTTLOfTheCache(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	clock := sturdyc.NewTestClock(time.Now())
+	c := sturdyc.New[string](100, 1, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithClock(clock),
+	)
+
+	fetchObserver := NewFetchObserver(3)
+	fetchObserver.Response("1")
+	c.GetOrFetch(ctx, "1", fetchObserver.Fetch, sturdyc.CallTTL(time.Minute))
+	<-fetchObserver.FetchCompleted
+
+	ids := []string{"2", "3"}
+	keyFn := c.BatchKeyFn("item")
+	fetchObserver.BatchResponse(ids)
+	c.GetOrFetchBatch(ctx, ids, keyFn, fetchObserver.FetchBatch, sturdyc.CallTTL(time.Minute))
+	<-fetchObserver.FetchCompleted
+
+	clock.Add(time.Minute + 1)
+	if _, ok := c.Get("1"); ok {
+		t.Error("expected the record to have expired")
+	}
+	if records := c.GetManyKeyFn(ids, keyFn); len(records) != 0 {
+		t.Errorf("expected the batch records to have expired, got %v", records)
+	}
+}
+
+func TestCallNoRefreshPreventsBackgroundRefreshes(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	clock := sturdyc.NewTestClock(time.Now())
+	refreshDelay := time.Second
+	c := sturdyc.New[string](100, 1, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithEarlyRefreshes(refreshDelay, refreshDelay, time.Millisecond),
+		sturdyc.WithClock(clock),
+	)
+
+	fetchObserver := NewFetchObserver(2)
+	fetchObserver.Response("1")
+	c.GetOrFetch(ctx, "1", fetchObserver.Fetch)
+	<-fetchObserver.FetchCompleted
+
+	clock.Add(refreshDelay + 1)
+	c.GetOrFetch(ctx, "1", fetchObserver.Fetch, sturdyc.CallNoRefresh())
+	time.Sleep(10 * time.Millisecond)
+	fetchObserver.AssertFetchCount(t, 1)
+
+	// Calls without the option are still going to refresh the record.
+	c.GetOrFetch(ctx, "1", fetchObserver.Fetch)
+	<-fetchObserver.FetchCompleted
+	fetchObserver.AssertFetchCount(t, 2)
+}
+
+func TestCallMissingRecordStorageOverridesTheCache(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	c := sturdyc.New[string](100, 1, time.Hour, 10)
+
+	fetchObserver := NewFetchObserver(2)
+	fetchObserver.Err(sturdyc.ErrNotFound)
+	_, err := c.GetOrFetch(ctx, "1", fetchObserver.Fetch, sturdyc.CallMissingRecordStorage(true))
+	<-fetchObserver.FetchCompleted
+	if !errors.Is(err, sturdyc.ErrMissingRecord) {
+		t.Fatalf("expected ErrMissingRecord, got %v", err)
+	}
+
+	// The record should now be served from the cache as a missing record.
+	_, err = c.GetOrFetch(ctx, "1", fetchObserver.Fetch)
+	if !errors.Is(err, sturdyc.ErrMissingRecord) {
+		t.Errorf("expected ErrMissingRecord, got %v", err)
+	}
+	fetchObserver.AssertFetchCount(t, 1)
+}
+
+func TestCallMissingRecordStorageCanDisableTheStorageForABatch(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	c := sturdyc.New[string](100, 1, time.Hour, 10, sturdyc.WithMissingRecordStorage())
+	keyFn := c.BatchKeyFn("item")
+
+	fetchObserver := NewFetchObserver(2)
+	fetchObserver.BatchResponse([]string{"1"})
+	res, err := c.GetOrFetchBatch(ctx, []string{"1", "2"}, keyFn, fetchObserver.FetchBatch, sturdyc.CallMissingRecordStorage(false))
+	<-fetchObserver.FetchCompleted
+	if err != nil || len(res) != 1 {
+		t.Fatalf("expected one record, got %v: %v", res, err)
+	}
+
+	// The ID that was missing should be fetched again rather than being served as a missing record.
+	fetchObserver.Clear()
+	fetchObserver.BatchResponse([]string{"2"})
+	res, err = c.GetOrFetchBatch(ctx, []string{"1", "2"}, keyFn, fetchObserver.FetchBatch)
+	<-fetchObserver.FetchCompleted
+	if err != nil || len(res) != 2 {
+		t.Fatalf("expected both records, got %v: %v", res, err)
+	}
+	fetchObserver.AssertFetchCount(t, 2)
+}
+
+func TestCallMissingRecordStorageIsUsedForTheDistributedStorage(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	isMissingRecord := func(storage *mockStorage, key string) (bool, bool) {
+		storage.Lock()
+		defer storage.Unlock()
+		bytes, ok := storage.records[key]
+		return ok && strings.Contains(string(bytes), `"is_missing_record":true`), ok
+	}
+
+	// The call disables the missing record storage of the cache.
+	storage := &mockStorage{}
+	c := sturdyc.New[string](100, 1, time.Hour, 10,
+		sturdyc.WithMissingRecordStorage(),
+		sturdyc.WithDistributedStorage(storage),
+	)
+	fetchObserver := NewFetchObserver(2)
+	fetchObserver.Err(sturdyc.ErrNotFound)
+	c.GetOrFetch(ctx, "1", fetchObserver.Fetch, sturdyc.CallMissingRecordStorage(false))
+	<-fetchObserver.FetchCompleted
+	keyFn := c.BatchKeyFn("item")
+	fetchObserver.Clear()
+	fetchObserver.BatchResponse([]string{"1"})
+	c.GetOrFetchBatch(ctx, []string{"1", "2"}, keyFn, fetchObserver.FetchBatch, sturdyc.CallMissingRecordStorage(false))
+	<-fetchObserver.FetchCompleted
+	if err := c.WaitForIdle(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := isMissingRecord(storage, "1"); ok {
+		t.Error("expected the missing record to not be written to the distributed storage")
+	}
+	if _, ok := isMissingRecord(storage, keyFn("2")); ok {
+		t.Error("expected the missing batch record to not be written to the distributed storage")
+	}
+	storage.assertRecord(t, keyFn("1"))
+
+	// The call enables the missing record storage for a cache that doesn't use it.
+	storage = &mockStorage{}
+	c = sturdyc.New[string](100, 1, time.Hour, 10,
+		sturdyc.WithDistributedStorage(storage),
+	)
+	fetchObserver = NewFetchObserver(2)
+	fetchObserver.Err(sturdyc.ErrNotFound)
+	c.GetOrFetch(ctx, "1", fetchObserver.Fetch, sturdyc.CallMissingRecordStorage(true))
+	<-fetchObserver.FetchCompleted
+	fetchObserver.Clear()
+	fetchObserver.BatchResponse([]string{"1"})
+	c.GetOrFetchBatch(ctx, []string{"1", "2"}, keyFn, fetchObserver.FetchBatch, sturdyc.CallMissingRecordStorage(true))
+	<-fetchObserver.FetchCompleted
+	if err := c.WaitForIdle(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if missing, _ := isMissingRecord(storage, "1"); !missing {
+		t.Error("expected the record to be marked as missing in the distributed storage")
+	}
+	if missing, _ := isMissingRecord(storage, keyFn("2")); !missing {
+		t.Error("expected the batch record to be marked as missing in the distributed storage")
+	}
+}
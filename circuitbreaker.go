@@ -0,0 +1,200 @@
+package sturdyc
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+type circuitState int
+
+const (
+	circuitClosed circuitState = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+// circuitBreaker keeps track of the consecutive failures for
+// the calls that are made to the underlying data source.
+type circuitBreaker struct {
+	state    circuitState
+	failures int
+	openedAt time.Time
+}
+
+// circuitBreakers holds a circuit breaker for each group of keys.
+type circuitBreakers struct {
+	sync.Mutex
+	failureThreshold int
+	openDuration     time.Duration
+	breakers         map[string]*circuitBreaker
+}
+
+func newCircuitBreakers(failureThreshold int, openDuration time.Duration) *circuitBreakers {
+	return &circuitBreakers{
+		failureThreshold: failureThreshold,
+		openDuration:     openDuration,
+		breakers:         make(map[string]*circuitBreaker),
+	}
+}
+
+// circuitBreakerGroup returns the name of the circuit breaker that should be used for the key.
+func (c *Config) circuitBreakerGroup(key string) string {
+	if c.circuitBreakerGroupFn == nil {
+		return ""
+	}
+	return c.circuitBreakerGroupFn(key)
+}
+
+// allow reports whether a call to the underlying data source should be made.
+// Once the open duration has passed, a single call is allowed through to probe
+// whether the data source has recovered.
+func (cb *circuitBreakers) allow(group string, now time.Time) bool {
+	cb.Lock()
+	defer cb.Unlock()
+
+	breaker, ok := cb.breakers[group]
+	if !ok {
+		return true
+	}
+
+	switch breaker.state {
+	case circuitOpen:
+		if now.Sub(breaker.openedAt) < cb.openDuration {
+			return false
+		}
+		breaker.state = circuitHalfOpen
+		return true
+	case circuitHalfOpen:
+		return false
+	case circuitClosed:
+	}
+	return true
+}
+
+// record updates the circuit breaker with the outcome of a call, and reports
+// whether the call made the circuit breaker open, or close after a probe.
+func (cb *circuitBreakers) record(group string, success bool, now time.Time) (opened, recovered bool) {
+	cb.Lock()
+	defer cb.Unlock()
+
+	breaker, ok := cb.breakers[group]
+	if !ok {
+		if success {
+			return false, false
+		}
+		breaker = &circuitBreaker{}
+		cb.breakers[group] = breaker
+	}
+
+	if success {
+		recovered = breaker.state == circuitHalfOpen
+		breaker.state = circuitClosed
+		breaker.failures = 0
+		return false, recovered
+	}
+
+	breaker.failures++
+	if breaker.state == circuitHalfOpen || breaker.failures >= cb.failureThreshold {
+		breaker.state = circuitOpen
+		breaker.openedAt = now
+		breaker.failures = 0
+		return true, false
+	}
+	return false, false
+}
+
+// abandon is called when the caller gave up on a call before the data source
+// responded. No outcome is recorded, but a probe of a half-open circuit
+// breaker is released so that the next call can probe the data source.
+func (cb *circuitBreakers) abandon(group string) {
+	cb.Lock()
+	defer cb.Unlock()
+
+	if breaker, ok := cb.breakers[group]; ok && breaker.state == circuitHalfOpen {
+		breaker.state = circuitOpen
+	}
+}
+
+// isFailure reports whether the error indicates that the underlying data
+// source is unhealthy. Records that are missing, or batches that only
+// partially failed, means that the data source was able to respond.
+func isFailure(err error) bool {
+	_, isBatchErr := asBatchError(err)
+	return err != nil && !isBatchErr && !errors.Is(err, ErrNotFound)
+}
+
+// isAbandoned reports whether the call failed because the caller cancelled
+// its context, or because the deadline of the context passed. This says
+// nothing about the health of the data source, so one impatient caller
+// shouldn't be able to open the circuit breaker for everyone else.
+func isAbandoned(ctx context.Context, err error) bool {
+	return err != nil && ctx.Err() != nil && errors.Is(err, ctx.Err())
+}
+
+// guard calls the fn if the circuit breaker for the group allows it.
+func (c *Client[T]) guard(ctx context.Context, group string, fn func() error) error {
+	if !c.circuitBreakers.allow(group, c.clock.Now()) {
+		return ErrCircuitOpen
+	}
+
+	// The failure is recorded in a deferred function so that
+	// a panic doesn't leave the circuit breaker half-open.
+	success, abandoned := false, false
+	defer func() {
+		if abandoned {
+			c.circuitBreakers.abandon(group)
+			return
+		}
+		if opened, _ := c.circuitBreakers.record(group, success, c.clock.Now()); opened {
+			c.logger(LogFetches).Warn("sturdyc: circuit breaker opened", "group", group)
+		}
+	}()
+
+	err := fn()
+	success, abandoned = !isFailure(err), isAbandoned(ctx, err)
+	return err
+}
+
+// circuitBreakerFetch wraps the fetchFn with the circuit breaker for the key.
+func circuitBreakerFetch[V, T any](c *Client[T], key string, fetchFn FetchFn[V]) FetchFn[V] {
+	if c.circuitBreakers == nil {
+		return fetchFn
+	}
+
+	return func(ctx context.Context) (V, error) {
+		var response V
+		err := c.guard(ctx, c.circuitBreakerGroup(key), func() error {
+			var fetchErr error
+			response, fetchErr = fetchFn(ctx)
+			return fetchErr
+		})
+		return response, err
+	}
+}
+
+// circuitBreakerBatchFetch wraps the fetchFn with the circuit breaker for the
+// batch. The group is determined by the cache key of the first ID.
+func circuitBreakerBatchFetch[V, T any](c *Client[T], keyFn KeyFn, fetchFn BatchFetchFn[V]) BatchFetchFn[V] {
+	if c.circuitBreakers == nil {
+		return fetchFn
+	}
+
+	return func(ctx context.Context, ids []string) (map[string]V, error) {
+		if len(ids) == 0 {
+			return fetchFn(ctx, ids)
+		}
+
+		var response map[string]V
+		err := c.guard(ctx, c.circuitBreakerGroup(keyFn(ids[0])), func() error {
+			var fetchErr error
+			response, fetchErr = fetchFn(ctx, ids)
+			return fetchErr
+		})
+		if response == nil {
+			response = map[string]V{}
+		}
+		return response, err
+	}
+}
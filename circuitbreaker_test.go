@@ -0,0 +1,195 @@
+package sturdyc_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/viccon/sturdyc"
+)
+
+func TestCircuitBreakerOpensAfterConsecutiveFailures(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	clock := sturdyc.NewTestClock(time.Now())
+	openDuration := time.Minute
+	c := sturdyc.New[string](100, 2, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithCircuitBreaker(3, openDuration),
+		sturdyc.WithClock(clock),
+	)
+
+	var calls atomic.Int32
+	var healthy atomic.Bool
+	fetchFn := func(_ context.Context) (string, error) {
+		calls.Add(1)
+		if healthy.Load() {
+			return "value", nil
+		}
+		return "", errors.New("unavailable")
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err := c.GetOrFetch(ctx, "key", fetchFn); errors.Is(err, sturdyc.ErrCircuitOpen) {
+			t.Fatalf("expected the circuit breaker to be closed after %d failures", i)
+		}
+	}
+
+	// The circuit breaker should now be open, which means that we shouldn't reach the data source.
+	_, err := c.GetOrFetch(ctx, "key", fetchFn)
+	if !errors.Is(err, sturdyc.ErrCircuitOpen) {
+		t.Errorf("expected ErrCircuitOpen, got %v", err)
+	}
+	if got := calls.Load(); got != 3 {
+		t.Errorf("expected 3 calls, got %d", got)
+	}
+
+	// Once the open duration has passed, the next call should probe the data
+	// source. Since it still fails, the circuit breaker should open again.
+	clock.Add(openDuration)
+	c.GetOrFetch(ctx, "key", fetchFn)
+	if got := calls.Load(); got != 4 {
+		t.Errorf("expected 4 calls, got %d", got)
+	}
+	if _, err := c.GetOrFetch(ctx, "key", fetchFn); !errors.Is(err, sturdyc.ErrCircuitOpen) {
+		t.Errorf("expected ErrCircuitOpen, got %v", err)
+	}
+
+	// A successful probe should close the circuit breaker.
+	healthy.Store(true)
+	clock.Add(openDuration)
+	res, err := c.GetOrFetch(ctx, "key", fetchFn)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if res != "value" {
+		t.Errorf("expected value, got %s", res)
+	}
+	if _, err := c.GetOrFetch(ctx, "key2", fetchFn); err != nil {
+		t.Errorf("expected the circuit breaker to be closed, got %v", err)
+	}
+}
+
+func TestCircuitBreakerKeepsServingRecordsThatAreDueForARefresh(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	clock := sturdyc.NewTestClock(time.Now())
+	c := sturdyc.New[string](100, 2, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithEarlyRefreshes(time.Second, time.Second, time.Millisecond),
+		sturdyc.WithCircuitBreaker(1, time.Minute),
+		sturdyc.WithClock(clock),
+	)
+
+	c.Set("key", "value")
+	failingFetch := func(_ context.Context) (string, error) {
+		return "", errors.New("unavailable")
+	}
+	c.GetOrFetch(ctx, "missing", failingFetch)
+
+	clock.Add(time.Second + 1)
+	res, err := c.GetOrFetch(ctx, "key", failingFetch)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if res != "value" {
+		t.Errorf("expected value, got %s", res)
+	}
+}
+
+func TestCircuitBreakerGroups(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	groupFn := func(key string) string {
+		return strings.Split(key, "-")[0]
+	}
+	c := sturdyc.New[string](100, 2, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithCircuitBreaker(1, time.Minute),
+		sturdyc.WithCircuitBreakerGroups(groupFn),
+	)
+
+	failingFetch := func(_ context.Context) (string, error) {
+		return "", errors.New("unavailable")
+	}
+	batchFetch := func(_ context.Context, ids []string) (map[string]string, error) {
+		return map[string]string{ids[0]: "value"}, nil
+	}
+
+	c.GetOrFetch(ctx, "foo-1", failingFetch)
+	if _, err := c.GetOrFetch(ctx, "foo-2", failingFetch); !errors.Is(err, sturdyc.ErrCircuitOpen) {
+		t.Errorf("expected ErrCircuitOpen, got %v", err)
+	}
+
+	_, err := c.GetOrFetchBatch(ctx, []string{"1"}, c.BatchKeyFn("foo"), batchFetch)
+	if !errors.Is(err, sturdyc.ErrCircuitOpen) {
+		t.Errorf("expected ErrCircuitOpen, got %v", err)
+	}
+
+	res, err := c.GetOrFetchBatch(ctx, []string{"1"}, c.BatchKeyFn("bar"), batchFetch)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if res["1"] != "value" {
+		t.Errorf("expected value, got %s", res["1"])
+	}
+}
+
+func TestCircuitBreakerIgnoresNotFoundErrors(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	c := sturdyc.New[string](100, 2, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithCircuitBreaker(1, time.Minute),
+	)
+
+	notFoundFetch := func(_ context.Context) (string, error) {
+		return "", sturdyc.ErrNotFound
+	}
+	c.GetOrFetch(ctx, "key1", notFoundFetch)
+	if _, err := c.GetOrFetch(ctx, "key2", notFoundFetch); !errors.Is(err, sturdyc.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestCircuitBreakerIgnoresCallsThatTheCallerCancelled(t *testing.T) {
+	t.Parallel()
+
+	c := sturdyc.New[string](100, 2, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithCircuitBreaker(2, time.Minute),
+	)
+
+	var calls atomic.Int32
+	fetchFn := func(ctx context.Context) (string, error) {
+		calls.Add(1)
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		return "value", nil
+	}
+
+	cancelledCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+	expiredCtx, cancelExpired := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancelExpired()
+	for _, ctx := range []context.Context{cancelledCtx, expiredCtx, cancelledCtx} {
+		if _, err := c.GetOrFetch(ctx, "key", fetchFn); errors.Is(err, sturdyc.ErrCircuitOpen) {
+			t.Fatal("expected the cancelled calls to leave the circuit breaker closed")
+		}
+	}
+
+	if _, err := c.GetOrFetch(context.Background(), "key", fetchFn); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	if got := calls.Load(); got != 4 {
+		t.Errorf("expected 4 calls, got %d", got)
+	}
+}
@@ -57,9 +57,24 @@ type testTicker struct {
 	stopped  *atomic.Bool
 }
 
-// TestClock is a clock that satisfies the Clock interface. It should only be used for testing.
+// TestClock is a clock that satisfies the Clock interface, and it can be passed
+// to the cache with WithClock in order to test it deterministically. Time only
+// moves forward when Set, Add or Advance is called. The clock gates:
+//
+//   - The expiry of the records, and the TTL of the cached errors.
+//   - The refresh times of WithEarlyRefreshes, and the backoff of failed refreshes.
+//   - The timeouts of the refresh buffers that are used by WithRefreshCoalescing.
+//   - The ticker of the continuous evictions.
+//   - The delays of the retry policy, the fetch timeout, and the hedged fetches.
+//   - The open duration of the circuit breakers.
+//   - The waits for the distributed locks.
+//
+// The timers and tickers are created by background goroutines, which means
+// that a test may have to call BlockUntil before advancing the clock in order
+// to make sure that they have been registered.
 type TestClock struct {
 	mu      sync.Mutex
+	cond    *sync.Cond
 	time    time.Time
 	timers  []*testTimer
 	tickers []*testTicker
@@ -71,6 +86,7 @@ func NewTestClock(time time.Time) *TestClock {
 	c.time = time
 	c.timers = make([]*testTimer, 0)
 	c.tickers = make([]*testTicker, 0)
+	c.cond = sync.NewCond(&c.mu)
 	return &c
 }
 
@@ -78,7 +94,11 @@ func NewTestClock(time time.Time) *TestClock {
 func (c *TestClock) Set(t time.Time) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	c.set(t)
+}
 
+// set should be called WITH a lock.
+func (c *TestClock) set(t time.Time) {
 	if t.Before(c.time) {
 		panic("can't go back in time")
 	}
@@ -111,7 +131,60 @@ func (c *TestClock) Set(t time.Time) {
 // Add adds the duration to the internal time of the test clock
 // and triggers any timers or tickers that should fire.
 func (c *TestClock) Add(d time.Duration) {
-	c.Set(c.time.Add(d))
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.set(c.time.Add(d))
+}
+
+// Advance is the same as Add.
+func (c *TestClock) Advance(d time.Duration) {
+	c.Add(d)
+}
+
+// NumTimers returns the number of timers that have yet to fire or be stopped.
+func (c *TestClock) NumTimers() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.numTimers()
+}
+
+// NumTickers returns the number of tickers that have yet to be stopped.
+func (c *TestClock) NumTickers() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.numTickers()
+}
+
+// BlockUntil blocks until the clock has at least n timers and tickers that
+// are waiting for the time to be advanced.
+func (c *TestClock) BlockUntil(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for c.numTimers()+c.numTickers() < n {
+		c.cond.Wait()
+	}
+}
+
+// numTimers should be called WITH a lock.
+func (c *TestClock) numTimers() int {
+	var n int
+	for _, timer := range c.timers {
+		if !timer.stopped.Load() {
+			n++
+		}
+	}
+	return n
+}
+
+// numTickers should be called WITH a lock.
+func (c *TestClock) numTickers() int {
+	var n int
+	for _, ticker := range c.tickers {
+		if !ticker.stopped.Load() {
+			n++
+		}
+	}
+	return n
 }
 
 // Now returns the internal time of the test clock.
@@ -131,6 +204,7 @@ func (c *TestClock) NewTicker(d time.Duration) (<-chan time.Time, func()) {
 	stopped := &atomic.Bool{}
 	ticker := &testTicker{nextTick: c.time, interval: d, ch: ch, stopped: stopped}
 	c.tickers = append(c.tickers, ticker)
+	c.cond.Broadcast()
 	stop := func() {
 		stopped.Store(true)
 	}
@@ -155,6 +229,7 @@ func (c *TestClock) NewTimer(d time.Duration) (<-chan time.Time, func() bool) {
 
 	timer := &testTimer{deadline: c.time.Add(d), ch: ch, stopped: stopped}
 	c.timers = append(c.timers, timer)
+	c.cond.Broadcast()
 	stop := func() bool {
 		return stopped.CompareAndSwap(false, true)
 	}
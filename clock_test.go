@@ -0,0 +1,69 @@
+package sturdyc_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/viccon/sturdyc"
+)
+
+func TestTestClockBlocksUntilTheTimersAreRegistered(t *testing.T) {
+	t.Parallel()
+
+	clock := sturdyc.NewTestClock(time.Now())
+	fired := make(chan time.Time)
+	go func() {
+		timer, _ := clock.NewTimer(time.Minute)
+		fired <- <-timer
+	}()
+
+	// Advancing the clock before the timer has been registered
+	// would leave it waiting for the next call to Advance.
+	clock.BlockUntil(1)
+	if n := clock.NumTimers(); n != 1 {
+		t.Fatalf("expected 1 timer, got %d", n)
+	}
+
+	clock.Advance(time.Minute)
+	if got, want := <-fired, clock.Now(); !got.Equal(want) {
+		t.Errorf("expected the timer to fire at %v, got %v", want, got)
+	}
+	if n := clock.NumTimers(); n != 0 {
+		t.Errorf("expected the timer to have fired, got %d timers", n)
+	}
+}
+
+func TestTestClockTickers(t *testing.T) {
+	t.Parallel()
+
+	clock := sturdyc.NewTestClock(time.Now())
+	ticks, stop := clock.NewTicker(time.Minute)
+	if n := clock.NumTickers(); n != 1 {
+		t.Fatalf("expected 1 ticker, got %d", n)
+	}
+
+	clock.Advance(time.Second * 30)
+	select {
+	case <-ticks:
+		t.Fatal("expected the ticker to not have ticked yet")
+	default:
+	}
+
+	clock.Advance(time.Second * 30)
+	select {
+	case <-ticks:
+	default:
+		t.Fatal("expected the ticker to have ticked")
+	}
+
+	stop()
+	if n := clock.NumTickers(); n != 0 {
+		t.Errorf("expected the ticker to have been stopped, got %d tickers", n)
+	}
+	clock.Advance(time.Minute)
+	select {
+	case <-ticks:
+		t.Error("expected a stopped ticker to not tick")
+	default:
+	}
+}
@@ -0,0 +1,173 @@
+package sturdyc
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// coalescedStorage buffers the writes and deletions that the cache performs
+// against the distributed storage, and flushes them using SetBatch and
+// DeleteBatch once the window has passed, or when the buffer is full.
+type coalescedStorage struct {
+	DistributedStorageWithDeletions
+	mu           sync.Mutex
+	maxBatchSize int
+	writes       map[string][]byte
+	deletes      map[string]struct{}
+	flushNow     chan struct{}
+	log          Logger
+}
+
+func newCoalescedStorage(storage DistributedStorageWithDeletions, maxBatchSize int, log Logger) *coalescedStorage {
+	return &coalescedStorage{
+		DistributedStorageWithDeletions: storage,
+		maxBatchSize:                    maxBatchSize,
+		writes:                          make(map[string][]byte),
+		deletes:                         make(map[string]struct{}),
+		flushNow:                        make(chan struct{}, 1),
+		log:                             log,
+	}
+}
+
+// run flushes the buffered operations every window. The goroutine is never
+// going to exit, as the client can still write to the storage once it's closed.
+func (s *coalescedStorage) run(clock Clock, window time.Duration) {
+	go func() {
+		ticker, stop := clock.NewTicker(window)
+		defer stop()
+		for {
+			select {
+			case <-ticker:
+			case <-s.flushNow:
+			}
+			s.flush()
+		}
+	}()
+}
+
+func (s *coalescedStorage) flush() {
+	defer func() {
+		if err := recover(); err != nil {
+			s.log.Error("sturdyc: panic recovered", "panic", err)
+		}
+	}()
+
+	s.mu.Lock()
+	writes, deletes := s.writes, s.deletes
+	s.writes = make(map[string][]byte)
+	s.deletes = make(map[string]struct{})
+	s.mu.Unlock()
+
+	if len(deletes) > 0 {
+		keys := make([]string, 0, len(deletes))
+		for key := range deletes {
+			keys = append(keys, key)
+		}
+		s.DistributedStorageWithDeletions.DeleteBatch(context.Background(), keys)
+	}
+
+	if len(writes) > 0 {
+		s.DistributedStorageWithDeletions.SetBatch(context.Background(), writes)
+	}
+}
+
+// signalIfFull signals that the buffer should be flushed without waiting
+// for the window to pass. It should be called with the lock held.
+func (s *coalescedStorage) signalIfFull() {
+	if s.maxBatchSize < 1 || len(s.writes)+len(s.deletes) < s.maxBatchSize {
+		return
+	}
+
+	select {
+	case s.flushNow <- struct{}{}:
+	default:
+	}
+}
+
+// pending returns the buffered operation for the key, if there is one.
+// It should be called with the lock held.
+func (s *coalescedStorage) pending(key string) (value []byte, exists, isPending bool) {
+	if _, ok := s.deletes[key]; ok {
+		return nil, false, true
+	}
+	if value, ok := s.writes[key]; ok {
+		return value, true, true
+	}
+	return nil, false, false
+}
+
+func (s *coalescedStorage) Get(ctx context.Context, key string) ([]byte, bool) {
+	s.mu.Lock()
+	value, exists, isPending := s.pending(key)
+	s.mu.Unlock()
+
+	// Operations that are yet to be flushed are newer than what's in the storage.
+	if isPending {
+		return value, exists
+	}
+	return s.DistributedStorageWithDeletions.Get(ctx, key)
+}
+
+func (s *coalescedStorage) GetBatch(ctx context.Context, keys []string) map[string][]byte {
+	records := make(map[string][]byte, len(keys))
+	keysToFetch := make([]string, 0, len(keys))
+
+	s.mu.Lock()
+	for _, key := range keys {
+		value, exists, isPending := s.pending(key)
+		if !isPending {
+			keysToFetch = append(keysToFetch, key)
+			continue
+		}
+		if exists {
+			records[key] = value
+		}
+	}
+	s.mu.Unlock()
+
+	if len(keysToFetch) == 0 {
+		return records
+	}
+
+	for key, value := range s.DistributedStorageWithDeletions.GetBatch(ctx, keysToFetch) {
+		records[key] = value
+	}
+	return records
+}
+
+func (s *coalescedStorage) Set(_ context.Context, key string, value []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.deletes, key)
+	s.writes[key] = value
+	s.signalIfFull()
+}
+
+func (s *coalescedStorage) SetBatch(_ context.Context, records map[string][]byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, value := range records {
+		delete(s.deletes, key)
+		s.writes[key] = value
+	}
+	s.signalIfFull()
+}
+
+func (s *coalescedStorage) Delete(_ context.Context, key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.writes, key)
+	s.deletes[key] = struct{}{}
+	s.signalIfFull()
+}
+
+func (s *coalescedStorage) DeleteBatch(_ context.Context, keys []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, key := range keys {
+		delete(s.writes, key)
+		s.deletes[key] = struct{}{}
+	}
+	s.signalIfFull()
+}
@@ -0,0 +1,122 @@
+package sturdyc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// Codec encodes the values of type T that the cache writes to the distributed
+// storage, exchanges between peers, and exports. The time that a record was
+// written, and whether it's a missing record, are encoded by the cache.
+type Codec[T any] interface {
+	Marshal(value T) ([]byte, error)
+	Unmarshal(data []byte) (T, error)
+}
+
+type jsonCodec[T any] struct{}
+
+// NewJSONCodec returns a Codec that uses encoding/json.
+func NewJSONCodec[T any]() Codec[T] {
+	return jsonCodec[T]{}
+}
+
+func (jsonCodec[T]) Marshal(value T) ([]byte, error) {
+	return json.Marshal(value)
+}
+
+func (jsonCodec[T]) Unmarshal(data []byte) (T, error) {
+	var value T
+	err := json.Unmarshal(data, &value)
+	return value, err
+}
+
+type gobCodec[T any] struct{}
+
+// NewGobCodec returns a Codec that uses encoding/gob. Values that hold
+// interfaces require their concrete types to be registered with gob.Register.
+func NewGobCodec[T any]() Codec[T] {
+	return gobCodec[T]{}
+}
+
+func (gobCodec[T]) Marshal(value T) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(value); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gobCodec[T]) Unmarshal(data []byte) (T, error) {
+	var value T
+	err := gob.NewDecoder(bytes.NewReader(data)).Decode(&value)
+	return value, err
+}
+
+// codecFor returns the codec that was registered for values of type V.
+func codecFor[V any](codecs map[reflect.Type]any) (Codec[V], bool) {
+	if len(codecs) == 0 {
+		return nil, false
+	}
+	codec, ok := codecs[reflect.TypeFor[V]()].(Codec[V])
+	return codec, ok
+}
+
+// codecRecordVersion is the first byte of the records that
+// are encoded with a codec. It's incremented if the format changes.
+const codecRecordVersion byte = 1
+
+// codecRecordHeaderSize is the size of the version, the flags and the creation time.
+const codecRecordHeaderSize = 10
+
+// codecRecordMissing is the flag of the missing records.
+const codecRecordMissing byte = 1
+
+// errInvalidCodecRecord is returned for records that weren't encoded with a codec.
+var errInvalidCodecRecord = errors.New("sturdyc: invalid codec record")
+
+// encodeRecord encodes the record with the codec. The header holds the
+// version, the flags and the creation time, and is followed by the value.
+func encodeRecord[V any](codec Codec[V], record distributedRecord[V]) ([]byte, error) {
+	header := make([]byte, codecRecordHeaderSize)
+	header[0] = codecRecordVersion
+	if record.IsMissingRecord {
+		header[1] = codecRecordMissing
+	}
+	binary.BigEndian.PutUint64(header[2:], uint64(record.CreatedAt.UnixNano()))
+	if record.IsMissingRecord {
+		return header, nil
+	}
+
+	value, err := codec.Marshal(record.Value)
+	if err != nil {
+		return nil, err
+	}
+	return append(header, value...), nil
+}
+
+// decodeRecord decodes a record that was encoded by encodeRecord.
+func decodeRecord[V any](codec Codec[V], data []byte) (distributedRecord[V], error) {
+	var record distributedRecord[V]
+	if len(data) < codecRecordHeaderSize || data[0] != codecRecordVersion {
+		return record, errInvalidCodecRecord
+	}
+
+	record.IsMissingRecord = data[1]&codecRecordMissing != 0
+	record.CreatedAt = time.Unix(0, int64(binary.BigEndian.Uint64(data[2:])))
+	if record.IsMissingRecord {
+		return record, nil
+	}
+
+	value, err := codec.Unmarshal(data[codecRecordHeaderSize:])
+	if err != nil {
+		return record, fmt.Errorf("%w: %w", errInvalidCodecRecord, err)
+	}
+	record.Value = value
+	return record, nil
+}
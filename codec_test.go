@@ -0,0 +1,175 @@
+package sturdyc_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/viccon/sturdyc"
+)
+
+func TestCodecIsUsedForTheDistributedRecords(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	distributedStorage := &mockStorage{}
+	c := sturdyc.New[string](1000, 10, time.Hour, 30,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithDistributedStorage(distributedStorage),
+		sturdyc.WithCodec(sturdyc.NewGobCodec[string]()),
+	)
+	fetchObserver := NewFetchObserver(1)
+
+	fetchObserver.Response("1")
+	sturdyc.GetOrFetch(ctx, c, "1", fetchObserver.Fetch)
+	<-fetchObserver.FetchCompleted
+
+	// The record is written asynchronously to the distributed storage.
+	time.Sleep(50 * time.Millisecond)
+	recordBytes, ok := distributedStorage.Get(ctx, "1")
+	if !ok || bytes.HasPrefix(recordBytes, []byte("{")) {
+		t.Fatalf("expected a gob encoded record, got %q", recordBytes)
+	}
+
+	c.Delete("1")
+	res, err := sturdyc.GetOrFetch(ctx, c, "1", fetchObserver.Fetch)
+	if err != nil || res != "value1" {
+		t.Errorf("expected value1, got %q %v", res, err)
+	}
+	fetchObserver.AssertFetchCount(t, 1)
+}
+
+type codecUser struct {
+	Name string
+}
+
+// upperCodec encodes the names of the users in upper case, which
+// lets the tests tell which values were encoded by the codec.
+type upperCodec struct{}
+
+func (upperCodec) Marshal(user codecUser) ([]byte, error) {
+	return bytes.ToUpper([]byte(user.Name)), nil
+}
+
+func (upperCodec) Unmarshal(data []byte) (codecUser, error) {
+	return codecUser{Name: string(bytes.ToLower(data))}, nil
+}
+
+func TestCodecsAreRegisteredPerType(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	distributedStorage := &mockStorage{}
+	c := sturdyc.New[any](1000, 10, time.Hour, 30,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithDistributedStorage(distributedStorage),
+		sturdyc.WithCodec[codecUser](upperCodec{}),
+	)
+
+	sturdyc.GetOrFetch(ctx, c, "user", func(context.Context) (codecUser, error) {
+		return codecUser{Name: "alice"}, nil
+	})
+	sturdyc.GetOrFetch(ctx, c, "count", func(context.Context) (int, error) {
+		return 2, nil
+	})
+
+	// The records are written asynchronously to the distributed storage.
+	time.Sleep(50 * time.Millisecond)
+	if record, _ := distributedStorage.Get(ctx, "user"); !bytes.HasSuffix(record, []byte("ALICE")) {
+		t.Errorf("expected the user to be encoded by its codec, got %q", record)
+	}
+	if record, _ := distributedStorage.Get(ctx, "count"); !bytes.HasPrefix(record, []byte("{")) {
+		t.Errorf("expected the types without a codec to be encoded as JSON, got %q", record)
+	}
+
+	c.Delete("user")
+	user, err := sturdyc.GetOrFetch(ctx, c, "user", func(context.Context) (codecUser, error) {
+		t.Error("expected the user to be read from the distributed storage")
+		return codecUser{}, nil
+	})
+	if err != nil || user.Name != "alice" {
+		t.Errorf("expected alice, got %+v %v", user, err)
+	}
+}
+
+func TestCodecIsUsedForTheMissingRecords(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	distributedStorage := &mockStorage{}
+	c := sturdyc.New[codecUser](1000, 10, time.Hour, 30,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithMissingRecordStorage(),
+		sturdyc.WithDistributedStorage(distributedStorage),
+		sturdyc.WithCodec[codecUser](upperCodec{}),
+	)
+
+	fetchFn := func(context.Context) (codecUser, error) {
+		return codecUser{}, sturdyc.ErrNotFound
+	}
+	c.GetOrFetch(ctx, "user", fetchFn)
+
+	// The record is written asynchronously to the distributed storage.
+	time.Sleep(50 * time.Millisecond)
+	c.Delete("user")
+	if _, err := c.GetOrFetch(ctx, "user", func(context.Context) (codecUser, error) {
+		t.Error("expected the missing record to be read from the distributed storage")
+		return codecUser{}, nil
+	}); err == nil {
+		t.Error("expected the record to be reported as missing")
+	}
+}
+
+func TestCodecIsUsedForExports(t *testing.T) {
+	t.Parallel()
+
+	source := sturdyc.New[codecUser](1000, 10, time.Hour, 30,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithCodec[codecUser](upperCodec{}),
+	)
+	source.Set("alice", codecUser{Name: "alice"})
+
+	var buf bytes.Buffer
+	if _, err := source.Export(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(buf.Bytes(), []byte("ALICE")) {
+		t.Errorf("expected the value to be encoded by the codec, got %q", buf.Bytes())
+	}
+
+	destination := sturdyc.New[codecUser](1000, 10, time.Hour, 30,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithCodec[codecUser](upperCodec{}),
+	)
+	if imported, err := destination.Import(&buf); err != nil || imported != 1 {
+		t.Fatalf("expected 1 record to be imported, got %d %v", imported, err)
+	}
+	if user, ok := destination.Get("alice"); !ok || user.Name != "alice" {
+		t.Errorf("expected alice, got %+v", user)
+	}
+}
+
+func TestCodecsRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	type record struct {
+		Name  string
+		Count int
+	}
+
+	codecs := map[string]sturdyc.Codec[record]{
+		"json": sturdyc.NewJSONCodec[record](),
+		"gob":  sturdyc.NewGobCodec[record](),
+	}
+	for name, codec := range codecs {
+		data, err := codec.Marshal(record{Name: "a", Count: 2})
+		if err != nil {
+			t.Fatalf("%s: failed to marshal: %v", name, err)
+		}
+		decoded, err := codec.Unmarshal(data)
+		if err != nil || decoded != (record{Name: "a", Count: 2}) {
+			t.Errorf("%s: expected the record to round trip, got %+v %v", name, decoded, err)
+		}
+	}
+}
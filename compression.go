@@ -0,0 +1,183 @@
+package sturdyc
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+)
+
+// Compressor is used to compress the records that are written to the
+// distributed storage. The package only ships with gzip, as it's the only
+// algorithm in the standard library, but other algorithms such as zstd or
+// snappy can be plugged in by wrapping their packages:
+//
+//	type zstdCompressor struct {
+//		encoder *zstd.Encoder
+//		decoder *zstd.Decoder
+//	}
+//
+//	func (z *zstdCompressor) Compress(data []byte) ([]byte, error) {
+//		return z.encoder.EncodeAll(data, nil), nil
+//	}
+//
+//	func (z *zstdCompressor) Decompress(data []byte) ([]byte, error) {
+//		return z.decoder.DecodeAll(data, nil)
+//	}
+//
+//	type snappyCompressor struct{}
+//
+//	func (snappyCompressor) Compress(data []byte) ([]byte, error) {
+//		return snappy.Encode(nil, data), nil
+//	}
+//
+//	func (snappyCompressor) Decompress(data []byte) ([]byte, error) {
+//		return snappy.Decode(nil, data)
+//	}
+type Compressor interface {
+	Compress(data []byte) ([]byte, error)
+	Decompress(data []byte) ([]byte, error)
+}
+
+type gzipCompressor struct {
+	level int
+}
+
+// NewGzipCompressor returns a Compressor that uses gzip with the given
+// compression level, such as gzip.DefaultCompression or gzip.BestSpeed.
+func NewGzipCompressor(level int) Compressor {
+	return &gzipCompressor{level: level}
+}
+
+func (g *gzipCompressor) Compress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	writer, err := gzip.NewWriterLevel(&buf, g.level)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := writer.Write(data); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (g *gzipCompressor) Decompress(data []byte) ([]byte, error) {
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
+
+// compressedRecordMagic is written at the start of every record that is
+// written by the compressedStorage, followed by one of the flags below. The
+// records that don't start with it were written before compression was
+// enabled, and are returned as they are. It's several bytes long so that the
+// output of a codec can't be mistaken for it.
+const compressedRecordMagic = "\x00sturdyc"
+
+const (
+	// uncompressedRecordFlag is written for the records that are smaller than the threshold.
+	uncompressedRecordFlag byte = iota
+	// compressedRecordFlag is written for the records that have been compressed.
+	compressedRecordFlag
+)
+
+// compressedStorage compresses the records that are larger than the
+// threshold before they are written to the distributed storage.
+type compressedStorage struct {
+	DistributedStorageWithDeletions
+	compressor Compressor
+	threshold  int
+	log        Logger
+}
+
+// compressedRecordHeader returns the magic and the flag that every record
+// starts with, with room for the size of the data that follows.
+func compressedRecordHeader(flag byte, size int) []byte {
+	header := make([]byte, 0, len(compressedRecordMagic)+1+size)
+	header = append(header, compressedRecordMagic...)
+	return append(header, flag)
+}
+
+func (s *compressedStorage) compress(key string, value []byte) []byte {
+	if len(value) < s.threshold {
+		return append(compressedRecordHeader(uncompressedRecordFlag, len(value)), value...)
+	}
+
+	compressed, err := s.compressor.Compress(value)
+	if err != nil {
+		s.log.Error("sturdyc: error compressing record", "key", key, "error", err)
+		return append(compressedRecordHeader(uncompressedRecordFlag, len(value)), value...)
+	}
+	return append(compressedRecordHeader(compressedRecordFlag, len(compressed)), compressed...)
+}
+
+func (s *compressedStorage) decompress(key string, value []byte) ([]byte, bool) {
+	data, ok := bytes.CutPrefix(value, []byte(compressedRecordMagic))
+	if !ok || len(data) == 0 {
+		return value, true
+	}
+
+	switch flag, data := data[0], data[1:]; flag {
+	case uncompressedRecordFlag:
+		return data, true
+	case compressedRecordFlag:
+		decompressed, err := s.compressor.Decompress(data)
+		if err != nil {
+			s.log.Error("sturdyc: error decompressing record", "key", key, "error", err)
+			return nil, false
+		}
+		return decompressed, true
+	default:
+		s.log.Error("sturdyc: unknown compression flag", "key", key, "flag", flag)
+		return nil, false
+	}
+}
+
+func (s *compressedStorage) Get(ctx context.Context, key string) ([]byte, bool) {
+	value, ok := s.DistributedStorageWithDeletions.Get(ctx, key)
+	if !ok {
+		return value, false
+	}
+	return s.decompress(key, value)
+}
+
+func (s *compressedStorage) Set(ctx context.Context, key string, value []byte) {
+	s.DistributedStorageWithDeletions.Set(ctx, key, s.compress(key, value))
+}
+
+func (s *compressedStorage) GetBatch(ctx context.Context, keys []string) map[string][]byte {
+	records := s.DistributedStorageWithDeletions.GetBatch(ctx, keys)
+	decompressedRecords := make(map[string][]byte, len(records))
+	for key, value := range records {
+		if decompressed, ok := s.decompress(key, value); ok {
+			decompressedRecords[key] = decompressed
+		}
+	}
+	return decompressedRecords
+}
+
+func (s *compressedStorage) compressRecords(records map[string][]byte) map[string][]byte {
+	compressedRecords := make(map[string][]byte, len(records))
+	for key, value := range records {
+		compressedRecords[key] = s.compress(key, value)
+	}
+	return compressedRecords
+}
+
+func (s *compressedStorage) SetBatch(ctx context.Context, records map[string][]byte) {
+	s.DistributedStorageWithDeletions.SetBatch(ctx, s.compressRecords(records))
+}
+
+func (s *compressedStorage) SetBatchErr(ctx context.Context, records map[string][]byte) error {
+	return setBatchErr(ctx, s.DistributedStorageWithDeletions, s.compressRecords(records))
+}
+
+func (s *compressedStorage) DeleteBatchErr(ctx context.Context, keys []string) error {
+	return deleteBatchErr(ctx, s.DistributedStorageWithDeletions, keys)
+}
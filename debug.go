@@ -0,0 +1,170 @@
+package sturdyc
+
+import (
+	"cmp"
+	"encoding/json"
+	"net/http"
+	"path"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// defaultDebugLimit is the number of keys that the debug handler
+// lists if the request doesn't specify a limit.
+const defaultDebugLimit = 100
+
+// DebugStats is the JSON view of the cache that is served by the debug handler.
+type DebugStats struct {
+	Name            string `json:"name,omitempty"`
+	Size            int    `json:"size"`
+	Shards          int    `json:"shards"`
+	KeysInflight    int    `json:"keys_inflight"`
+	RefreshesPaused bool   `json:"refreshes_paused"`
+}
+
+// DebugKey is the JSON view of a key and the number of times it has been read.
+type DebugKey struct {
+	Key      string `json:"key"`
+	Accesses int64  `json:"accesses"`
+}
+
+// DebugEntryPage is the JSON view of a page of entry metadata.
+type DebugEntryPage struct {
+	Entries    []EntryInfo `json:"entries"`
+	NextCursor string      `json:"next_cursor"`
+}
+
+// DebugEntry is the JSON view of an entry, including its metadata.
+type DebugEntry[T any] struct {
+	Key             string    `json:"key"`
+	Value           T         `json:"value"`
+	IsMissingRecord bool      `json:"is_missing_record"`
+	CachedAt        time.Time `json:"cached_at"`
+	ExpiresAt       time.Time `json:"expires_at"`
+	RefreshAt       time.Time `json:"refresh_at"`
+	RefreshRetries  int       `json:"refresh_retries"`
+	Accesses        int64     `json:"accesses"`
+	LastAccessedAt  time.Time `json:"last_accessed_at"`
+}
+
+// debugEntries returns the entries of the shard that haven't expired.
+func (s *shard[T]) debugEntries() []DebugEntry[T] {
+	s.RLock()
+	defer s.RUnlock()
+
+	now := s.clock.Now()
+	entries := make([]DebugEntry[T], 0, len(s.entries))
+	for _, e := range s.entries {
+		if now.After(e.expiresAt) {
+			continue
+		}
+		entries = append(entries, DebugEntry[T]{
+			Key:             e.key,
+			Value:           e.value,
+			IsMissingRecord: e.isMissingRecord,
+			CachedAt:        e.cachedAt,
+			ExpiresAt:       e.expiresAt,
+			RefreshAt:       e.refreshAt,
+			RefreshRetries:  e.numOfRefreshRetries,
+			Accesses:        e.accesses.Load(),
+			LastAccessedAt:  e.lastAccessed(),
+		})
+	}
+	return entries
+}
+
+// debugEntries returns the entries of every shard, sorted by the number of accesses.
+func (c *Client[T]) debugEntries() []DebugEntry[T] {
+	var entries []DebugEntry[T]
+	for _, shard := range c.getShards() {
+		entries = append(entries, shard.debugEntries()...)
+	}
+	slices.SortFunc(entries, func(a, b DebugEntry[T]) int {
+		if a.Accesses != b.Accesses {
+			return cmp.Compare(b.Accesses, a.Accesses)
+		}
+		return cmp.Compare(a.Key, b.Key)
+	})
+	return entries
+}
+
+// DebugHandler returns an http.Handler that serves JSON views of the internals
+// of the cache. The view is determined by the last segment of the path:
+//
+//	/stats - The size of the cache, and the number of keys that are in flight.
+//	/shards - The number of entries in each shard.
+//	/hot - The keys that have been read the most times since they were written.
+//	/inflight - The keys that are being fetched, and for how long.
+//	/list - A page of entry metadata, without the values.
+//	/entries - The entries along with their metadata, if showEntries is true.
+//
+// The hot keys, list and entries accept a limit query parameter, and the list
+// accepts the cursor that was returned with the previous page. Use http.StripPrefix
+// to mount the handler under a path such as /debug/cache. The values of the
+// entries are served as is, so showEntries should only be enabled if they are
+// safe to expose.
+func (c *Client[T]) DebugHandler(showEntries bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		limit := defaultDebugLimit
+		if rawLimit := r.URL.Query().Get("limit"); rawLimit != "" {
+			parsedLimit, err := strconv.Atoi(rawLimit)
+			if err != nil || parsedLimit < 1 {
+				http.Error(w, "limit must be a positive integer", http.StatusBadRequest)
+				return
+			}
+			limit = parsedLimit
+		}
+
+		var view any
+		switch path.Base(r.URL.Path) {
+		case "stats":
+			view = DebugStats{
+				Name:            c.name,
+				Size:            c.Size(),
+				Shards:          len(c.getShards()),
+				KeysInflight:    c.NumKeysInflight(),
+				RefreshesPaused: c.RefreshesPaused(),
+			}
+		case "shards":
+			shards := c.getShards()
+			sizes := make([]int, 0, len(shards))
+			for _, shard := range shards {
+				sizes = append(sizes, shard.size())
+			}
+			view = sizes
+		case "hot":
+			entries := c.debugEntries()
+			keys := make([]DebugKey, 0, min(limit, len(entries)))
+			for _, e := range entries[:min(limit, len(entries))] {
+				keys = append(keys, DebugKey{Key: e.Key, Accesses: e.Accesses})
+			}
+			view = keys
+		case "inflight":
+			durations := make(map[string]string)
+			for key, duration := range c.InflightDurations() {
+				durations[key] = duration.String()
+			}
+			view = durations
+		case "list":
+			infos, next := c.ListEntries(r.URL.Query().Get("cursor"), limit)
+			if infos == nil {
+				infos = []EntryInfo{}
+			}
+			view = DebugEntryPage{Entries: infos, NextCursor: next}
+		case "entries":
+			if !showEntries {
+				http.NotFound(w, r)
+				return
+			}
+			entries := c.debugEntries()
+			view = entries[:min(limit, len(entries))]
+		default:
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(view) //nolint:errcheck // There is nothing we can do if the client has gone away.
+	})
+}
@@ -0,0 +1,86 @@
+package sturdyc_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/viccon/sturdyc"
+)
+
+func serveDebug(t *testing.T, handler http.Handler, target string, out any) int {
+	t.Helper()
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, target, nil))
+	if recorder.Code == http.StatusOK {
+		if err := json.NewDecoder(recorder.Body).Decode(out); err != nil {
+			t.Fatalf("failed to decode the response: %v", err)
+		}
+	}
+	return recorder.Code
+}
+
+func TestDebugHandler(t *testing.T) {
+	t.Parallel()
+
+	c := sturdyc.New[string](1000, 4, time.Hour, 30, sturdyc.WithNoContinuousEvictions(), sturdyc.WithName("user-cache"))
+	c.SetMany(map[string]string{"1": "value1", "2": "value2", "3": "value3"})
+	for i := 0; i < 3; i++ {
+		c.Get("2")
+	}
+	c.Get("3")
+
+	mux := http.NewServeMux()
+	mux.Handle("/debug/cache/", http.StripPrefix("/debug/cache", c.DebugHandler(true)))
+
+	var stats sturdyc.DebugStats
+	serveDebug(t, mux, "/debug/cache/stats", &stats)
+	if stats.Name != "user-cache" || stats.Size != 3 || stats.Shards != 4 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+
+	var shards []int
+	serveDebug(t, mux, "/debug/cache/shards", &shards)
+	if len(shards) != 4 || shards[0]+shards[1]+shards[2]+shards[3] != 3 {
+		t.Errorf("unexpected shard sizes: %v", shards)
+	}
+
+	var hot []sturdyc.DebugKey
+	serveDebug(t, mux, "/debug/cache/hot?limit=2", &hot)
+	if len(hot) != 2 || hot[0] != (sturdyc.DebugKey{Key: "2", Accesses: 3}) || hot[1] != (sturdyc.DebugKey{Key: "3", Accesses: 1}) {
+		t.Errorf("unexpected hot keys: %v", hot)
+	}
+
+	var entries []sturdyc.DebugEntry[string]
+	serveDebug(t, mux, "/debug/cache/entries", &entries)
+	if len(entries) != 3 || entries[0].Key != "2" || entries[0].Value != "value2" {
+		t.Errorf("unexpected entries: %v", entries)
+	}
+
+	var page sturdyc.DebugEntryPage
+	serveDebug(t, mux, "/debug/cache/list?limit=2", &page)
+	if len(page.Entries) != 2 || page.NextCursor == "" {
+		t.Fatalf("unexpected first page: %+v", page)
+	}
+	serveDebug(t, mux, "/debug/cache/list?limit=2&cursor="+page.NextCursor, &page)
+	if len(page.Entries) != 1 || page.NextCursor != "" {
+		t.Errorf("unexpected last page: %+v", page)
+	}
+}
+
+func TestDebugHandlerHidesTheEntriesByDefault(t *testing.T) {
+	t.Parallel()
+
+	c := sturdyc.New[string](1000, 4, time.Hour, 30, sturdyc.WithNoContinuousEvictions())
+	c.Set("1", "value1")
+
+	var entries []sturdyc.DebugEntry[string]
+	if code := serveDebug(t, c.DebugHandler(false), "/entries", &entries); code != http.StatusNotFound {
+		t.Errorf("expected the entries to be hidden, got status %d", code)
+	}
+	if code := serveDebug(t, c.DebugHandler(false), "/hot?limit=0", &entries); code != http.StatusBadRequest {
+		t.Errorf("expected an invalid limit to be rejected, got status %d", code)
+	}
+}
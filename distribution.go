@@ -4,7 +4,6 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
-	"fmt"
 	"maps"
 	"time"
 )
@@ -38,6 +37,33 @@ type DistributedStorageWithDeletions interface {
 	DeleteBatch(ctx context.Context, keys []string)
 }
 
+// DistributedStorageWithErrors can be implemented by the distributed storage
+// in order to report the batch writes that failed. It's used by the workers of
+// WithDistributedWriteBehind, which retry the batches that returned an error
+// according to the policy of WithDistributedWriteBehindRetries.
+type DistributedStorageWithErrors interface {
+	SetBatchErr(ctx context.Context, records map[string][]byte) error
+	DeleteBatchErr(ctx context.Context, keys []string) error
+}
+
+// setBatchErr writes the records using SetBatchErr if the storage implements it.
+func setBatchErr(ctx context.Context, storage DistributedStorage, records map[string][]byte) error {
+	if s, ok := storage.(DistributedStorageWithErrors); ok {
+		return s.SetBatchErr(ctx, records)
+	}
+	storage.SetBatch(ctx, records)
+	return nil
+}
+
+// deleteBatchErr deletes the keys using DeleteBatchErr if the storage implements it.
+func deleteBatchErr(ctx context.Context, storage DistributedStorageWithDeletions, keys []string) error {
+	if s, ok := storage.(DistributedStorageWithErrors); ok {
+		return s.DeleteBatchErr(ctx, keys)
+	}
+	storage.DeleteBatch(ctx, keys)
+	return nil
+}
+
 // distributedStorage adds noop implementations for the delete functions so
 // that the cache doesn't have to deal with multiple storage types.
 type distributedStorage struct {
@@ -52,11 +78,20 @@ func (d *distributedStorage) Delete(_ context.Context, _ string) {
 func (d *distributedStorage) DeleteBatch(_ context.Context, _ []string) {
 }
 
+func (d *distributedStorage) SetBatchErr(ctx context.Context, records map[string][]byte) error {
+	return setBatchErr(ctx, d.DistributedStorage, records)
+}
+
+// DeleteBatchErr is a noop implementation of the delete batch function.
+func (d *distributedStorage) DeleteBatchErr(_ context.Context, _ []string) error {
+	return nil
+}
+
 func marshalRecord[V, T any](value V, c *Client[T]) ([]byte, error) {
 	record := distributedRecord[V]{CreatedAt: c.clock.Now(), Value: value, IsMissingRecord: false}
-	bytes, err := json.Marshal(record)
+	bytes, err := encodeDistributedRecord(c, record)
 	if err != nil {
-		c.log.Error(fmt.Sprintf("sturdyc: error marshalling record: %v", err))
+		c.logger(LogDistributed).Error("sturdyc: error marshalling record", "error", err)
 	}
 	return bytes, err
 }
@@ -65,41 +100,83 @@ func marshalMissingRecord[V, T any](c *Client[T]) ([]byte, error) {
 	var missingRecord distributedRecord[V]
 	missingRecord.CreatedAt = c.clock.Now()
 	missingRecord.IsMissingRecord = true
-	bytes, err := json.Marshal(missingRecord)
+	bytes, err := encodeDistributedRecord(c, missingRecord)
 	if err != nil {
-		c.log.Error(fmt.Sprintf("sturdyc: error marshalling missing record: %v", err))
+		c.logger(LogDistributed).Error("sturdyc: error marshalling missing record", "error", err)
 	}
 	return bytes, err
 }
 
-func unmarshalRecord[V any](bytes []byte, key string, log Logger) (distributedRecord[V], error) {
+func unmarshalRecord[V, T any](bytes []byte, key string, c *Client[T]) (distributedRecord[V], error) {
 	var record distributedRecord[V]
-	unmarshalErr := json.Unmarshal(bytes, &record)
+	var unmarshalErr error
+	if codec, ok := codecFor[V](c.codecs); ok {
+		record, unmarshalErr = decodeRecord(codec, bytes)
+	} else {
+		unmarshalErr = json.Unmarshal(bytes, &record)
+	}
 	if unmarshalErr != nil {
-		log.Error("sturdyc: error unmarshalling key: " + key)
+		c.logger(LogDistributed).Error("sturdyc: error unmarshalling record", "key", key)
 	}
 	return record, unmarshalErr
 }
 
+// encodeDistributedRecord encodes the record with the codec that was
+// registered for values of type V, and as JSON if there isn't one.
+func encodeDistributedRecord[V, T any](c *Client[T], record distributedRecord[V]) ([]byte, error) {
+	if codec, ok := codecFor[V](c.codecs); ok {
+		return encodeRecord(codec, record)
+	}
+	return json.Marshal(record)
+}
+
 func writeMissingRecord[V, T any](c *Client[T], key string) {
-	c.safeGo(func() {
+	c.trackedGo(func() {
 		if missingRecordBytes, missingRecordErr := marshalMissingRecord[V](c); missingRecordErr == nil {
 			c.distributedStorage.Set(context.Background(), key, missingRecordBytes)
 		}
 	})
 }
 
-func distributedFetch[V, T any](c *Client[T], key string, fetchFn FetchFn[V]) FetchFn[V] {
+// fillDistributedStorage writes the result of a call to the underlying data
+// source to the distributed storage. Records that have been deleted at the
+// data source are either marked as missing, or removed if we had a stale copy.
+func fillDistributedStorage[V, T any](c *Client[T], key string, response V, fetchErr error, hasStale bool, opts callOptions) {
+	if fetchErr == nil {
+		if recordBytes, marshalErr := marshalRecord[V](response, c); marshalErr == nil {
+			c.distributedStorage.Set(context.Background(), key, recordBytes)
+		}
+		return
+	}
+
+	if !errors.Is(fetchErr, ErrNotFound) {
+		return
+	}
+
+	if opts.storeMissingRecords {
+		if missingRecordBytes, missingRecordErr := marshalMissingRecord[V](c); missingRecordErr == nil {
+			c.distributedStorage.Set(context.Background(), key, missingRecordBytes)
+		}
+		return
+	}
+
+	if hasStale {
+		c.distributedStorage.Delete(context.Background(), key)
+	}
+}
+
+func distributedFetch[V, T any](c *Client[T], key string, fetchFn FetchFn[V], opts callOptions) FetchFn[V] {
 	if c.distributedStorage == nil {
 		return fetchFn
 	}
 
 	return func(ctx context.Context) (V, error) {
-		stale, hasStale := *new(V), false
+		var stale distributedRecord[V]
+		hasStale := false
 		bytes, ok := c.distributedStorage.Get(ctx, key)
 		if ok {
 			c.reportDistributedCacheHit(true)
-			record, unmarshalErr := unmarshalRecord[V](bytes, key, c.log)
+			record, unmarshalErr := unmarshalRecord[V](bytes, key, c)
 			if unmarshalErr != nil {
 				return record.Value, unmarshalErr
 			}
@@ -113,7 +190,7 @@ func distributedFetch[V, T any](c *Client[T], key string, fetchFn FetchFn[V]) Fe
 				return record.Value, nil
 			}
 			c.reportDistributedRefresh()
-			stale, hasStale = record.Value, true
+			stale, hasStale = record, true
 		}
 
 		if !ok {
@@ -121,9 +198,40 @@ func distributedFetch[V, T any](c *Client[T], key string, fetchFn FetchFn[V]) Fe
 		}
 
 		// If it's not fresh enough, we'll retrieve it from the source.
+		response, filled, fetchErr := lockedFetch(ctx, c, key, fetchFn, stale, hasStale, opts)
+		if !filled {
+			c.trackedGo(func() {
+				fillDistributedStorage(c, key, response, fetchErr, hasStale, opts)
+			})
+		}
+
+		if fetchErr == nil || errors.Is(fetchErr, ErrNotFound) {
+			return response, fetchErr
+		}
+
+		if hasStale {
+			c.reportDistributedStaleFallback()
+			if stale.IsMissingRecord {
+				return stale.Value, ErrNotFound
+			}
+			return stale.Value, nil
+		}
+
+		return response, fetchErr
+	}
+}
+
+// distributedWrite skips the lookup in the distributed storage, and only
+// writes the response from the underlying data source to it.
+func distributedWrite[V, T any](c *Client[T], key string, fetchFn FetchFn[V], opts callOptions) FetchFn[V] {
+	if c.distributedStorage == nil {
+		return fetchFn
+	}
+
+	return func(ctx context.Context) (V, error) {
 		response, fetchErr := fetchFn(ctx)
 		if fetchErr == nil {
-			c.safeGo(func() {
+			c.trackedGo(func() {
 				if recordBytes, marshalErr := marshalRecord[V](response, c); marshalErr == nil {
 					c.distributedStorage.Set(context.Background(), key, recordBytes)
 				}
@@ -132,28 +240,20 @@ func distributedFetch[V, T any](c *Client[T], key string, fetchFn FetchFn[V]) Fe
 		}
 
 		if errors.Is(fetchErr, ErrNotFound) {
-			if c.storeMissingRecords {
+			if opts.storeMissingRecords {
 				writeMissingRecord[V](c, key)
 				return response, fetchErr
 			}
-			if hasStale {
-				c.safeGo(func() {
-					c.distributedStorage.Delete(context.Background(), key)
-				})
-			}
-			return response, fetchErr
-		}
-
-		if hasStale {
-			c.reportDistributedStaleFallback()
-			return stale, nil
+			c.trackedGo(func() {
+				c.distributedStorage.Delete(context.Background(), key)
+			})
 		}
 
 		return response, fetchErr
 	}
 }
 
-func distributedBatchFetch[V, T any](c *Client[T], keyFn KeyFn, fetchFn BatchFetchFn[V]) BatchFetchFn[V] {
+func distributedBatchFetch[V, T any](c *Client[T], keyFn KeyFn, fetchFn BatchFetchFn[V], opts callOptions) BatchFetchFn[V] {
 	if c.distributedStorage == nil {
 		return fetchFn
 	}
@@ -185,7 +285,7 @@ func distributedBatchFetch[V, T any](c *Client[T], keyFn KeyFn, fetchFn BatchFet
 			}
 
 			c.reportDistributedCacheHit(true)
-			record, unmarshalErr := unmarshalRecord[V](bytes, key, c.log)
+			record, unmarshalErr := unmarshalRecord[V](bytes, key, c)
 			if unmarshalErr != nil {
 				idsToRefresh = append(idsToRefresh, id)
 				continue
@@ -218,15 +318,16 @@ func distributedBatchFetch[V, T any](c *Client[T], keyFn KeyFn, fetchFn BatchFet
 		}
 
 		dataSourceResponses, err := fetchFn(ctx, idsToRefresh)
+		batchErr, isBatchErr := asBatchError(err)
 		// In case of an error, we'll proceed with the ones we got from the distributed storage.
 		// NOTE: It's important that we return a specific error here, otherwise we'll potentially
 		// end up caching the IDs that we weren't able to retrieve from the underlying data source
 		// as missing records.
-		if err != nil {
+		if err != nil && !isBatchErr {
 			for i := 0; i < len(stale); i++ {
 				c.reportDistributedStaleFallback()
 			}
-			c.log.Error(fmt.Sprintf("sturdyc: error fetching records from the underlying data source. %v", err))
+			c.logger(LogDistributed).Error("sturdyc: error fetching records from the underlying data source", "error", err)
 			maps.Copy(stale, fresh)
 			return stale, errOnlyDistributedRecords
 		}
@@ -234,6 +335,7 @@ func distributedBatchFetch[V, T any](c *Client[T], keyFn KeyFn, fetchFn BatchFet
 		// Next, we'll want to check if we should change any of the records to be missing or perform deletions.
 		recordsToWrite := make(map[string][]byte, len(dataSourceResponses))
 		keysToDelete := make([]string, 0, max(len(idsToRefresh)-len(dataSourceResponses), 0))
+		failedIDs := make([]string, 0)
 		for _, id := range idsToRefresh {
 			key := keyFn(id)
 			response, ok := dataSourceResponses[id]
@@ -245,8 +347,20 @@ func distributedBatchFetch[V, T any](c *Client[T], keyFn KeyFn, fetchFn BatchFet
 				continue
 			}
 
+			// The data source failed to retrieve this specific ID, so we'll fall back
+			// to the value from the distributed storage if we have one.
+			if isBatchErr && batchErr.failed(id) {
+				if staleValue, okStale := stale[id]; okStale {
+					c.reportDistributedStaleFallback()
+					fresh[id] = staleValue
+					continue
+				}
+				failedIDs = append(failedIDs, id)
+				continue
+			}
+
 			// At this point, we know that we weren't able to retrieve this ID from the underlying data source.
-			if c.storeMissingRecords {
+			if opts.storeMissingRecords {
 				if bytes, err := marshalMissingRecord[V](c); err == nil {
 					recordsToWrite[key] = bytes
 				}
@@ -260,18 +374,128 @@ func distributedBatchFetch[V, T any](c *Client[T], keyFn KeyFn, fetchFn BatchFet
 		}
 
 		if len(keysToDelete) > 0 {
-			c.safeGo(func() {
+			c.trackedGo(func() {
 				c.distributedStorage.DeleteBatch(context.Background(), keysToDelete)
 			})
 		}
 
 		if len(recordsToWrite) > 0 {
-			c.safeGo(func() {
+			c.trackedGo(func() {
 				c.distributedStorage.SetBatch(context.Background(), recordsToWrite)
 			})
 		}
 
 		maps.Copy(fresh, dataSourceResponses)
+		if isBatchErr {
+			return fresh, failedIDsError(batchErr.Errors, failedIDs)
+		}
 		return fresh, nil
 	}
 }
+
+// distributedBatchWrite is the batch equivalent of distributedWrite.
+func distributedBatchWrite[V, T any](c *Client[T], keyFn KeyFn, fetchFn BatchFetchFn[V], opts callOptions) BatchFetchFn[V] {
+	if c.distributedStorage == nil {
+		return fetchFn
+	}
+
+	return func(ctx context.Context, ids []string) (map[string]V, error) {
+		dataSourceResponses, err := fetchFn(ctx, ids)
+		batchErr, isBatchErr := asBatchError(err)
+		if err != nil && !isBatchErr {
+			return dataSourceResponses, err
+		}
+
+		recordsToWrite := make(map[string][]byte, len(ids))
+		keysToDelete := make([]string, 0)
+		for _, id := range ids {
+			key := keyFn(id)
+			if response, ok := dataSourceResponses[id]; ok {
+				if recordBytes, marshalErr := marshalRecord[V](response, c); marshalErr == nil {
+					recordsToWrite[key] = recordBytes
+				}
+				continue
+			}
+
+			if isBatchErr && batchErr.failed(id) {
+				continue
+			}
+
+			if opts.storeMissingRecords {
+				if bytes, marshalErr := marshalMissingRecord[V](c); marshalErr == nil {
+					recordsToWrite[key] = bytes
+				}
+				continue
+			}
+			keysToDelete = append(keysToDelete, key)
+		}
+
+		if len(keysToDelete) > 0 {
+			c.trackedGo(func() {
+				c.distributedStorage.DeleteBatch(context.Background(), keysToDelete)
+			})
+		}
+
+		if len(recordsToWrite) > 0 {
+			c.trackedGo(func() {
+				c.distributedStorage.SetBatch(context.Background(), recordsToWrite)
+			})
+		}
+
+		return dataSourceResponses, err
+	}
+}
+
+// writeThrough writes records that were set explicitly to the distributed
+// storage if the cache has been configured to use write-through.
+func (c *Client[T]) writeThrough(ctx context.Context, records map[string]T) {
+	if !c.distributedWriteThrough || len(records) == 0 {
+		return
+	}
+
+	recordsToWrite := make(map[string][]byte, len(records))
+	for key, value := range records {
+		if recordBytes, marshalErr := marshalRecord[T](value, c); marshalErr == nil {
+			recordsToWrite[key] = recordBytes
+		}
+	}
+
+	if len(recordsToWrite) == 1 {
+		for key, recordBytes := range recordsToWrite {
+			c.distributedStorage.Set(ctx, key, recordBytes)
+		}
+		return
+	}
+	c.distributedStorage.SetBatch(ctx, recordsToWrite)
+}
+
+// writeRecordThrough is the equivalent of writeThrough for a single record.
+// It spares Set from allocating a map when write-through is disabled.
+func (c *Client[T]) writeRecordThrough(ctx context.Context, key string, value T) {
+	if !c.distributedWriteThrough {
+		return
+	}
+	if recordBytes, marshalErr := marshalRecord[T](value, c); marshalErr == nil {
+		c.distributedStorage.Set(ctx, key, recordBytes)
+	}
+}
+
+// writeMissingRecordThrough is the equivalent of writeThrough for missing records.
+func (c *Client[T]) writeMissingRecordThrough(ctx context.Context, key string) {
+	if !c.distributedWriteThrough {
+		return
+	}
+	if missingRecordBytes, missingRecordErr := marshalMissingRecord[T](c); missingRecordErr == nil {
+		c.distributedStorage.Set(ctx, key, missingRecordBytes)
+	}
+}
+
+// deleteThrough is the equivalent of writeThrough for deletions. Deletions are
+// also propagated when an invalidation bus is used, as the other instances
+// would otherwise read the record back from the distributed storage.
+func (c *Client[T]) deleteThrough(ctx context.Context, key string) {
+	if !c.distributedWriteThrough && c.invalidationBus == nil {
+		return
+	}
+	c.distributedStorage.Delete(ctx, key)
+}
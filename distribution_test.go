@@ -1,6 +1,8 @@
 package sturdyc_test
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
 	"errors"
 	"strconv"
@@ -106,6 +108,12 @@ func (m *mockStorage) assertRecords(t *testing.T, ids []string, keyFn sturdyc.Ke
 	}
 }
 
+func (m *mockStorage) size() int {
+	m.Lock()
+	defer m.Unlock()
+	return len(m.records)
+}
+
 func (m *mockStorage) assertGetCount(t *testing.T, count int) {
 	t.Helper()
 	m.Lock()
@@ -869,3 +877,289 @@ func TestPartialResponseForRefreshesDoesNotResultInMissingRecords(t *testing.T)
 		t.Fatalf("expected cache size to be 100, got %d", c.Size())
 	}
 }
+
+func TestDistributedKeyPrefix(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	distributedStorage := &mockStorage{}
+	c := sturdyc.New[string](1000, 10, time.Minute, 30,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithDistributedStorage(distributedStorage),
+		sturdyc.WithDistributedKeyPrefix("svc-a:"),
+	)
+	fetchObserver := NewFetchObserver(2)
+
+	fetchObserver.Response("1")
+	sturdyc.GetOrFetch(ctx, c, "1", fetchObserver.Fetch)
+	<-fetchObserver.FetchCompleted
+
+	ids := []string{"2", "3"}
+	keyFn := c.BatchKeyFn("item")
+	fetchObserver.BatchResponse(ids)
+	sturdyc.GetOrFetchBatch(ctx, c, ids, keyFn, fetchObserver.FetchBatch)
+	<-fetchObserver.FetchCompleted
+
+	// The keys are written asynchonously to the distributed storage.
+	time.Sleep(100 * time.Millisecond)
+	distributedStorage.assertRecord(t, "svc-a:1")
+	distributedStorage.assertRecords(t, ids, func(id string) string {
+		return "svc-a:" + keyFn(id)
+	})
+
+	// The records should be read from the distributed storage once they've been evicted from memory.
+	c.Delete("1")
+	c.Delete(keyFn("2"))
+	c.Delete(keyFn("3"))
+	res, err := sturdyc.GetOrFetch(ctx, c, "1", fetchObserver.Fetch)
+	if err != nil || res != "value1" {
+		t.Errorf("expected value1, got %q %v", res, err)
+	}
+	batchRes, err := sturdyc.GetOrFetchBatch(ctx, c, ids, keyFn, fetchObserver.FetchBatch)
+	if err != nil || len(batchRes) != 2 {
+		t.Errorf("expected both records, got %v %v", batchRes, err)
+	}
+	fetchObserver.AssertFetchCount(t, 2)
+}
+
+func TestDistributedCompression(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	distributedStorage := &mockStorage{}
+	c := sturdyc.New[string](1000, 10, time.Minute, 30,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithDistributedStorage(distributedStorage),
+		sturdyc.WithDistributedCompression(sturdyc.NewGzipCompressor(gzip.BestSpeed), 0),
+	)
+	fetchObserver := NewFetchObserver(2)
+
+	fetchObserver.Response("1")
+	sturdyc.GetOrFetch(ctx, c, "1", fetchObserver.Fetch)
+	<-fetchObserver.FetchCompleted
+
+	ids := []string{"2", "3"}
+	keyFn := c.BatchKeyFn("item")
+	fetchObserver.BatchResponse(ids)
+	sturdyc.GetOrFetchBatch(ctx, c, ids, keyFn, fetchObserver.FetchBatch)
+	<-fetchObserver.FetchCompleted
+
+	// The keys are written asynchonously to the distributed storage.
+	time.Sleep(100 * time.Millisecond)
+	distributedStorage.Lock()
+	for key, value := range distributedStorage.records {
+		if bytes.HasPrefix(value, []byte("{")) {
+			t.Errorf("expected the record for key %s to be compressed, got %s", key, value)
+		}
+	}
+	distributedStorage.Unlock()
+
+	// Records that were written without compression should still be readable.
+	uncompressed := `{"created_at":"` + time.Now().Format(time.RFC3339Nano) + `","value":"value4","is_missing_record":false}`
+	distributedStorage.Set(ctx, "4", []byte(uncompressed))
+
+	c.Delete("1")
+	c.Delete(keyFn("2"))
+	c.Delete(keyFn("3"))
+	res, err := sturdyc.GetOrFetch(ctx, c, "1", fetchObserver.Fetch)
+	if err != nil || res != "value1" {
+		t.Errorf("expected value1, got %q %v", res, err)
+	}
+	batchRes, err := sturdyc.GetOrFetchBatch(ctx, c, ids, keyFn, fetchObserver.FetchBatch)
+	if err != nil || len(batchRes) != 2 {
+		t.Errorf("expected both records, got %v %v", batchRes, err)
+	}
+	res, err = sturdyc.GetOrFetch(ctx, c, "4", fetchObserver.Fetch)
+	if err != nil || res != "value4" {
+		t.Errorf("expected value4, got %q %v", res, err)
+	}
+	fetchObserver.AssertFetchCount(t, 2)
+}
+
+func TestDistributedCompressionMarksTheRecordsBelowTheThreshold(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	distributedStorage := &mockStorage{}
+	c := sturdyc.New[string](1000, 10, time.Minute, 30,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithDistributedStorage(distributedStorage),
+		sturdyc.WithDistributedCompression(sturdyc.NewGzipCompressor(gzip.BestSpeed), 1<<20),
+	)
+	fetchObserver := NewFetchObserver(1)
+
+	fetchObserver.Response("1")
+	sturdyc.GetOrFetch(ctx, c, "1", fetchObserver.Fetch)
+	<-fetchObserver.FetchCompleted
+
+	// Every record carries a header, so that the records that weren't
+	// compressed can't be mistaken for compressed ones, whatever the
+	// codec writes.
+	time.Sleep(50 * time.Millisecond)
+	record, ok := distributedStorage.Get(ctx, "1")
+	if !ok || !bytes.HasPrefix(record, []byte("\x00sturdyc\x00{")) {
+		t.Fatalf("expected an uncompressed record with a header, got %q", record)
+	}
+
+	c.Delete("1")
+	res, err := sturdyc.GetOrFetch(ctx, c, "1", fetchObserver.Fetch)
+	if err != nil || res != "value1" {
+		t.Errorf("expected value1, got %q %v", res, err)
+	}
+	fetchObserver.AssertFetchCount(t, 1)
+}
+
+func TestDistributedWriteCoalescing(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	clock := sturdyc.NewTestClock(time.Now())
+	distributedStorage := &mockStorage{}
+	c := sturdyc.New[string](1000, 10, time.Hour, 30,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithClock(clock),
+		sturdyc.WithDistributedStorage(distributedStorage),
+		sturdyc.WithDistributedWriteCoalescing(time.Second, 0),
+	)
+	fetchObserver := NewFetchObserver(2)
+
+	fetchObserver.Response("1")
+	sturdyc.GetOrFetch(ctx, c, "1", fetchObserver.Fetch)
+	<-fetchObserver.FetchCompleted
+
+	ids := []string{"2", "3"}
+	keyFn := c.BatchKeyFn("item")
+	fetchObserver.BatchResponse(ids)
+	sturdyc.GetOrFetchBatch(ctx, c, ids, keyFn, fetchObserver.FetchBatch)
+	<-fetchObserver.FetchCompleted
+
+	// The writes should be buffered until the window has passed.
+	time.Sleep(50 * time.Millisecond)
+	distributedStorage.assertSetCount(t, 0)
+
+	// Records that are yet to be flushed should still be read from the buffer.
+	c.Delete("1")
+	c.Delete(keyFn("2"))
+	c.Delete(keyFn("3"))
+	res, err := sturdyc.GetOrFetch(ctx, c, "1", fetchObserver.Fetch)
+	if err != nil || res != "value1" {
+		t.Errorf("expected value1, got %q %v", res, err)
+	}
+	batchRes, err := sturdyc.GetOrFetchBatch(ctx, c, ids, keyFn, fetchObserver.FetchBatch)
+	if err != nil || len(batchRes) != 2 {
+		t.Errorf("expected both records, got %v %v", batchRes, err)
+	}
+	fetchObserver.AssertFetchCount(t, 2)
+	// Only the initial lookups should have reached the storage.
+	distributedStorage.assertGetCount(t, 2)
+
+	// Once the window has passed, all of the writes should be flushed in a single batch.
+	for distributedStorage.size() == 0 {
+		clock.Add(time.Second)
+		time.Sleep(time.Millisecond)
+	}
+	distributedStorage.assertSetCount(t, 1)
+	distributedStorage.assertRecord(t, "1")
+	distributedStorage.assertRecords(t, ids, keyFn)
+}
+
+func TestDistributedWriteCoalescingFlushesWhenTheBufferIsFull(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	distributedStorage := &mockStorage{}
+	c := sturdyc.New[string](1000, 10, time.Hour, 30,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithDistributedStorage(distributedStorage),
+		sturdyc.WithDistributedWriteCoalescing(time.Hour, 3),
+	)
+	fetchObserver := NewFetchObserver(2)
+
+	fetchObserver.Response("1")
+	sturdyc.GetOrFetch(ctx, c, "1", fetchObserver.Fetch)
+	<-fetchObserver.FetchCompleted
+
+	ids := []string{"2", "3"}
+	keyFn := c.BatchKeyFn("item")
+	fetchObserver.BatchResponse(ids)
+	sturdyc.GetOrFetchBatch(ctx, c, ids, keyFn, fetchObserver.FetchBatch)
+	<-fetchObserver.FetchCompleted
+
+	time.Sleep(100 * time.Millisecond)
+	distributedStorage.assertSetCount(t, 1)
+	distributedStorage.assertRecord(t, "1")
+	distributedStorage.assertRecords(t, ids, keyFn)
+}
+
+func TestDistributedWriteThrough(t *testing.T) {
+	t.Parallel()
+
+	distributedStorage := &mockStorage{}
+	c := sturdyc.New[string](1000, 10, time.Hour, 30,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithDistributedStorageEarlyRefreshes(distributedStorage, time.Hour),
+		sturdyc.WithDistributedWriteThrough(),
+	)
+
+	// The writes should reach the distributed storage before the calls return.
+	c.Set("1", "value1")
+	distributedStorage.assertRecord(t, "1")
+
+	keyFn := c.BatchKeyFn("item")
+	c.SetManyKeyFn(map[string]string{"2": "value2", "3": "value3"}, keyFn)
+	distributedStorage.assertRecords(t, []string{"2", "3"}, keyFn)
+	distributedStorage.assertSetCount(t, 2)
+
+	c.Delete("1")
+	distributedStorage.assertDeleteCount(t, 1)
+	if distributedStorage.size() != 2 {
+		t.Errorf("expected the record to have been deleted from the distributed storage")
+	}
+}
+
+func TestDistributedWriteThroughIsDisabledByDefault(t *testing.T) {
+	t.Parallel()
+
+	distributedStorage := &mockStorage{}
+	c := sturdyc.New[string](1000, 10, time.Hour, 30,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithDistributedStorage(distributedStorage),
+	)
+
+	c.Set("1", "value1")
+	c.SetMany(map[string]string{"2": "value2", "3": "value3"})
+	c.Delete("1")
+	distributedStorage.assertSetCount(t, 0)
+	distributedStorage.assertDeleteCount(t, 0)
+}
+
+func TestDistributedStorageReportsLatencyMetrics(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	metricsRecorder := &latencyMetricsRecorder{
+		TestMetricsRecorder:  newTestMetricsRecorder(1),
+		distributedDurations: make(map[sturdyc.DistributedOperation][]time.Duration),
+	}
+	c := sturdyc.New[string](1000, 1, time.Minute, 30,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithDistributedStorage(&mockStorage{}),
+		sturdyc.WithMetrics(metricsRecorder),
+	)
+
+	fetchFn := func(context.Context) (string, error) { return "value", nil }
+	if _, err := c.GetOrFetch(ctx, "1", fetchFn); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.WaitForIdle(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	metricsRecorder.mu.Lock()
+	defer metricsRecorder.mu.Unlock()
+	for _, operation := range []sturdyc.DistributedOperation{sturdyc.DistributedGet, sturdyc.DistributedSet} {
+		if n := len(metricsRecorder.distributedDurations[operation]); n != 1 {
+			t.Errorf("expected 1 %s to be timed, got %d", operation, n)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package sturdyc
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	xxhash "github.com/cespare/xxhash/v2"
+)
+
+// doorkeeperHashes is the number of bits that each key sets in the filter,
+// which gives a false positive rate of about 1% at the expected number of keys.
+const doorkeeperHashes = 7
+
+// doorkeeper is a bloom filter that remembers which keys have been fetched
+// since it was last reset. Keys are only admitted to the cache once they've
+// been fetched a second time, which keeps one-hit wonders from evicting the
+// entries that are actually being reused.
+type doorkeeper struct {
+	sync.Mutex
+	clock   Clock
+	bits    []uint64
+	window  time.Duration
+	resetAt time.Time
+}
+
+func newDoorkeeper(expectedKeys int, window time.Duration, clock Clock) *doorkeeper {
+	// m = -n * ln(p) / ln(2)^2 with a false positive rate p of 1%.
+	numBits := int(math.Ceil(-float64(expectedKeys) * math.Log(0.01) / (math.Ln2 * math.Ln2)))
+	return &doorkeeper{
+		clock:   clock,
+		bits:    make([]uint64, (numBits+63)/64),
+		window:  window,
+		resetAt: clock.Now().Add(window),
+	}
+}
+
+// admit reports whether the key has been seen since the filter was last
+// reset. If it hasn't, the key is added to the filter.
+func (d *doorkeeper) admit(key string) bool {
+	d.Lock()
+	defer d.Unlock()
+
+	if now := d.clock.Now(); !now.Before(d.resetAt) {
+		clear(d.bits)
+		d.resetAt = now.Add(d.window)
+	}
+
+	// Derive the bit positions from the two halves of a single hash.
+	hash := xxhash.Sum64String(key)
+	h1, h2 := hash&math.MaxUint32, hash>>32
+	numBits := uint64(len(d.bits) * 64)
+	seen := true
+	for i := uint64(0); i < doorkeeperHashes; i++ {
+		bit := (h1 + i*h2) % numBits
+		word, mask := bit/64, uint64(1)<<(bit%64)
+		if d.bits[word]&mask == 0 {
+			seen = false
+			d.bits[word] |= mask
+		}
+	}
+	return seen
+}
+
+// admit reports whether a fetched record should be written to the cache.
+func (c *Config) admit(key string) bool {
+	return c.doorkeeper == nil || c.doorkeeper.admit(key)
+}
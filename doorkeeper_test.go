@@ -0,0 +1,57 @@
+package sturdyc_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/viccon/sturdyc"
+)
+
+func TestDoorkeeperCachesKeysOnTheSecondFetch(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	clock := sturdyc.NewTestClock(time.Now())
+	c := sturdyc.New[string](100, 1, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithDoorkeeper(1000, time.Minute),
+		sturdyc.WithClock(clock),
+	)
+
+	fetchObserver := NewFetchObserver(10)
+	fetchObserver.Response("1")
+	for i := 0; i < 3; i++ {
+		res, err := c.GetOrFetch(ctx, "1", fetchObserver.Fetch)
+		if err != nil || res != "value1" {
+			t.Fatalf("expected value1, got %q and %v", res, err)
+		}
+	}
+	<-fetchObserver.FetchCompleted
+	<-fetchObserver.FetchCompleted
+	fetchObserver.AssertFetchCount(t, 2)
+	if c.Size() != 1 {
+		t.Errorf("expected the key to be cached after the second fetch, got a size of %d", c.Size())
+	}
+
+	// Once the filter has been reset, the keys have to be fetched twice again.
+	c.GetOrFetch(ctx, "2", fetchObserver.Fetch)
+	<-fetchObserver.FetchCompleted
+	clock.Add(time.Minute)
+	c.GetOrFetch(ctx, "2", fetchObserver.Fetch)
+	<-fetchObserver.FetchCompleted
+	fetchObserver.AssertFetchCount(t, 4)
+	if c.Size() != 1 {
+		t.Errorf("expected the filter to have been reset, got a size of %d", c.Size())
+	}
+
+	ids := []string{"3", "4"}
+	fetchObserver.BatchResponse(ids)
+	c.GetOrFetchBatch(ctx, ids, c.BatchKeyFn("item"), fetchObserver.FetchBatch)
+	<-fetchObserver.FetchCompleted
+	c.GetOrFetchBatch(ctx, ids, c.BatchKeyFn("item"), fetchObserver.FetchBatch)
+	<-fetchObserver.FetchCompleted
+	if c.Size() != 3 {
+		t.Errorf("expected the batch to be cached after the second fetch, got a size of %d", c.Size())
+	}
+}
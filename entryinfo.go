@@ -0,0 +1,118 @@
+package sturdyc
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EntryInfo holds the metadata of an entry in the cache.
+type EntryInfo struct {
+	Key             string    `json:"key"`
+	CachedAt        time.Time `json:"cached_at"`
+	ExpiresAt       time.Time `json:"expires_at"`
+	RefreshAt       time.Time `json:"refresh_at"`
+	LastAccessedAt  time.Time `json:"last_accessed_at"`
+	Accesses        int64     `json:"accesses"`
+	RefreshRetries  int       `json:"refresh_retries"`
+	IsMissingRecord bool      `json:"is_missing_record"`
+	Pinned          bool      `json:"pinned"`
+}
+
+// info returns the metadata of the entry. Should be called with a lock.
+func (e *entry[T]) info(key string) EntryInfo {
+	return EntryInfo{
+		Key:             key,
+		CachedAt:        e.cachedAt,
+		ExpiresAt:       e.expiresAt,
+		RefreshAt:       e.refreshAt,
+		LastAccessedAt:  e.lastAccessed(),
+		Accesses:        e.accesses.Load(),
+		RefreshRetries:  e.numOfRefreshRetries,
+		IsMissingRecord: e.isMissingRecord,
+		Pinned:          e.pinned,
+	}
+}
+
+// entryInfo returns the metadata of the entry, if it exists.
+func (s *shard[T]) entryInfo(key string) (EntryInfo, bool) {
+	s.RLock()
+	if s.successor != nil {
+		s.RUnlock()
+		return s.successor(key).entryInfo(key)
+	}
+	defer s.RUnlock()
+
+	e, ok := s.entries[key]
+	if !ok {
+		return EntryInfo{}, false
+	}
+	return e.info(key), true
+}
+
+// entryInfosAfter returns the metadata of up to limit entries that haven't
+// expired, in the order of their keys, starting after the given key.
+func (s *shard[T]) entryInfosAfter(after string, limit int) []EntryInfo {
+	s.RLock()
+	defer s.RUnlock()
+
+	now := s.clock.Now()
+	keys := make([]string, 0)
+	for key, e := range s.entries {
+		if key > after && (e.pinned || !now.After(e.expiresAt)) {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+
+	infos := make([]EntryInfo, 0, min(limit, len(keys)))
+	for _, key := range keys[:min(limit, len(keys))] {
+		infos = append(infos, s.entries[key].info(key))
+	}
+	return infos
+}
+
+// EntryInfo returns the metadata of the entry without counting as a read.
+// The last access time has a granularity of a second. Entries that have
+// expired, but haven't been evicted yet, are included.
+func (c *Client[T]) EntryInfo(key string) (EntryInfo, bool) {
+	return c.getShard(key).entryInfo(key)
+}
+
+// ListEntries returns the metadata of up to limit entries, starting at the
+// cursor, along with the cursor of the next page. An empty cursor starts at
+// the beginning, and an empty next cursor means that every entry has been
+// listed. The values aren't included, which makes this suitable for building
+// inspection tools. The cursors are invalidated by client.Reshard.
+func (c *Client[T]) ListEntries(cursor string, limit int) ([]EntryInfo, string) {
+	shards := c.getShards()
+	shardIndex, after, ok := parseEntriesCursor(cursor)
+	if !ok || shardIndex >= len(shards) || limit < 1 {
+		return nil, ""
+	}
+
+	var infos []EntryInfo
+	for shardIndex < len(shards) {
+		page := shards[shardIndex].entryInfosAfter(after, limit-len(infos))
+		infos = append(infos, page...)
+		if len(infos) == limit && len(page) > 0 {
+			return infos, strconv.Itoa(shardIndex) + ":" + page[len(page)-1].Key
+		}
+		shardIndex, after = shardIndex+1, ""
+	}
+	return infos, ""
+}
+
+// parseEntriesCursor returns the shard and the key that the cursor points to.
+func parseEntriesCursor(cursor string) (int, string, bool) {
+	if cursor == "" {
+		return 0, "", true
+	}
+	index, key, found := strings.Cut(cursor, ":")
+	shardIndex, err := strconv.Atoi(index)
+	if !found || err != nil || shardIndex < 0 {
+		return 0, "", false
+	}
+	return shardIndex, key, true
+}
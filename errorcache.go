@@ -0,0 +1,161 @@
+package sturdyc
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"sync"
+	"time"
+)
+
+type cachedError struct {
+	err       error
+	expiresAt time.Time
+}
+
+// errorCache holds the errors that the underlying data source returned for
+// keys that weren't in the cache. While an error is cached, any subsequent
+// misses for the same key are going to receive the error rather than
+// calling the data source again.
+type errorCache struct {
+	sync.Mutex
+	ttl    time.Duration
+	errors map[string]cachedError
+	// nextSweep is when set is going to remove the expired errors next. The
+	// errors are swept at most once per TTL, which bounds the size of the cache
+	// even when the evictions are disabled, without scanning it on every write.
+	nextSweep time.Time
+}
+
+func newErrorCache(ttl time.Duration) *errorCache {
+	return &errorCache{
+		ttl:    ttl,
+		errors: make(map[string]cachedError),
+	}
+}
+
+// get returns the error for the key, or nil if there is no error that hasn't expired.
+func (e *errorCache) get(key string, now time.Time) error {
+	e.Lock()
+	defer e.Unlock()
+
+	cached, ok := e.errors[key]
+	if !ok {
+		return nil
+	}
+
+	if now.After(cached.expiresAt) {
+		delete(e.errors, key)
+		return nil
+	}
+
+	return cached.err
+}
+
+// set caches the error for the key, and removes the errors that have expired
+// if it's time for another sweep.
+func (e *errorCache) set(key string, err error, now time.Time) {
+	e.Lock()
+	defer e.Unlock()
+	if !now.Before(e.nextSweep) {
+		e.removeExpired(now)
+		e.nextSweep = now.Add(e.ttl)
+	}
+	e.errors[key] = cachedError{err: err, expiresAt: now.Add(e.ttl)}
+}
+
+// evictExpired removes all the errors that have expired.
+func (e *errorCache) evictExpired(now time.Time) {
+	e.Lock()
+	defer e.Unlock()
+	e.removeExpired(now)
+}
+
+// removeExpired should be called WITH a lock.
+func (e *errorCache) removeExpired(now time.Time) {
+	for key, cached := range e.errors {
+		if now.After(cached.expiresAt) {
+			delete(e.errors, key)
+		}
+	}
+}
+
+// isCacheableError reports whether an error from the underlying data source
+// should be cached. Missing records have their own handling, and errors that
+// are caused by the caller, or by the cache itself, are not cached.
+func isCacheableError(err error) bool {
+	return err != nil &&
+		!errors.Is(err, ErrNotFound) &&
+		!errors.Is(err, ErrMissingRecord) &&
+		!errors.Is(err, ErrOnlyCachedRecords) &&
+		!errors.Is(err, ErrCircuitOpen) &&
+		!errors.Is(err, ErrInvalidType) &&
+		!errors.Is(err, context.Canceled) &&
+		!errors.Is(err, context.DeadlineExceeded)
+}
+
+// getCachedError returns the cached error for the key, or nil if there isn't one.
+func (c *Client[T]) getCachedError(key string) error {
+	if c.errorCache == nil {
+		return nil
+	}
+	return c.errorCache.get(key, c.clock.Now())
+}
+
+// cacheError caches the error for the key if it should be cached.
+func (c *Client[T]) cacheError(key string, err error) {
+	if c.errorCache == nil || !isCacheableError(err) {
+		return
+	}
+	c.errorCache.set(key, err, c.clock.Now())
+}
+
+// cacheBatchErrors caches the errors for the IDs that we failed to fetch.
+func (c *Client[T]) cacheBatchErrors(ids []string, keyFn KeyFn, err error) {
+	if c.errorCache == nil || err == nil {
+		return
+	}
+
+	if batchErr, ok := asBatchError(err); ok {
+		for id, idErr := range batchErr.Errors {
+			c.cacheError(keyFn(id), idErr)
+		}
+		return
+	}
+
+	for _, id := range ids {
+		c.cacheError(keyFn(id), err)
+	}
+}
+
+// removeCachedErrors removes the IDs that have a cached error from the misses.
+func (c *Client[T]) removeCachedErrors(misses []string, keyFn KeyFn) ([]string, map[string]error) {
+	if c.errorCache == nil {
+		return misses, nil
+	}
+
+	remaining := make([]string, 0, len(misses))
+	idErrors := make(map[string]error)
+	for _, id := range misses {
+		if err := c.getCachedError(keyFn(id)); err != nil {
+			idErrors[id] = err
+			continue
+		}
+		remaining = append(remaining, id)
+	}
+	return remaining, idErrors
+}
+
+// withCachedErrors adds the cached errors to the error that is returned for a batch.
+func withCachedErrors(err error, cachedErrors map[string]error) error {
+	if len(cachedErrors) == 0 {
+		return err
+	}
+
+	idErrors := make(map[string]error, len(cachedErrors))
+	if batchErr, ok := asBatchError(err); ok {
+		maps.Copy(idErrors, batchErr.Errors)
+	}
+	maps.Copy(idErrors, cachedErrors)
+	return &BatchError{Errors: idErrors}
+}
@@ -0,0 +1,113 @@
+package sturdyc_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/viccon/sturdyc"
+)
+
+func TestGetOrFetchCachesErrors(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	errorTTL := time.Second
+	clock := sturdyc.NewTestClock(time.Now())
+	c := sturdyc.New[string](100, 2, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithErrorCaching(errorTTL),
+		sturdyc.WithClock(clock),
+	)
+
+	var calls atomic.Int32
+	errUnavailable := errors.New("unavailable")
+	fetchFn := func(_ context.Context) (string, error) {
+		if calls.Add(1) == 1 {
+			return "", errUnavailable
+		}
+		return "value", nil
+	}
+
+	for i := 0; i < 5; i++ {
+		if _, err := c.GetOrFetch(ctx, "key", fetchFn); !errors.Is(err, errUnavailable) {
+			t.Errorf("expected the cached error, got %v", err)
+		}
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("expected 1 call, got %d", got)
+	}
+
+	// Once the error has expired, we should call the data source again.
+	clock.Add(errorTTL + 1)
+	res, err := c.GetOrFetch(ctx, "key", fetchFn)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if res != "value" {
+		t.Errorf("expected value, got %s", res)
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("expected 2 calls, got %d", got)
+	}
+}
+
+func TestNotFoundErrorsAreNotCached(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	c := sturdyc.New[string](100, 2, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithErrorCaching(time.Minute),
+	)
+
+	var calls atomic.Int32
+	fetchFn := func(_ context.Context) (string, error) {
+		calls.Add(1)
+		return "", sturdyc.ErrNotFound
+	}
+
+	c.GetOrFetch(ctx, "key", fetchFn)
+	c.GetOrFetch(ctx, "key", fetchFn)
+	if got := calls.Load(); got != 2 {
+		t.Errorf("expected 2 calls, got %d", got)
+	}
+}
+
+func TestGetOrFetchBatchCachesErrorsPerID(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	c := sturdyc.New[string](100, 2, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithErrorCaching(time.Minute),
+	)
+
+	errBadID := errors.New("bad id")
+	observer := &chunkObserver{}
+	fetchFn := func(ctx context.Context, ids []string) (map[string]string, error) {
+		response, _ := observer.FetchBatch(ctx, ids)
+		if _, ok := response["2"]; !ok {
+			return response, nil
+		}
+		delete(response, "2")
+		return response, &sturdyc.BatchError{Errors: map[string]error{"2": errBadID}}
+	}
+
+	keyFn := c.BatchKeyFn("item")
+	c.GetOrFetchBatch(ctx, []string{"1", "2"}, keyFn, fetchFn)
+	res, err := c.GetOrFetchBatch(ctx, []string{"1", "2", "3"}, keyFn, fetchFn)
+
+	var batchErr *sturdyc.BatchError
+	if !errors.As(err, &batchErr) || !errors.Is(batchErr.Errors["2"], errBadID) {
+		t.Fatalf("expected a BatchError for ID 2, got %v", err)
+	}
+	if len(res) != 2 || res["1"] != "value1" || res["3"] != "value3" {
+		t.Errorf("expected records 1 and 3, got %v", res)
+	}
+
+	// The second call should only have fetched ID 3.
+	observer.assertSizes(t, []int{2, 1})
+}
@@ -1,6 +1,9 @@
 package sturdyc
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 var (
 	// errOnlyDistributedRecords is an internal error that the cache uses to not
@@ -25,7 +28,87 @@ var (
 	// fetch the remaining records failed. As the consumer, you can then decide whether to
 	// proceed with the cached records or if the entire batch is necessary.
 	ErrOnlyCachedRecords = errors.New("sturdyc: failed to fetch the records that were not in the cache")
+	// ErrCircuitOpen is returned when the circuit breaker is open, and the call
+	// to the underlying data source was rejected in order to let it recover.
+	ErrCircuitOpen = errors.New("sturdyc: the circuit breaker is open")
+	// ErrFetchTimeout is returned when a call to the underlying data source
+	// didn't complete within the duration that was passed to WithFetchTimeout.
+	ErrFetchTimeout = errors.New("sturdyc: the call to the underlying data source timed out")
+	// ErrRateLimited is returned when the context was cancelled while the call
+	// to the underlying data source was waiting for the rate limiter.
+	ErrRateLimited = errors.New("sturdyc: the call to the underlying data source was rate limited")
+	// ErrDistributedWriteFailed is passed to the retry policy of the write-behind
+	// queue when a write to the distributed storage timed out or panicked.
+	ErrDistributedWriteFailed = errors.New("sturdyc: the write to the distributed storage failed")
+	// ErrInvalidExport is returned by client.Import when the data wasn't written by
+	// client.Export, or was written using a version of the format that isn't supported.
+	ErrInvalidExport = errors.New("sturdyc: invalid export")
+	// ErrPreloadUnsupported is returned by client.Preload when the cache doesn't have a
+	// distributed storage, or when the storage doesn't implement DistributedStorageScanner.
+	ErrPreloadUnsupported = errors.New("sturdyc: the distributed storage can't be scanned")
+	// ErrInvalidValue is returned when a value that was fetched from the
+	// underlying data source was rejected by the validator of the cache.
+	ErrInvalidValue = errors.New("sturdyc: the value was rejected by the validator")
 	// ErrInvalidType is returned when you try to use one of the generic
 	// package level functions but the type assertion fails.
 	ErrInvalidType = errors.New("sturdyc: invalid response type")
 )
+
+// KeyError is returned by client.GetOrFetch and client.Passthrough to add the
+// key of the record to the error. It unwraps to the underlying error, so the
+// outcome of the call can still be checked with errors.Is, while errors.As
+// can be used to retrieve the key.
+type KeyError struct {
+	Key string
+	Err error
+}
+
+func (e *KeyError) Error() string {
+	return fmt.Sprintf("%s (key %q)", e.Err, e.Key)
+}
+
+func (e *KeyError) Unwrap() error {
+	return e.Err
+}
+
+// withKey wraps the error in a KeyError, unless it's nil or already has the key.
+func withKey(key string, err error) error {
+	var keyErr *KeyError
+	if err == nil || (errors.As(err, &keyErr) && keyErr.Key == key) {
+		return err
+	}
+	return &KeyError{Key: key, Err: err}
+}
+
+// BatchError can be returned from a BatchFetchFn, along with the records that
+// were retrieved successfully, to report errors for individual IDs. This
+// allows the cache to store the rest of the batch even if some of the IDs
+// fail. IDs that are mapped to ErrNotFound are handled like any other missing
+// record, while IDs that are mapped to any other error are neither stored as
+// missing records nor deleted from the cache.
+//
+// When the cache returns a BatchError to the caller, it only contains the IDs
+// that failed. It unwraps to ErrOnlyCachedRecords if records were returned
+// along with it.
+type BatchError struct {
+	Errors map[string]error
+	// noRecords is set when the error is returned without any records.
+	noRecords bool
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("sturdyc: failed to fetch %d of the records in the batch", len(e.Errors))
+}
+
+func (e *BatchError) Unwrap() error {
+	if e.noRecords {
+		return nil
+	}
+	return ErrOnlyCachedRecords
+}
+
+// failed reports whether the ID failed with an error other than ErrNotFound.
+func (e *BatchError) failed(id string) bool {
+	err, ok := e.Errors[id]
+	return ok && !errors.Is(err, ErrNotFound)
+}
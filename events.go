@@ -0,0 +1,130 @@
+package sturdyc
+
+import (
+	"sync"
+	"time"
+)
+
+// RefreshEventType is the stage of a background refresh that an event describes.
+type RefreshEventType int
+
+const (
+	// RefreshScheduled is emitted when a read determines that a key should be refreshed.
+	RefreshScheduled RefreshEventType = iota
+	// RefreshBuffered is emitted when a key is added to a refresh buffer.
+	RefreshBuffered
+	// RefreshStarted is emitted right before the data source is called.
+	RefreshStarted
+	// RefreshSucceeded is emitted when the key has been refreshed.
+	RefreshSucceeded
+	// RefreshFailed is emitted when the refresh of the key failed.
+	RefreshFailed
+	// RecordCreated is emitted when a key that has been marked as missing is
+	// given a value, which distinguishes the creation of a record from an
+	// overwrite.
+	RecordCreated
+)
+
+func (t RefreshEventType) String() string {
+	switch t {
+	case RefreshScheduled:
+		return "scheduled"
+	case RefreshBuffered:
+		return "buffered"
+	case RefreshStarted:
+		return "started"
+	case RefreshSucceeded:
+		return "succeeded"
+	case RefreshFailed:
+		return "failed"
+	case RecordCreated:
+		return "created"
+	default:
+		return "unknown"
+	}
+}
+
+// RefreshEvent describes a stage of the background refresh of a key.
+type RefreshEvent struct {
+	Type RefreshEventType
+	Key  string
+	// Err is only set for events of the RefreshFailed type.
+	Err  error
+	Time time.Time
+}
+
+// refreshEvents keeps track of the subscribers of the refresh events.
+type refreshEvents struct {
+	mu          sync.RWMutex
+	nextID      int
+	subscribers map[int]chan RefreshEvent
+}
+
+func newRefreshEvents() *refreshEvents {
+	return &refreshEvents{subscribers: make(map[int]chan RefreshEvent)}
+}
+
+func (r *refreshEvents) subscribe(bufferSize int) (<-chan RefreshEvent, func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	id := r.nextID
+	r.nextID++
+	ch := make(chan RefreshEvent, bufferSize)
+	r.subscribers[id] = ch
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			delete(r.subscribers, id)
+			close(ch)
+		})
+	}
+	return ch, unsubscribe
+}
+
+// publish sends the event to every subscriber. The refreshes are never going
+// to wait for a subscriber, so events are dropped if a subscriber falls behind.
+func (r *refreshEvents) publish(event RefreshEvent) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, ch := range r.subscribers {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+func (r *refreshEvents) hasSubscribers() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.subscribers) > 0
+}
+
+// SubscribeRefreshEvents returns a channel that receives an event for every
+// stage of the background refreshes. The channel is buffered with the given
+// size, and events are dropped rather than blocking the refreshes if the
+// subscriber doesn't keep up. The returned function unsubscribes and closes
+// the channel.
+func (c *Client[T]) SubscribeRefreshEvents(bufferSize int) (<-chan RefreshEvent, func()) {
+	return c.refreshEvents.subscribe(bufferSize)
+}
+
+func (c *Config) emitRefreshEvent(eventType RefreshEventType, key string, err error) {
+	if !c.refreshEvents.hasSubscribers() {
+		return
+	}
+	c.refreshEvents.publish(RefreshEvent{Type: eventType, Key: key, Err: err, Time: c.clock.Now()})
+}
+
+func (c *Config) emitBatchRefreshEvent(eventType RefreshEventType, ids []string, keyFn KeyFn) {
+	if !c.refreshEvents.hasSubscribers() {
+		return
+	}
+	for _, id := range ids {
+		c.emitRefreshEvent(eventType, keyFn(id), nil)
+	}
+}
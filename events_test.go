@@ -0,0 +1,149 @@
+package sturdyc_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/viccon/sturdyc"
+)
+
+func assertRefreshEvents(t *testing.T, events <-chan sturdyc.RefreshEvent, expected ...sturdyc.RefreshEventType) []sturdyc.RefreshEvent {
+	t.Helper()
+	received := make([]sturdyc.RefreshEvent, 0, len(expected))
+	for _, eventType := range expected {
+		select {
+		case event := <-events:
+			if event.Type != eventType {
+				t.Fatalf("expected a %s event, got %s", eventType, event.Type)
+			}
+			received = append(received, event)
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for a %s event", eventType)
+		}
+	}
+	return received
+}
+
+func TestRefreshEventsAreEmittedForEachStage(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	clock := sturdyc.NewTestClock(time.Now())
+	refreshDelay := time.Second
+	c := sturdyc.New[string](100, 1, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithEarlyRefreshes(refreshDelay, refreshDelay, time.Millisecond),
+		sturdyc.WithClock(clock),
+	)
+	events, unsubscribe := c.SubscribeRefreshEvents(10)
+	defer unsubscribe()
+
+	fetchObserver := NewFetchObserver(10)
+	fetchObserver.Response("1")
+	c.GetOrFetch(ctx, "1", fetchObserver.Fetch)
+	<-fetchObserver.FetchCompleted
+
+	clock.Add(refreshDelay + 1)
+	c.GetOrFetch(ctx, "1", fetchObserver.Fetch)
+	received := assertRefreshEvents(t, events,
+		sturdyc.RefreshScheduled, sturdyc.RefreshStarted, sturdyc.RefreshSucceeded,
+	)
+	for _, event := range received {
+		if event.Key != "1" {
+			t.Errorf("expected the event to be for key 1, got %s", event.Key)
+		}
+	}
+
+	fetchErr := errors.New("error")
+	fetchObserver.Err(fetchErr)
+	clock.Add(refreshDelay + 1)
+	c.GetOrFetch(ctx, "1", fetchObserver.Fetch)
+	received = assertRefreshEvents(t, events,
+		sturdyc.RefreshScheduled, sturdyc.RefreshStarted, sturdyc.RefreshFailed,
+	)
+	if !errors.Is(received[2].Err, fetchErr) {
+		t.Errorf("expected the failed event to carry the error, got %v", received[2].Err)
+	}
+}
+
+func TestRefreshEventsAreEmittedForBufferedKeys(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	clock := sturdyc.NewTestClock(time.Now())
+	refreshDelay := time.Second
+	c := sturdyc.New[string](100, 1, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithEarlyRefreshes(refreshDelay, refreshDelay, time.Millisecond),
+		sturdyc.WithRefreshCoalescing(10, time.Minute),
+		sturdyc.WithClock(clock),
+	)
+	events, unsubscribe := c.SubscribeRefreshEvents(10)
+	defer unsubscribe()
+
+	ids := []string{"1"}
+	keyFn := c.BatchKeyFn("item")
+	fetchObserver := NewFetchObserver(10)
+	fetchObserver.BatchResponse(ids)
+	c.GetOrFetchBatch(ctx, ids, keyFn, fetchObserver.FetchBatch)
+	<-fetchObserver.FetchCompleted
+
+	clock.Add(refreshDelay + 1)
+	c.GetOrFetchBatch(ctx, ids, keyFn, fetchObserver.FetchBatch)
+	assertRefreshEvents(t, events, sturdyc.RefreshScheduled, sturdyc.RefreshBuffered)
+
+	// Wait for the buffer's timer to be created before we advance the clock.
+	time.Sleep(10 * time.Millisecond)
+	clock.Add(time.Minute)
+	assertRefreshEvents(t, events, sturdyc.RefreshStarted, sturdyc.RefreshSucceeded)
+	fetchObserver.AssertFetchCount(t, 2)
+}
+
+func TestRefreshEventsAreNotSentAfterUnsubscribing(t *testing.T) {
+	t.Parallel()
+
+	c := sturdyc.New[string](100, 1, time.Hour, 10,
+		sturdyc.WithEarlyRefreshes(time.Second, time.Second, time.Millisecond),
+	)
+	events, unsubscribe := c.SubscribeRefreshEvents(10)
+	unsubscribe()
+	unsubscribe()
+
+	if _, ok := <-events; ok {
+		t.Error("expected the channel to be closed")
+	}
+}
+
+func TestSettingAMissingRecordEmitsACreatedEvent(t *testing.T) {
+	t.Parallel()
+
+	recorder := newTestMetricsRecorder(1)
+	c := sturdyc.New[string](100, 1, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithMetrics(recorder),
+	)
+	events, unsubscribe := c.SubscribeRefreshEvents(10)
+	defer unsubscribe()
+
+	c.StoreMissingRecord("key")
+	c.Set("key", "value")
+	c.Set("key", "overwritten")
+
+	received := assertRefreshEvents(t, events, sturdyc.RecordCreated)
+	if received[0].Key != "key" {
+		t.Errorf("expected the event to be for key, got %s", received[0].Key)
+	}
+	select {
+	case event := <-events:
+		t.Errorf("expected the overwrite to not emit an event, got %s", event.Type)
+	default:
+	}
+
+	recorder.Lock()
+	defer recorder.Unlock()
+	if recorder.promoted != 1 {
+		t.Errorf("expected 1 promoted missing record, got %d", recorder.promoted)
+	}
+}
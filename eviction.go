@@ -0,0 +1,102 @@
+package sturdyc
+
+import "container/list"
+
+// EvictionPolicy determines which entries are evicted
+// when a shard has reached its capacity.
+type EvictionPolicy int
+
+const (
+	// EvictByExpiration evicts the entries that are closest to expiring. This is the default.
+	EvictByExpiration EvictionPolicy = iota
+	// EvictFIFO evicts the entries in the order in which they were first written.
+	EvictFIFO
+	// EvictARC evicts the entries according to the Adaptive Replacement Cache
+	// algorithm, which balances between the entries that have been read once
+	// and the ones that are read repeatedly. This makes the cache resistant to
+	// scans, while still adapting to workloads that favour recent entries.
+	EvictARC
+	// EvictLRU evicts the entries that were read or written the longest time
+	// ago. The access times are tracked with a granularity of a second.
+	EvictLRU
+	// EvictLFU evicts the entries that have been read the fewest times. The
+	// reads are counted across the writes of a key, so refreshes don't reset
+	// them, and ties are broken by evicting the least recently used entry.
+	// The victims are picked from a random sample of the shard, which keeps
+	// the evictions from sorting every entry.
+	EvictLFU
+)
+
+const (
+	// lfuSampleFactor is the number of entries that EvictLFU samples for
+	// every entry that it has to evict.
+	lfuSampleFactor = 4
+	// lfuMinSampleSize is the smallest sample that EvictLFU picks its
+	// victims from. Shards that hold fewer entries are sampled in full.
+	lfuMinSampleSize = 64
+)
+
+// evictionIndex keeps track of the order in which the entries of a shard
+// should be evicted. The add, remove and victims methods are called while the
+// shard holds its write lock, whereas access is called with a read lock.
+type evictionIndex interface {
+	// add is called when a new key is written to the shard.
+	add(key string)
+	// access is called when a key is read from the shard. The key points to
+	// the key of the entry, which the index can hold on to without copying.
+	access(key *string)
+	// remove is called when a key is removed from the shard.
+	remove(key string)
+	// victims returns up to n keys in the order in which they should be
+	// evicted. Keys for which skip returns true are passed over.
+	victims(n int, skip func(key string) bool) []string
+}
+
+// newEvictionIndex returns the index of the eviction policy, or nil
+// if the entries should be evicted by their expiration time.
+func (c *Config) newEvictionIndex(capacity func() int) evictionIndex {
+	switch c.evictionPolicy {
+	case EvictFIFO:
+		return newFIFOIndex()
+	case EvictARC:
+		return newARCIndex(capacity)
+	default:
+		return nil
+	}
+}
+
+// fifoIndex keeps the keys in the order in which they were inserted.
+type fifoIndex struct {
+	order    *list.List
+	elements map[string]*list.Element
+}
+
+func newFIFOIndex() *fifoIndex {
+	return &fifoIndex{order: list.New(), elements: make(map[string]*list.Element)}
+}
+
+func (f *fifoIndex) add(key string) {
+	if _, ok := f.elements[key]; ok {
+		return
+	}
+	f.elements[key] = f.order.PushBack(key)
+}
+
+func (f *fifoIndex) access(*string) {}
+
+func (f *fifoIndex) remove(key string) {
+	if element, ok := f.elements[key]; ok {
+		f.order.Remove(element)
+		delete(f.elements, key)
+	}
+}
+
+func (f *fifoIndex) victims(n int, skip func(key string) bool) []string {
+	keys := make([]string, 0, n)
+	for element := f.order.Front(); element != nil && len(keys) < n; element = element.Next() {
+		if key := element.Value.(string); !skip(key) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
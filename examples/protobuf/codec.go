@@ -0,0 +1,23 @@
+package main
+
+import "google.golang.org/protobuf/proto"
+
+// protoCodec is a sturdyc.Codec for protobuf messages. The messages are
+// decoded into the empty message that is returned by newMessage.
+type protoCodec[T proto.Message] struct {
+	newMessage func() T
+}
+
+func newProtoCodec[T proto.Message](newMessage func() T) *protoCodec[T] {
+	return &protoCodec[T]{newMessage: newMessage}
+}
+
+func (c *protoCodec[T]) Marshal(message T) ([]byte, error) {
+	return proto.Marshal(message)
+}
+
+func (c *protoCodec[T]) Unmarshal(data []byte) (T, error) {
+	message := c.newMessage()
+	err := proto.Unmarshal(data, message)
+	return message, err
+}
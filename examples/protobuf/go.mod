@@ -0,0 +1,12 @@
+module github.com/viccon/sturdyc/examples/protobuf
+
+go 1.23
+
+require (
+	github.com/viccon/sturdyc v0.0.0
+	google.golang.org/protobuf v1.36.9
+)
+
+require github.com/cespare/xxhash/v2 v2.3.0 // indirect
+
+replace github.com/viccon/sturdyc => ../..
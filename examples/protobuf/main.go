@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/viccon/sturdyc"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func main() {
+	// Maximum number of entries in the cache.
+	capacity := 10000
+	// Number of shards to use.
+	numShards := 10
+	// Time-to-live for cache entries.
+	ttl := 2 * time.Hour
+	// Percentage of entries to evict when the cache is full.
+	evictionPercentage := 10
+
+	storage := newDistributedStorage()
+	newClient := func() *sturdyc.Client[*structpb.Struct] {
+		return sturdyc.New[*structpb.Struct](capacity, numShards, ttl, evictionPercentage,
+			sturdyc.WithDistributedStorage(storage),
+			// The values are written to the distributed storage as protobuf.
+			sturdyc.WithCodec(newProtoCodec(func() *structpb.Struct { return &structpb.Struct{} })),
+		)
+	}
+
+	fetchFn := func(_ context.Context) (*structpb.Struct, error) {
+		log.Println("Fetching the product from the underlying data source")
+		return structpb.NewStruct(map[string]any{"name": "sneakers", "price": 99.5})
+	}
+
+	// The first client fetches the product, and writes it to the distributed storage.
+	if _, err := newClient().GetOrFetch(context.Background(), "product-1", fetchFn); err != nil {
+		log.Fatal(err)
+	}
+
+	// The records are written to the distributed storage in the background.
+	time.Sleep(50 * time.Millisecond)
+
+	// The second client decodes the product from the distributed storage.
+	product, err := newClient().GetOrFetch(context.Background(), "product-1", fetchFn)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("Retrieved %s for %.2f\n", product.Fields["name"].GetStringValue(), product.Fields["price"].GetNumberValue())
+}
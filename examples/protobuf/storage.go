@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"log"
+	"sync"
+)
+
+type distributedStorage struct {
+	mu      sync.Mutex
+	records map[string][]byte
+}
+
+func newDistributedStorage() *distributedStorage {
+	return &distributedStorage{
+		records: make(map[string][]byte),
+	}
+}
+
+func (d *distributedStorage) Get(_ context.Context, key string) ([]byte, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	log.Printf("Getting key %s from the distributed storage\n", key)
+	value, ok := d.records[key]
+	return value, ok
+}
+
+func (d *distributedStorage) Set(_ context.Context, key string, value []byte) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	log.Printf("Writing %d bytes for key %s to the distributed storage\n", len(value), key)
+	d.records[key] = value
+}
+
+func (d *distributedStorage) GetBatch(_ context.Context, keys []string) map[string][]byte {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	records := make(map[string][]byte)
+	for _, key := range keys {
+		if value, ok := d.records[key]; ok {
+			records[key] = value
+		}
+	}
+	return records
+}
+
+func (d *distributedStorage) SetBatch(_ context.Context, records map[string][]byte) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for key, value := range records {
+		d.records[key] = value
+	}
+}
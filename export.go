@@ -0,0 +1,206 @@
+package sturdyc
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// exportFormatVersion is incremented whenever the format of the exports changes.
+const exportFormatVersion byte = 1
+
+// exportMagic is written at the start of every export.
+const exportMagic = "sturdyc"
+
+// maxExportRecordSize is the largest record that Import accepts. The length of
+// each record is read from the input, and is capped so that a corrupt export
+// can't make the cache allocate an arbitrary amount of memory.
+const maxExportRecordSize = 64 << 20
+
+// exportRecord is the format of each record in an export.
+type exportRecord[T any] struct {
+	Key             string        `json:"key"`
+	Value           T             `json:"value"`
+	IsMissingRecord bool          `json:"is_missing_record"`
+	TTL             time.Duration `json:"ttl"`
+}
+
+// ExportOption configures a call to Export.
+type ExportOption func(*exportOptions)
+
+type exportOptions struct {
+	filter func(key string) bool
+}
+
+// ExportFilter makes Export only include the keys that the filter returns true for.
+func ExportFilter(filter func(key string) bool) ExportOption {
+	return func(o *exportOptions) {
+		o.filter = filter
+	}
+}
+
+// exportRecords returns the records of the shard that haven't expired.
+func (s *shard[T]) exportRecords(filter func(key string) bool) []exportRecord[T] {
+	s.RLock()
+	defer s.RUnlock()
+
+	now := s.clock.Now()
+	records := make([]exportRecord[T], 0, len(s.entries))
+	for key, e := range s.entries {
+		if !e.expiresAt.After(now) || (filter != nil && !filter(key)) {
+			continue
+		}
+		records = append(records, exportRecord[T]{
+			Key:             key,
+			Value:           e.value,
+			IsMissingRecord: e.isMissingRecord,
+			TTL:             e.expiresAt.Sub(now),
+		})
+	}
+	return records
+}
+
+// Export writes the records of the cache to w, along with the time that they
+// have left to live. The values are encoded using the codec that was passed
+// to WithCodec, or as JSON if there isn't one, and the records are
+// length-prefixed so that the export can be streamed to Import. Returns the
+// number of records that were written.
+func (c *Client[T]) Export(w io.Writer, opts ...ExportOption) (int, error) {
+	var options exportOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	writer := bufio.NewWriter(w)
+	header := append([]byte(exportMagic), exportFormatVersion)
+	if _, err := writer.Write(header); err != nil {
+		return 0, err
+	}
+
+	var exported int
+	lengthPrefix := make([]byte, binary.MaxVarintLen64)
+	for _, shard := range c.getShards() {
+		for _, record := range shard.exportRecords(options.filter) {
+			recordBytes, err := c.marshalExportRecord(record)
+			if err != nil {
+				return exported, fmt.Errorf("sturdyc: error marshalling key %s: %w", record.Key, err)
+			}
+			n := binary.PutUvarint(lengthPrefix, uint64(len(recordBytes)))
+			if _, err := writer.Write(lengthPrefix[:n]); err != nil {
+				return exported, err
+			}
+			if _, err := writer.Write(recordBytes); err != nil {
+				return exported, err
+			}
+			exported++
+		}
+	}
+	return exported, writer.Flush()
+}
+
+// Import reads the records that were written by Export, and writes them to
+// the cache with the time that they had left to live when they were exported.
+// The cache has to use the same codec as the one that wrote the export.
+// Returns the number of records that were imported.
+func (c *Client[T]) Import(r io.Reader) (int, error) {
+	reader := bufio.NewReader(r)
+	header := make([]byte, len(exportMagic)+1)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		return 0, fmt.Errorf("%w: %w", ErrInvalidExport, err)
+	}
+	if string(header[:len(exportMagic)]) != exportMagic {
+		return 0, ErrInvalidExport
+	}
+	if version := header[len(exportMagic)]; version != exportFormatVersion {
+		return 0, fmt.Errorf("%w: unsupported version %d", ErrInvalidExport, version)
+	}
+
+	var imported int
+	for {
+		length, err := binary.ReadUvarint(reader)
+		if errors.Is(err, io.EOF) {
+			return imported, nil
+		}
+		if err != nil {
+			return imported, fmt.Errorf("%w: %w", ErrInvalidExport, err)
+		}
+
+		if length > maxExportRecordSize {
+			return imported, fmt.Errorf("%w: record of %d bytes exceeds the limit of %d bytes", ErrInvalidExport, length, maxExportRecordSize)
+		}
+
+		// The buffer grows with the bytes that are read, rather than being
+		// allocated up front, so a truncated export can't claim more memory
+		// than it holds.
+		var recordBytes bytes.Buffer
+		if _, err := io.CopyN(&recordBytes, reader, int64(length)); err != nil {
+			return imported, fmt.Errorf("%w: %w", ErrInvalidExport, err)
+		}
+
+		record, err := c.unmarshalExportRecord(recordBytes.Bytes())
+		if err != nil {
+			return imported, fmt.Errorf("%w: %w", ErrInvalidExport, err)
+		}
+		if record.TTL <= 0 {
+			continue
+		}
+		c.getShard(record.Key).setWithoutJitter(record.Key, record.Value, record.IsMissingRecord, record.TTL)
+		imported++
+	}
+}
+
+// marshalExportRecord encodes the key and the TTL of the record, followed by
+// the record itself, if the cache has a codec for its values. Otherwise, the
+// record is encoded as JSON.
+func (c *Client[T]) marshalExportRecord(record exportRecord[T]) ([]byte, error) {
+	codec, ok := codecFor[T](c.codecs)
+	if !ok {
+		return json.Marshal(record)
+	}
+
+	recordBytes, err := encodeRecord(codec, distributedRecord[T]{Value: record.Value, IsMissingRecord: record.IsMissingRecord})
+	if err != nil {
+		return nil, err
+	}
+	buf := binary.AppendUvarint(nil, uint64(len(record.Key)))
+	buf = append(buf, record.Key...)
+	buf = binary.AppendVarint(buf, int64(record.TTL))
+	return append(buf, recordBytes...), nil
+}
+
+// unmarshalExportRecord decodes a record that was encoded by marshalExportRecord.
+func (c *Client[T]) unmarshalExportRecord(data []byte) (exportRecord[T], error) {
+	var record exportRecord[T]
+	codec, ok := codecFor[T](c.codecs)
+	if !ok {
+		err := json.Unmarshal(data, &record)
+		return record, err
+	}
+
+	keyLength, n := binary.Uvarint(data)
+	if n <= 0 || keyLength > uint64(len(data)-n) {
+		return record, errInvalidCodecRecord
+	}
+	data = data[n:]
+	record.Key = string(data[:keyLength])
+	data = data[keyLength:]
+
+	ttl, n := binary.Varint(data)
+	if n <= 0 {
+		return record, errInvalidCodecRecord
+	}
+	record.TTL = time.Duration(ttl)
+
+	decoded, err := decodeRecord(codec, data[n:])
+	if err != nil {
+		return record, err
+	}
+	record.Value = decoded.Value
+	record.IsMissingRecord = decoded.IsMissingRecord
+	return record, nil
+}
@@ -0,0 +1,121 @@
+package sturdyc_test
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/viccon/sturdyc"
+)
+
+func TestExportAndImport(t *testing.T) {
+	t.Parallel()
+
+	clock := sturdyc.NewTestClock(time.Now())
+	source := sturdyc.New[string](1000, 10, time.Hour, 30,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithMissingRecordStorage(),
+		sturdyc.WithClock(clock),
+	)
+	source.SetMany(map[string]string{"1": "value1", "2": "value2", "skip-3": "value3"})
+	source.StoreMissingRecord("4")
+
+	// The time that is left of the TTL should be carried over.
+	clock.Add(30 * time.Minute)
+
+	var buf bytes.Buffer
+	exported, err := source.Export(&buf, sturdyc.ExportFilter(func(key string) bool {
+		return !strings.HasPrefix(key, "skip-")
+	}))
+	if err != nil || exported != 3 {
+		t.Fatalf("expected 3 records to be exported, got %d %v", exported, err)
+	}
+
+	destinationClock := sturdyc.NewTestClock(clock.Now())
+	destination := sturdyc.New[string](1000, 10, time.Hour, 30,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithMissingRecordStorage(),
+		sturdyc.WithClock(destinationClock),
+	)
+	imported, err := destination.Import(&buf)
+	if err != nil || imported != 3 {
+		t.Fatalf("expected 3 records to be imported, got %d %v", imported, err)
+	}
+
+	if res, ok := destination.Get("1"); !ok || res != "value1" {
+		t.Errorf("expected value1, got %q", res)
+	}
+	if _, ok := destination.Get("skip-3"); ok {
+		t.Error("expected the filtered key to not have been exported")
+	}
+	if _, ok := destination.Get("4"); ok || destination.Size() != 3 {
+		t.Error("expected the missing record to have been imported as missing")
+	}
+
+	destinationClock.Add(31 * time.Minute)
+	if _, ok := destination.Get("1"); ok {
+		t.Error("expected the record to expire when the rest of its TTL has passed")
+	}
+}
+
+func TestImportRejectsInvalidData(t *testing.T) {
+	t.Parallel()
+
+	c := sturdyc.New[string](1000, 10, time.Hour, 30, sturdyc.WithNoContinuousEvictions())
+	invalid := []string{
+		"",
+		"not an export",
+		"sturdyc\x02",
+		// A record that claims to be larger than the rest of the export.
+		"sturdyc\x01\x64",
+		// A record with a length that exceeds the limit.
+		"sturdyc\x01\xff\xff\xff\xff\xff\xff\xff\xff\xff\x01",
+	}
+	for _, data := range invalid {
+		if _, err := c.Import(strings.NewReader(data)); !errors.Is(err, sturdyc.ErrInvalidExport) {
+			t.Errorf("expected ErrInvalidExport for %q, got %v", data, err)
+		}
+	}
+}
+
+func TestImportKeepsTheExpiryOfTheExportedRecords(t *testing.T) {
+	t.Parallel()
+
+	clock := sturdyc.NewTestClock(time.Now())
+	source := sturdyc.New[string](1000, 10, time.Hour, 30,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithClock(clock),
+	)
+	for i := 0; i < 20; i++ {
+		source.Set(strconv.Itoa(i), "value")
+	}
+	clock.Add(30 * time.Minute)
+
+	var buf bytes.Buffer
+	if _, err := source.Export(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	// The TTL of the imported records has already been decided by the
+	// source, so the jitter of the destination shouldn't be applied again.
+	destination := sturdyc.New[string](1000, 10, time.Hour, 30,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithTTLJitter(0.5),
+		sturdyc.WithClock(sturdyc.NewTestClock(clock.Now())),
+	)
+	if _, err := destination.Import(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 20; i++ {
+		key := strconv.Itoa(i)
+		sourceInfo, _ := source.EntryInfo(key)
+		destinationInfo, ok := destination.EntryInfo(key)
+		if !ok || !destinationInfo.ExpiresAt.Equal(sourceInfo.ExpiresAt) {
+			t.Errorf("expected key %s to expire at %v, got %v", key, sourceInfo.ExpiresAt, destinationInfo.ExpiresAt)
+		}
+	}
+}
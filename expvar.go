@@ -0,0 +1,46 @@
+package sturdyc
+
+import (
+	"expvar"
+	"sync"
+)
+
+// expvarMu serializes the checks for names that have already been published,
+// as expvar.Publish panics if a name is published twice.
+var expvarMu sync.Mutex
+
+// publishExpvars publishes the statistics of the cache through the expvar
+// package. The variables are read when the expvar handler is called. The
+// names are published once per process, which means that a client that is
+// created with the same prefix as an earlier one logs a warning, and leaves
+// the variables of the earlier client in place.
+func (c *Client[T]) publishExpvars() {
+	vars := map[string]func() any{
+		"size":             func() any { return c.Size() },
+		"hits":             func() any { return c.stats.hits.Load() },
+		"misses":           func() any { return c.stats.misses.Load() },
+		"hit_ratio":        func() any { return c.hitRatio() },
+		"evictions":        func() any { return c.stats.evictions.Load() },
+		"forced_evictions": func() any { return c.stats.forcedEvictions.Load() },
+		"in_flight":        func() any { return c.NumKeysInflight() },
+	}
+	expvarMu.Lock()
+	defer expvarMu.Unlock()
+	for name, fn := range vars {
+		name = c.expvarPrefix + "." + name
+		if expvar.Get(name) != nil {
+			c.logger(LogCache).Warn("sturdyc: expvar has already been published", "name", name)
+			continue
+		}
+		expvar.Publish(name, expvar.Func(fn))
+	}
+}
+
+// hitRatio returns the share of lookups that were hits since the cache was created.
+func (c *Client[T]) hitRatio() float64 {
+	hits, misses := c.stats.hits.Load(), c.stats.misses.Load()
+	if hits+misses == 0 {
+		return 0
+	}
+	return float64(hits) / float64(hits+misses)
+}
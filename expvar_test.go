@@ -0,0 +1,66 @@
+package sturdyc_test
+
+import (
+	"expvar"
+	"testing"
+	"time"
+
+	"github.com/viccon/sturdyc"
+)
+
+func TestExpvarPublishesTheStatistics(t *testing.T) {
+	t.Parallel()
+
+	// The names have to be unique per process, which includes repeated test runs.
+	prefix := "sturdyc_" + randKey(8)
+	c := sturdyc.New[string](100, 1, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithExpvar(prefix),
+	)
+	c.Set("1", "value")
+	c.Get("1")
+	c.Get("2")
+
+	expected := map[string]string{
+		"size":             "1",
+		"hits":             "1",
+		"misses":           "1",
+		"hit_ratio":        "0.5",
+		"evictions":        "0",
+		"forced_evictions": "0",
+		"in_flight":        "0",
+	}
+	for name, value := range expected {
+		name = prefix + "." + name
+		v := expvar.Get(name)
+		if v == nil {
+			t.Errorf("expected %s to have been published", name)
+			continue
+		}
+		if v.String() != value {
+			t.Errorf("expected %s to be %s, got %s", name, value, v.String())
+		}
+	}
+}
+
+func TestExpvarDoesNotPanicForDuplicatePrefixes(t *testing.T) {
+	t.Parallel()
+
+	prefix := "sturdyc_" + randKey(8)
+	first := sturdyc.New[string](100, 1, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithExpvar(prefix),
+	)
+	second := sturdyc.New[string](100, 1, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithExpvar(prefix),
+	)
+	first.Set("1", "value")
+	second.Set("1", "value")
+	second.Set("2", "value")
+
+	// The variables of the first client are left in place.
+	if v := expvar.Get(prefix + ".size"); v == nil || v.String() != "1" {
+		t.Errorf("expected the size of the first client to be published, got %v", v)
+	}
+}
@@ -0,0 +1,83 @@
+package sturdyc
+
+import "context"
+
+// Sourced is a value along with the position of the fetch function that
+// served it, which allows the cache to keep track of where each record came
+// from when it's used with NewSourcedFallbackFetch.
+type Sourced[V any] struct {
+	Value V
+	// Source is the index of the fetch function that returned the value.
+	Source int
+}
+
+// NewFallbackFetch returns a FetchFn that calls the fetch functions in order
+// until one of them succeeds, such as a replica, then the primary database,
+// and then a static default. If every function fails, the error of the last
+// one is returned. The chain is cut short if the context is cancelled.
+func NewFallbackFetch[V any](fetchFns ...FetchFn[V]) FetchFn[V] {
+	sourced := NewSourcedFallbackFetch(fetchFns...)
+	return func(ctx context.Context) (V, error) {
+		res, err := sourced(ctx)
+		return res.Value, err
+	}
+}
+
+// NewSourcedFallbackFetch is the same as NewFallbackFetch, but the values are
+// returned along with the index of the fetch function that served them.
+func NewSourcedFallbackFetch[V any](fetchFns ...FetchFn[V]) FetchFn[Sourced[V]] {
+	if len(fetchFns) == 0 {
+		panic("at least one fetch function is required")
+	}
+	return func(ctx context.Context) (Sourced[V], error) {
+		var err error
+		for i, fetchFn := range fetchFns {
+			var value V
+			value, err = fetchFn(ctx)
+			if err == nil {
+				return Sourced[V]{Value: value, Source: i}, nil
+			}
+			if ctx.Err() != nil {
+				break
+			}
+		}
+		return Sourced[V]{}, err
+	}
+}
+
+// NewFallbackBatchFetch returns a BatchFetchFn that calls the fetch functions
+// in order. Each function is only asked for the IDs that the previous ones
+// failed to return, and the records of every function are merged. The error
+// of the last function that was called is returned along with the records.
+func NewFallbackBatchFetch[V any](fetchFns ...BatchFetchFn[V]) BatchFetchFn[V] {
+	if len(fetchFns) == 0 {
+		panic("at least one fetch function is required")
+	}
+	return func(ctx context.Context, ids []string) (map[string]V, error) {
+		records := make(map[string]V, len(ids))
+		remaining := ids
+		var err error
+		for _, fetchFn := range fetchFns {
+			var response map[string]V
+			response, err = fetchFn(ctx, remaining)
+			for id, value := range response {
+				records[id] = value
+			}
+
+			missing := make([]string, 0, len(remaining))
+			for _, id := range remaining {
+				if _, ok := records[id]; !ok {
+					missing = append(missing, id)
+				}
+			}
+			remaining = missing
+			if len(remaining) == 0 {
+				return records, nil
+			}
+			if ctx.Err() != nil {
+				break
+			}
+		}
+		return records, err
+	}
+}
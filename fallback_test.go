@@ -0,0 +1,84 @@
+package sturdyc_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/viccon/sturdyc"
+)
+
+func TestFallbackFetchTriesTheFunctionsInOrder(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	c := sturdyc.New[sturdyc.Sourced[string]](100, 1, time.Hour, 10, sturdyc.WithNoContinuousEvictions())
+
+	var calls []string
+	replica := func(context.Context) (string, error) {
+		calls = append(calls, "replica")
+		return "", errors.New("replica unavailable")
+	}
+	primary := func(context.Context) (string, error) {
+		calls = append(calls, "primary")
+		return "primary value", nil
+	}
+	fallback := func(context.Context) (string, error) {
+		calls = append(calls, "default")
+		return "default value", nil
+	}
+
+	fetchFn := sturdyc.NewSourcedFallbackFetch(replica, primary, fallback)
+	res, err := c.GetOrFetch(ctx, "key", fetchFn)
+	if err != nil || res.Value != "primary value" || res.Source != 1 {
+		t.Fatalf("expected the value of the primary, got %+v: %v", res, err)
+	}
+	if len(calls) != 2 {
+		t.Errorf("expected the default to not be called, got %v", calls)
+	}
+
+	cached, ok := c.Get("key")
+	if !ok || cached.Source != 1 {
+		t.Errorf("expected the source to be cached along with the value, got %+v", cached)
+	}
+}
+
+func TestFallbackFetchReturnsTheLastError(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	c := sturdyc.New[string](100, 1, time.Hour, 10, sturdyc.WithNoContinuousEvictions())
+
+	failing := func(context.Context) (string, error) { return "", errors.New("unavailable") }
+	notFound := func(context.Context) (string, error) { return "", sturdyc.ErrNotFound }
+	_, err := c.GetOrFetch(ctx, "key", sturdyc.NewFallbackFetch(failing, notFound))
+	if !errors.Is(err, sturdyc.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestFallbackBatchFetchOnlyAsksForTheMissingIDs(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	c := sturdyc.New[string](100, 1, time.Hour, 10, sturdyc.WithNoContinuousEvictions())
+
+	replica := func(_ context.Context, ids []string) (map[string]string, error) {
+		return map[string]string{"1": "replica"}, nil
+	}
+	var primaryIDs []string
+	primary := func(_ context.Context, ids []string) (map[string]string, error) {
+		primaryIDs = ids
+		return map[string]string{"2": "primary"}, nil
+	}
+
+	fetchFn := sturdyc.NewFallbackBatchFetch(replica, primary)
+	res, err := c.GetOrFetchBatch(ctx, []string{"1", "2", "3"}, c.BatchKeyFn("item"), fetchFn)
+	if err != nil || len(res) != 2 || res["1"] != "replica" || res["2"] != "primary" {
+		t.Fatalf("expected the records of both functions, got %v: %v", res, err)
+	}
+	if len(primaryIDs) != 2 {
+		t.Errorf("expected the primary to only be asked for the missing IDs, got %v", primaryIDs)
+	}
+}
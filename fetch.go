@@ -6,14 +6,14 @@ import (
 	"maps"
 )
 
-func (c *Client[T]) groupIDs(ids []string, keyFn KeyFn) (hits map[string]T, misses, refreshes []string) {
+func (c *Client[T]) groupIDs(ids []string, keyFn KeyFn, opts callOptions) (hits map[string]T, misses, refreshes []string) {
 	hits = make(map[string]T)
 	misses = make([]string, 0)
 	refreshes = make([]string, 0)
 
 	for _, id := range ids {
 		key := keyFn(id)
-		value, exists, markedAsMissing, shouldRefresh := c.getWithState(key)
+		value, exists, markedAsMissing, shouldRefresh := c.getWithState(key, !opts.noRefresh)
 
 		// Check if the record should be refreshed in the background.
 		if shouldRefresh {
@@ -34,27 +34,41 @@ func (c *Client[T]) groupIDs(ids []string, keyFn KeyFn) (hits map[string]T, miss
 	return hits, misses, refreshes
 }
 
-func getFetch[V, T any](ctx context.Context, c *Client[T], key string, fetchFn FetchFn[V]) (T, error) {
-	wrappedFetch := wrap[T](distributedFetch(c, key, fetchFn))
+func getFetch[V, T any](ctx context.Context, c *Client[T], key string, fetchFn FetchFn[V], opts callOptions) (T, error) {
+	wrappedFetch := wrap[T](distributedFetch(c, key, originFetch(c, key, fetchFn), opts))
 
 	// Begin by checking if we have the item in our cache.
-	value, ok, markedAsMissing, shouldRefresh := c.getWithState(key)
+	value, ok, markedAsMissing, shouldRefresh := c.getWithState(key, !opts.noRefresh)
 
 	if shouldRefresh {
-		c.safeGo(func() {
-			c.refresh(key, wrappedFetch)
+		c.emitRefreshEvent(RefreshScheduled, key, nil)
+		c.scheduleRefresh(func() {
+			c.refresh(context.WithoutCancel(ctx), key, wrappedFetch, opts)
 		})
 	}
 
 	if markedAsMissing {
-		return value, ErrMissingRecord
+		return value, withKey(key, ErrMissingRecord)
 	}
 
 	if ok {
+		opts.reportCacheHits(1)
 		return value, nil
 	}
 
-	return callAndCache(ctx, c, key, wrappedFetch)
+	// If the data source recently failed for this key, we'll return the same error again.
+	res, err := value, c.getCachedError(key)
+	if err == nil {
+		res, err = callAndCache(ctx, c, key, wrappedFetch, opts)
+		c.cacheError(key, err)
+	}
+	if err != nil && !errors.Is(err, ErrMissingRecord) && !errors.Is(err, ErrNotFound) {
+		if staleValue, okStale := c.getStale(key); okStale {
+			opts.reportStale(withKey(key, err))
+			return staleValue, nil
+		}
+	}
+	return res, withKey(key, err)
 }
 
 // GetOrFetch attempts to retrieve the specified key from the cache. If the value
@@ -67,12 +81,13 @@ func getFetch[V, T any](ctx context.Context, c *Client[T], key string, fetchFn F
 //	ctx - The context to be used for the request.
 //	key - The key to be fetched.
 //	fetchFn - Used to retrieve the data from the underlying data source if the key is not found in the cache.
+//	opts - Optional overrides of the cache configuration for this call.
 //
 // Returns:
 //
 //	The value corresponding to the key and an error if one occurred.
-func (c *Client[T]) GetOrFetch(ctx context.Context, key string, fetchFn FetchFn[T]) (T, error) {
-	return getFetch[T, T](ctx, c, key, fetchFn)
+func (c *Client[T]) GetOrFetch(ctx context.Context, key string, fetchFn FetchFn[T], opts ...CallOption) (T, error) {
+	return getFetch[T, T](ctx, c, key, fetchFn, c.newCallOptions(opts))
 }
 
 // GetOrFetch is a convenience function that performs type assertion on the result of client.GetOrFetch.
@@ -83,6 +98,7 @@ func (c *Client[T]) GetOrFetch(ctx context.Context, key string, fetchFn FetchFn[
 //	c - The cache client.
 //	key - The key to be fetched.
 //	fetchFn - Used to retrieve the data from the underlying data source if the key is not found in the cache.
+//	opts - Optional overrides of the cache configuration for this call.
 //
 // Returns:
 //
@@ -92,33 +108,51 @@ func (c *Client[T]) GetOrFetch(ctx context.Context, key string, fetchFn FetchFn[
 //
 //	V - The type returned by the fetchFn. Must be assignable to T.
 //	T - The type stored in the cache.
-func GetOrFetch[V, T any](ctx context.Context, c *Client[T], key string, fetchFn FetchFn[V]) (V, error) {
-	res, err := getFetch[V, T](ctx, c, key, fetchFn)
+func GetOrFetch[V, T any](ctx context.Context, c *Client[T], key string, fetchFn FetchFn[V], opts ...CallOption) (V, error) {
+	res, err := getFetch[V, T](ctx, c, key, fetchFn, c.newCallOptions(opts))
 	return unwrap[V](res, err)
 }
 
-func getFetchBatch[V, T any](ctx context.Context, c *Client[T], ids []string, keyFn KeyFn, fetchFn BatchFetchFn[V]) (map[string]T, error) {
-	wrappedFetch := wrapBatch[T](distributedBatchFetch[V, T](c, keyFn, fetchFn))
-	cachedRecords, cacheMisses, idsToRefresh := c.groupIDs(ids, keyFn)
+func getFetchBatch[V, T any](ctx context.Context, c *Client[T], ids []string, keyFn KeyFn, fetchFn BatchFetchFn[V], opts callOptions) (map[string]T, error) {
+	wrappedFetch := wrapBatch[T](distributedBatchFetch[V, T](c, keyFn, originBatchFetch(c, keyFn, fetchFn), opts))
+	cachedRecords, cacheMisses, idsToRefresh := c.groupIDs(ids, keyFn, opts)
+	opts.reportCacheHits(len(cachedRecords))
 
 	// If any records need to be refreshed, we'll do so in the background.
 	if len(idsToRefresh) > 0 {
-		c.safeGo(func() {
-			if c.bufferRefreshes {
-				bufferBatchRefresh(c, idsToRefresh, keyFn, wrappedFetch)
-				return
-			}
-			c.refreshBatch(idsToRefresh, keyFn, wrappedFetch)
-		})
+		c.emitBatchRefreshEvent(RefreshScheduled, idsToRefresh, keyFn)
+		if c.bufferRefreshes {
+			c.trackedGo(func() {
+				bufferBatchRefresh(context.WithoutCancel(ctx), c, idsToRefresh, keyFn, wrappedFetch, opts)
+			})
+		} else {
+			c.scheduleRefresh(func() {
+				c.refreshBatch(context.WithoutCancel(ctx), idsToRefresh, keyFn, wrappedFetch, opts)
+			})
+		}
+	}
+
+	// IDs that the data source recently failed for are not going to be fetched again.
+	cacheMisses, cachedErrors := c.removeCachedErrors(cacheMisses, keyFn)
+	if len(cachedErrors) > 0 {
+		addStaleRecords(c, cachedRecords, cachedErrors, keyFn)
 	}
 
 	// If we were able to retrieve all records from the cache, we can return them straight away.
 	if len(cacheMisses) == 0 {
-		return cachedRecords, nil
+		return cachedRecords, withReturnedRecords(len(cachedRecords), withCachedErrors(nil, cachedErrors))
 	}
 
-	callBatchOpts := callBatchOpts[T, T]{ids: cacheMisses, keyFn: keyFn, fn: wrappedFetch}
+	callBatchOpts := callBatchOpts[T, T]{ids: cacheMisses, keyFn: keyFn, fn: wrappedFetch, options: opts}
 	response, err := callAndCacheBatch(ctx, c, callBatchOpts)
+	c.cacheBatchErrors(cacheMisses, keyFn, err)
+	if err != nil {
+		for _, id := range cacheMisses {
+			if _, ok := response[id]; !ok {
+				addStaleRecord(c, cachedRecords, id, keyFn)
+			}
+		}
+	}
 	if err != nil && !errors.Is(err, ErrOnlyCachedRecords) {
 		if len(cachedRecords) > 0 {
 			return cachedRecords, ErrOnlyCachedRecords
@@ -127,7 +161,23 @@ func getFetchBatch[V, T any](ctx context.Context, c *Client[T], ids []string, ke
 	}
 
 	maps.Copy(cachedRecords, response)
-	return cachedRecords, err
+	return cachedRecords, withReturnedRecords(len(cachedRecords), withCachedErrors(err, cachedErrors))
+}
+
+// addStaleRecords adds the stale values for the IDs to the
+// records, if the cache has been configured to serve them.
+func addStaleRecords[T any](c *Client[T], records map[string]T, idErrors map[string]error, keyFn KeyFn) {
+	for id := range idErrors {
+		addStaleRecord(c, records, id, keyFn)
+	}
+}
+
+// addStaleRecord adds the stale value for the ID to the
+// records, if the cache has been configured to serve it.
+func addStaleRecord[T any](c *Client[T], records map[string]T, id string, keyFn KeyFn) {
+	if staleValue, ok := c.getStale(keyFn(id)); ok {
+		records[id] = staleValue
+	}
 }
 
 // GetOrFetchBatch attempts to retrieve the specified ids from the cache. If
@@ -142,12 +192,13 @@ func getFetchBatch[V, T any](ctx context.Context, c *Client[T], ids []string, ke
 //	ids - The list of IDs to be fetched.
 //	keyFn - Used to generate the cache key for each ID.
 //	fetchFn - Used to retrieve the data from the underlying data source if any IDs are not found in the cache.
+//	opts - Optional overrides of the cache configuration for this call.
 //
 // Returns:
 //
 //	A map of IDs to their corresponding values and an error if one occurred.
-func (c *Client[T]) GetOrFetchBatch(ctx context.Context, ids []string, keyFn KeyFn, fetchFn BatchFetchFn[T]) (map[string]T, error) {
-	return getFetchBatch[T, T](ctx, c, ids, keyFn, fetchFn)
+func (c *Client[T]) GetOrFetchBatch(ctx context.Context, ids []string, keyFn KeyFn, fetchFn BatchFetchFn[T], opts ...CallOption) (map[string]T, error) {
+	return getFetchBatch[T, T](ctx, c, ids, keyFn, fetchFn, c.newCallOptions(opts))
 }
 
 // GetOrFetchBatch is a convenience function that performs type assertion on the
@@ -160,6 +211,7 @@ func (c *Client[T]) GetOrFetchBatch(ctx context.Context, ids []string, keyFn Key
 //	ids - The list of IDs to be fetched.
 //	keyFn - Used to prefix each ID in order to create a unique cache key.
 //	fetchFn - Used to retrieve the data from the underlying data source.
+//	opts - Optional overrides of the cache configuration for this call.
 //
 // Returns:
 //
@@ -170,7 +222,7 @@ func (c *Client[T]) GetOrFetchBatch(ctx context.Context, ids []string, keyFn Key
 //	V - The type returned by the fetchFn. Must be assignable to T.
 //	T - The type stored in the cache.
 
-func GetOrFetchBatch[V, T any](ctx context.Context, c *Client[T], ids []string, keyFn KeyFn, fetchFn BatchFetchFn[V]) (map[string]V, error) {
-	res, err := getFetchBatch[V, T](ctx, c, ids, keyFn, fetchFn)
+func GetOrFetchBatch[V, T any](ctx context.Context, c *Client[T], ids []string, keyFn KeyFn, fetchFn BatchFetchFn[V], opts ...CallOption) (map[string]V, error) {
+	res, err := getFetchBatch[V, T](ctx, c, ids, keyFn, fetchFn, c.newCallOptions(opts))
 	return unwrapBatch[V](res, err)
 }
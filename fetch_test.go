@@ -692,3 +692,62 @@ func TestGetFetchBatchConvertsDeletedRecordsToMissingRecords(t *testing.T) {
 		t.Errorf("expected key3 to not be returned by Get")
 	}
 }
+
+func TestGetOrFetchWrapsTheErrorsWithTheKey(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	c := sturdyc.New[string](1000, 10, time.Hour, 5,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithMissingRecordStorage(),
+	)
+	fetchFn := func(context.Context) (string, error) { return "", sturdyc.ErrNotFound }
+
+	for i := 0; i < 2; i++ {
+		_, err := c.GetOrFetch(ctx, "key1", fetchFn)
+		if !errors.Is(err, sturdyc.ErrMissingRecord) {
+			t.Fatalf("expected ErrMissingRecord, got %v", err)
+		}
+		var keyErr *sturdyc.KeyError
+		if !errors.As(err, &keyErr) || keyErr.Key != "key1" {
+			t.Fatalf("expected the error to carry the key, got %v", err)
+		}
+	}
+
+	sourceErr := errors.New("source error")
+	_, err := c.GetOrFetch(ctx, "key2", func(context.Context) (string, error) { return "", sourceErr })
+	var keyErr *sturdyc.KeyError
+	if !errors.Is(err, sourceErr) || !errors.As(err, &keyErr) || keyErr.Key != "key2" {
+		t.Errorf("expected the error of the data source to be wrapped with the key, got %v", err)
+	}
+}
+
+func TestFetchesThatStartedBeforeASetDontMarkTheKeyAsMissing(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	clock := sturdyc.NewTestClock(time.Now())
+	c := sturdyc.New[string](1000, 10, time.Hour, 5,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithMissingRecordStorage(),
+		sturdyc.WithClock(clock),
+	)
+
+	fetchStarted := make(chan struct{})
+	valueSet := make(chan struct{})
+	go func() {
+		<-fetchStarted
+		clock.Add(time.Second)
+		c.Set("key", "created")
+		close(valueSet)
+	}()
+
+	c.GetOrFetch(ctx, "key", func(context.Context) (string, error) {
+		close(fetchStarted)
+		<-valueSet
+		return "", sturdyc.ErrNotFound
+	})
+	if value, ok := c.Get("key"); !ok || value != "created" {
+		t.Errorf("expected the value that was set during the fetch, got %q", value)
+	}
+}
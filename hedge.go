@@ -0,0 +1,165 @@
+package sturdyc
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+const (
+	// hedgeLatencySamples is the number of latencies that are used to calculate the hedge delay.
+	hedgeLatencySamples = 100
+	// hedgeMinSamples is the number of latencies that have to be recorded
+	// before the percentile is used instead of the fallback delay.
+	hedgeMinSamples = 10
+)
+
+// hedging keeps track of the latencies of the calls to the underlying data
+// source in order to determine when a hedged request should be issued.
+type hedging struct {
+	sync.Mutex
+	percentile    float64
+	fallbackDelay time.Duration
+	samples       []time.Duration
+	next          int
+}
+
+func newHedging(percentile float64, fallbackDelay time.Duration) *hedging {
+	return &hedging{
+		percentile:    percentile,
+		fallbackDelay: fallbackDelay,
+		samples:       make([]time.Duration, 0, hedgeLatencySamples),
+	}
+}
+
+// record adds a latency to the ring of samples.
+func (h *hedging) record(latency time.Duration) {
+	h.Lock()
+	defer h.Unlock()
+	if len(h.samples) < hedgeLatencySamples {
+		h.samples = append(h.samples, latency)
+		return
+	}
+	h.samples[h.next] = latency
+	h.next = (h.next + 1) % hedgeLatencySamples
+}
+
+// delay returns how long we should wait for a response before issuing a hedged request.
+func (h *hedging) delay() time.Duration {
+	h.Lock()
+	if len(h.samples) < hedgeMinSamples {
+		h.Unlock()
+		return h.fallbackDelay
+	}
+	samples := slices.Clone(h.samples)
+	h.Unlock()
+
+	slices.Sort(samples)
+	index := min(int(float64(len(samples))*h.percentile), len(samples)-1)
+	return samples[index]
+}
+
+type callResult[V any] struct {
+	val V
+	err error
+}
+
+// hedgingKey is the context key that marks the fetches that may be hedged.
+type hedgingKey struct{}
+
+// withHedging marks the context of a foreground fetch. Background refreshes
+// are never hedged, so their contexts are left unmarked. The marker is tied to
+// the hedging of this client so that a fetchFn that calls another cache with
+// the context doesn't enable the hedging of that cache.
+func (c *Config) withHedging(ctx context.Context) context.Context {
+	if c.hedging == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, hedgingKey{}, c.hedging)
+}
+
+func (c *Config) shouldHedge(ctx context.Context) bool {
+	return c.hedging != nil && ctx.Value(hedgingKey{}) == c.hedging
+}
+
+// hedgedFetch wraps the fetchFn so that foreground calls to the underlying
+// data source are hedged.
+func hedgedFetch[V, T any](c *Client[T], fetchFn FetchFn[V]) FetchFn[V] {
+	if c.hedging == nil {
+		return fetchFn
+	}
+
+	return func(ctx context.Context) (V, error) {
+		if !c.shouldHedge(ctx) {
+			return fetchFn(ctx)
+		}
+		return hedgedCall(ctx, c, fetchFn)
+	}
+}
+
+// hedgedBatchFetch is the batch equivalent of hedgedFetch.
+func hedgedBatchFetch[V, T any](c *Client[T], fetchFn BatchFetchFn[V]) BatchFetchFn[V] {
+	if c.hedging == nil {
+		return fetchFn
+	}
+
+	return func(ctx context.Context, ids []string) (map[string]V, error) {
+		if !c.shouldHedge(ctx) {
+			return fetchFn(ctx, ids)
+		}
+		return hedgedCall(ctx, c, func(ctx context.Context) (map[string]V, error) {
+			return fetchFn(ctx, ids)
+		})
+	}
+}
+
+// hedgedCall calls the fn, and issues a second hedged call if the first one
+// hasn't returned within the hedge delay. The first successful response
+// wins, and the context of the other call is cancelled. The latency that is
+// recorded is measured from the start of the first call, so a hedged call that
+// wins never drags the delay below the time it had to wait for.
+func hedgedCall[V, T any](ctx context.Context, c *Client[T], fn func(ctx context.Context) (V, error)) (V, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	start := c.clock.Now()
+	results := make(chan callResult[V], 2)
+	call := func() {
+		go func() {
+			defer func() {
+				if err := recover(); err != nil {
+					results <- callResult[V]{err: fmt.Errorf("sturdyc: panic recovered: %v", err)}
+				}
+			}()
+			val, err := fn(ctx)
+			results <- callResult[V]{val: val, err: err}
+		}()
+	}
+
+	call()
+	outstanding := 1
+	timer, stop := c.clock.NewTimer(c.hedging.delay())
+	defer stop()
+
+	var res callResult[V]
+	for outstanding > 0 {
+		select {
+		case <-timer:
+			call()
+			outstanding++
+			timer = nil
+			continue
+		case res = <-results:
+			outstanding--
+		}
+
+		if res.err == nil {
+			break
+		}
+	}
+
+	c.hedging.record(c.clock.Since(start))
+	return res.val, res.err
+}
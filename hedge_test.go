@@ -0,0 +1,171 @@
+package sturdyc_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/viccon/sturdyc"
+)
+
+func TestHedgedFetchWinsOverASlowRequest(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	c := sturdyc.New[string](100, 2, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithHedgedFetches(0.9, 10*time.Millisecond),
+	)
+
+	var calls atomic.Int32
+	var cancelled atomic.Bool
+	fetchFn := func(ctx context.Context) (string, error) {
+		if calls.Add(1) == 1 {
+			<-ctx.Done()
+			cancelled.Store(true)
+			return "", ctx.Err()
+		}
+		return "hedged", nil
+	}
+
+	res, err := c.GetOrFetch(ctx, "key", fetchFn)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if res != "hedged" {
+		t.Errorf("expected the hedged response, got %s", res)
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("expected 2 calls, got %d", got)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	if !cancelled.Load() {
+		t.Error("expected the slow request to have been cancelled")
+	}
+}
+
+func TestFastFetchesAreNotHedged(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	c := sturdyc.New[string](100, 2, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithHedgedFetches(0.9, time.Second),
+	)
+
+	var calls atomic.Int32
+	fetchFn := func(_ context.Context, ids []string) (map[string]string, error) {
+		calls.Add(1)
+		return map[string]string{ids[0]: "value"}, nil
+	}
+
+	res, err := c.GetOrFetchBatch(ctx, []string{"1"}, c.BatchKeyFn("item"), fetchFn)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if res["1"] != "value" {
+		t.Errorf("expected value, got %s", res["1"])
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("expected 1 call, got %d", got)
+	}
+}
+
+func TestHedgedFetchWaitsForTheOtherRequestIfOneFails(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	c := sturdyc.New[string](100, 2, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithHedgedFetches(0.9, 10*time.Millisecond),
+	)
+
+	var calls atomic.Int32
+	fetchFn := func(_ context.Context) (string, error) {
+		if calls.Add(1) == 1 {
+			time.Sleep(30 * time.Millisecond)
+			return "slow", nil
+		}
+		return "", errors.New("hedge failed")
+	}
+
+	res, err := c.GetOrFetch(ctx, "key", fetchFn)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if res != "slow" {
+		t.Errorf("expected the slow response, got %s", res)
+	}
+}
+
+func TestHedgedFetchesOnlyHedgeTheDataSource(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	distributedStorage := &mockStorage{}
+	c := sturdyc.New[string](100, 2, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithDistributedStorage(distributedStorage),
+		sturdyc.WithHedgedFetches(0.9, 10*time.Millisecond),
+	)
+
+	var calls atomic.Int32
+	fetchFn := func(ctx context.Context) (string, error) {
+		if calls.Add(1) == 1 {
+			<-ctx.Done()
+			return "", ctx.Err()
+		}
+		return "hedged", nil
+	}
+
+	res, err := c.GetOrFetch(ctx, "key", fetchFn)
+	if err != nil || res != "hedged" {
+		t.Fatalf("expected the hedged response, got %q %v", res, err)
+	}
+	if err := c.WaitForIdle(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("expected 2 calls to the data source, got %d", got)
+	}
+	distributedStorage.assertGetCount(t, 1)
+	distributedStorage.assertSetCount(t, 1)
+}
+
+func TestBackgroundRefreshesAreNotHedged(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	clock := sturdyc.NewTestClock(time.Now())
+	refreshDelay := time.Minute
+	c := sturdyc.New[string](100, 2, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithEarlyRefreshes(refreshDelay, refreshDelay, time.Second),
+		sturdyc.WithHedgedFetches(0.9, time.Millisecond),
+		sturdyc.WithClock(clock),
+	)
+
+	var calls atomic.Int32
+	fetchFn := func(_ context.Context) (string, error) {
+		calls.Add(1)
+		return "value", nil
+	}
+	c.GetOrFetch(ctx, "key", fetchFn)
+
+	clock.Add(refreshDelay + time.Second)
+	c.GetOrFetch(ctx, "key", func(ctx context.Context) (string, error) {
+		calls.Add(1)
+		time.Sleep(20 * time.Millisecond)
+		clock.Add(time.Second)
+		return "refreshed", nil
+	})
+	if err := c.WaitForIdle(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("expected 2 calls, got %d", got)
+	}
+}
@@ -0,0 +1,69 @@
+package sturdyc
+
+import "time"
+
+// RefreshSuccessFn is called when a background refresh of a key succeeds.
+// Retries is the number of refreshes that failed before this one, and
+// staleness is for how long the previous value had been cached.
+type RefreshSuccessFn func(key string, retries int, staleness time.Duration)
+
+// RefreshFailureFn is called when a background refresh of a key fails.
+// Retries is the number of refreshes that failed before this one, and
+// staleness is for how long the value that is being served has been cached.
+type RefreshFailureFn func(key string, err error, retries int, staleness time.Duration)
+
+// refreshState holds the state of a record at the time a refresh started.
+type refreshState struct {
+	retries  int
+	cachedAt time.Time
+}
+
+func (c *Config) hasRefreshHooks() bool {
+	return c.onRefreshSuccess != nil || c.onRefreshFailure != nil
+}
+
+// refreshStates captures the state of each key before the refresh is
+// performed, as the state is reset once the new values have been written.
+func (c *Client[T]) refreshStates(keys ...string) map[string]refreshState {
+	if !c.hasRefreshHooks() {
+		return nil
+	}
+
+	states := make(map[string]refreshState, len(keys))
+	for _, key := range keys {
+		retries, cachedAt, ok := c.getShard(key).refreshState(key)
+		if !ok {
+			continue
+		}
+		// The retries are incremented when the refresh is scheduled.
+		states[key] = refreshState{retries: max(retries-1, 0), cachedAt: cachedAt}
+	}
+	return states
+}
+
+func (c *Client[T]) reportRefreshSuccess(key string, states map[string]refreshState) {
+	c.recordRefresh(true)
+	c.emitRefreshEvent(RefreshSucceeded, key, nil)
+	if c.onRefreshSuccess == nil {
+		return
+	}
+	state := states[key]
+	c.onRefreshSuccess(key, state.retries, c.staleness(state))
+}
+
+func (c *Client[T]) reportRefreshFailure(key string, err error, states map[string]refreshState) {
+	c.recordRefresh(false)
+	c.emitRefreshEvent(RefreshFailed, key, err)
+	if c.onRefreshFailure == nil {
+		return
+	}
+	state := states[key]
+	c.onRefreshFailure(key, err, state.retries, c.staleness(state))
+}
+
+func (c *Client[T]) staleness(state refreshState) time.Duration {
+	if state.cachedAt.IsZero() {
+		return 0
+	}
+	return c.clock.Since(state.cachedAt)
+}
@@ -0,0 +1,133 @@
+package sturdyc_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/viccon/sturdyc"
+)
+
+type refreshHookCall struct {
+	key       string
+	err       error
+	retries   int
+	staleness time.Duration
+}
+
+type refreshHookRecorder struct {
+	sync.Mutex
+	successes chan refreshHookCall
+	failures  chan refreshHookCall
+}
+
+func newRefreshHookRecorder(bufferSize int) *refreshHookRecorder {
+	return &refreshHookRecorder{
+		successes: make(chan refreshHookCall, bufferSize),
+		failures:  make(chan refreshHookCall, bufferSize),
+	}
+}
+
+func (r *refreshHookRecorder) onSuccess(key string, retries int, staleness time.Duration) {
+	r.successes <- refreshHookCall{key: key, retries: retries, staleness: staleness}
+}
+
+func (r *refreshHookRecorder) onFailure(key string, err error, retries int, staleness time.Duration) {
+	r.failures <- refreshHookCall{key: key, err: err, retries: retries, staleness: staleness}
+}
+
+func TestRefreshHooksReceiveTheRetriesAndStaleness(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	clock := sturdyc.NewTestClock(time.Now())
+	refreshDelay := time.Second
+	recorder := newRefreshHookRecorder(10)
+	c := sturdyc.New[string](100, 1, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithEarlyRefreshes(refreshDelay, refreshDelay, time.Millisecond),
+		sturdyc.WithOnRefreshSuccess(recorder.onSuccess),
+		sturdyc.WithOnRefreshFailure(recorder.onFailure),
+		sturdyc.WithClock(clock),
+	)
+
+	fetchObserver := NewFetchObserver(10)
+	fetchObserver.Response("1")
+	c.GetOrFetch(ctx, "1", fetchObserver.Fetch)
+	<-fetchObserver.FetchCompleted
+
+	fetchErr := errors.New("error")
+	fetchObserver.Err(fetchErr)
+	for i := 0; i < 2; i++ {
+		clock.Add(time.Minute)
+		c.GetOrFetch(ctx, "1", fetchObserver.Fetch)
+		failure := <-recorder.failures
+		if failure.key != "1" || !errors.Is(failure.err, fetchErr) {
+			t.Errorf("unexpected failure: %+v", failure)
+		}
+		if failure.retries != i {
+			t.Errorf("expected %d retries, got %d", i, failure.retries)
+		}
+		if failure.staleness != time.Duration(i+1)*time.Minute {
+			t.Errorf("expected a staleness of %d minutes, got %v", i+1, failure.staleness)
+		}
+	}
+
+	fetchObserver.Err(nil)
+	clock.Add(time.Minute)
+	c.GetOrFetch(ctx, "1", fetchObserver.Fetch)
+	success := <-recorder.successes
+	if success.key != "1" || success.retries != 2 || success.staleness != 3*time.Minute {
+		t.Errorf("unexpected success: %+v", success)
+	}
+}
+
+func TestRefreshHooksAreCalledForEachIDInABatch(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	clock := sturdyc.NewTestClock(time.Now())
+	refreshDelay := time.Second
+	recorder := newRefreshHookRecorder(10)
+	c := sturdyc.New[string](100, 1, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithEarlyRefreshes(refreshDelay, refreshDelay, time.Millisecond),
+		sturdyc.WithOnRefreshSuccess(recorder.onSuccess),
+		sturdyc.WithOnRefreshFailure(recorder.onFailure),
+		sturdyc.WithClock(clock),
+	)
+
+	ids := []string{"1", "2", "3"}
+	keyFn := c.BatchKeyFn("item")
+	fetchObserver := NewFetchObserver(10)
+	fetchObserver.BatchResponse(ids)
+	c.GetOrFetchBatch(ctx, ids, keyFn, fetchObserver.FetchBatch)
+	<-fetchObserver.FetchCompleted
+
+	// Make the third ID fail.
+	fetchErr := errors.New("error")
+	batchFetch := func(ctx context.Context, ids []string) (map[string]string, error) {
+		res, _ := fetchObserver.FetchBatch(ctx, ids)
+		delete(res, "3")
+		return res, &sturdyc.BatchError{Errors: map[string]error{"3": fetchErr}}
+	}
+
+	clock.Add(time.Minute)
+	c.GetOrFetchBatch(ctx, ids, keyFn, batchFetch)
+
+	succeeded := make(map[string]bool)
+	for i := 0; i < 2; i++ {
+		success := <-recorder.successes
+		succeeded[success.key] = true
+	}
+	if !succeeded[keyFn("1")] || !succeeded[keyFn("2")] {
+		t.Errorf("expected the first two IDs to succeed, got %v", succeeded)
+	}
+
+	failure := <-recorder.failures
+	if failure.key != keyFn("3") || !errors.Is(failure.err, fetchErr) {
+		t.Errorf("unexpected failure: %+v", failure)
+	}
+}
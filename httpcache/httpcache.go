@@ -0,0 +1,110 @@
+// Package httpcache provides an http.RoundTripper that caches the responses
+// of an underlying transport in a sturdyc.Client.
+package httpcache
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httputil"
+	"strings"
+
+	"github.com/viccon/sturdyc"
+)
+
+// Transport is an http.RoundTripper that serves GET and HEAD requests from
+// the cache. The responses are stored in their serialized form, and requests
+// are keyed by their method, URL, and the values of the vary headers. If the
+// client has been configured with early refreshes, the cached responses are
+// served while they're being revalidated in the background.
+type Transport struct {
+	// Next is used to perform the requests that can't be served from the
+	// cache. If it's nil, http.DefaultTransport is used.
+	Next http.RoundTripper
+	// Cache stores the serialized responses.
+	Cache *sturdyc.Client[[]byte]
+	// VaryHeaders are the request headers whose values are part of the key.
+	VaryHeaders []string
+}
+
+// NewTransport returns a Transport that stores the responses of next in the
+// cache. The values of the vary headers are used to tell requests apart.
+func NewTransport(cache *sturdyc.Client[[]byte], next http.RoundTripper, varyHeaders ...string) *Transport {
+	return &Transport{Next: next, Cache: cache, VaryHeaders: varyHeaders}
+}
+
+// uncacheableResponse is returned from the fetch function for responses that
+// shouldn't be cached, so that they can still be passed on to the caller.
+type uncacheableResponse struct {
+	dump []byte
+}
+
+func (e *uncacheableResponse) Error() string {
+	return "httpcache: the response is not cacheable"
+}
+
+func (t *Transport) next() http.RoundTripper {
+	if t.Next == nil {
+		return http.DefaultTransport
+	}
+	return t.Next
+}
+
+// key returns the cache key for the request.
+func (t *Transport) key(req *http.Request) string {
+	var builder strings.Builder
+	builder.WriteString(req.Method)
+	builder.WriteString(" ")
+	builder.WriteString(req.URL.String())
+	for _, header := range t.VaryHeaders {
+		builder.WriteString("\n")
+		builder.WriteString(http.CanonicalHeaderKey(header))
+		builder.WriteString(": ")
+		builder.WriteString(strings.Join(req.Header.Values(header), ","))
+	}
+	return builder.String()
+}
+
+// cacheable reports whether the request can be served from the cache.
+func cacheable(req *http.Request) bool {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return false
+	}
+	return !strings.Contains(req.Header.Get("Cache-Control"), "no-store")
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !cacheable(req) {
+		return t.next().RoundTrip(req)
+	}
+
+	fetchFn := func(ctx context.Context) ([]byte, error) {
+		res, err := t.next().RoundTrip(req.Clone(ctx))
+		if err != nil {
+			return nil, err
+		}
+		defer res.Body.Close()
+
+		dump, err := httputil.DumpResponse(res, true)
+		if err != nil {
+			return nil, err
+		}
+		if res.StatusCode != http.StatusOK || strings.Contains(res.Header.Get("Cache-Control"), "no-store") {
+			return nil, &uncacheableResponse{dump: dump}
+		}
+		return dump, nil
+	}
+
+	dump, err := t.Cache.GetOrFetch(req.Context(), t.key(req), fetchFn)
+	var uncacheable *uncacheableResponse
+	if errors.As(err, &uncacheable) {
+		dump, err = uncacheable.dump, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return http.ReadResponse(bufio.NewReader(bytes.NewReader(dump)), req)
+}
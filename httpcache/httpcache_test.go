@@ -0,0 +1,135 @@
+package httpcache_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/viccon/sturdyc"
+	"github.com/viccon/sturdyc/httpcache"
+)
+
+type upstream struct {
+	calls  atomic.Int32
+	status atomic.Int32
+}
+
+func (u *upstream) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	call := u.calls.Add(1)
+	if status := u.status.Load(); status != 0 {
+		w.WriteHeader(int(status))
+	}
+	io.WriteString(w, r.Header.Get("Accept-Language")+"response"+strconv.Itoa(int(call)))
+}
+
+func newClient(t *testing.T, u *upstream, cache *sturdyc.Client[[]byte], varyHeaders ...string) (*http.Client, string) {
+	t.Helper()
+	server := httptest.NewServer(u)
+	t.Cleanup(server.Close)
+	return &http.Client{Transport: httpcache.NewTransport(cache, server.Client().Transport, varyHeaders...)}, server.URL
+}
+
+func get(t *testing.T, client *http.Client, url string, header http.Header) (int, string) {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer res.Body.Close()
+	body, _ := io.ReadAll(res.Body)
+	return res.StatusCode, string(body)
+}
+
+func TestTransportCachesResponses(t *testing.T) {
+	t.Parallel()
+
+	u := &upstream{}
+	cache := sturdyc.New[[]byte](100, 2, time.Hour, 10)
+	client, url := newClient(t, u, cache)
+
+	for i := 0; i < 3; i++ {
+		if _, body := get(t, client, url+"/a", nil); body != "response1" {
+			t.Fatalf("expected the cached response, got %q", body)
+		}
+	}
+	if u.calls.Load() != 1 {
+		t.Errorf("expected 1 upstream call, got %d", u.calls.Load())
+	}
+
+	res, err := client.Post(url+"/a", "text/plain", strings.NewReader("body"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if u.calls.Load() != 2 {
+		t.Errorf("expected the POST request to bypass the cache, got %d upstream calls", u.calls.Load())
+	}
+}
+
+func TestTransportKeysByVaryHeaders(t *testing.T) {
+	t.Parallel()
+
+	u := &upstream{}
+	cache := sturdyc.New[[]byte](100, 2, time.Hour, 10)
+	client, url := newClient(t, u, cache, "Accept-Language")
+
+	_, english := get(t, client, url, http.Header{"Accept-Language": {"en"}})
+	_, swedish := get(t, client, url, http.Header{"Accept-Language": {"sv"}})
+	_, englishAgain := get(t, client, url, http.Header{"Accept-Language": {"en"}})
+	if english != "enresponse1" || swedish != "svresponse2" || englishAgain != english {
+		t.Errorf("unexpected responses: %q %q %q", english, swedish, englishAgain)
+	}
+}
+
+func TestTransportDoesNotCacheErrorResponses(t *testing.T) {
+	t.Parallel()
+
+	u := &upstream{}
+	u.status.Store(http.StatusInternalServerError)
+	cache := sturdyc.New[[]byte](100, 2, time.Hour, 10)
+	client, url := newClient(t, u, cache)
+
+	for i := 1; i <= 2; i++ {
+		status, body := get(t, client, url, nil)
+		if status != http.StatusInternalServerError || body != "response"+strconv.Itoa(i) {
+			t.Errorf("expected the error response to be passed through, got %d %q", status, body)
+		}
+	}
+}
+
+func TestTransportRevalidatesInTheBackground(t *testing.T) {
+	t.Parallel()
+
+	u := &upstream{}
+	clock := sturdyc.NewTestClock(time.Now())
+	cache := sturdyc.New[[]byte](100, 2, time.Hour, 10,
+		sturdyc.WithClock(clock),
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithEarlyRefreshes(time.Minute, time.Minute, time.Second),
+	)
+	client, url := newClient(t, u, cache)
+
+	get(t, client, url, nil)
+	clock.Add(2 * time.Minute)
+
+	// The stale response is served while it's being refreshed.
+	if _, body := get(t, client, url, nil); body != "response1" {
+		t.Errorf("expected the stale response, got %q", body)
+	}
+	for i := 0; i < 100 && u.calls.Load() != 2; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+	if _, body := get(t, client, url, nil); body != "response2" {
+		t.Errorf("expected the refreshed response, got %q", body)
+	}
+}
@@ -0,0 +1,48 @@
+package sturdyc
+
+import (
+	"context"
+	"time"
+)
+
+// idlePollInterval is how often WaitForIdle checks whether the cache is idle.
+const idlePollInterval = time.Millisecond
+
+// IsIdle returns true if there are no fetches in flight, no refreshes waiting
+// in the refresh buffers, and no background refreshes or writes to the
+// distributed storage that have yet to complete.
+func (c *Client[T]) IsIdle() bool {
+	if c.NumKeysInflight() > 0 || c.pendingWork.Load() > 0 {
+		return false
+	}
+	if !c.bufferRefreshes {
+		return true
+	}
+	c.batchMutex.Lock()
+	defer c.batchMutex.Unlock()
+	return len(c.permutationBufferMap) == 0
+}
+
+// WaitForIdle blocks until the cache is idle, as reported by IsIdle, or until
+// the context is done. It allows tests to assert the state of the cache after
+// a background refresh without having to sleep. The cache is polled using the
+// real time rather than its clock, which means that a test which is using a
+// TestClock has to advance it, or call FlushRefreshBuffers, for the refreshes
+// that are waiting in the buffers to complete.
+func (c *Client[T]) WaitForIdle(ctx context.Context) error {
+	return c.waitUntil(ctx, c.IsIdle)
+}
+
+// waitUntil polls the condition until it's true, or until the context is done.
+func (c *Client[T]) waitUntil(ctx context.Context, condition func() bool) error {
+	for !condition() {
+		timer := time.NewTimer(idlePollInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package sturdyc_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/viccon/sturdyc"
+)
+
+func TestWaitForIdleWaitsForTheBackgroundRefreshes(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	refreshDelay := time.Minute
+	clock := sturdyc.NewTestClock(time.Now())
+	c := sturdyc.New[string](100, 1, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithEarlyRefreshes(refreshDelay, refreshDelay, time.Second),
+		sturdyc.WithClock(clock),
+	)
+
+	c.Set("1", "value1")
+	clock.Add(refreshDelay + time.Second)
+
+	release := make(chan struct{})
+	_, err := c.GetOrFetch(ctx, "1", func(context.Context) (string, error) {
+		<-release
+		return "value2", nil
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if err := c.WaitForIdle(timeoutCtx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the refresh to keep the cache busy, got %v", err)
+	}
+
+	close(release)
+	if err := c.WaitForIdle(ctx); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if value, _ := c.Get("1"); value != "value2" {
+		t.Errorf("expected the refreshed value, got %s", value)
+	}
+}
+
+func TestWaitForIdleWaitsForTheRefreshBuffers(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	refreshDelay := time.Minute
+	clock := sturdyc.NewTestClock(time.Now())
+	c := sturdyc.New[string](100, 1, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithEarlyRefreshes(refreshDelay, refreshDelay, time.Second),
+		sturdyc.WithRefreshCoalescing(10, time.Minute),
+		sturdyc.WithClock(clock),
+	)
+
+	ids := []string{"1", "2"}
+	fetchObserver := NewFetchObserver(2)
+	fetchObserver.BatchResponse(ids)
+	sturdyc.GetOrFetchBatch(ctx, c, ids, c.BatchKeyFn("item"), fetchObserver.FetchBatch)
+	<-fetchObserver.FetchCompleted
+
+	clock.Add(refreshDelay + time.Second)
+	sturdyc.GetOrFetchBatch(ctx, c, ids, c.BatchKeyFn("item"), fetchObserver.FetchBatch)
+
+	// Wait for the buffer to register the timer of its timeout.
+	clock.BlockUntil(1)
+	if c.IsIdle() {
+		t.Fatal("expected the buffered refresh to keep the cache busy")
+	}
+
+	c.FlushRefreshBuffers()
+	if err := c.WaitForIdle(ctx); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	fetchObserver.AssertFetchCount(t, 2)
+}
@@ -4,37 +4,90 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"sync"
+	"time"
 )
 
 type inFlightCall[T any] struct {
 	sync.WaitGroup
-	val T
-	err error
+	val       T
+	err       error
+	startedAt time.Time
+	// partial is set when the key failed on its own as part of a batch.
+	partial bool
+	// onlyCached is set when the value of the key came from the distributed
+	// storage because the call to the underlying data source failed.
+	onlyCached bool
 }
 
-// newFlight should be called with a lock.
-func (c *Client[T]) newFlight(key string) *inFlightCall[T] {
+// inFlightShard holds a subset of the calls that are currently in flight.
+// The calls are sharded by key so that concurrent cache misses for unrelated
+// keys don't have to serialize on a single mutex.
+type inFlightShard[T any] struct {
+	sync.Mutex
+	calls map[string]*inFlightCall[T]
+}
+
+// contains reports whether the key is in flight.
+func (s *inFlightShard[T]) contains(key string) bool {
+	s.Lock()
+	defer s.Unlock()
+	_, ok := s.calls[key]
+	return ok
+}
+
+// durations adds the time that each call has been outstanding to the map.
+func (s *inFlightShard[T]) durations(now time.Time, durations map[string]time.Duration) {
+	s.Lock()
+	defer s.Unlock()
+	for key, call := range s.calls {
+		durations[key] = now.Sub(call.startedAt)
+	}
+}
+
+func newInFlightShards[T any](numShards int) []*inFlightShard[T] {
+	shards := make([]*inFlightShard[T], numShards)
+	for i := range shards {
+		shards[i] = &inFlightShard[T]{calls: make(map[string]*inFlightCall[T])}
+	}
+	return shards
+}
+
+// inFlightShardIndex returns the index of the in-flight shard that tracks the key.
+func (c *Client[T]) inFlightShardIndex(key string) int {
+	return int(c.keyHasher(key) % uint64(len(c.inFlight)))
+}
+
+// newFlight should be called with a lock on the shard.
+func (s *inFlightShard[T]) newFlight(key string, now time.Time) *inFlightCall[T] {
 	call := new(inFlightCall[T])
+	call.startedAt = now
 	call.Add(1)
-	c.inFlightMap[key] = call
+	s.calls[key] = call
 	return call
 }
 
-func makeCall[T, V any](ctx context.Context, c *Client[T], key string, fn FetchFn[V], call *inFlightCall[T]) {
+func makeCall[T, V any](ctx context.Context, c *Client[T], key string, fn FetchFn[V], call *inFlightCall[T], opts callOptions) {
 	defer func() {
 		if err := recover(); err != nil {
 			call.err = fmt.Errorf("sturdyc: panic recovered: %v", err)
 		}
 		call.Done()
-		c.inFlightMutex.Lock()
-		delete(c.inFlightMap, key)
-		c.inFlightMutex.Unlock()
+		shard := c.inFlight[c.inFlightShardIndex(key)]
+		shard.Lock()
+		delete(shard.calls, key)
+		shard.Unlock()
 	}()
 
-	response, err := fn(ctx)
-	if err != nil && c.storeMissingRecords && errors.Is(err, ErrNotFound) {
-		c.StoreMissingRecord(key)
+	response, err := fn(c.withHedging(ctx))
+	latency := c.clock.Since(call.startedAt)
+	c.reportFetchDuration(latency)
+	c.logger(LogFetches).Debug("sturdyc: fetched key", "key", key, "latency", latency, "error", err)
+	if err != nil && opts.storeMissingRecords && errors.Is(err, ErrNotFound) {
+		if c.admit(key) {
+			c.storeMissingRecord(key, opts, call.startedAt)
+		}
 		call.err = ErrMissingRecord
 		return
 	}
@@ -52,150 +105,207 @@ func makeCall[T, V any](ctx context.Context, c *Client[T], key string, fn FetchF
 
 	call.err = nil
 	call.val = res
-	c.Set(key, res)
+	if c.admit(key) {
+		c.set(key, res, opts)
+	}
 }
 
-func callAndCache[V, T any](ctx context.Context, c *Client[T], key string, fn FetchFn[V]) (V, error) {
-	c.inFlightMutex.Lock()
-	if call, ok := c.inFlightMap[key]; ok {
-		c.inFlightMutex.Unlock()
+func callAndCache[V, T any](ctx context.Context, c *Client[T], key string, fn FetchFn[V], opts callOptions) (V, error) {
+	shard := c.inFlight[c.inFlightShardIndex(key)]
+	shard.Lock()
+	if call, ok := shard.calls[key]; ok {
+		shard.Unlock()
+		c.reportFetchCoalesced(1)
 		call.Wait()
 		return unwrap[V, T](call.val, call.err)
 	}
 
-	call := c.newFlight(key)
-	c.inFlightMutex.Unlock()
-	makeCall(ctx, c, key, fn, call)
+	call := shard.newFlight(key, c.clock.Now())
+	shard.Unlock()
+	makeCall(ctx, c, key, fn, call, opts)
 	return unwrap[V, T](call.val, call.err)
 }
 
-// newBatchFlight should be called with a lock.
-func (c *Client[T]) newBatchFlight(ids []string, keyFn KeyFn) *inFlightCall[map[string]T] {
-	call := new(inFlightCall[map[string]T])
-	call.val = make(map[string]T, len(ids))
-	call.Add(1)
-	for _, id := range ids {
-		c.inFlightBatchMap[keyFn(id)] = call
+// lockInFlightShards locks every in-flight shard that is used by the keys.
+// The shards are always locked in ascending order to avoid deadlocks. The
+// returned slice holds the shard index for each of the keys.
+func (c *Client[T]) lockInFlightShards(keys []string) (shardIndexes, lockedShards []int) {
+	shardIndexes = make([]int, len(keys))
+	lockedShards = make([]int, 0, len(keys))
+	for i, key := range keys {
+		shardIndexes[i] = c.inFlightShardIndex(key)
+		lockedShards = append(lockedShards, shardIndexes[i])
+	}
+	slices.Sort(lockedShards)
+	lockedShards = slices.Compact(lockedShards)
+	for _, index := range lockedShards {
+		c.inFlight[index].Lock()
+	}
+	return shardIndexes, lockedShards
+}
+
+func (c *Client[T]) unlockInFlightShards(lockedShards []int) {
+	for _, index := range lockedShards {
+		c.inFlight[index].Unlock()
 	}
-	return call
 }
 
-func (c *Client[T]) endBatchFlight(ids []string, keyFn KeyFn, call *inFlightCall[map[string]T]) {
-	call.Done()
-	c.inFlightBatchMutex.Lock()
-	for _, id := range ids {
-		delete(c.inFlightBatchMap, keyFn(id))
+// endFlights releases the callers that are waiting for the keys of a batch.
+func (c *Client[T]) endFlights(keys []string, calls []*inFlightCall[T]) {
+	for i, key := range keys {
+		calls[i].Done()
+		shard := c.inFlight[c.inFlightShardIndex(key)]
+		shard.Lock()
+		delete(shard.calls, key)
+		shard.Unlock()
 	}
-	c.inFlightBatchMutex.Unlock()
 }
 
 type makeBatchCallOpts[T, V any] struct {
-	ids   []string
-	fn    BatchFetchFn[V]
-	keyFn KeyFn
-	call  *inFlightCall[map[string]T]
+	ids     []string
+	keys    []string
+	calls   []*inFlightCall[T]
+	fn      BatchFetchFn[V]
+	options callOptions
 }
 
+// makeBatchCall fetches the IDs, and sets the outcome of each of them on its
+// call so that both batch and single callers can wait for the individual keys.
 func makeBatchCall[T, V any](ctx context.Context, c *Client[T], opts makeBatchCallOpts[T, V]) {
-	response, err := opts.fn(ctx, opts.ids)
-	if err != nil && !errors.Is(err, errOnlyDistributedRecords) {
-		opts.call.err = err
+	startedAt := c.clock.Now()
+	response, err := opts.fn(c.withHedging(ctx), opts.ids)
+	latency := c.clock.Since(startedAt)
+	c.reportFetchDuration(latency)
+	c.logger(LogFetches).Debug("sturdyc: fetched batch", "ids", len(opts.ids), "latency", latency, "error", err)
+	batchErr, isBatchErr := asBatchError(err)
+	if err != nil && !errors.Is(err, errOnlyDistributedRecords) && !isBatchErr {
+		for _, call := range opts.calls {
+			call.err = err
+		}
 		return
 	}
 
-	if errors.Is(err, errOnlyDistributedRecords) {
-		opts.call.err = ErrOnlyCachedRecords
-	}
-
-	// Check if we should store any of these IDs as a missing record. However, we
-	// don't want to do this if we only received records from the distributed
-	// storage. That means that the underlying data source errored for the ID's
-	// that we didn't have in our distributed storage, and we don't know wether
-	// these records are missing or not.
-	if c.storeMissingRecords && len(response) < len(opts.ids) && !errors.Is(err, errOnlyDistributedRecords) {
-		for _, id := range opts.ids {
-			if _, ok := response[id]; !ok {
-				c.StoreMissingRecord(opts.keyFn(id))
+	for i, id := range opts.ids {
+		key, call := opts.keys[i], opts.calls[i]
+		record, ok := response[id]
+		switch {
+		case ok:
+			v, ok := any(record).(T)
+			if !ok {
+				c.logger(LogFetches).Error("sturdyc: invalid type", "key", key, "id", id)
+				call.err, call.partial = ErrInvalidType, true
+				continue
 			}
+			if c.admit(key) {
+				c.set(key, v, opts.options)
+			}
+			call.val = v
+			call.onlyCached = errors.Is(err, errOnlyDistributedRecords)
+		// IDs that failed with anything but ErrNotFound might not be missing.
+		case isBatchErr && batchErr.failed(id):
+			call.err, call.partial = batchErr.Errors[id], true
+		// If we only received records from the distributed storage, the
+		// underlying data source errored for the IDs that we didn't have in
+		// our distributed storage, and we don't know whether they're missing.
+		case errors.Is(err, errOnlyDistributedRecords):
+			call.err = ErrOnlyCachedRecords
+		case opts.options.storeMissingRecords:
+			if c.admit(key) {
+				c.storeMissingRecord(key, opts.options, call.startedAt)
+			}
+			call.err = ErrMissingRecord
+		default:
+			call.err = ErrNotFound
 		}
 	}
-
-	// Store the records in the cache.
-	for id, record := range response {
-		v, ok := any(record).(T)
-		if !ok {
-			c.log.Error("sturdyc: invalid type for ID:" + id)
-			continue
-		}
-		c.Set(opts.keyFn(id), v)
-		opts.call.val[id] = v
-	}
 }
 
 type callBatchOpts[T, V any] struct {
-	ids   []string
-	keyFn KeyFn
-	fn    BatchFetchFn[V]
+	ids     []string
+	keyFn   KeyFn
+	fn      BatchFetchFn[V]
+	options callOptions
 }
 
+// callAndCacheBatch tracks the in-flight status of each individual key, using
+// the same calls as callAndCache. If a batch overlaps with keys that are already
+// in flight, whether by another batch or a single fetch, it waits for those keys
+// and only fetches the disjoint remainder from the data source.
 func callAndCacheBatch[V, T any](ctx context.Context, c *Client[T], opts callBatchOpts[T, V]) (map[string]V, error) {
-	c.inFlightBatchMutex.Lock()
+	keys := make([]string, 0, len(opts.ids))
+	for _, id := range opts.ids {
+		keys = append(keys, opts.keyFn(id))
+	}
+	shardIndexes, lockedShards := c.lockInFlightShards(keys)
 
-	callIDs := make(map[*inFlightCall[map[string]T]][]string)
+	now := c.clock.Now()
+	calls := make([]*inFlightCall[T], len(opts.ids))
+	owned := make([]bool, len(opts.ids))
 	uniqueIDs := make([]string, 0, len(opts.ids))
-	for _, id := range opts.ids {
-		if call, ok := c.inFlightBatchMap[opts.keyFn(id)]; ok {
-			callIDs[call] = append(callIDs[call], id)
+	uniqueKeys := make([]string, 0, len(opts.ids))
+	uniqueCalls := make([]*inFlightCall[T], 0, len(opts.ids))
+	for i, id := range opts.ids {
+		shard := c.inFlight[shardIndexes[i]]
+		if call, ok := shard.calls[keys[i]]; ok {
+			calls[i] = call
 			continue
 		}
+		calls[i], owned[i] = shard.newFlight(keys[i], now), true
 		uniqueIDs = append(uniqueIDs, id)
+		uniqueKeys = append(uniqueKeys, keys[i])
+		uniqueCalls = append(uniqueCalls, calls[i])
 	}
 
 	if len(uniqueIDs) > 0 {
-		call := c.newBatchFlight(uniqueIDs, opts.keyFn)
-		callIDs[call] = append(callIDs[call], uniqueIDs...)
 		go func() {
 			defer func() {
 				if err := recover(); err != nil {
-					call.err = fmt.Errorf("sturdyc: panic recovered: %v", err)
+					for _, call := range uniqueCalls {
+						call.err = fmt.Errorf("sturdyc: panic recovered: %v", err)
+					}
 				}
-				c.endBatchFlight(uniqueIDs, opts.keyFn, call)
+				c.endFlights(uniqueKeys, uniqueCalls)
 			}()
-			batchCallOpts := makeBatchCallOpts[T, V]{ids: uniqueIDs, fn: opts.fn, keyFn: opts.keyFn, call: call}
+			batchCallOpts := makeBatchCallOpts[T, V]{ids: uniqueIDs, keys: uniqueKeys, calls: uniqueCalls, fn: opts.fn, options: opts.options}
 			makeBatchCall(ctx, c, batchCallOpts)
 		}()
 	}
-	c.inFlightBatchMutex.Unlock()
+	c.unlockInFlightShards(lockedShards)
+	c.reportFetchCoalesced(len(opts.ids) - len(uniqueIDs))
 
 	var err error
+	idErrors := make(map[string]error)
 	response := make(map[string]V, len(opts.ids))
-	for call, callIDs := range callIDs {
+	for i, id := range opts.ids {
+		call := calls[i]
 		call.Wait()
-		// It could be only cached records here, if we we're able
-		// to get some of the IDs from the distributed storage.
-		if call.err != nil && !errors.Is(call.err, ErrOnlyCachedRecords) {
-			return response, call.err
-		}
-
-		if errors.Is(call.err, ErrOnlyCachedRecords) {
-			err = ErrOnlyCachedRecords
-		}
-
-		// We need to iterate through the values that we want from this call. The
-		// batch could contain a hundred IDs, but we might only want a few of them.
-		for _, id := range callIDs {
-			v, ok := call.val[id]
+		switch {
+		case call.err == nil:
+			val, ok := any(call.val).(V)
 			if !ok {
-				continue
+				return response, ErrInvalidType
 			}
-
-			if val, ok := any(v).(V); ok {
-				response[id] = val
-				continue
+			response[id] = val
+			// It could be only cached records here, if we we're able
+			// to get some of the IDs from the distributed storage.
+			if call.onlyCached {
+				err = ErrOnlyCachedRecords
 			}
-			return response, ErrInvalidType
+		case errors.Is(call.err, ErrNotFound), errors.Is(call.err, ErrMissingRecord):
+		case errors.Is(call.err, ErrOnlyCachedRecords):
+			err = ErrOnlyCachedRecords
+		// The keys that were fetched by other calls, or that failed individually,
+		// are reported as failed IDs rather than failing the entire batch.
+		case call.partial || !owned[i]:
+			idErrors[id] = call.err
+		default:
+			return response, call.err
 		}
 	}
 
+	if len(idErrors) > 0 {
+		return response, &BatchError{Errors: idErrors}
+	}
+
 	return response, err
 }
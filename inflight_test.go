@@ -3,6 +3,7 @@ package sturdyc_test
 import (
 	"context"
 	"math/rand/v2"
+	"slices"
 	"strconv"
 	"sync"
 	"sync/atomic"
@@ -228,3 +229,313 @@ func TestInflightKeyIsRemovedIfTheRequestPanics(t *testing.T) {
 		t.Errorf("expected no keys in cache; got %d", c.Size())
 	}
 }
+
+func TestRequestsForUnrelatedMissingKeysAreTrackedAcrossShards(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	capacity := 1000
+	numShards := 10
+	ttl := time.Minute
+	evictionPercentage := 10
+	c := sturdyc.New[string](capacity, numShards, ttl, evictionPercentage)
+
+	ch := make(chan struct{})
+	var calls atomic.Int32
+	fn := func(_ context.Context) (string, error) {
+		calls.Add(1)
+		<-ch
+		return "value", nil
+	}
+
+	numKeys := 50
+	var wg sync.WaitGroup
+	for i := 0; i < numKeys; i++ {
+		for j := 0; j < 5; j++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if _, err := c.GetOrFetch(ctx, "key-"+strconv.Itoa(i), fn); err != nil {
+					t.Error(err)
+				}
+			}()
+		}
+	}
+	time.Sleep(50 * time.Millisecond)
+	if c.NumKeysInflight() != numKeys {
+		t.Errorf("expected %d inflight keys; got %d", numKeys, c.NumKeysInflight())
+	}
+	close(ch)
+	wg.Wait()
+	if got := calls.Load(); got != int32(numKeys) {
+		t.Errorf("got %d calls; wanted %d", got, numKeys)
+	}
+	if c.NumKeysInflight() > 0 {
+		t.Errorf("expected no inflight keys; got %d", c.NumKeysInflight())
+	}
+}
+
+func TestInflightKeysAndDurations(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	capacity := 100
+	numShards := 2
+	ttl := time.Minute
+	evictionPercentage := 10
+	clock := sturdyc.NewTestClock(time.Now())
+	c := sturdyc.New[string](capacity, numShards, ttl, evictionPercentage,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithClock(clock),
+	)
+
+	ch := make(chan struct{})
+	fetchFn := func(_ context.Context) (string, error) {
+		<-ch
+		return "value", nil
+	}
+	batchFn := func(_ context.Context, ids []string) (map[string]string, error) {
+		<-ch
+		res := make(map[string]string, len(ids))
+		for _, id := range ids {
+			res[id] = "value"
+		}
+		return res, nil
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		c.GetOrFetch(ctx, "key1", fetchFn)
+	}()
+	go func() {
+		defer wg.Done()
+		c.GetOrFetchBatch(ctx, []string{"1", "2"}, c.BatchKeyFn("batch"), batchFn)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	clock.Add(time.Second)
+
+	keys := c.InflightKeys()
+	if len(keys) != 3 {
+		t.Fatalf("expected 3 inflight keys; got %v", keys)
+	}
+	for _, key := range []string{"key1", "batch-ID-1", "batch-ID-2"} {
+		if !c.IsInflight(key) {
+			t.Errorf("expected %s to be inflight", key)
+		}
+	}
+	if c.IsInflight("key2") {
+		t.Error("expected key2 to not be inflight")
+	}
+	for key, duration := range c.InflightDurations() {
+		if duration != time.Second {
+			t.Errorf("expected %s to have been inflight for a second; got %v", key, duration)
+		}
+	}
+
+	close(ch)
+	wg.Wait()
+	time.Sleep(50 * time.Millisecond)
+	if keys := c.InflightKeys(); len(keys) > 0 {
+		t.Errorf("expected no inflight keys; got %v", keys)
+	}
+}
+
+func TestReportsMetricsForCoalescedFetches(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	capacity := 100
+	numShards := 2
+	ttl := time.Minute
+	evictionPercentage := 10
+	metricsRecorder := newTestMetricsRecorder(numShards)
+	c := sturdyc.New[string](capacity, numShards, ttl, evictionPercentage,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithMetrics(metricsRecorder),
+	)
+
+	ch := make(chan struct{})
+	fetchFn := func(_ context.Context) (string, error) {
+		<-ch
+		return "value", nil
+	}
+	batchFn := func(_ context.Context, ids []string) (map[string]string, error) {
+		<-ch
+		res := make(map[string]string, len(ids))
+		for _, id := range ids {
+			res[id] = "value"
+		}
+		return res, nil
+	}
+
+	keyFn := c.BatchKeyFn("batch")
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		c.GetOrFetch(ctx, "key1", fetchFn)
+	}()
+	go func() {
+		defer wg.Done()
+		c.GetOrFetchBatch(ctx, []string{"1", "2"}, keyFn, batchFn)
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	// These requests should all get coalesced onto the calls that are already in flight.
+	wg.Add(4)
+	for i := 0; i < 3; i++ {
+		go func() {
+			defer wg.Done()
+			c.GetOrFetch(ctx, "key1", fetchFn)
+		}()
+	}
+	go func() {
+		defer wg.Done()
+		c.GetOrFetchBatch(ctx, []string{"1", "2"}, keyFn, batchFn)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	close(ch)
+	wg.Wait()
+
+	metricsRecorder.Lock()
+	defer metricsRecorder.Unlock()
+	if metricsRecorder.coalesced != 5 {
+		t.Errorf("expected 5 coalesced fetches; got %d", metricsRecorder.coalesced)
+	}
+}
+
+func TestOverlappingBatchesOnlyFetchTheDisjointIDs(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	capacity := 100
+	numShards := 10
+	ttl := time.Minute
+	evictionPercentage := 10
+	c := sturdyc.New[string](capacity, numShards, ttl, evictionPercentage,
+		sturdyc.WithNoContinuousEvictions(),
+	)
+
+	ch := make(chan struct{})
+	var mu sync.Mutex
+	requestedIDs := make([][]string, 0)
+	fetchFn := func(_ context.Context, ids []string) (map[string]string, error) {
+		mu.Lock()
+		requestedIDs = append(requestedIDs, ids)
+		mu.Unlock()
+		<-ch
+		res := make(map[string]string, len(ids))
+		for _, id := range ids {
+			res[id] = "value" + id
+		}
+		return res, nil
+	}
+
+	keyFn := c.BatchKeyFn("item")
+	firstBatch := []string{"1", "2", "3", "4", "5", "6", "7", "8", "9", "10"}
+	secondBatch := []string{"8", "9", "10", "11", "12"}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		c.GetOrFetchBatch(ctx, firstBatch, keyFn, fetchFn)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	go func() {
+		defer wg.Done()
+		res, err := c.GetOrFetchBatch(ctx, secondBatch, keyFn, fetchFn)
+		if err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+		for _, id := range secondBatch {
+			if res[id] != "value"+id {
+				t.Errorf("expected value%s, got %s", id, res[id])
+			}
+		}
+	}()
+	time.Sleep(50 * time.Millisecond)
+	close(ch)
+	wg.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(requestedIDs) != 2 {
+		t.Fatalf("expected 2 fetches, got %d", len(requestedIDs))
+	}
+	if !slices.Equal(requestedIDs[1], []string{"11", "12"}) {
+		t.Errorf("expected the second batch to only fetch 11 and 12, got %v", requestedIDs[1])
+	}
+}
+
+func TestSingleAndBatchFetchesAreCoalesced(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	c := sturdyc.New[string](100, 10, time.Minute, 10,
+		sturdyc.WithNoContinuousEvictions(),
+	)
+	keyFn := c.BatchKeyFn("item")
+
+	ch := make(chan struct{})
+	var singleCalls atomic.Int32
+	singleFetchFn := func(context.Context) (string, error) {
+		singleCalls.Add(1)
+		<-ch
+		return "value1", nil
+	}
+
+	var mu sync.Mutex
+	requestedIDs := make([][]string, 0)
+	batchFetchFn := func(_ context.Context, ids []string) (map[string]string, error) {
+		mu.Lock()
+		requestedIDs = append(requestedIDs, ids)
+		mu.Unlock()
+		<-ch
+		res := make(map[string]string, len(ids))
+		for _, id := range ids {
+			res[id] = "value" + id
+		}
+		return res, nil
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		res, err := c.GetOrFetch(ctx, keyFn("1"), singleFetchFn)
+		if err != nil || res != "value1" {
+			t.Errorf("expected value1, got %q: %v", res, err)
+		}
+	}()
+	time.Sleep(50 * time.Millisecond)
+	go func() {
+		defer wg.Done()
+		res, err := c.GetOrFetchBatch(ctx, []string{"1", "2", "3"}, keyFn, batchFetchFn)
+		if err != nil || len(res) != 3 {
+			t.Errorf("expected 3 records, got %v: %v", res, err)
+		}
+	}()
+	time.Sleep(50 * time.Millisecond)
+	go func() {
+		defer wg.Done()
+		res, err := c.GetOrFetch(ctx, keyFn("2"), singleFetchFn)
+		if err != nil || res != "value2" {
+			t.Errorf("expected the value of the batch, got %q: %v", res, err)
+		}
+	}()
+	time.Sleep(50 * time.Millisecond)
+	close(ch)
+	wg.Wait()
+
+	if calls := singleCalls.Load(); calls != 1 {
+		t.Errorf("expected 1 single fetch, got %d", calls)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(requestedIDs) != 1 || !slices.Equal(requestedIDs[0], []string{"2", "3"}) {
+		t.Errorf("expected the batch to only fetch 2 and 3, got %v", requestedIDs)
+	}
+}
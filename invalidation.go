@@ -0,0 +1,39 @@
+package sturdyc
+
+import (
+	"context"
+	"strings"
+)
+
+// InvalidationBus is used to tell the other instances that share the
+// distributed storage which keys have been deleted, so that they can remove
+// them from memory. It could, for example, be implemented using Redis Pub/Sub.
+type InvalidationBus interface {
+	// Publish sends the keys to every instance, including the one that published them.
+	Publish(ctx context.Context, keys []string)
+	// Subscribe registers the handler that should be called with the keys
+	// that are published by any of the instances.
+	Subscribe(handler func(keys []string))
+}
+
+// subscribeToInvalidations removes the keys that are deleted
+// by the other instances from the in-memory cache.
+func (c *Client[T]) subscribeToInvalidations() {
+	c.invalidationBus.Subscribe(func(keys []string) {
+		for _, key := range keys {
+			// The keys are prefixed so that services which share
+			// the bus don't invalidate each other's records.
+			if unprefixedKey, ok := strings.CutPrefix(key, c.distributedKeyPrefix); ok {
+				c.getShard(unprefixedKey).delete(unprefixedKey)
+			}
+		}
+	})
+}
+
+// publishInvalidation tells the other instances that the key has been deleted.
+func (c *Client[T]) publishInvalidation(ctx context.Context, key string) {
+	if c.invalidationBus == nil {
+		return
+	}
+	c.invalidationBus.Publish(ctx, []string{c.distributedKeyPrefix + key})
+}
@@ -0,0 +1,92 @@
+package sturdyc_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/viccon/sturdyc"
+)
+
+type mockInvalidationBus struct {
+	sync.Mutex
+	handlers []func(keys []string)
+}
+
+func (b *mockInvalidationBus) Publish(_ context.Context, keys []string) {
+	b.Lock()
+	handlers := b.handlers
+	b.Unlock()
+	for _, handler := range handlers {
+		handler(keys)
+	}
+}
+
+func (b *mockInvalidationBus) Subscribe(handler func(keys []string)) {
+	b.Lock()
+	defer b.Unlock()
+	b.handlers = append(b.handlers, handler)
+}
+
+func TestDeletesArePropagatedToEveryTier(t *testing.T) {
+	t.Parallel()
+
+	bus := &mockInvalidationBus{}
+	distributedStorage := &mockStorage{}
+	newClient := func(prefix string) *sturdyc.Client[string] {
+		return sturdyc.New[string](1000, 10, time.Hour, 30,
+			sturdyc.WithNoContinuousEvictions(),
+			sturdyc.WithDistributedStorageEarlyRefreshes(distributedStorage, time.Hour),
+			sturdyc.WithDistributedKeyPrefix(prefix),
+			sturdyc.WithInvalidationBus(bus),
+		)
+	}
+	instanceOne, instanceTwo, otherService := newClient("svc-a:"), newClient("svc-a:"), newClient("svc-b:")
+	for _, c := range []*sturdyc.Client[string]{instanceOne, instanceTwo, otherService} {
+		c.Set("1", "value1")
+	}
+	distributedStorage.Set(context.Background(), "svc-a:1", []byte("value1"))
+
+	instanceOne.Delete("1")
+	if _, ok := instanceTwo.Get("1"); ok {
+		t.Error("expected the key to have been deleted from the other instance")
+	}
+	if _, ok := otherService.Get("1"); !ok {
+		t.Error("expected the key of the other service to be left intact")
+	}
+	distributedStorage.assertDeleteCount(t, 1)
+	if distributedStorage.size() != 0 {
+		t.Error("expected the key to have been deleted from the distributed storage")
+	}
+}
+
+func TestRefreshesOnlyDeleteRecordsThatNoLongerExistLocally(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	bus := &mockInvalidationBus{}
+	distributedStorage := &mockStorage{}
+	newClient := func() *sturdyc.Client[string] {
+		return sturdyc.New[string](1000, 10, time.Hour, 30,
+			sturdyc.WithNoContinuousEvictions(),
+			sturdyc.WithDistributedStorageEarlyRefreshes(distributedStorage, time.Hour),
+			sturdyc.WithInvalidationBus(bus),
+		)
+	}
+	instanceOne, instanceTwo := newClient(), newClient()
+	instanceOne.Set("1", "value1")
+	instanceTwo.Set("1", "value1")
+
+	notFound := func(context.Context) (string, error) { return "", sturdyc.ErrNotFound }
+	if err := instanceOne.Refresh(ctx, "1", notFound); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if _, ok := instanceOne.Get("1"); ok {
+		t.Error("expected the key to have been deleted from the refreshing instance")
+	}
+	if _, ok := instanceTwo.Get("1"); !ok {
+		t.Error("expected the key to be left intact on the other instance")
+	}
+	distributedStorage.assertDeleteCount(t, 0)
+}
@@ -0,0 +1,128 @@
+package sturdyc
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	xxhash "github.com/cespare/xxhash/v2"
+)
+
+// keyBuilderField is a field of the struct that is part of the key.
+type keyBuilderField struct {
+	fieldOptions
+	index int
+}
+
+// KeyBuilder generates cache keys from the fields of a struct that have a
+// "sturdyc" tag. Fields without the tag are left out of the keys. The tag is
+// the same as the one that is used by PermutatedKey:
+//
+//	`sturdyc:"name"` includes the field as "name:value".
+//	`sturdyc:"name,omitempty"` leaves the field out when it holds its zero value.
+//	`sturdyc:"name,sorted"` sorts the values of a slice, which makes their order irrelevant.
+//
+// Each key includes a version, which is derived from the names and types of
+// the tagged fields. Changing the fields that are relevant to the key will
+// therefore bust the keys that were written by the previous version. The
+// version can also be bumped explicitly by adding a blank field with a version
+// tag to the struct:
+//
+//	_ struct{} `sturdyc:",version=2"`
+type KeyBuilder struct {
+	prefix  string
+	typ     reflect.Type
+	fields  []keyBuilderField
+	version string
+	format  func(v reflect.Value, sorted bool) string
+}
+
+// NewKeyBuilder creates a KeyBuilder for the type of the permutationStruct.
+// Passing anything but a struct, or a pointer to a struct, will result in a
+// panic. The values are formatted in the same way as they are for
+// PermutatedKey, which means that time.Time values respect the time key
+// options of the client.
+func (c *Client[T]) NewKeyBuilder(prefix string, permutationStruct any) *KeyBuilder {
+	typ := reflect.TypeOf(permutationStruct)
+	if typ != nil && typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ == nil || typ.Kind() != reflect.Struct {
+		panic("val must be a struct")
+	}
+
+	builder := &KeyBuilder{prefix: prefix, typ: typ, format: c.formatValue}
+	fingerprint := xxhash.New()
+	explicitVersion := ""
+	for i := 0; i < typ.NumField(); i++ {
+		structField := typ.Field(i)
+		if _, ok := structField.Tag.Lookup(keyTag); !ok {
+			continue
+		}
+
+		opts := parseFieldOptions(structField)
+		if opts.version != "" {
+			explicitVersion = opts.version
+		}
+
+		// The blank fields are only used to hold the version tag.
+		if opts.skip || structField.Name == "_" {
+			continue
+		}
+		if !structField.IsExported() {
+			panic(fmt.Sprintf("field %s must be exported to be part of the cache key", structField.Name))
+		}
+		if opts.name == "" {
+			opts.name = structField.Name
+		}
+		builder.fields = append(builder.fields, keyBuilderField{fieldOptions: opts, index: i})
+		fmt.Fprintf(fingerprint, "%s:%s:%t:%t;", opts.name, structField.Type.String(), opts.omitempty, opts.sorted)
+	}
+
+	fmt.Fprintf(fingerprint, "version:%s", explicitVersion)
+	builder.version = strconv.FormatUint(fingerprint.Sum64()&0xffffffff, 36)
+	return builder
+}
+
+// Version returns the version that is included in the keys.
+func (b *KeyBuilder) Version() string {
+	return b.version
+}
+
+// Key returns the cache key for the permutationStruct, which has to be of the
+// same type as the struct that the KeyBuilder was created with.
+func (b *KeyBuilder) Key(permutationStruct any) string {
+	v := reflect.ValueOf(permutationStruct)
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	if v.Type() != b.typ {
+		panic(fmt.Sprintf("expected a value of type %s, got %s", b.typ, v.Type()))
+	}
+
+	var sb strings.Builder
+	sb.WriteString(b.prefix)
+	sb.WriteString("-v")
+	sb.WriteString(b.version)
+	for _, field := range b.fields {
+		value := v.Field(field.index)
+		if field.omitempty && value.IsZero() {
+			continue
+		}
+		sb.WriteString("-")
+		sb.WriteString(field.name)
+		sb.WriteString(":")
+		sb.WriteString(b.format(value, field.sorted))
+	}
+	return sb.String()
+}
+
+// BatchKeyFn returns a KeyFn that appends the ID to the key of the
+// permutationStruct. It can be used in conjunction with GetOrFetchBatch.
+func (b *KeyBuilder) BatchKeyFn(permutationStruct any) KeyFn {
+	key := b.Key(permutationStruct)
+	return func(id string) string {
+		return fmt.Sprintf("%s-ID-%s", key, id)
+	}
+}
@@ -0,0 +1,107 @@
+package sturdyc_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/viccon/sturdyc"
+)
+
+func TestKeyBuilderUsesTheTaggedFields(t *testing.T) {
+	t.Parallel()
+
+	c := sturdyc.New[any](100, 1, time.Hour, 5,
+		sturdyc.WithNoContinuousEvictions(),
+	)
+
+	type queryParams struct {
+		Country string   `sturdyc:"country"`
+		Tags    []string `sturdyc:"tags,omitempty,sorted"`
+		Limit   int      `sturdyc:",omitempty"`
+		TraceID string
+	}
+
+	builder := c.NewKeyBuilder("products", queryParams{})
+	version := builder.Version()
+
+	got := builder.Key(queryParams{Country: "se", TraceID: "abc"})
+	want := "products-v" + version + "-country:se"
+	if got != want {
+		t.Errorf("got: %s wanted: %s", got, want)
+	}
+
+	got = builder.Key(&queryParams{Country: "se", Tags: []string{"b", "a"}, Limit: 10})
+	want = "products-v" + version + "-country:se-tags:a,b-Limit:10"
+	if got != want {
+		t.Errorf("got: %s wanted: %s", got, want)
+	}
+
+	keyFn := builder.BatchKeyFn(queryParams{Country: "no"})
+	want = "products-v" + version + "-country:no-ID-1"
+	if got := keyFn("1"); got != want {
+		t.Errorf("got: %s wanted: %s", got, want)
+	}
+}
+
+func TestKeyBuilderVersionChangesWithTheFields(t *testing.T) {
+	t.Parallel()
+
+	c := sturdyc.New[any](100, 1, time.Hour, 5,
+		sturdyc.WithNoContinuousEvictions(),
+	)
+
+	type v1 struct {
+		Country string `sturdyc:"country"`
+		TraceID string
+	}
+	type v1WithUntaggedField struct {
+		Country string `sturdyc:"country"`
+		TraceID string
+		Locale  string
+	}
+	type v2 struct {
+		Country string `sturdyc:"country"`
+		Locale  string `sturdyc:"locale"`
+	}
+	type v3 struct {
+		_       struct{} `sturdyc:",version=3"`
+		Country string   `sturdyc:"country"`
+		TraceID string
+	}
+
+	versionOne := c.NewKeyBuilder("key", v1{}).Version()
+	if got := c.NewKeyBuilder("key", v1WithUntaggedField{}).Version(); got != versionOne {
+		t.Errorf("expected untagged fields to leave the version unchanged, got %s and %s", versionOne, got)
+	}
+	if got := c.NewKeyBuilder("key", v2{}).Version(); got == versionOne {
+		t.Error("expected a new tagged field to change the version")
+	}
+	if got := c.NewKeyBuilder("key", v3{}).Version(); got == versionOne {
+		t.Error("expected the version tag to change the version")
+	}
+}
+
+func TestKeyBuilderPanicsForOtherTypes(t *testing.T) {
+	t.Parallel()
+
+	c := sturdyc.New[any](100, 1, time.Hour, 5,
+		sturdyc.WithNoContinuousEvictions(),
+	)
+
+	type first struct {
+		ID string `sturdyc:"id"`
+	}
+	type second struct {
+		ID string `sturdyc:"id"`
+	}
+
+	builder := c.NewKeyBuilder("key", first{})
+	defer func() {
+		err := recover()
+		if err == nil || !strings.Contains(err.(string), "expected a value of type") {
+			t.Errorf("expected a panic when the key is built from another type, got %v", err)
+		}
+	}()
+	builder.Key(second{})
+}
@@ -3,26 +3,68 @@ package sturdyc
 import (
 	"fmt"
 	"reflect"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 )
 
-func handleSlice(v reflect.Value) string {
-	// If the value is a pointer to a slice, get the actual slice
-	if v.Kind() == reflect.Ptr {
-		v = v.Elem()
+// keyTag is the struct tag that configures how the fields of a struct are
+// included in the keys of PermutatedKey, PermutatedBatchKeyFn and KeyBuilder.
+const keyTag = "sturdyc"
+
+// fieldOptions holds the parsed struct tag of a field.
+type fieldOptions struct {
+	name      string
+	skip      bool
+	sorted    bool
+	omitempty bool
+	// version is only used by the KeyBuilder.
+	version string
+}
+
+// parseFieldOptions parses tags such as `sturdyc:"-"` or `sturdyc:"name,sorted,omitempty"`.
+func parseFieldOptions(field reflect.StructField) fieldOptions {
+	tag, ok := field.Tag.Lookup(keyTag)
+	if !ok {
+		return fieldOptions{}
 	}
+	if tag == "-" {
+		return fieldOptions{skip: true}
+	}
+	name, flags, _ := strings.Cut(tag, ",")
+	opts := fieldOptions{name: name}
+	for _, flag := range strings.Split(flags, ",") {
+		switch {
+		case flag == "sorted":
+			opts.sorted = true
+		case flag == "omitempty":
+			opts.omitempty = true
+		case strings.HasPrefix(flag, "version="):
+			opts.version = strings.TrimPrefix(flag, "version=")
+		}
+	}
+	return opts
+}
 
+func handleSlice(v reflect.Value, sorted bool) string {
 	if v.Len() < 1 {
 		return "empty"
 	}
 
-	var sliceStrings []string
+	// The elements are formatted with fmt, rather than formatValue,
+	// to keep the keys of existing slice fields, e.g. []time.Time.
+	sliceStrings := make([]string, 0, v.Len())
 	for i := 0; i < v.Len(); i++ {
 		sliceStrings = append(sliceStrings, fmt.Sprintf("%v", v.Index(i).Interface()))
 	}
 
+	// Sorted slices produce the same key for the
+	// same set of values regardless of their order.
+	if sorted {
+		slices.Sort(sliceStrings)
+	}
+
 	return strings.Join(sliceStrings, ",")
 }
 
@@ -66,6 +108,9 @@ func (c *Client[T]) relativeTime(t time.Time) string {
 func (c *Client[T]) handleTime(v reflect.Value) string {
 	if timestamp, ok := v.Interface().(time.Time); ok {
 		if !timestamp.IsZero() {
+			if c.timeKeyFormatter != nil {
+				return c.timeKeyFormatter(c.clock.Now(), timestamp)
+			}
 			if c.useRelativeTimeKeyFormat {
 				return c.relativeTime(timestamp)
 			}
@@ -79,9 +124,17 @@ func (c *Client[T]) handleTime(v reflect.Value) string {
 // PermutatedKey takes a prefix and a struct where the fields are concatenated
 // in order to create a unique cache key. Passing anything but a struct for
 // "permutationStruct" will result in a panic. The cache will only use the
-// EXPORTED fields of the struct to construct the key. The permutation struct
-// should be FLAT, with no nested structs. The fields can be any of the basic
-// types, as well as slices and time.Time values.
+// EXPORTED fields of the struct to construct the key. The fields can be any
+// of the basic types, as well as slices, maps, nested structs, time.Time
+// values and types that implement fmt.Stringer. Maps are ordered by their
+// keys so that the keys are deterministic, while slices keep their order.
+//
+// The fields can be configured with the "sturdyc" struct tag:
+//
+//	`sturdyc:"-"` excludes the field from the key.
+//	`sturdyc:"name"` prefixes the value with the name, e.g. "name:value".
+//	`sturdyc:",sorted"` sorts the values of the slice, which makes the order irrelevant to the key.
+//	`sturdyc:",omitempty"` leaves the field out when it holds its zero value.
 //
 // Parameters:
 //
@@ -93,10 +146,6 @@ func (c *Client[T]) handleTime(v reflect.Value) string {
 //
 //	A string to be used as the cache key.
 func (c *Client[T]) PermutatedKey(prefix string, permutationStruct interface{}) string {
-	var sb strings.Builder
-	sb.WriteString(prefix)
-	sb.WriteString("-")
-
 	// Get the value of the interface
 	v := reflect.ValueOf(permutationStruct)
 
@@ -108,55 +157,82 @@ func (c *Client[T]) PermutatedKey(prefix string, permutationStruct interface{})
 		panic("val must be a struct")
 	}
 
+	return prefix + "-" + c.handleStruct(v, "-")
+}
+
+// handleStruct concatenates the exported fields of the struct using the separator.
+func (c *Client[T]) handleStruct(v reflect.Value, separator string) string {
+	var sb strings.Builder
+	wrote := false
 	for i := 0; i < v.NumField(); i++ {
 		field := v.Field(i)
 
 		// Check if the field is exported, and if so skip it.
 		if !field.CanInterface() {
-			message := fmt.Sprintf(
-				"sturdyc: permutationStruct contains unexported field: %s which won't be part of the cache key",
-				v.Type().Field(i).Name,
+			c.logger(LogCache).Warn(
+				"sturdyc: permutationStruct contains an unexported field which won't be part of the cache key",
+				"field", v.Type().Field(i).Name,
 			)
-			c.log.Warn(message)
 			continue
 		}
 
-		if i > 0 {
-			sb.WriteString("-")
+		opts := parseFieldOptions(v.Type().Field(i))
+		if opts.skip || (opts.omitempty && field.IsZero()) {
+			continue
 		}
 
-		if field.Kind() == reflect.Ptr {
-			if field.IsNil() {
-				sb.WriteString("nil")
-				continue
-			}
-			// If it's not nil we'll dereference the pointer to handle its value.
-			field = field.Elem()
+		// The fields that were skipped mustn't leave a separator behind.
+		if wrote {
+			sb.WriteString(separator)
 		}
+		wrote = true
 
-		//nolint:exhaustive // We only need special logic for slices and time.Time values.
-		switch field.Kind() {
-		case reflect.Slice:
-			if field.IsNil() {
-				sb.WriteString("nil")
-			} else {
-				sliceString := handleSlice(field)
-				sb.WriteString(sliceString)
-			}
-		case reflect.Struct:
-			if field.Type() == reflect.TypeOf(time.Time{}) {
-				sb.WriteString(c.handleTime(field))
-				continue
-			}
-			sb.WriteString(fmt.Sprintf("%v", field.Interface()))
-		default:
-			sb.WriteString(fmt.Sprintf("%v", field.Interface()))
+		if opts.name != "" {
+			sb.WriteString(opts.name)
+			sb.WriteString(":")
 		}
+		sb.WriteString(c.formatValue(field, opts.sorted))
 	}
 
 	return sb.String()
 }
 
+// formatValue turns a single value into its part of the cache key.
+func (c *Client[T]) formatValue(v reflect.Value, sorted bool) string {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() && v.Kind() == reflect.Interface {
+			return "<nil>"
+		}
+		if v.IsNil() {
+			return "nil"
+		}
+		// If it's not nil we'll dereference the pointer to handle its value.
+		v = v.Elem()
+	}
+
+	if v.Type() == reflect.TypeOf(time.Time{}) {
+		return c.handleTime(v)
+	}
+
+	if stringer, ok := v.Interface().(fmt.Stringer); ok {
+		return stringer.String()
+	}
+
+	//nolint:exhaustive // We only need special logic for slices and structs. Maps are ordered by fmt.
+	switch v.Kind() {
+	case reflect.Slice:
+		if v.IsNil() {
+			return "nil"
+		}
+		return handleSlice(v, sorted)
+	case reflect.Struct:
+		// Nested structs are formatted like fmt does, e.g. "{1 2}".
+		return "{" + c.handleStruct(v, " ") + "}"
+	default:
+		return fmt.Sprintf("%v", v.Interface())
+	}
+}
+
 // BatchKeyFn provides a function that can be used in conjunction with
 // "GetOrFetchBatch". It takes in a prefix and returns a function that will
 // append the ID as a suffix for each item.
@@ -178,9 +254,8 @@ func (c *Client[T]) BatchKeyFn(prefix string) KeyFn {
 // with GetOrFetchBatch. It takes a prefix and a struct where the fields are
 // concatenated with the ID in order to make a unique cache key. Passing
 // anything but a struct for "permutationStruct" will result in a panic. The
-// cache will only use the EXPORTED fields of the struct to construct the key.
-// The permutation struct should be FLAT, with no nested structs. The fields
-// can be any of the basic types, as well as slices and time.Time values.
+// cache will only use the EXPORTED fields of the struct to construct the key,
+// which are formatted and tagged in the same way as they are for PermutatedKey.
 //
 // Parameters:
 //
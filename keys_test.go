@@ -73,6 +73,43 @@ func TestTimeBasedKeys(t *testing.T) {
 	}
 }
 
+func TestTimeKeyFormatter(t *testing.T) {
+	t.Parallel()
+
+	clock := sturdyc.NewTestClock(time.Date(2024, 3, 4, 10, 7, 0, 0, time.UTC))
+	c := sturdyc.New[any](100, 1, time.Hour, 5,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithRelativeTimeKeyFormat(time.Minute),
+		sturdyc.WithTimeKeyFormatter(func(now, t time.Time) string {
+			// Bucket the values into 15-minute windows relative to the day of the clock.
+			days := int(t.Truncate(24*time.Hour).Sub(now.Truncate(24*time.Hour)).Hours() / 24)
+			return strconv.Itoa(days) + "d" + t.Truncate(15*time.Minute).Format("15:04")
+		}),
+		sturdyc.WithClock(clock),
+	)
+
+	type opts struct {
+		From time.Time
+		To   time.Time
+	}
+
+	now := clock.Now()
+	keyOne := c.PermutatedKey("keyPrefix", opts{From: now.Add(time.Minute), To: now.Add(24 * time.Hour)})
+	keyTwo := c.PermutatedKey("keyPrefix", opts{From: now.Add(5 * time.Minute), To: now.Add(24*time.Hour + 7*time.Minute)})
+	wantKey := "keyPrefix-0d10:00-1d10:00"
+	if keyOne != wantKey {
+		t.Errorf("got: %s wanted: %s", keyOne, wantKey)
+	}
+	if keyTwo != wantKey {
+		t.Errorf("got: %s wanted: %s", keyTwo, wantKey)
+	}
+
+	zeroKey := c.PermutatedKey("keyPrefix", opts{})
+	if zeroKey != "keyPrefix-empty-time-empty-time" {
+		t.Errorf("got: %s wanted: %s", zeroKey, "keyPrefix-empty-time-empty-time")
+	}
+}
+
 func TestPermutatedRelativeTimeKeys(t *testing.T) {
 	t.Parallel()
 
@@ -221,3 +258,160 @@ func TestTimePointers(t *testing.T) {
 		t.Errorf("got: %s wanted: %s", got, want)
 	}
 }
+
+type sortOrder int
+
+func (s sortOrder) String() string {
+	if s == 0 {
+		return "asc"
+	}
+	return "desc"
+}
+
+func TestPermutatedKeyHandlesCompositeValues(t *testing.T) {
+	t.Parallel()
+
+	c := sturdyc.New[any](100, 1, time.Hour, 5,
+		sturdyc.WithNoContinuousEvictions(),
+	)
+
+	type pagination struct {
+		Limit  int
+		Offset int
+	}
+
+	type queryParams struct {
+		IDs        []int `sturdyc:",sorted"`
+		Columns    []string
+		Filters    map[string]string
+		Pagination pagination
+		Order      sortOrder `sturdyc:"order"`
+		TraceID    string    `sturdyc:"-"`
+		Metadata   map[string]int
+	}
+
+	queryOne := queryParams{
+		IDs:        []int{3, 1, 2},
+		Columns:    []string{"name", "age"},
+		Filters:    map[string]string{"country": "se", "active": "true"},
+		Pagination: pagination{Limit: 10, Offset: 20},
+		Order:      1,
+		TraceID:    "abc",
+	}
+	queryTwo := queryParams{
+		IDs:        []int{1, 2, 3},
+		Columns:    []string{"name", "age"},
+		Filters:    map[string]string{"active": "true", "country": "se"},
+		Pagination: pagination{Limit: 10, Offset: 20},
+		Order:      1,
+		TraceID:    "def",
+	}
+
+	want := "key-1,2,3-name,age-map[active:true country:se]-{10 20}-order:desc-map[]"
+	if got := c.PermutatedKey("key", queryOne); got != want {
+		t.Errorf("got: %s wanted: %s", got, want)
+	}
+	if got := c.PermutatedKey("key", queryTwo); got != want {
+		t.Errorf("got: %s wanted: %s", got, want)
+	}
+
+	// The order of the slices without the sorted tag is part of the key.
+	queryTwo.Columns = []string{"age", "name"}
+	if got := c.PermutatedKey("key", queryTwo); got == want {
+		t.Errorf("expected the order of the columns to change the key, got: %s", got)
+	}
+}
+
+func TestPermutatedKeyKeepsTheFormatOfUntaggedFields(t *testing.T) {
+	t.Parallel()
+
+	c := sturdyc.New[any](100, 1, time.Hour, 5,
+		sturdyc.WithNoContinuousEvictions(),
+	)
+
+	type pagination struct {
+		Limit  int
+		Offset int
+	}
+
+	type queryParams struct {
+		unexported int
+		OrderBy    []string
+		Pagination pagination
+		Counts     map[int]string
+		Any        any
+	}
+
+	params := queryParams{
+		OrderBy:    []string{"name", "age"},
+		Pagination: pagination{Limit: 10, Offset: 20},
+		Counts:     map[int]string{10: "a", 2: "b"},
+	}
+	want := "key-name,age-{10 20}-map[2:b 10:a]-<nil>"
+	if got := c.PermutatedKey("key", params); got != want {
+		t.Errorf("got: %s wanted: %s", got, want)
+	}
+}
+
+func TestPermutatedKeyDoesNotSeparateFieldsThatWereSkipped(t *testing.T) {
+	t.Parallel()
+
+	c := sturdyc.New[any](100, 1, time.Hour, 5,
+		sturdyc.WithNoContinuousEvictions(),
+	)
+
+	type pagination struct {
+		Cursor string `sturdyc:"-"`
+		Limit  int
+	}
+
+	type queryParams struct {
+		Internal   string `sturdyc:"-"`
+		Locale     string `sturdyc:"locale,omitempty"`
+		B          string
+		Pagination pagination
+	}
+
+	params := queryParams{B: "b", Pagination: pagination{Cursor: "abc", Limit: 10}}
+	if got, want := c.PermutatedKey("prefix", params), "prefix-b-{10}"; got != want {
+		t.Errorf("got: %s wanted: %s", got, want)
+	}
+}
+
+func TestPermutatedKeyKeepsTheFormatOfSliceElements(t *testing.T) {
+	t.Parallel()
+
+	c := sturdyc.New[any](100, 1, time.Hour, 5,
+		sturdyc.WithNoContinuousEvictions(),
+	)
+
+	type queryParams struct {
+		Dates []time.Time
+	}
+
+	date := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	params := queryParams{Dates: []time.Time{date}}
+	if got, want := c.PermutatedKey("key", params), "key-"+date.String(); got != want {
+		t.Errorf("got: %s wanted: %s", got, want)
+	}
+}
+
+func TestPermutatedKeyOmitsEmptyFields(t *testing.T) {
+	t.Parallel()
+
+	c := sturdyc.New[any](100, 1, time.Hour, 5,
+		sturdyc.WithNoContinuousEvictions(),
+	)
+
+	type queryParams struct {
+		Country string `sturdyc:"country"`
+		Locale  string `sturdyc:"locale,omitempty"`
+	}
+
+	if got, want := c.PermutatedKey("key", queryParams{Country: "se"}), "key-country:se"; got != want {
+		t.Errorf("got: %s wanted: %s", got, want)
+	}
+	if got, want := c.PermutatedKey("key", queryParams{Country: "se", Locale: "sv"}), "key-country:se-locale:sv"; got != want {
+		t.Errorf("got: %s wanted: %s", got, want)
+	}
+}
@@ -0,0 +1,66 @@
+package sturdyc
+
+import (
+	"math/rand/v2"
+	"sync"
+)
+
+// paddedRWMutex is padded to the size of a cache line so that the
+// stripes of a stripedRWMutex don't end up sharing one.
+type paddedRWMutex struct {
+	sync.RWMutex
+	_ [40]byte
+}
+
+// stripedRWMutex is a read-write mutex where readers only have to acquire one
+// of the stripes, while writers have to acquire all of them. This spreads the
+// reader count across multiple cache lines, which reduces the contention on
+// shards that are being read from by a lot of goroutines at once.
+type stripedRWMutex struct {
+	stripes []paddedRWMutex
+}
+
+func newStripedRWMutex(numStripes int) stripedRWMutex {
+	return stripedRWMutex{stripes: make([]paddedRWMutex, numStripes)}
+}
+
+// Lock acquires the write lock by locking every stripe.
+func (m *stripedRWMutex) Lock() {
+	for i := range m.stripes {
+		m.stripes[i].Lock()
+	}
+}
+
+// Unlock releases every stripe.
+func (m *stripedRWMutex) Unlock() {
+	for i := range m.stripes {
+		m.stripes[i].Unlock()
+	}
+}
+
+// RLock acquires a read lock on the first stripe. It should be used for
+// operations that are performed infrequently, such as scanning the keys.
+func (m *stripedRWMutex) RLock() {
+	m.stripes[0].RLock()
+}
+
+// RUnlock releases the read lock that was acquired by RLock.
+func (m *stripedRWMutex) RUnlock() {
+	m.stripes[0].RUnlock()
+}
+
+// rlockStripe acquires a read lock on a random stripe. The returned
+// index has to be passed to runlockStripe to release the lock.
+func (m *stripedRWMutex) rlockStripe() int {
+	var stripe int
+	if len(m.stripes) > 1 {
+		stripe = rand.IntN(len(m.stripes))
+	}
+	m.stripes[stripe].RLock()
+	return stripe
+}
+
+// runlockStripe releases the read lock that was acquired by rlockStripe.
+func (m *stripedRWMutex) runlockStripe(stripe int) {
+	m.stripes[stripe].RUnlock()
+}
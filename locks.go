@@ -0,0 +1,87 @@
+package sturdyc
+
+import (
+	"context"
+	"time"
+)
+
+// distributedLockPolls is the number of times that we check the distributed
+// storage for the record while we wait for the instance that holds the lock.
+const distributedLockPolls = 10
+
+// DistributedLocker is used to make sure that only one instance in the fleet
+// calls the underlying data source for a given key at a time. The locks are
+// expected to be released after the ttl, even if unlock is never called.
+type DistributedLocker interface {
+	// TryLock attempts to acquire the lock for the key without waiting for it.
+	// It returns a function that releases the lock if it was acquired.
+	TryLock(ctx context.Context, key string, ttl time.Duration) (unlock func(), acquired bool)
+}
+
+// lockedFetch calls the fetchFn while holding the lease for the key, and
+// writes the result to the distributed storage before the lease is released.
+// If another instance holds the lease, the stale record from the distributed
+// storage is served, or ErrNotFound if the stale record is a missing record. Without a stale record, we'll wait for the lease holder
+// to fill the distributed storage, rather than calling the data source again.
+// Should the lease be released without the record having been written, we'll
+// try to take it over. If neither happens before the wait is over, we call the
+// fetchFn ourselves. The boolean reports whether the distributed
+// storage is already up to date with the response.
+func lockedFetch[V, T any](ctx context.Context, c *Client[T], key string, fetchFn FetchFn[V], stale distributedRecord[V], hasStale bool, opts callOptions) (V, bool, error) {
+	if c.distributedLocker == nil {
+		response, err := fetchFn(ctx)
+		return response, false, err
+	}
+
+	fill := func(unlock func()) (V, bool, error) {
+		defer unlock()
+		response, err := fetchFn(ctx)
+		fillDistributedStorage(c, key, response, err, hasStale, opts)
+		return response, true, err
+	}
+
+	lockKey := c.distributedKeyPrefix + key
+	if unlock, acquired := c.distributedLocker.TryLock(ctx, lockKey, c.distributedLockTTL); acquired {
+		return fill(unlock)
+	}
+
+	if hasStale {
+		c.reportDistributedStaleFallback()
+		if stale.IsMissingRecord {
+			return stale.Value, true, ErrNotFound
+		}
+		return stale.Value, true, nil
+	}
+
+	timeout, stopTimeout := c.clock.NewTimer(c.distributedLockWait)
+	defer stopTimeout()
+	poll, stopPoll := c.clock.NewTicker(c.distributedLockWait / distributedLockPolls)
+	defer stopPoll()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return *new(V), true, ctx.Err()
+		case <-timeout:
+			response, err := fetchFn(ctx)
+			return response, false, err
+		case <-poll:
+			bytes, ok := c.distributedStorage.Get(ctx, key)
+			if !ok {
+				if unlock, acquired := c.distributedLocker.TryLock(ctx, lockKey, c.distributedLockTTL); acquired {
+					return fill(unlock)
+				}
+				continue
+			}
+			record, unmarshalErr := unmarshalRecord[V](bytes, key, c)
+			if unmarshalErr != nil {
+				response, err := fetchFn(ctx)
+				return response, false, err
+			}
+			if record.IsMissingRecord {
+				return record.Value, true, ErrNotFound
+			}
+			return record.Value, true, nil
+		}
+	}
+}
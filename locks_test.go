@@ -0,0 +1,223 @@
+package sturdyc_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/viccon/sturdyc"
+)
+
+type mockLocker struct {
+	sync.Mutex
+	locks map[string]bool
+}
+
+func newMockLocker() *mockLocker {
+	return &mockLocker{locks: make(map[string]bool)}
+}
+
+func (l *mockLocker) TryLock(_ context.Context, key string, _ time.Duration) (func(), bool) {
+	l.Lock()
+	defer l.Unlock()
+	if l.locks[key] {
+		return nil, false
+	}
+	l.locks[key] = true
+	return func() {
+		l.Lock()
+		defer l.Unlock()
+		delete(l.locks, key)
+	}, true
+}
+
+func (l *mockLocker) isLocked(key string) bool {
+	l.Lock()
+	defer l.Unlock()
+	return l.locks[key]
+}
+
+func TestDistributedLockIsReleasedAfterTheRecordHasBeenWritten(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	distributedStorage := &mockStorage{}
+	locker := newMockLocker()
+	c := sturdyc.New[string](1000, 10, time.Hour, 30,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithDistributedStorage(distributedStorage),
+		sturdyc.WithDistributedLocks(locker, time.Minute, time.Second),
+	)
+
+	fetchFn := func(_ context.Context) (string, error) {
+		if !locker.isLocked("1") {
+			t.Error("expected the lock to be held during the fetch")
+		}
+		return "value1", nil
+	}
+	res, err := sturdyc.GetOrFetch(ctx, c, "1", fetchFn)
+	if err != nil || res != "value1" {
+		t.Fatalf("expected value1, got %q %v", res, err)
+	}
+	if locker.isLocked("1") {
+		t.Error("expected the lock to have been released")
+	}
+	// The lock holder should have written the record before releasing the lock.
+	distributedStorage.assertRecord(t, "1")
+}
+
+func TestDistributedLockWaitsForTheRecordOfTheLockHolder(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	clock := sturdyc.NewTestClock(time.Now())
+	distributedStorage := &mockStorage{}
+	locker := newMockLocker()
+	c := sturdyc.New[string](1000, 10, time.Hour, 30,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithClock(clock),
+		sturdyc.WithDistributedStorage(distributedStorage),
+		sturdyc.WithDistributedLocks(locker, time.Minute, time.Hour),
+	)
+
+	// Another instance is fetching the key.
+	locker.TryLock(ctx, "1", time.Minute)
+	fetchFn := func(_ context.Context) (string, error) {
+		t.Error("expected the value to be read from the distributed storage")
+		return "", nil
+	}
+
+	results := make(chan string)
+	go func() {
+		res, _ := sturdyc.GetOrFetch(ctx, c, "1", fetchFn)
+		results <- res
+	}()
+
+	// The other instance writes the record to the distributed storage.
+	time.Sleep(10 * time.Millisecond)
+	record := `{"created_at":"` + clock.Now().Format(time.RFC3339Nano) + `","value":"value1","is_missing_record":false}`
+	distributedStorage.Set(ctx, "1", []byte(record))
+
+	if res := advanceUntil(clock, results); res != "value1" {
+		t.Errorf("expected value1, got %q", res)
+	}
+}
+
+func TestDistributedLockFetchesOnceTheWaitIsOver(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	clock := sturdyc.NewTestClock(time.Now())
+	locker := newMockLocker()
+	c := sturdyc.New[string](1000, 10, time.Hour, 30,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithClock(clock),
+		sturdyc.WithDistributedStorage(&mockStorage{}),
+		sturdyc.WithDistributedLocks(locker, time.Minute, 10*time.Second),
+	)
+	fetchObserver := NewFetchObserver(1)
+	fetchObserver.Response("1")
+
+	// Another instance holds the lock, but never writes the record.
+	locker.TryLock(ctx, "1", time.Minute)
+	results := make(chan string)
+	go func() {
+		res, _ := sturdyc.GetOrFetch(ctx, c, "1", fetchObserver.Fetch)
+		results <- res
+	}()
+
+	if res := advanceUntil(clock, results); res != "value1" {
+		t.Errorf("expected value1, got %q", res)
+	}
+	fetchObserver.AssertFetchCount(t, 1)
+}
+
+func TestDistributedLockIsTakenOverIfTheRecordIsNeverWritten(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	clock := sturdyc.NewTestClock(time.Now())
+	distributedStorage := &mockStorage{}
+	locker := newMockLocker()
+	c := sturdyc.New[string](1000, 10, time.Hour, 30,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithClock(clock),
+		sturdyc.WithDistributedStorage(distributedStorage),
+		sturdyc.WithDistributedLocks(locker, time.Minute, time.Hour),
+	)
+	fetchObserver := NewFetchObserver(1)
+	fetchObserver.Response("1")
+
+	unlock, _ := locker.TryLock(ctx, "1", time.Minute)
+	results := make(chan string)
+	go func() {
+		res, _ := sturdyc.GetOrFetch(ctx, c, "1", fetchObserver.Fetch)
+		results <- res
+	}()
+
+	// The other instance fails, and releases the lock without writing the record.
+	time.Sleep(10 * time.Millisecond)
+	unlock()
+
+	// The lock should be taken over on one of the polls, long before the wait is over.
+	for i := 0; i < 9; i++ {
+		clock.Add(6 * time.Minute)
+		select {
+		case res := <-results:
+			if res != "value1" {
+				t.Errorf("expected value1, got %q", res)
+			}
+			fetchObserver.AssertFetchCount(t, 1)
+			distributedStorage.assertRecord(t, "1")
+			return
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	t.Fatal("expected the lock to be taken over")
+}
+
+func TestDistributedLockServesStaleMissingRecordsAsNotFound(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	clock := sturdyc.NewTestClock(time.Now())
+	distributedStorage := &mockStorage{}
+	locker := newMockLocker()
+	c := sturdyc.New[string](1000, 10, time.Hour, 30,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithClock(clock),
+		sturdyc.WithDistributedStorageEarlyRefreshes(distributedStorage, time.Minute),
+		sturdyc.WithDistributedLocks(locker, time.Minute, time.Hour),
+	)
+
+	// The distributed storage holds a missing record that is due for a refresh.
+	record := `{"created_at":"` + clock.Now().Add(-2*time.Minute).Format(time.RFC3339Nano) + `","value":"","is_missing_record":true}`
+	distributedStorage.Set(ctx, "1", []byte(record))
+	distributedStorage.Set(ctx, "2", []byte(record))
+
+	// Another instance is refreshing the first key, so the stale record is served.
+	locker.TryLock(ctx, "1", time.Minute)
+	fetchFn := func(_ context.Context) (string, error) {
+		t.Error("expected the stale record to be served")
+		return "", nil
+	}
+	if _, err := sturdyc.GetOrFetch(ctx, c, "1", fetchFn); !errors.Is(err, sturdyc.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+	if _, ok := c.Get("1"); ok {
+		t.Error("expected the missing record to not be cached as a value")
+	}
+
+	// The data source fails for the second key, so the stale record is served.
+	failingFetchFn := func(_ context.Context) (string, error) {
+		return "", errors.New("unavailable")
+	}
+	if _, err := sturdyc.GetOrFetch(ctx, c, "2", failingFetchFn); !errors.Is(err, sturdyc.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+	if _, ok := c.Get("2"); ok {
+		t.Error("expected the missing record to not be cached as a value")
+	}
+}
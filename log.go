@@ -1,11 +1,158 @@
 package sturdyc
 
+import (
+	"context"
+	"log/slog"
+)
+
 type Logger interface {
 	Warn(msg string, args ...any)
 	Error(msg string, args ...any)
 }
 
+// LeveledLogger is implemented by the loggers that also accept debug and info
+// messages, such as *slog.Logger. Loggers that only implement Logger never
+// receive the messages below the warning level.
+type LeveledLogger interface {
+	Logger
+	Debug(msg string, args ...any)
+	Info(msg string, args ...any)
+}
+
+// ContextLogger is implemented by the loggers that accept the context of the
+// request, such as *slog.Logger. The messages that are logged on behalf of a
+// request, such as those of client.GetCtx and client.SetCtx, are passed the
+// context so that the handler can pick up values such as trace IDs.
+type ContextLogger interface {
+	DebugContext(ctx context.Context, msg string, args ...any)
+	InfoContext(ctx context.Context, msg string, args ...any)
+	WarnContext(ctx context.Context, msg string, args ...any)
+	ErrorContext(ctx context.Context, msg string, args ...any)
+}
+
 type NoopLogger struct{}
 
+func (l *NoopLogger) Debug(_ string, _ ...any) {}
+func (l *NoopLogger) Info(_ string, _ ...any)  {}
 func (l *NoopLogger) Warn(_ string, _ ...any)  {}
 func (l *NoopLogger) Error(_ string, _ ...any) {}
+
+// LogSubsystem identifies the part of the cache that a message was logged by.
+// Every message includes it as the "subsystem" attribute.
+type LogSubsystem string
+
+const (
+	// LogCache is used for the messages that don't belong to any of the other subsystems.
+	LogCache LogSubsystem = "cache"
+	// LogEvictions is used for the evictions of the shards.
+	LogEvictions LogSubsystem = "evictions"
+	// LogFetches is used for the calls to the underlying data source.
+	LogFetches LogSubsystem = "fetches"
+	// LogRefreshes is used for the background refreshes.
+	LogRefreshes LogSubsystem = "refreshes"
+	// LogDistributed is used for the interactions with the distributed storage.
+	LogDistributed LogSubsystem = "distributed"
+)
+
+// defaultLogLevel keeps the cache from logging anything but warnings and
+// errors for the subsystems that haven't been given a level.
+const defaultLogLevel = slog.LevelWarn
+
+// subsystemLogger drops the messages that are below the level of the
+// subsystem, and adds the subsystem to the attributes of the others.
+type subsystemLogger struct {
+	log       Logger
+	name      string
+	subsystem LogSubsystem
+	level     slog.Level
+	ctx       context.Context
+}
+
+// logger returns the logger of the subsystem.
+func (c *Config) logger(subsystem LogSubsystem) subsystemLogger {
+	level, ok := c.logLevels[subsystem]
+	if !ok {
+		level = defaultLogLevel
+	}
+	return subsystemLogger{log: c.log, name: c.name, subsystem: subsystem, level: level}
+}
+
+// Enabled returns true if messages of the given level are logged.
+func (l subsystemLogger) Enabled(level slog.Level) bool {
+	if level < l.level {
+		return false
+	}
+	if level >= slog.LevelWarn {
+		return true
+	}
+	_, ok := l.log.(LeveledLogger)
+	return ok
+}
+
+// withContext returns a logger that passes the context to loggers that
+// implement ContextLogger.
+func (l subsystemLogger) withContext(ctx context.Context) subsystemLogger {
+	l.ctx = ctx
+	return l
+}
+
+// contextLogger returns the logger as a ContextLogger if it has been given a
+// context and the logger accepts it.
+func (l subsystemLogger) contextLogger() (ContextLogger, bool) {
+	if l.ctx == nil {
+		return nil, false
+	}
+	logger, ok := l.log.(ContextLogger)
+	return logger, ok
+}
+
+func (l subsystemLogger) attrs(args []any) []any {
+	if l.name != "" {
+		return append([]any{"cache", l.name, "subsystem", string(l.subsystem)}, args...)
+	}
+	return append([]any{"subsystem", string(l.subsystem)}, args...)
+}
+
+func (l subsystemLogger) Debug(msg string, args ...any) {
+	if !l.Enabled(slog.LevelDebug) {
+		return
+	}
+	if logger, ok := l.contextLogger(); ok {
+		logger.DebugContext(l.ctx, msg, l.attrs(args)...)
+		return
+	}
+	l.log.(LeveledLogger).Debug(msg, l.attrs(args)...)
+}
+
+func (l subsystemLogger) Info(msg string, args ...any) {
+	if !l.Enabled(slog.LevelInfo) {
+		return
+	}
+	if logger, ok := l.contextLogger(); ok {
+		logger.InfoContext(l.ctx, msg, l.attrs(args)...)
+		return
+	}
+	l.log.(LeveledLogger).Info(msg, l.attrs(args)...)
+}
+
+func (l subsystemLogger) Warn(msg string, args ...any) {
+	if !l.Enabled(slog.LevelWarn) {
+		return
+	}
+	if logger, ok := l.contextLogger(); ok {
+		logger.WarnContext(l.ctx, msg, l.attrs(args)...)
+		return
+	}
+	l.log.Warn(msg, l.attrs(args)...)
+}
+
+func (l subsystemLogger) Error(msg string, args ...any) {
+	if !l.Enabled(slog.LevelError) {
+		return
+	}
+	if logger, ok := l.contextLogger(); ok {
+		logger.ErrorContext(l.ctx, msg, l.attrs(args)...)
+		return
+	}
+	l.log.Error(msg, l.attrs(args)...)
+}
@@ -0,0 +1,168 @@
+package sturdyc_test
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/viccon/sturdyc"
+)
+
+// recordingHandler is a slog.Handler that keeps every record it receives.
+type recordingHandler struct {
+	mu         sync.Mutex
+	records    []slog.Record
+	requestIDs []any
+}
+
+func (h *recordingHandler) Enabled(context.Context, slog.Level) bool { return true }
+
+func (h *recordingHandler) Handle(ctx context.Context, r slog.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.records = append(h.records, r)
+	h.requestIDs = append(h.requestIDs, ctx.Value(requestIDKey{}))
+	return nil
+}
+
+func (h *recordingHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+
+func (h *recordingHandler) WithGroup(string) slog.Handler { return h }
+
+// attrs returns the attributes of the records that were logged by the subsystem.
+func (h *recordingHandler) attrs(subsystem sturdyc.LogSubsystem) []map[string]slog.Value {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var matches []map[string]slog.Value
+	for _, r := range h.records {
+		attrs := make(map[string]slog.Value)
+		r.Attrs(func(a slog.Attr) bool {
+			attrs[a.Key] = a.Value
+			return true
+		})
+		if attrs["subsystem"].String() == string(subsystem) {
+			matches = append(matches, attrs)
+		}
+	}
+	return matches
+}
+
+func TestLogLevelsArePerSubsystem(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	handler := &recordingHandler{}
+	refreshDelay := time.Minute
+	clock := sturdyc.NewTestClock(time.Now())
+	c := sturdyc.New[string](100, 1, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithEarlyRefreshes(refreshDelay, refreshDelay, time.Second),
+		sturdyc.WithLog(slog.New(handler)),
+		sturdyc.WithLogLevel(sturdyc.LogRefreshes, slog.LevelDebug),
+		sturdyc.WithClock(clock),
+	)
+
+	fetchObserver := NewFetchObserver(2)
+	fetchObserver.Response("1")
+	c.GetOrFetch(ctx, "1", fetchObserver.Fetch)
+	<-fetchObserver.FetchCompleted
+
+	clock.Add(refreshDelay + time.Second)
+	c.GetOrFetch(ctx, "1", fetchObserver.Fetch)
+	<-fetchObserver.FetchCompleted
+	if err := c.WaitForIdle(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	refreshes := handler.attrs(sturdyc.LogRefreshes)
+	if len(refreshes) != 1 {
+		t.Fatalf("expected 1 refresh to be logged, got %d", len(refreshes))
+	}
+	if refreshes[0]["key"].String() != "1" {
+		t.Errorf("expected the key to be logged, got %v", refreshes[0]["key"])
+	}
+	if _, ok := refreshes[0]["latency"]; !ok {
+		t.Error("expected the latency to be logged")
+	}
+
+	// The fetches are logged at the debug level, which is below the default level.
+	if fetches := handler.attrs(sturdyc.LogFetches); len(fetches) != 0 {
+		t.Errorf("expected no fetches to be logged, got %d", len(fetches))
+	}
+}
+
+func TestLogsIncludeTheNameOfTheCache(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	handler := &recordingHandler{}
+	c := sturdyc.New[string](100, 1, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithName("user-cache"),
+		sturdyc.WithLog(slog.New(handler)),
+		sturdyc.WithLogLevel(sturdyc.LogFetches, slog.LevelDebug),
+	)
+	if c.Name() != "user-cache" {
+		t.Errorf("expected the name to be user-cache, got %q", c.Name())
+	}
+
+	fetchFn := func(context.Context) (string, error) { return "value", nil }
+	if _, err := c.GetOrFetch(ctx, "1", fetchFn); err != nil {
+		t.Fatal(err)
+	}
+
+	fetches := handler.attrs(sturdyc.LogFetches)
+	if len(fetches) != 1 {
+		t.Fatalf("expected 1 fetch to be logged, got %d", len(fetches))
+	}
+	if fetches[0]["cache"].String() != "user-cache" {
+		t.Errorf("expected the name of the cache to be logged, got %v", fetches[0]["cache"])
+	}
+}
+
+func TestGetCtxAndSetCtxPassTheContextToTheLogger(t *testing.T) {
+	t.Parallel()
+
+	handler := &recordingHandler{}
+	c := sturdyc.New[int](100, 1, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithLog(slog.New(handler)),
+		sturdyc.WithLogLevel(sturdyc.LogCache, slog.LevelDebug),
+	)
+
+	c.SetCtx(context.WithValue(context.Background(), requestIDKey{}, "set"), "1", 1)
+	if value, ok := c.GetCtx(context.WithValue(context.Background(), requestIDKey{}, "get"), "1"); !ok || value != 1 {
+		t.Errorf("expected the value 1 to be in the cache, got %d %v", value, ok)
+	}
+	if _, ok := c.GetCtx(context.Background(), "2"); ok {
+		t.Error("expected key 2 to not be in the cache")
+	}
+
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	want := []any{"set", "get", nil}
+	if len(handler.requestIDs) != len(want) {
+		t.Fatalf("expected %d messages, got %d", len(want), len(handler.requestIDs))
+	}
+	for i, id := range want {
+		if handler.requestIDs[i] != id {
+			t.Errorf("expected message %d to have been logged with %v, got %v", i, id, handler.requestIDs[i])
+		}
+	}
+}
+
+func TestGetCtxSkipsTheCacheWhenBypassed(t *testing.T) {
+	t.Parallel()
+
+	c := sturdyc.New[int](100, 1, time.Hour, 10, sturdyc.WithNoContinuousEvictions())
+	c.SetCtx(sturdyc.WithCacheBypass(context.Background()), "1", 1)
+
+	if _, ok := c.GetCtx(sturdyc.WithCacheBypass(context.Background()), "1"); ok {
+		t.Error("expected the bypassed read to report a miss")
+	}
+	if value, ok := c.GetCtx(context.Background(), "1"); !ok || value != 1 {
+		t.Errorf("expected the value 1 to be in the cache, got %d %v", value, ok)
+	}
+}
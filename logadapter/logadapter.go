@@ -0,0 +1,63 @@
+// Package logadapter provides thin adapters that make other logging libraries
+// satisfy the sturdyc.Logger and sturdyc.LeveledLogger interfaces. The adapters
+// are defined in terms of the methods that they call, so this package doesn't
+// depend on any of the libraries. The adapter for zerolog, whose events are
+// concrete types, is in the zerologadapter module.
+package logadapter
+
+import (
+	"log/slog"
+
+	"github.com/viccon/sturdyc"
+)
+
+// SugaredLogger is the subset of the methods of *zap.SugaredLogger that the
+// adapter uses.
+type SugaredLogger interface {
+	Debugw(msg string, keysAndValues ...any)
+	Infow(msg string, keysAndValues ...any)
+	Warnw(msg string, keysAndValues ...any)
+	Errorw(msg string, keysAndValues ...any)
+}
+
+// Sugared adapts a *zap.SugaredLogger, which can be created from a *zap.Logger
+// by calling its Sugar method. The attributes of the messages are passed as
+// the loosely typed key-value pairs of the sugared logger:
+//
+//	sturdyc.WithLog(logadapter.Sugared(zapLogger.Sugar()))
+func Sugared(log SugaredLogger) sturdyc.LeveledLogger {
+	return &sugared{log}
+}
+
+type sugared struct {
+	log SugaredLogger
+}
+
+func (s *sugared) Debug(msg string, args ...any) { s.log.Debugw(msg, args...) }
+func (s *sugared) Info(msg string, args ...any)  { s.log.Infow(msg, args...) }
+func (s *sugared) Warn(msg string, args ...any)  { s.log.Warnw(msg, args...) }
+func (s *sugared) Error(msg string, args ...any) { s.log.Errorw(msg, args...) }
+
+// LogFunc is called with the level, message and key-value pairs of every
+// message that the cache logs.
+type LogFunc func(level slog.Level, msg string, args ...any)
+
+// Func adapts a function, which makes it possible to plug in loggers that
+// don't have an adapter without defining a type:
+//
+//	sturdyc.WithLog(logadapter.Func(func(level slog.Level, msg string, args ...any) {
+//		log.Println(level, msg, args)
+//	}))
+func Func(fn LogFunc) sturdyc.LeveledLogger {
+	return fn
+}
+
+func (fn LogFunc) Debug(msg string, args ...any) { fn(slog.LevelDebug, msg, args...) }
+func (fn LogFunc) Info(msg string, args ...any)  { fn(slog.LevelInfo, msg, args...) }
+func (fn LogFunc) Warn(msg string, args ...any)  { fn(slog.LevelWarn, msg, args...) }
+func (fn LogFunc) Error(msg string, args ...any) { fn(slog.LevelError, msg, args...) }
+
+// Noop returns a logger that discards every message.
+func Noop() sturdyc.LeveledLogger {
+	return &sturdyc.NoopLogger{}
+}
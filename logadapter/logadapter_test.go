@@ -0,0 +1,80 @@
+package logadapter_test
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/viccon/sturdyc"
+	"github.com/viccon/sturdyc/logadapter"
+)
+
+// fakeSugaredLogger has the same methods as a *zap.SugaredLogger.
+type fakeSugaredLogger struct {
+	lines []string
+}
+
+func (l *fakeSugaredLogger) log(level, msg string, keysAndValues []any) {
+	l.lines = append(l.lines, fmt.Sprintf("%s %s %v", level, msg, keysAndValues))
+}
+
+func (l *fakeSugaredLogger) Debugw(msg string, kv ...any) { l.log("debug", msg, kv) }
+func (l *fakeSugaredLogger) Infow(msg string, kv ...any)  { l.log("info", msg, kv) }
+func (l *fakeSugaredLogger) Warnw(msg string, kv ...any)  { l.log("warn", msg, kv) }
+func (l *fakeSugaredLogger) Errorw(msg string, kv ...any) { l.log("error", msg, kv) }
+
+func TestSugared(t *testing.T) {
+	t.Parallel()
+
+	fake := &fakeSugaredLogger{}
+	log := logadapter.Sugared(fake)
+	log.Debug("debug message", "key", "1")
+	log.Warn("warn message", "key", "2")
+
+	want := []string{"debug debug message [key 1]", "warn warn message [key 2]"}
+	if strings.Join(fake.lines, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got: %v wanted: %v", fake.lines, want)
+	}
+}
+
+func TestFuncReceivesTheMessagesOfTheCache(t *testing.T) {
+	t.Parallel()
+
+	type message struct {
+		level slog.Level
+		msg   string
+		args  []any
+	}
+	messages := make(chan message, 10)
+	c := sturdyc.New[string](100, 1, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithLog(logadapter.Func(func(level slog.Level, msg string, args ...any) {
+			messages <- message{level, msg, args}
+		})),
+	)
+
+	type opts struct {
+		hidden bool
+	}
+	c.PermutatedKey("key", opts{})
+
+	m := <-messages
+	if m.level != slog.LevelWarn {
+		t.Errorf("expected a warning, got %v", m.level)
+	}
+	if fmt.Sprint(m.args) != "[subsystem cache field hidden]" {
+		t.Errorf("expected the subsystem and field to be passed as attributes, got %v", m.args)
+	}
+}
+
+func TestNoop(t *testing.T) {
+	t.Parallel()
+
+	var log sturdyc.LeveledLogger = logadapter.Noop()
+	log.Debug("message")
+	log.Info("message")
+	log.Warn("message")
+	log.Error("message")
+}
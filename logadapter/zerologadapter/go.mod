@@ -0,0 +1,17 @@
+module github.com/viccon/sturdyc/logadapter/zerologadapter
+
+go 1.22
+
+require (
+	github.com/rs/zerolog v1.34.0
+	github.com/viccon/sturdyc v0.0.0
+)
+
+require (
+	github.com/cespare/xxhash/v2 v2.3.0 // indirect
+	github.com/mattn/go-colorable v0.1.13 // indirect
+	github.com/mattn/go-isatty v0.0.19 // indirect
+	golang.org/x/sys v0.25.0 // indirect
+)
+
+replace github.com/viccon/sturdyc => ../..
@@ -0,0 +1,27 @@
+// Package zerologadapter makes a zerolog.Logger satisfy the
+// sturdyc.LeveledLogger interface. The events of zerolog are concrete types,
+// which is why this adapter lives in a module of its own rather than in the
+// logadapter package, which doesn't depend on any of the logging libraries.
+package zerologadapter
+
+import (
+	"github.com/rs/zerolog"
+	"github.com/viccon/sturdyc"
+)
+
+// New adapts a zerolog.Logger. The attributes of the messages are added to
+// the events as fields:
+//
+//	sturdyc.WithLog(zerologadapter.New(zerologLogger))
+func New(log zerolog.Logger) sturdyc.LeveledLogger {
+	return &logger{log}
+}
+
+type logger struct {
+	log zerolog.Logger
+}
+
+func (l *logger) Debug(msg string, args ...any) { l.log.Debug().Fields(args).Msg(msg) }
+func (l *logger) Info(msg string, args ...any)  { l.log.Info().Fields(args).Msg(msg) }
+func (l *logger) Warn(msg string, args ...any)  { l.log.Warn().Fields(args).Msg(msg) }
+func (l *logger) Error(msg string, args ...any) { l.log.Error().Fields(args).Msg(msg) }
@@ -0,0 +1,28 @@
+package zerologadapter_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/viccon/sturdyc/logadapter/zerologadapter"
+)
+
+func TestLevelsAndFieldsArePassedToZerolog(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	log := zerologadapter.New(zerolog.New(&buf).Level(zerolog.InfoLevel))
+	log.Debug("debug message", "key", "1")
+	log.Warn("warn message", "key", "2")
+	log.Error("error message", "key", 3)
+
+	want := []string{
+		`{"level":"warn","key":"2","message":"warn message"}`,
+		`{"level":"error","key":3,"message":"error message"}`,
+	}
+	if got := strings.TrimSpace(buf.String()); got != strings.Join(want, "\n") {
+		t.Errorf("got: %s wanted: %s", got, strings.Join(want, "\n"))
+	}
+}
@@ -1,5 +1,32 @@
 package sturdyc
 
+import "time"
+
+// BufferFlushReason describes why a refresh buffer was flushed.
+type BufferFlushReason int
+
+const (
+	// BufferFlushSize is used when the buffer reached its size.
+	BufferFlushSize BufferFlushReason = iota
+	// BufferFlushTimeout is used when the buffer timed out before reaching its size.
+	BufferFlushTimeout
+	// BufferFlushManual is used when the buffer was flushed by client.FlushRefreshBuffers.
+	BufferFlushManual
+)
+
+func (r BufferFlushReason) String() string {
+	switch r {
+	case BufferFlushSize:
+		return "size"
+	case BufferFlushTimeout:
+		return "timeout"
+	case BufferFlushManual:
+		return "manual"
+	default:
+		return "unknown"
+	}
+}
+
 type MetricsRecorder interface {
 	// CacheHit is called for every key that results in a cache hit.
 	CacheHit()
@@ -42,6 +69,162 @@ type DistributedMetricsRecorder interface {
 	DistributedFallback()
 }
 
+// StorageHealthMetricsRecorder can be implemented by the distributed metrics
+// recorders that want to know when the circuit breaker of the distributed
+// storage changes state. It's only used with WithDistributedStorageCircuitBreaker.
+type StorageHealthMetricsRecorder interface {
+	DistributedMetricsRecorder
+	// DistributedStorageUnavailable is called when the circuit breaker of the
+	// distributed storage opens, and the cache stops calling the storage.
+	DistributedStorageUnavailable()
+	// DistributedStorageRecovered is called when the circuit breaker of the
+	// distributed storage closes after a successful probe.
+	DistributedStorageRecovered()
+}
+
+// BufferMetricsRecorder can be implemented by the metrics recorders that want
+// to observe the refresh buffers of WithRefreshCoalescing. The cache checks
+// for it when it's created.
+type BufferMetricsRecorder interface {
+	MetricsRecorder
+	// RefreshBufferSize is called to report the number of IDs that are waiting
+	// in the refresh buffer of a key permutation. A size of 0 is reported once
+	// the buffer has been flushed.
+	RefreshBufferSize(permutation string, size int)
+	// RefreshBufferFlushed is called when a refresh buffer is flushed, along
+	// with the reason and the time that passed since the buffer was created.
+	RefreshBufferFlushed(reason BufferFlushReason, timeInBuffer time.Duration)
+}
+
+// CoalescingMetricsRecorder can be implemented by the metrics recorders that
+// want to know how many calls to the data source were saved by coalescing
+// them. The cache checks for it when it's created.
+type CoalescingMetricsRecorder interface {
+	MetricsRecorder
+	// FetchCoalesced is called for every key that gets coalesced onto a
+	// fetch that is already in flight, rather than calling the data source.
+	FetchCoalesced()
+}
+
+// MissingRecordMetricsRecorder can be implemented by the metrics recorders
+// that want to know when the records that were marked as missing get created.
+// The cache checks for it when it's created.
+type MissingRecordMetricsRecorder interface {
+	MetricsRecorder
+	// MissingRecordPromoted is called when a key that has been marked as
+	// missing is given a value, which means that the record has been created.
+	MissingRecordPromoted()
+}
+
+// DistributedOperation identifies a call to the distributed storage.
+type DistributedOperation string
+
+const (
+	DistributedGet         DistributedOperation = "get"
+	DistributedGetBatch    DistributedOperation = "get_batch"
+	DistributedSet         DistributedOperation = "set"
+	DistributedSetBatch    DistributedOperation = "set_batch"
+	DistributedDelete      DistributedOperation = "delete"
+	DistributedDeleteBatch DistributedOperation = "delete_batch"
+)
+
+// LatencyMetricsRecorder can be implemented by the metrics recorders that
+// want to record the durations of the calls that the cache makes, which
+// would typically be observed as histograms. The cache checks for it when
+// it's created, which means that it can be passed to both WithMetrics and
+// WithDistributedMetrics.
+type LatencyMetricsRecorder interface {
+	MetricsRecorder
+	// ObserveFetchDuration is called with the duration of every call to the
+	// underlying data source that was made because of a cache miss. A batch
+	// counts as one call.
+	ObserveFetchDuration(d time.Duration)
+	// ObserveRefreshDuration is called with the duration of every call to the
+	// underlying data source that was made to refresh one or more records.
+	ObserveRefreshDuration(d time.Duration)
+	// ObserveDistributedDuration is called with the duration of every call to
+	// the distributed storage.
+	ObserveDistributedDuration(operation DistributedOperation, d time.Duration)
+}
+
+// EvictionReason describes why an entry was evicted.
+type EvictionReason int
+
+const (
+	// EvictionExpired is used for the entries that were evicted because they had expired.
+	EvictionExpired EvictionReason = iota
+	// EvictionCapacity is used for the entries that were evicted to make room
+	// for new ones, either because the shard was full or had been resized.
+	EvictionCapacity
+)
+
+func (r EvictionReason) String() string {
+	switch r {
+	case EvictionExpired:
+		return "expired"
+	case EvictionCapacity:
+		return "capacity"
+	default:
+		return "unknown"
+	}
+}
+
+// EvictionMetricsRecorder can be implemented by the metrics recorders that
+// want to tell whether it's the capacity or the TTL that is driving the
+// evictions. The cache checks for it when it's created.
+type EvictionMetricsRecorder interface {
+	MetricsRecorder
+	// EntryEvicted is called for every entry that is evicted, along with the
+	// reason and the time that passed since the entry was written. The size
+	// is what the function passed to WithCostFunc returned for the entry, or
+	// 0 if no cost function has been configured.
+	EntryEvicted(reason EvictionReason, age time.Duration, size int)
+}
+
+// NamedMetricsRecorder can be implemented by the metrics recorders that are
+// shared between several clients. If the cache has been given a name with
+// WithName, it reports its metrics to the recorder that ForCache returns for
+// that name, which would typically add the name as a label to every metric.
+type NamedMetricsRecorder interface {
+	ForCache(name string) MetricsRecorder
+}
+
+// setupMetricsRecorder should be called once the options have been applied.
+func (c *Config) setupMetricsRecorder() {
+	if c.metricsRecorder == nil {
+		return
+	}
+
+	if c.name != "" {
+		var recorder MetricsRecorder = c.metricsRecorder
+		if d, ok := c.metricsRecorder.(*distributedMetricsRecorder); ok {
+			recorder = d.MetricsRecorder
+		}
+		if named, ok := recorder.(NamedMetricsRecorder); ok {
+			recorder = named.ForCache(c.name)
+			if distributed, ok := recorder.(DistributedMetricsRecorder); ok {
+				c.metricsRecorder = distributed
+			} else {
+				c.metricsRecorder = &distributedMetricsRecorder{recorder}
+			}
+		}
+	}
+
+	// The optional interfaces are implemented by the recorder that was passed
+	// to WithMetrics, rather than the wrapper that adds the distributed metrics.
+	var recorder MetricsRecorder = c.metricsRecorder
+	if d, ok := c.metricsRecorder.(*distributedMetricsRecorder); ok {
+		recorder = d.MetricsRecorder
+	}
+	c.bufferRecorder, _ = recorder.(BufferMetricsRecorder)
+	c.coalescingRecorder, _ = recorder.(CoalescingMetricsRecorder)
+	c.latencyRecorder, _ = recorder.(LatencyMetricsRecorder)
+	c.missingRecordRecorder, _ = recorder.(MissingRecordMetricsRecorder)
+	c.evictionRecorder, _ = recorder.(EvictionMetricsRecorder)
+
+	c.metricsRecorder.ObserveCacheSize(c.getSize)
+}
+
 type distributedMetricsRecorder struct {
 	MetricsRecorder
 }
@@ -57,6 +240,8 @@ func (d *distributedMetricsRecorder) DistributedMissingRecord() {}
 func (d *distributedMetricsRecorder) DistributedFallback() {}
 
 func (s *shard[T]) reportForcedEviction() {
+	s.stats.forcedEvictions.Add(1)
+	s.logger(LogEvictions).Debug("sturdyc: the shard reached its capacity", "capacity", s.capacity)
 	if s.metricsRecorder == nil {
 		return
 	}
@@ -64,14 +249,37 @@ func (s *shard[T]) reportForcedEviction() {
 }
 
 func (s *shard[T]) reportEntriesEvicted(n int) {
+	s.stats.evictions.Add(int64(n))
+	s.logger(LogEvictions).Debug("sturdyc: evicted entries", "entries", n)
 	if s.metricsRecorder == nil {
 		return
 	}
 	s.metricsRecorder.EntriesEvicted(n)
 }
 
+func (s *shard[T]) reportEntryEvicted(e *entry[T], reason EvictionReason, now time.Time) {
+	if s.evictionRecorder == nil {
+		return
+	}
+	var size int
+	// The type of the cost function is checked when the client is created.
+	if costFn, ok := s.costFn.(func(key string, value T) int); ok {
+		size = costFn(e.key, e.value)
+	}
+	s.evictionRecorder.EntryEvicted(reason, now.Sub(e.cachedAt), size)
+}
+
+func (s *shard[T]) reportMissingRecordPromoted(key string) {
+	s.emitRefreshEvent(RecordCreated, key, nil)
+	if s.missingRecordRecorder == nil {
+		return
+	}
+	s.missingRecordRecorder.MissingRecordPromoted()
+}
+
 // reportCacheHits is used to report cache hits and misses to the metrics recorder.
 func (c *Client[T]) reportCacheHits(cacheHit, missingRecord, refresh bool) {
+	c.recordCacheHits(cacheHit, missingRecord, refresh)
 	if c.metricsRecorder == nil {
 		return
 	}
@@ -91,6 +299,15 @@ func (c *Client[T]) reportCacheHits(cacheHit, missingRecord, refresh bool) {
 	c.metricsRecorder.CacheHit()
 }
 
+func (c *Client[T]) reportFetchCoalesced(n int) {
+	if c.coalescingRecorder == nil {
+		return
+	}
+	for i := 0; i < n; i++ {
+		c.coalescingRecorder.FetchCoalesced()
+	}
+}
+
 func (c *Client[T]) reportShardIndex(index int) {
 	if c.metricsRecorder == nil {
 		return
@@ -105,6 +322,35 @@ func (c *Client[T]) reportBatchRefreshSize(n int) {
 	c.metricsRecorder.CacheBatchRefreshSize(n)
 }
 
+func (c *Client[T]) reportRefreshBufferSize(permutation string, size int) {
+	if c.bufferRecorder == nil {
+		return
+	}
+	c.bufferRecorder.RefreshBufferSize(permutation, size)
+}
+
+func (c *Client[T]) reportRefreshBufferFlushed(permutation string, reason BufferFlushReason, buf *buffer) {
+	if c.bufferRecorder == nil {
+		return
+	}
+	c.bufferRecorder.RefreshBufferSize(permutation, 0)
+	c.bufferRecorder.RefreshBufferFlushed(reason, c.clock.Since(buf.createdAt))
+}
+
+func (c *Client[T]) reportFetchDuration(d time.Duration) {
+	if c.latencyRecorder == nil {
+		return
+	}
+	c.latencyRecorder.ObserveFetchDuration(d)
+}
+
+func (c *Client[T]) reportRefreshDuration(d time.Duration) {
+	if c.latencyRecorder == nil {
+		return
+	}
+	c.latencyRecorder.ObserveRefreshDuration(d)
+}
+
 func (c *Client[T]) reportDistributedCacheHit(cacheHit bool) {
 	if c.metricsRecorder == nil {
 		return
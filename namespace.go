@@ -0,0 +1,184 @@
+package sturdyc
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync/atomic"
+)
+
+// namespaceSeparator separates the name of a namespace from the keys.
+const namespaceSeparator = ":"
+
+// Namespace is a logical cache within a client. Every key is prefixed with
+// the name of the namespace, which allows one client to back several caches
+// without their keys colliding.
+type Namespace[T any] struct {
+	client  *Client[T]
+	prefix  string
+	options []CallOption
+	hits    atomic.Int64
+	misses  atomic.Int64
+}
+
+// NamespaceStats holds the statistics of a namespace.
+type NamespaceStats struct {
+	// Size is the number of keys in the namespace.
+	Size int
+	// Hits is the number of lookups that were served from the cache.
+	Hits int64
+	// Misses is the number of lookups that had to fetch the value, or that
+	// didn't find the key in the cache.
+	Misses int64
+}
+
+// Namespace returns a namespace that prefixes its keys with the name followed
+// by a colon. The name can't contain a colon itself, as the keys of two
+// namespaces could otherwise collide. The options are used as the defaults for
+// the writes and fetches of the namespace, which makes it possible to give
+// each namespace its own TTL. The statistics only cover the calls made through
+// the returned value, so it should be kept around rather than recreated for
+// each call.
+func (c *Client[T]) Namespace(name string, opts ...CallOption) *Namespace[T] {
+	if name == "" {
+		panic("name must not be empty")
+	}
+	if strings.Contains(name, namespaceSeparator) {
+		panic("name must not contain " + namespaceSeparator)
+	}
+	return &Namespace[T]{client: c, prefix: name + namespaceSeparator, options: opts}
+}
+
+// Key returns the key that is used in the underlying cache.
+func (n *Namespace[T]) Key(key string) string {
+	return n.prefix + key
+}
+
+// keyFn prefixes the keys that are produced by the keyFn.
+func (n *Namespace[T]) keyFn(keyFn KeyFn) KeyFn {
+	return func(id string) string {
+		return n.prefix + keyFn(id)
+	}
+}
+
+// callOptions returns the options of the namespace followed by the options of the call.
+func (n *Namespace[T]) callOptions(opts []CallOption) callOptions {
+	return n.client.newCallOptions(append(slices.Clip(n.options), opts...))
+}
+
+// Get retrieves a single value from the namespace.
+func (n *Namespace[T]) Get(key string) (T, bool) {
+	value, ok := n.client.Get(n.Key(key))
+	n.record(ok)
+	return value, ok
+}
+
+// GetMany retrieves multiple values from the namespace.
+func (n *Namespace[T]) GetMany(keys []string) map[string]T {
+	records := n.client.GetManyKeyFn(keys, n.Key)
+	n.hits.Add(int64(len(records)))
+	n.misses.Add(int64(len(keys) - len(records)))
+	return records
+}
+
+// Set writes a single value to the namespace, using the TTL of the namespace.
+// Returns true if it triggered an eviction.
+func (n *Namespace[T]) Set(key string, value T) bool {
+	return n.SetCtx(context.Background(), key, value)
+}
+
+// SetCtx is the same as Set, but it accepts the context of the request so
+// that it can be passed along to the distributed storage.
+func (n *Namespace[T]) SetCtx(ctx context.Context, key string, value T) bool {
+	key = n.Key(key)
+	evicted := n.client.set(key, value, n.callOptions(nil))
+	n.client.writeRecordThrough(ctx, key, value)
+	return evicted
+}
+
+// Delete removes a single value from the namespace.
+func (n *Namespace[T]) Delete(key string) {
+	n.client.Delete(n.Key(key))
+}
+
+// GetOrFetch is the same as client.GetOrFetch, but the key is prefixed with
+// the name of the namespace and the options of the namespace are applied
+// before the options of the call.
+func (n *Namespace[T]) GetOrFetch(ctx context.Context, key string, fetchFn FetchFn[T], opts ...CallOption) (T, error) {
+	return namespaceGetOrFetch(ctx, n, key, fetchFn, opts)
+}
+
+// GetOrFetchBatch is the same as client.GetOrFetchBatch, but the keys are
+// prefixed with the name of the namespace and the options of the namespace
+// are applied before the options of the call.
+func (n *Namespace[T]) GetOrFetchBatch(ctx context.Context, ids []string, keyFn KeyFn, fetchFn BatchFetchFn[T], opts ...CallOption) (map[string]T, error) {
+	return namespaceGetOrFetchBatch(ctx, n, ids, keyFn, fetchFn, opts)
+}
+
+// namespaceGetOrFetch fetches the key within the namespace, and records
+// whether the value was read from the cache. Calls that waited for the fetch
+// of another caller count as misses. V is the type of the fetch function,
+// which differs from T for the TypedView.
+func namespaceGetOrFetch[V, T any](ctx context.Context, n *Namespace[T], key string, fetchFn FetchFn[V], opts []CallOption) (T, error) {
+	var hits int
+	callOpts := n.callOptions(opts)
+	callOpts.cacheHits = func(n int) { hits += n }
+	value, err := getFetch[V, T](ctx, n.client, n.Key(key), fetchFn, callOpts)
+	n.record(hits > 0)
+	return value, err
+}
+
+// namespaceGetOrFetchBatch fetches the IDs within the namespace, and records
+// how many of them were read from the cache.
+func namespaceGetOrFetchBatch[V, T any](ctx context.Context, n *Namespace[T], ids []string, keyFn KeyFn, fetchFn BatchFetchFn[V], opts []CallOption) (map[string]T, error) {
+	var hits int
+	callOpts := n.callOptions(opts)
+	callOpts.cacheHits = func(n int) { hits += n }
+	records, err := getFetchBatch[V, T](ctx, n.client, ids, n.keyFn(keyFn), fetchFn, callOpts)
+	n.hits.Add(int64(hits))
+	n.misses.Add(int64(len(ids) - hits))
+	return records, err
+}
+
+// ScanKeys returns the keys of the namespace, without the prefix.
+func (n *Namespace[T]) ScanKeys() []string {
+	keys := n.client.KeysWhere(func(key string) bool {
+		return strings.HasPrefix(key, n.prefix)
+	})
+	for i, key := range keys {
+		keys[i] = strings.TrimPrefix(key, n.prefix)
+	}
+	return keys
+}
+
+// Clear deletes every key of the namespace, and leaves the rest of the cache
+// untouched. Returns the number of keys that were deleted. The keys are found
+// by scanning every shard of the client, so the cost grows with the size of
+// the whole cache rather than with the size of the namespace.
+func (n *Namespace[T]) Clear() int {
+	keys := n.ScanKeys()
+	for _, key := range keys {
+		n.Delete(key)
+	}
+	return len(keys)
+}
+
+// Stats returns the size of the namespace, along with the number of hits and
+// misses of the calls that have been made through it. Like Clear, it scans
+// every shard of the client to count the keys, so it shouldn't be called on
+// the hot path of a large cache.
+func (n *Namespace[T]) Stats() NamespaceStats {
+	return NamespaceStats{
+		Size:   len(n.ScanKeys()),
+		Hits:   n.hits.Load(),
+		Misses: n.misses.Load(),
+	}
+}
+
+func (n *Namespace[T]) record(hit bool) {
+	if hit {
+		n.hits.Add(1)
+		return
+	}
+	n.misses.Add(1)
+}
@@ -0,0 +1,166 @@
+package sturdyc_test
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/viccon/sturdyc"
+)
+
+func TestNamespacesDontCollide(t *testing.T) {
+	t.Parallel()
+
+	c := sturdyc.New[string](1000, 10, time.Hour, 5, sturdyc.WithNoContinuousEvictions())
+	users := c.Namespace("users")
+	orders := c.Namespace("orders")
+
+	users.Set("1", "user")
+	orders.Set("1", "order")
+	if value, ok := users.Get("1"); !ok || value != "user" {
+		t.Errorf("expected the user namespace to return user, got %q", value)
+	}
+	if value, ok := orders.Get("1"); !ok || value != "order" {
+		t.Errorf("expected the order namespace to return order, got %q", value)
+	}
+	if _, ok := c.Get("users:1"); !ok {
+		t.Error("expected the key to be prefixed with the name of the namespace")
+	}
+	if keys := users.ScanKeys(); !slices.Equal(keys, []string{"1"}) {
+		t.Errorf("expected the keys of the namespace to be unprefixed, got %v", keys)
+	}
+
+	if n := users.Clear(); n != 1 {
+		t.Errorf("expected 1 key to be cleared, got %d", n)
+	}
+	if _, ok := users.Get("1"); ok {
+		t.Error("expected the namespace to be cleared")
+	}
+	if _, ok := orders.Get("1"); !ok {
+		t.Error("expected the other namespace to be left untouched")
+	}
+
+	stats := users.Stats()
+	if stats.Size != 0 || stats.Hits != 1 || stats.Misses != 1 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestNamespaceAppliesItsTTL(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	clock := sturdyc.NewTestClock(time.Now())
+	c := sturdyc.New[string](1000, 10, time.Hour, 5,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithClock(clock),
+	)
+	sessions := c.Namespace("sessions", sturdyc.CallTTL(time.Minute))
+
+	fetchFn := func(context.Context) (string, error) { return "session", nil }
+	if _, err := sessions.GetOrFetch(ctx, "1", fetchFn); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sessions.GetOrFetch(ctx, "1", fetchFn); err != nil {
+		t.Fatal(err)
+	}
+	sessions.Set("2", "session")
+	c.Set("sessions-but-not-namespaced", "value")
+
+	clock.Add(time.Minute * 2)
+	if keys := sessions.ScanKeys(); len(keys) != 0 {
+		t.Errorf("expected the keys of the namespace to have expired, got %v", keys)
+	}
+	if _, ok := c.Get("sessions-but-not-namespaced"); !ok {
+		t.Error("expected the key outside the namespace to use the TTL of the cache")
+	}
+
+	stats := sessions.Stats()
+	if stats.Hits != 1 || stats.Misses != 1 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestNamespaceCountsCoalescedCallsAsMisses(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	c := sturdyc.New[string](1000, 10, time.Hour, 5, sturdyc.WithNoContinuousEvictions())
+	users := c.Namespace("users")
+
+	started, release := make(chan struct{}), make(chan struct{})
+	fetchFn := func(context.Context) (string, error) {
+		close(started)
+		<-release
+		return "value", nil
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		users.GetOrFetch(ctx, "1", fetchFn)
+	}()
+	<-started
+	go func() {
+		defer wg.Done()
+		users.GetOrFetch(ctx, "1", fetchFn)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if _, err := users.GetOrFetch(ctx, "1", fetchFn); err != nil {
+		t.Fatal(err)
+	}
+	if stats := users.Stats(); stats.Hits != 1 || stats.Misses != 2 {
+		t.Errorf("expected only the read from the cache to count as a hit, got %+v", stats)
+	}
+}
+
+func TestPanicsIfTheNamespaceContainsTheSeparator(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		err := recover()
+		if err == nil {
+			t.Error("expected a panic when the name of the namespace contains a colon")
+		}
+	}()
+	c := sturdyc.New[string](1000, 10, time.Hour, 5, sturdyc.WithNoContinuousEvictions())
+	c.Namespace("a:b")
+}
+
+func TestNamespaceCountsNilValuesThatWereReadFromTheCacheAsHits(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	c := sturdyc.New[any](1000, 10, time.Hour, 5, sturdyc.WithNoContinuousEvictions())
+	values := c.Namespace("values")
+	keyFn := c.BatchKeyFn("batch")
+	values.Set("1", nil)
+	values.Set(keyFn("2"), nil)
+	values.Set(keyFn("3"), nil)
+
+	fetchFn := func(context.Context) (any, error) { return "value", nil }
+	if _, err := values.GetOrFetch(ctx, "1", fetchFn); err != nil {
+		t.Fatal(err)
+	}
+
+	batchFetchFn := func(_ context.Context, ids []string) (map[string]any, error) {
+		records := make(map[string]any, len(ids))
+		for _, id := range ids {
+			records[id] = "value"
+		}
+		return records, nil
+	}
+	if _, err := values.GetOrFetchBatch(ctx, []string{"2", "3", "4"}, keyFn, batchFetchFn); err != nil {
+		t.Fatal(err)
+	}
+
+	if stats := values.Stats(); stats.Hits != 3 || stats.Misses != 1 {
+		t.Errorf("expected 3 hits and 1 miss, got %+v", stats)
+	}
+}
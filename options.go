@@ -1,17 +1,72 @@
 package sturdyc
 
-import "time"
+import (
+	"log/slog"
+	"math/rand/v2"
+	"reflect"
+	"time"
+)
 
 type Option func(*Config)
 
+// WithName gives the cache a name, which makes it possible to tell several
+// clients apart. The name is added to the attributes of every message that is
+// logged, and included in the stats of the debug handler. Metrics recorders
+// that implement NamedMetricsRecorder are asked for a recorder for the name.
+func WithName(name string) Option {
+	return func(c *Config) {
+		c.name = name
+	}
+}
+
 // WithMetrics is used to make the cache report metrics.
 func WithMetrics(recorder MetricsRecorder) Option {
 	return func(c *Config) {
-		recorder.ObserveCacheSize(c.getSize)
 		c.metricsRecorder = &distributedMetricsRecorder{recorder}
 	}
 }
 
+// WithStatsWindow makes client.Stats report the hit ratio and refresh failure
+// rate of the last window, which is tracked in buckets of the given duration.
+// This lets autoscaling and alerting react to recent behavior rather than the
+// averages since the cache was created.
+func WithStatsWindow(window, bucket time.Duration) Option {
+	return func(c *Config) {
+		c.statsWindow = window
+		c.statsBucket = bucket
+	}
+}
+
+// WithExpvar publishes the size, hit ratio, evictions and number of in-flight
+// keys of the cache through the expvar package. The variables are named after
+// the prefix, e.g. "prefix.hit_ratio", and are only published by the first
+// client that uses the prefix. Later clients with the same prefix log a warning.
+func WithExpvar(prefix string) Option {
+	return func(c *Config) {
+		c.useExpvar = true
+		c.expvarPrefix = prefix
+	}
+}
+
+// WithShardSkewWarning logs a warning when the fullest shard holds more than
+// threshold times the average number of entries per shard. The shards are
+// checked after every pass of the continuous evictions, which means that this
+// option has no effect if WithNoContinuousEvictions is used.
+func WithShardSkewWarning(threshold float64) Option {
+	return func(c *Config) {
+		c.shardSkewThreshold = threshold
+	}
+}
+
+// WithKeyHasher replaces the xxhash function that is used to map keys to
+// shards. This allows you to use a seeded hash, such as SipHash, if the keys
+// come from untrusted input, or to match the shard layout of another system.
+func WithKeyHasher(hasher func(key string) uint64) Option {
+	return func(c *Config) {
+		c.keyHasher = hasher
+	}
+}
+
 // WithClock can be used to change the clock that the cache uses. This is useful for testing.
 func WithClock(clock Clock) Option {
 	return func(c *Config) {
@@ -29,6 +84,43 @@ func WithEvictionInterval(interval time.Duration) Option {
 	}
 }
 
+// WithAdaptiveEvictions makes the continuous eviction job adjust the interval
+// at which it scans the shards. The interval is shortened when the shards are
+// close to their capacity or hold a lot of expired entries, and lengthened
+// when there is nothing to evict, while staying between the given bounds.
+func WithAdaptiveEvictions(minInterval, maxInterval time.Duration) Option {
+	return func(c *Config) {
+		c.adaptiveEvictions = true
+		c.minEvictionInterval = minInterval
+		c.maxEvictionInterval = maxInterval
+	}
+}
+
+// WithEvictionPolicy determines which entries are evicted once a shard has
+// reached its capacity. The default policy evicts the entries that are closest
+// to expiring, whereas EvictFIFO evicts them in the order in which they were
+// first written, which suits append-only workloads. EvictLRU and EvictLFU
+// evict the entries that have been read the least recently and the least
+// frequently, and EvictARC balances between the two.
+func WithEvictionPolicy(policy EvictionPolicy) Option {
+	return func(c *Config) {
+		c.evictionPolicy = policy
+	}
+}
+
+// WithDoorkeeper only writes the records that the underlying data source
+// returns to the cache once they've been fetched a second time within the
+// window. The first fetch is returned to the caller without being cached. The
+// keys are tracked in a bloom filter that is sized for the expected number of
+// keys per window, and reset once the window has passed. This keeps keys that
+// are only requested once from evicting the ones that are actually reused.
+func WithDoorkeeper(expectedKeys int, window time.Duration) Option {
+	return func(c *Config) {
+		c.doorkeeperKeys = expectedKeys
+		c.doorkeeperWindow = window
+	}
+}
+
 // WithNoContinuousEvictions improves cache performance when the cache capacity
 // is unlikely to be exceeded. While this setting disables the continuous
 // eviction job, it still allows for the eviction of the least recently used
@@ -39,6 +131,62 @@ func WithNoContinuousEvictions() Option {
 	}
 }
 
+// WithStripedLocks splits the read lock of each shard into multiple stripes.
+// Reads only have to acquire one of the stripes, while writes have to acquire
+// all of them. This reduces the lock contention for workloads where a few
+// hot shards are being read from by a lot of goroutines at once, at the cost
+// of making every write slightly more expensive.
+func WithStripedLocks(stripesPerShard int) Option {
+	return func(c *Config) {
+		c.lockStripes = stripesPerShard
+	}
+}
+
+// WithParallelGetMany makes GetMany and GetManyKeyFn read from the shards in
+// parallel when they are called with at least minKeys keys. The keys are
+// always grouped by shard, so that each shard is only locked once, but for
+// large lookups it can also be worth spreading the shards across goroutines.
+// A minKeys of 0 disables the parallel reads.
+func WithParallelGetMany(minKeys int) Option {
+	return func(c *Config) {
+		c.parallelGetManyKeys = minKeys
+	}
+}
+
+// WithValidator registers a function that is called with every value that is
+// fetched from the underlying data source, before it's written to the cache.
+// Values that are rejected, such as empty structs or partially hydrated
+// records, are treated as if the fetch had failed with an error that wraps
+// ErrInvalidValue. For batches, only the rejected IDs are reported as failed.
+// The value type of the validator has to match the type of the cache.
+func WithValidator[T any](validator func(key string, value T) error) Option {
+	return func(c *Config) {
+		c.validator = validator
+	}
+}
+
+// WithTransform registers a function that is applied to every value that is
+// fetched from the underlying data source, before it's written to the cache.
+// It can be used to trim heavy fields or normalize the values without having
+// to wrap every fetch function. The transform is applied after the validator,
+// and its type has to match the type of the cache.
+func WithTransform[T any](transform func(key string, value T) T) Option {
+	return func(c *Config) {
+		c.transform = transform
+	}
+}
+
+// WithCostFunc registers a function that returns the size of an entry, such
+// as the number of bytes that it occupies. The size is passed to a metrics
+// recorder that implements EvictionMetricsRecorder whenever the entry is
+// evicted, which makes it possible to track the number of evicted bytes. The
+// value type of the function has to match the type of the cache.
+func WithCostFunc[T any](costFn func(key string, value T) int) Option {
+	return func(c *Config) {
+		c.costFn = costFn
+	}
+}
+
 // WithMissingRecordStorage allows the cache to mark keys as missing from the
 // underlying data source. This allows you to stop streams of outgoing requests
 // for requests that don't exist. The keys will still have the same TTL and
@@ -49,6 +197,47 @@ func WithMissingRecordStorage() Option {
 	}
 }
 
+// WithMissingRecordTTL gives the missing records a TTL of their own. Negative
+// caching is usually meant to shield the data source from repeated lookups of
+// keys that don't exist, and a shorter TTL makes records that are created
+// shortly after a miss visible sooner. It can be overridden for a single call
+// with CallMissingRecordTTL.
+func WithMissingRecordTTL(ttl time.Duration) Option {
+	return func(c *Config) {
+		c.missingRecordTTL = ttl
+	}
+}
+
+// WithStaleOnError makes the cache keep expired records around for the
+// maxStaleness duration. If the underlying data source fails when one of
+// these records is requested again, the stale value is returned instead.
+// client.GetOrFetch returns the stale value without an error, and reports it
+// to the function that was passed to CallOnStale, while
+// client.GetOrFetchBatch adds the stale values to the records that are
+// returned along with ErrOnlyCachedRecords. Missing records are never served
+// as stale values, and records that the data source reports as missing are
+// not replaced by stale values either.
+func WithStaleOnError(maxStaleness time.Duration) Option {
+	return func(c *Config) {
+		c.serveStaleOnError = true
+		c.staleOnErrorDuration = maxStaleness
+	}
+}
+
+// WithErrorCaching makes the cache remember the errors that the underlying
+// data source returns for keys that weren't in the cache. For the duration of
+// the ttl, any subsequent requests for the same key are going to receive the
+// same error rather than calling the data source again. This prevents error
+// storms from amplifying the load on a failing data source. Errors that wrap
+// ErrNotFound are handled by the missing record functionality instead, and
+// context cancellations are never cached. For client.GetOrFetchBatch, the
+// cached errors are returned as part of a BatchError.
+func WithErrorCaching(ttl time.Duration) Option {
+	return func(c *Config) {
+		c.errorCache = newErrorCache(ttl)
+	}
+}
+
 // WithEarlyRefreshes instructs the cache to refresh the keys that are in
 // active rotation, thereby preventing them from ever expiring. This can have a
 // significant impact on your application's latency as you're able to
@@ -65,6 +254,194 @@ func WithEarlyRefreshes(minRefreshTime, maxRefreshTime, retryBaseDelay time.Dura
 	}
 }
 
+// WithRandSource replaces the global random number generator that the cache
+// uses for the padding of the refresh times and the jitter of the TTLs. Passing
+// a seeded source, such as rand.NewPCG, makes the timing of the expiries and
+// refreshes reproducible in tests and simulations.
+func WithRandSource(src rand.Source) Option {
+	return func(c *Config) {
+		c.rand = &lockedRand{r: rand.New(src)}
+	}
+}
+
+// WithTTLJitter pads the TTL of every record that is written to the cache with
+// a random fraction of up to the given fraction of the TTL. For example, a
+// fraction of 0.1 makes a record with a TTL of 10 minutes expire after 10 to
+// 11 minutes. This prevents records that are written in the same burst, such
+// as when the cache is warmed up, from expiring at the same time. Records
+// that are imported keep the expiry that they were exported with.
+func WithTTLJitter(fraction float64) Option {
+	return func(c *Config) {
+		c.ttlJitter = fraction
+	}
+}
+
+// WithRefreshAtFraction makes the records eligible for a refresh once the
+// given fraction of their TTL has passed, rather than after the min and max
+// refresh times of WithEarlyRefreshes. A random fraction of up to jitter is
+// added for each record so that the refreshes are spread out over time. For
+// example, a fraction of 0.8 and a jitter of 0.1 refreshes a record that has
+// a TTL of 10 minutes after 8 to 9 minutes.
+//
+// NOTE: This requires the WithEarlyRefreshes functionality to be enabled,
+// and the min and max refresh times that it was given are ignored.
+func WithRefreshAtFraction(fraction, jitter float64) Option {
+	return func(c *Config) {
+		c.refreshAtFraction = fraction
+		c.refreshAtJitter = jitter
+	}
+}
+
+// WithFetchTimeout limits how long each call to the underlying data source is
+// allowed to take, both for the fetches that are performed in the foreground
+// and the refreshes that are performed in the background. The context that is
+// passed to the fetch function is cancelled once the timeout has passed, and
+// ErrFetchTimeout is returned even if the fetch function doesn't return. The
+// timeout applies to every attempt and chunk individually.
+func WithFetchTimeout(timeout time.Duration) Option {
+	return func(c *Config) {
+		c.fetchTimeout = timeout
+	}
+}
+
+// WithOriginRateLimit puts a ceiling on the rate at which the cache calls the
+// underlying data source. The limiter is enforced for Passthrough, the fetches
+// that are performed in the foreground and the refreshes that are performed in
+// the background. Every attempt and chunk has to wait for the limiter, and
+// ErrRateLimited is returned if the context is cancelled while waiting.
+func WithOriginRateLimit(limiter RateLimiter) Option {
+	return func(c *Config) {
+		c.originRateLimiter = limiter
+	}
+}
+
+// WithRetryPolicy makes the cache retry failed calls to the underlying data
+// source according to the policy. This applies to both foreground fetches and
+// background refreshes. The backoff of the policy also replaces the default
+// exponential backoff that is used to schedule the next refresh of a record
+// whose background refresh failed.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *Config) {
+		c.retryPolicy = policy
+	}
+}
+
+// WithCircuitBreaker makes the cache stop calling the underlying data source
+// after failureThreshold consecutive failures. While the circuit breaker is
+// open, fetches fail fast with ErrCircuitOpen, which means that records that
+// are due for a refresh keep being served from the cache. Once the
+// openDuration has passed, a single call is let through to probe if the data
+// source has recovered. Errors that wrap ErrNotFound are not considered to be
+// failures, and neither are calls that failed because the caller's context was
+// cancelled or its deadline passed.
+func WithCircuitBreaker(failureThreshold int, openDuration time.Duration) Option {
+	return func(c *Config) {
+		c.circuitBreakers = newCircuitBreakers(failureThreshold, openDuration)
+	}
+}
+
+// WithCircuitBreakerGroups makes the cache use a separate circuit breaker for
+// each group of keys, rather than one for the entire cache. The groupFn is
+// given the cache key, and should return the name of its group, e.g. the
+// prefix of the key. For batches, the group is determined by the key of the
+// first ID.
+//
+// NOTE: This requires the WithCircuitBreaker functionality to be enabled.
+func WithCircuitBreakerGroups(groupFn func(key string) string) Option {
+	return func(c *Config) {
+		c.circuitBreakerGroupFn = groupFn
+	}
+}
+
+// WithRefreshBackoff controls the delay between the refreshes of a record
+// whose background refresh keeps failing. The delay starts at baseDelay, and
+// grows according to the strategy without ever exceeding maxDelay. The
+// baseDelay takes precedence over the retryBaseDelay that was passed to
+// WithEarlyRefreshes.
+//
+// NOTE: This requires the WithEarlyRefreshes functionality to be enabled.
+func WithRefreshBackoff(baseDelay, maxDelay time.Duration, strategy BackoffStrategy) Option {
+	return func(c *Config) {
+		c.refreshBackoff = true
+		c.refreshBackoffBase = baseDelay
+		c.refreshBackoffMax = maxDelay
+		c.refreshBackoffStrategy = strategy
+	}
+}
+
+// WithMaxRefreshRetries stops records whose background refreshes keep failing
+// from being served forever. Once a record has been refreshed maxRetries
+// times without succeeding, it is either deleted from the cache or turned into
+// a missing record, depending on the policy. Missing records are still
+// refreshed like any other record.
+//
+// NOTE: This requires the WithEarlyRefreshes functionality to be enabled, and
+// the ExhaustedMarkMissing policy requires the WithMissingRecordStorage
+// functionality to be enabled.
+func WithMaxRefreshRetries(maxRetries int, onExhausted ExhaustedPolicy) Option {
+	return func(c *Config) {
+		c.maxRefreshRetries = maxRetries
+		c.refreshesExhaustedPolicy = onExhausted
+	}
+}
+
+// WithOnRefreshSuccess registers a function that is called every time a
+// background refresh of a key succeeds. The function is called from the
+// goroutine that performed the refresh, and should therefore not block.
+func WithOnRefreshSuccess(fn RefreshSuccessFn) Option {
+	return func(c *Config) {
+		c.onRefreshSuccess = fn
+	}
+}
+
+// WithOnRefreshFailure registers a function that is called every time a
+// background refresh of a key fails. The staleness it receives can be used to
+// alert on keys that haven't been refreshed successfully for a long time. The
+// function is called from the goroutine that performed the refresh, and should
+// therefore not block.
+func WithOnRefreshFailure(fn RefreshFailureFn) Option {
+	return func(c *Config) {
+		c.onRefreshFailure = fn
+	}
+}
+
+// WithRefreshTimeout limits how long a background refresh is allowed to take,
+// including any retries. The refreshes don't inherit the cancellation of the
+// request that triggered them, but they do keep its values, such as trace IDs.
+//
+// NOTE: This requires the WithEarlyRefreshes functionality to be enabled.
+func WithRefreshTimeout(timeout time.Duration) Option {
+	return func(c *Config) {
+		c.refreshTimeout = timeout
+	}
+}
+
+// WithRefreshAccessThreshold makes the cache only refresh the keys that have
+// been read at least minAccesses times since they were written. Keys that are
+// read less often than that are left to expire, rather than being refreshed
+// for as long as they remain in the cache.
+//
+// NOTE: This requires the WithEarlyRefreshes functionality to be enabled.
+func WithRefreshAccessThreshold(minAccesses int) Option {
+	return func(c *Config) {
+		c.minRefreshAccesses = minAccesses
+	}
+}
+
+// WithRefreshConcurrency limits the number of background refreshes that are
+// performed at once. The refreshes are handed to a pool of workers through a
+// queue of the given size, and the overflow policy determines what happens to
+// the refreshes that are scheduled while the queue is full.
+//
+// NOTE: This requires the WithEarlyRefreshes functionality to be enabled.
+func WithRefreshConcurrency(workers, queueSize int, overflow OverflowPolicy) Option {
+	return func(c *Config) {
+		c.refreshWorkers = workers
+		c.refreshQueueSize = queueSize
+		c.refreshOverflow = overflow
+	}
+}
+
 // WithRefreshCoalescing will make the cache refresh data from batchable
 // endpoints more efficiently. It is going to create a buffer for each cache
 // key permutation, and gather IDs until the bufferSize is reached, or the
@@ -80,6 +457,55 @@ func WithRefreshCoalescing(bufferSize int, bufferDuration time.Duration) Option
 	}
 }
 
+// WithRefreshCoalescingFn makes it possible to configure the size and timeout
+// of the refresh buffers for each cache key permutation. The bufferFn is given
+// the permutation string of the buffer, and a size or timeout that is not
+// greater than 0 falls back to the values of WithRefreshCoalescing. This is
+// useful when the batchable endpoints accept a different number of IDs.
+//
+// NOTE: This requires the WithRefreshCoalescing functionality to be enabled.
+func WithRefreshCoalescingFn(bufferFn func(permutation string) (bufferSize int, bufferDuration time.Duration)) Option {
+	return func(c *Config) {
+		c.bufferFn = bufferFn
+	}
+}
+
+// WithHedgedFetches makes the cache issue a second, hedged, request to the
+// underlying data source if a foreground fetch hasn't returned within the
+// given percentile of the latencies that have been observed for previous
+// fetches. The first successful response wins, and the context of the other
+// request is cancelled. Until enough latencies have been observed, the
+// fallbackDelay is used instead. Background refreshes are never hedged.
+func WithHedgedFetches(percentile float64, fallbackDelay time.Duration) Option {
+	return func(c *Config) {
+		c.hedging = newHedging(percentile, fallbackDelay)
+	}
+}
+
+// WithMaxBatchSize limits the number of IDs that are passed to a
+// BatchFetchFn. If a batch of cache misses exceeds this size, the IDs are
+// split into chunks that are fetched separately, and the responses are merged
+// before they're returned. This is useful for upstream APIs that cap the
+// number of IDs that can be requested at once. The IDs of the chunks that
+// fail are returned in a BatchError, while the records of the other chunks
+// are cached and returned.
+func WithMaxBatchSize(size int) Option {
+	return func(c *Config) {
+		c.maxBatchSize = size
+	}
+}
+
+// WithBatchConcurrency allows the chunks that are created by WithMaxBatchSize
+// to be fetched in parallel. At most concurrency chunks are going to be
+// fetched from the underlying data source at once.
+//
+// NOTE: This requires the WithMaxBatchSize functionality to be enabled.
+func WithBatchConcurrency(concurrency int) Option {
+	return func(c *Config) {
+		c.batchConcurrency = concurrency
+	}
+}
+
 // WithRelativeTimeKeyFormat allows you to control the truncation of time.Time
 // values that are being passed in to the cache key functions.
 func WithRelativeTimeKeyFormat(truncation time.Duration) Option {
@@ -89,6 +515,18 @@ func WithRelativeTimeKeyFormat(truncation time.Duration) Option {
 	}
 }
 
+// WithTimeKeyFormatter replaces the formatting of the time.Time values that
+// are being passed in to the cache key functions. The formatter is given the
+// current time of the cache's clock along with the value, which makes it
+// possible to bucket the values with custom rules, such as business days or
+// 15-minute windows. Zero values are still formatted as "empty-time". The
+// formatter takes precedence over WithRelativeTimeKeyFormat.
+func WithTimeKeyFormatter(formatter func(now, t time.Time) string) Option {
+	return func(c *Config) {
+		c.timeKeyFormatter = formatter
+	}
+}
+
 // WithLog allows you to set a custom logger for the cache. The cache isn't chatty,
 // and will only log warnings and errors that would be a nightmare to debug. If you
 // absolutely don't want any logs, you can pass in the sturdyc.NoopLogger.
@@ -98,6 +536,20 @@ func WithLog(log Logger) Option {
 	}
 }
 
+// WithLogLevel sets the minimum level of the messages that are logged by the
+// subsystem. The subsystems log warnings and errors by default, and the debug
+// and info messages, such as the latency of each refresh, are only passed to
+// loggers that implement LeveledLogger. Every message includes structured
+// attributes, such as the subsystem, key and error.
+func WithLogLevel(subsystem LogSubsystem, level slog.Level) Option {
+	return func(c *Config) {
+		if c.logLevels == nil {
+			c.logLevels = make(map[LogSubsystem]slog.Level)
+		}
+		c.logLevels[subsystem] = level
+	}
+}
+
 // WithDistributedStorage allows you to use the cache with a distributed
 // key-value store. The "GetOrFetch" and "GetOrFetchBatch" functions will check
 // this store first and only proceed to the underlying data source if the key
@@ -134,11 +586,210 @@ func WithDistributedStorageEarlyRefreshes(storage DistributedStorageWithDeletion
 	}
 }
 
+// WithStorageTiers replaces the single distributed storage with an ordered
+// list of tiers that sit between the in-memory cache and the underlying data
+// source. Reads check the tiers in order, and the records that are found are
+// promoted to the tiers above that have Promote set. Writes are performed
+// according to the write policy of each tier, while deletions are applied to
+// every tier. NewClientStorage can be used to make another client one of the
+// tiers. The tiers are used like the storage of WithDistributedStorage, and
+// can be combined with the other distributed options.
+func WithStorageTiers(tiers ...Tier) Option {
+	return func(c *Config) {
+		c.distributedStorage = &tieredStorage{tiers: tiers}
+		c.distributedEarlyRefreshes = false
+	}
+}
+
+// WithPeers makes the instances of the cache share their records with each
+// other. Each key is owned by one of the peers, and a miss is first looked up
+// in the memory of the owner before the underlying data source is called. The
+// records that are fetched are written to the owner as well. NewPeerRing can
+// be used as the picker, and the peers have to expose client.PeerHandler if
+// NewHTTPPeer is used. The peers are used like the storage of
+// WithDistributedStorage, and can be combined with the other distributed options.
+func WithPeers(picker PeerPicker) Option {
+	return func(c *Config) {
+		c.distributedStorage = &peerStorage{picker: picker}
+		c.distributedEarlyRefreshes = false
+	}
+}
+
+// WithCodec replaces the JSON encoding of the values of type T that are
+// written to the distributed storage, exchanged between peers, and exported.
+// The option can be passed once per type, which allows a Client[any] to use
+// a codec for each of the types that it stores. The values of the types that
+// don't have a codec are encoded as JSON. Every instance that shares the
+// storage has to use the same codecs.
+func WithCodec[T any](codec Codec[T]) Option {
+	return func(c *Config) {
+		if c.codecs == nil {
+			c.codecs = make(map[reflect.Type]any)
+		}
+		c.codecs[reflect.TypeFor[T]()] = codec
+	}
+}
+
+// WithDistributedKeyPrefix prefixes every key that the cache reads from,
+// writes to, or deletes from the distributed storage. This allows multiple
+// services to share the same storage without their keys colliding.
+//
+// NOTE: This requires one of the distributed storage options to be used.
+func WithDistributedKeyPrefix(prefix string) Option {
+	return func(c *Config) {
+		c.distributedKeyPrefix = prefix
+	}
+}
+
+// WithDistributedCompression compresses the records that are written to the
+// distributed storage, and decompresses them when they are read. Records that
+// are smaller than the threshold, in bytes, are written without compression.
+// Records that were written without compression can still be read after this
+// option has been enabled. NewGzipCompressor can be used as the compressor,
+// or you can implement the Compressor interface for any other algorithm.
+//
+// NOTE: This requires one of the distributed storage options to be used.
+func WithDistributedCompression(compressor Compressor, threshold int) Option {
+	return func(c *Config) {
+		c.distributedCompressor = compressor
+		c.distributedCompressionThreshold = threshold
+	}
+}
+
+// WithDistributedWriteCoalescing buffers the records that the cache writes to,
+// and deletes from, the distributed storage. The buffered operations are
+// flushed using SetBatch and DeleteBatch once the window has passed, or as soon
+// as the buffer holds maxBatchSize keys. A maxBatchSize of 0 means that the
+// buffer is only flushed when the window has passed. Reads from the storage
+// are served from the buffer for keys that are yet to be flushed.
+//
+// NOTE: This requires one of the distributed storage options to be used.
+func WithDistributedWriteCoalescing(window time.Duration, maxBatchSize int) Option {
+	return func(c *Config) {
+		c.distributedWriteWindow = window
+		c.distributedWriteMaxBatchSize = maxBatchSize
+	}
+}
+
+// WithDistributedStorageCircuitBreaker stops the cache from calling the
+// distributed storage after failureThreshold consecutive operations have
+// failed. An operation is considered to have failed if it takes longer than
+// the timeout, which is also applied to the context that is passed to the
+// storage. While the circuit breaker is open, reads from the storage are
+// treated as misses, and writes are dropped. Once the openDuration has passed,
+// a single operation is let through to probe if the storage has recovered.
+//
+// NOTE: This requires one of the distributed storage options to be used.
+func WithDistributedStorageCircuitBreaker(failureThreshold int, openDuration, timeout time.Duration) Option {
+	return func(c *Config) {
+		c.distributedBreakers = newCircuitBreakers(failureThreshold, openDuration)
+		c.distributedStorageTimeout = timeout
+	}
+}
+
+// WithDistributedLocks makes the instances that share the distributed storage
+// coordinate their calls to the underlying data source, so that only one of
+// them fetches a given key at a time. The locks expire after the lockTTL. The
+// instance that holds the lock writes the record to the distributed storage
+// before it releases it. An instance that can't acquire the lock serves the
+// stale record from the distributed storage if there is one. Otherwise, it
+// waits up to maxWait for the record to be written by the lock holder, and
+// takes over the lock if it's released without the record having been
+// written. Batches are not locked.
+//
+// NOTE: This requires one of the distributed storage options to be used.
+func WithDistributedLocks(locker DistributedLocker, lockTTL, maxWait time.Duration) Option {
+	return func(c *Config) {
+		c.distributedLocker = locker
+		c.distributedLockTTL = lockTTL
+		c.distributedLockWait = maxWait
+	}
+}
+
+// WithDistributedWriteThrough makes Set, SetMany, SetManyKeyFn,
+// StoreMissingRecord and Delete propagate their changes to the distributed
+// storage synchronously, rather than only updating the in-memory cache. This
+// keeps the distributed storage authoritative for the records that are
+// written to the cache explicitly. Deletions are only propagated if the
+// storage was passed to WithDistributedStorageEarlyRefreshes.
+//
+// NOTE: This requires one of the distributed storage options to be used.
+func WithDistributedWriteThrough() Option {
+	return func(c *Config) {
+		c.distributedWriteThrough = true
+	}
+}
+
+// WithDistributedWriteBehind makes the writes and deletions that the cache
+// performs against the distributed storage asynchronous. The operations are
+// added to an in-memory queue of queueSize, and flushed in batches using
+// SetBatch and DeleteBatch by the given number of workers. The operations for
+// a key are always flushed by the same worker, in the order they were made.
+// The overflow policy determines whether operations are dropped, or if the
+// caller has to wait, when the queue is full. Reads don't see the operations
+// that are still in the queue. Use client.Drain to flush the queue before
+// shutting down.
+//
+// NOTE: The queue is only kept in memory, which means that the operations
+// that haven't been flushed are lost if the process crashes, or exits without
+// calling client.Drain. Use WithDistributedWriteBehindQueue to persist them.
+//
+// NOTE: This requires one of the distributed storage options to be used.
+func WithDistributedWriteBehind(workers, queueSize int, overflow OverflowPolicy) Option {
+	return func(c *Config) {
+		c.writeBehindWorkers = workers
+		c.writeBehindQueueSize = queueSize
+		c.writeBehindOverflow = overflow
+	}
+}
+
+// WithDistributedWriteBehindRetries makes the workers of the write-behind
+// queue retry the batches that failed according to the policy. A batch is
+// considered to have failed if the storage implements
+// DistributedStorageWithErrors and returns an error, if it takes longer than
+// the timeout, which is also applied to the context that is passed to the
+// storage, or if it panics. The error that is passed to the policy wraps
+// ErrDistributedWriteFailed.
+//
+// NOTE: This requires the WithDistributedWriteBehind functionality to be enabled.
+func WithDistributedWriteBehindRetries(timeout time.Duration, policy RetryPolicy) Option {
+	return func(c *Config) {
+		c.writeBehindTimeout = timeout
+		c.writeBehindRetryPolicy = policy
+	}
+}
+
+// WithDistributedWriteBehindQueue persists the operations of the write-behind
+// queue in the durable queue before they are queued in memory. The operations
+// that were never acknowledged, e.g. because the process crashed, are flushed
+// when the client is created. Operations that are dropped by the overflow
+// policy are acknowledged without being flushed.
+//
+// NOTE: This requires the WithDistributedWriteBehind functionality to be enabled.
+func WithDistributedWriteBehindQueue(queue WriteBehindQueue) Option {
+	return func(c *Config) {
+		c.writeBehindQueue = queue
+	}
+}
+
+// WithInvalidationBus makes explicit calls to Delete propagate to every tier.
+// The key is deleted from the distributed storage, and published on the bus so
+// that the other instances remove it from memory. Keys are published with the
+// prefix of WithDistributedKeyPrefix, and keys with other prefixes are ignored.
+// Deletions are only propagated to the distributed storage if it was passed to
+// WithDistributedStorageEarlyRefreshes.
+//
+// NOTE: This requires one of the distributed storage options to be used.
+func WithInvalidationBus(bus InvalidationBus) Option {
+	return func(c *Config) {
+		c.invalidationBus = bus
+	}
+}
+
 // WithDistributedMetrics instructs the cache to report additional metrics
 // regarding its interaction with the distributed storage.
 func WithDistributedMetrics(metricsRecorder DistributedMetricsRecorder) Option {
 	return func(c *Config) {
-		metricsRecorder.ObserveCacheSize(c.getSize)
 		c.metricsRecorder = metricsRecorder
 	}
 }
@@ -173,10 +824,74 @@ func validateConfig(capacity, numShards int, ttl time.Duration, evictionPercenta
 		panic("bufferTimeout must be greater than 0")
 	}
 
+	if cfg.bufferFn != nil && !cfg.bufferRefreshes {
+		panic("per permutation buffers requires refresh coalescing to be enabled")
+	}
+
+	if cfg.serveStaleOnError && cfg.staleOnErrorDuration < 1 {
+		panic("maxStaleness must be greater than 0")
+	}
+
+	if cfg.errorCache != nil && cfg.errorCache.ttl < 1 {
+		panic("error caching ttl must be greater than 0")
+	}
+
+	if cfg.retryPolicy != nil && cfg.retryPolicy.MaxAttempts() < 1 {
+		panic("retryPolicy.MaxAttempts must be greater than 0")
+	}
+
+	if cfg.circuitBreakers != nil && cfg.circuitBreakers.failureThreshold < 1 {
+		panic("failureThreshold must be greater than 0")
+	}
+
+	if cfg.circuitBreakerGroupFn != nil && cfg.circuitBreakers == nil {
+		panic("circuit breaker groups requires the circuit breaker to be enabled")
+	}
+
+	if cfg.hedging != nil && (cfg.hedging.percentile <= 0 || cfg.hedging.percentile > 1) {
+		panic("percentile must be greater than 0 and less than or equal to 1")
+	}
+
+	if cfg.hedging != nil && cfg.hedging.fallbackDelay < 1 {
+		panic("fallbackDelay must be greater than 0")
+	}
+
+	if cfg.maxBatchSize < 0 {
+		panic("maxBatchSize must be greater than or equal to 0")
+	}
+
+	if cfg.batchConcurrency < 0 {
+		panic("batchConcurrency must be greater than or equal to 0")
+	}
+
+	if cfg.batchConcurrency > 0 && cfg.maxBatchSize < 1 {
+		panic("batch concurrency requires a max batch size to be set")
+	}
+
+	if cfg.lockStripes < 1 {
+		panic("stripesPerShard must be greater than 0")
+	}
+
+	if cfg.parallelGetManyKeys < 0 {
+		panic("minKeys must be greater than or equal to 0")
+	}
+
 	if cfg.evictionInterval < 1 {
 		panic("evictionInterval must be greater than 0")
 	}
 
+	if (cfg.doorkeeperKeys != 0 || cfg.doorkeeperWindow != 0) && (cfg.doorkeeperKeys < 1 || cfg.doorkeeperWindow <= 0) {
+		panic("expectedKeys and window must be greater than 0")
+	}
+
+	if cfg.adaptiveEvictions && cfg.disableContinuousEvictions {
+		panic("adaptive evictions requires continuous evictions to be enabled")
+	}
+
+	if cfg.adaptiveEvictions && (cfg.minEvictionInterval < 1 || cfg.maxEvictionInterval < cfg.minEvictionInterval) {
+		panic("minInterval must be greater than 0 and less than or equal to maxInterval")
+	}
+
 	if cfg.minRefreshTime > cfg.maxRefreshTime {
 		panic("minRefreshTime must be less than or equal to maxRefreshTime")
 	}
@@ -184,4 +899,182 @@ func validateConfig(capacity, numShards int, ttl time.Duration, evictionPercenta
 	if cfg.retryBaseDelay < 0 {
 		panic("retryBaseDelay must be greater than or equal to 0")
 	}
+
+	if cfg.refreshBackoff && !cfg.refreshInBackground {
+		panic("refresh backoff requires background refreshes to be enabled")
+	}
+
+	if cfg.hasRefreshHooks() && !cfg.refreshInBackground {
+		panic("refresh hooks require background refreshes to be enabled")
+	}
+
+	if cfg.distributedKeyPrefix != "" && cfg.distributedStorage == nil {
+		panic("a distributed key prefix requires a distributed storage")
+	}
+
+	if cfg.distributedCompressor != nil && cfg.distributedStorage == nil {
+		panic("distributed compression requires a distributed storage")
+	}
+
+	if cfg.distributedCompressionThreshold < 0 {
+		panic("threshold must be greater than or equal to 0")
+	}
+
+	if cfg.distributedWriteWindow != 0 && cfg.distributedStorage == nil {
+		panic("distributed write coalescing requires a distributed storage")
+	}
+
+	if cfg.distributedWriteWindow < 0 {
+		panic("window must be greater than 0")
+	}
+
+	if cfg.distributedWriteMaxBatchSize < 0 {
+		panic("maxBatchSize must be greater than or equal to 0")
+	}
+
+	if cfg.distributedBreakers != nil && cfg.distributedStorage == nil {
+		panic("distributed storage circuit breaker requires a distributed storage")
+	}
+
+	if cfg.distributedBreakers != nil && cfg.distributedBreakers.failureThreshold < 1 {
+		panic("failureThreshold must be greater than 0")
+	}
+
+	if cfg.distributedBreakers != nil && cfg.distributedStorageTimeout <= 0 {
+		panic("timeout must be greater than 0")
+	}
+
+	if cfg.distributedWriteThrough && cfg.distributedStorage == nil {
+		panic("distributed write-through requires a distributed storage")
+	}
+
+	if cfg.writeBehindWorkers != 0 && cfg.distributedStorage == nil {
+		panic("distributed write-behind requires a distributed storage")
+	}
+
+	if cfg.writeBehindWorkers < 0 || cfg.writeBehindQueueSize < 0 {
+		panic("workers and queueSize must be greater than or equal to 0")
+	}
+
+	if cfg.writeBehindRetryPolicy != nil && cfg.writeBehindWorkers == 0 {
+		panic("write-behind retries requires write-behind to be enabled")
+	}
+
+	if cfg.writeBehindQueue != nil && cfg.writeBehindWorkers == 0 {
+		panic("a write-behind queue requires write-behind to be enabled")
+	}
+
+	if cfg.writeBehindTimeout < 0 {
+		panic("timeout must be greater than or equal to 0")
+	}
+
+	if tiered, ok := cfg.distributedStorage.(*tieredStorage); ok && len(tiered.tiers) == 0 {
+		panic("at least one storage tier is required")
+	}
+
+	for _, codec := range cfg.codecs {
+		if codec == nil {
+			panic("codec must not be nil")
+		}
+	}
+
+	if cfg.keyHasher == nil {
+		panic("hasher must not be nil")
+	}
+
+	if cfg.shardSkewThreshold < 0 || (cfg.shardSkewThreshold > 0 && cfg.shardSkewThreshold < 1) {
+		panic("threshold must be greater than or equal to 1")
+	}
+
+	if cfg.useExpvar && cfg.expvarPrefix == "" {
+		panic("expvar prefix must not be empty")
+	}
+
+	if cfg.statsWindow < 0 || (cfg.statsWindow > 0 && (cfg.statsBucket <= 0 || cfg.statsBucket > cfg.statsWindow)) {
+		panic("bucket must be greater than 0 and less than or equal to the window")
+	}
+
+	if cfg.invalidationBus != nil && cfg.distributedStorage == nil {
+		panic("invalidation bus requires a distributed storage")
+	}
+
+	if cfg.distributedLocker != nil && cfg.distributedStorage == nil {
+		panic("distributed locks requires a distributed storage")
+	}
+
+	if cfg.distributedLocker != nil && (cfg.distributedLockTTL <= 0 || cfg.distributedLockWait <= 0) {
+		panic("lockTTL and maxWait must be greater than 0")
+	}
+
+	if cfg.missingRecordTTL < 0 {
+		panic("ttl must be greater than or equal to 0")
+	}
+
+	if cfg.fetchTimeout < 0 {
+		panic("timeout must be greater than or equal to 0")
+	}
+
+	if cfg.refreshAtFraction < 0 || cfg.refreshAtFraction > 1 {
+		panic("fraction must be between 0 and 1")
+	}
+
+	if cfg.refreshAtJitter < 0 || cfg.refreshAtFraction+cfg.refreshAtJitter > 1 {
+		panic("jitter must be greater than or equal to 0, and fraction+jitter must not exceed 1")
+	}
+
+	if cfg.ttlJitter < 0 || cfg.ttlJitter > 1 {
+		panic("ttl jitter must be between 0 and 1")
+	}
+
+	if cfg.refreshAtFraction > 0 && !cfg.refreshInBackground {
+		panic("refreshing at a fraction of the TTL requires background refreshes to be enabled")
+	}
+
+	if cfg.refreshTimeout < 0 {
+		panic("timeout must be greater than or equal to 0")
+	}
+
+	if cfg.refreshTimeout > 0 && !cfg.refreshInBackground {
+		panic("refresh timeout requires background refreshes to be enabled")
+	}
+
+	if cfg.minRefreshAccesses < 0 {
+		panic("minAccesses must be greater than or equal to 0")
+	}
+
+	if cfg.minRefreshAccesses > 0 && !cfg.refreshInBackground {
+		panic("the refresh access threshold requires background refreshes to be enabled")
+	}
+
+	if cfg.refreshWorkers < 0 {
+		panic("workers must be greater than or equal to 0")
+	}
+
+	if cfg.refreshQueueSize < 0 {
+		panic("queueSize must be greater than or equal to 0")
+	}
+
+	if cfg.refreshWorkers > 0 && !cfg.refreshInBackground {
+		panic("refresh concurrency requires background refreshes to be enabled")
+	}
+
+	if cfg.maxRefreshRetries < 0 {
+		panic("maxRetries must be greater than or equal to 0")
+	}
+
+	if cfg.maxRefreshRetries > 0 && !cfg.refreshInBackground {
+		panic("max refresh retries requires background refreshes to be enabled")
+	}
+
+	if cfg.maxRefreshRetries > 0 && cfg.refreshesExhaustedPolicy == ExhaustedMarkMissing && !cfg.storeMissingRecords {
+		panic("the ExhaustedMarkMissing policy requires missing record storage to be enabled")
+	}
+
+	if cfg.refreshBackoff && cfg.refreshBackoffBase < 0 {
+		panic("baseDelay must be greater than or equal to 0")
+	}
+
+	if cfg.refreshBackoff && cfg.refreshBackoffMax < cfg.refreshBackoffBase {
+		panic("maxDelay must be greater than or equal to baseDelay")
+	}
 }
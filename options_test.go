@@ -1,6 +1,7 @@
 package sturdyc_test
 
 import (
+	"compress/gzip"
 	"testing"
 	"time"
 
@@ -152,3 +153,485 @@ func TestPanicsIfTheRetryBaseDelayIsLessThanZero(t *testing.T) {
 		sturdyc.WithEarlyRefreshes(time.Minute, time.Hour, -1),
 	)
 }
+
+func TestPanicsIfTheNumberOfLockStripesIsLessThanOne(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		err := recover()
+		if err == nil {
+			t.Error("expected a panic when trying to use 0 lock stripes")
+		}
+	}()
+	sturdyc.New[string](100, 10, time.Minute, 5,
+		sturdyc.WithStripedLocks(0),
+	)
+}
+
+func TestPanicsIfTheMaxBatchSizeIsLessThanZero(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		err := recover()
+		if err == nil {
+			t.Error("expected a panic when trying to use -1 as max batch size")
+		}
+	}()
+	sturdyc.New[string](100, 10, time.Minute, 5,
+		sturdyc.WithMaxBatchSize(-1),
+	)
+}
+
+func TestPanicsIfBatchConcurrencyIsUsedWithoutMaxBatchSize(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		err := recover()
+		if err == nil {
+			t.Error("expected a panic when trying to use batch concurrency without a max batch size")
+		}
+	}()
+	sturdyc.New[string](100, 10, time.Minute, 5,
+		sturdyc.WithBatchConcurrency(2),
+	)
+}
+
+func TestPanicsIfTheRetryPolicyHasNoAttempts(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		err := recover()
+		if err == nil {
+			t.Error("expected a panic when trying to use a retry policy with 0 attempts")
+		}
+	}()
+	sturdyc.New[string](100, 10, time.Minute, 5,
+		sturdyc.WithRetryPolicy(sturdyc.NewRetryPolicy(0, time.Second, time.Second, nil)),
+	)
+}
+
+func TestPanicsIfCircuitBreakerGroupsAreUsedWithoutACircuitBreaker(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		err := recover()
+		if err == nil {
+			t.Error("expected a panic when trying to use circuit breaker groups without a circuit breaker")
+		}
+	}()
+	sturdyc.New[string](100, 10, time.Minute, 5,
+		sturdyc.WithCircuitBreakerGroups(func(key string) string { return key }),
+	)
+}
+
+func TestPanicsIfTheHedgePercentileIsOutOfRange(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		err := recover()
+		if err == nil {
+			t.Error("expected a panic when trying to use 1.5 as hedge percentile")
+		}
+	}()
+	sturdyc.New[string](100, 10, time.Minute, 5,
+		sturdyc.WithHedgedFetches(1.5, time.Second),
+	)
+}
+
+func TestPanicsIfTheErrorCachingTTLIsLessThanOne(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		err := recover()
+		if err == nil {
+			t.Error("expected a panic when trying to use 0 as error caching ttl")
+		}
+	}()
+	sturdyc.New[string](100, 10, time.Minute, 5,
+		sturdyc.WithErrorCaching(0),
+	)
+}
+
+func TestPanicsIfTheRefreshBackoffMaxIsLessThanTheBase(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		err := recover()
+		if err == nil {
+			t.Error("expected a panic when trying to use a max delay that is less than the base delay")
+		}
+	}()
+	sturdyc.New[string](100, 10, time.Minute, 5,
+		sturdyc.WithEarlyRefreshes(time.Minute, time.Hour, time.Second),
+		sturdyc.WithRefreshBackoff(time.Minute, time.Second, sturdyc.ExponentialBackoff),
+	)
+}
+
+func TestPanicsIfMarkMissingOnExhaustedRefreshesIsUsedWithoutMissingRecordStorage(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		err := recover()
+		if err == nil {
+			t.Error("expected a panic when trying to mark exhausted records as missing without missing record storage")
+		}
+	}()
+	sturdyc.New[string](100, 10, time.Minute, 5,
+		sturdyc.WithEarlyRefreshes(time.Minute, time.Hour, time.Second),
+		sturdyc.WithMaxRefreshRetries(3, sturdyc.ExhaustedMarkMissing),
+	)
+}
+
+func TestPanicsIfRefreshHooksAreUsedWithoutEarlyRefreshes(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		err := recover()
+		if err == nil {
+			t.Error("expected a panic when trying to use refresh hooks without early refreshes")
+		}
+	}()
+	sturdyc.New[string](100, 10, time.Minute, 5,
+		sturdyc.WithOnRefreshFailure(func(string, error, int, time.Duration) {}),
+	)
+}
+
+func TestPanicsIfRefreshConcurrencyIsUsedWithoutEarlyRefreshes(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		err := recover()
+		if err == nil {
+			t.Error("expected a panic when trying to use refresh concurrency without early refreshes")
+		}
+	}()
+	sturdyc.New[string](100, 10, time.Minute, 5,
+		sturdyc.WithRefreshConcurrency(10, 100, sturdyc.OverflowDrop),
+	)
+}
+
+func TestPanicsIfTheRefreshFractionAndJitterExceedsOne(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		err := recover()
+		if err == nil {
+			t.Error("expected a panic when trying to use 0.8 as fraction and 0.3 as jitter")
+		}
+	}()
+	sturdyc.New[string](100, 10, time.Minute, 5,
+		sturdyc.WithEarlyRefreshes(time.Minute, time.Hour, time.Second),
+		sturdyc.WithRefreshAtFraction(0.8, 0.3),
+	)
+}
+
+func TestPanicsIfDistributedCompressionIsUsedWithoutDistributedStorage(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		err := recover()
+		if err == nil {
+			t.Error("expected a panic when trying to use distributed compression without a distributed storage")
+		}
+	}()
+	sturdyc.New[string](100, 10, time.Minute, 5,
+		sturdyc.WithDistributedCompression(sturdyc.NewGzipCompressor(gzip.DefaultCompression), 0),
+	)
+}
+
+func TestPanicsIfDistributedWriteCoalescingIsUsedWithoutDistributedStorage(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		err := recover()
+		if err == nil {
+			t.Error("expected a panic when trying to use write coalescing without a distributed storage")
+		}
+	}()
+	sturdyc.New[string](100, 10, time.Minute, 5,
+		sturdyc.WithDistributedWriteCoalescing(time.Second, 100),
+	)
+}
+
+func TestPanicsIfDistributedStorageCircuitBreakerIsUsedWithoutDistributedStorage(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		err := recover()
+		if err == nil {
+			t.Error("expected a panic when trying to use the storage circuit breaker without a distributed storage")
+		}
+	}()
+	sturdyc.New[string](100, 10, time.Minute, 5,
+		sturdyc.WithDistributedStorageCircuitBreaker(3, time.Minute, time.Second),
+	)
+}
+
+func TestPanicsIfDistributedLocksAreUsedWithoutDistributedStorage(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		err := recover()
+		if err == nil {
+			t.Error("expected a panic when trying to use distributed locks without a distributed storage")
+		}
+	}()
+	sturdyc.New[string](100, 10, time.Minute, 5,
+		sturdyc.WithDistributedLocks(&mockLocker{}, time.Minute, time.Second),
+	)
+}
+
+func TestPanicsIfDistributedWriteThroughIsUsedWithoutDistributedStorage(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		err := recover()
+		if err == nil {
+			t.Error("expected a panic when trying to use write-through without a distributed storage")
+		}
+	}()
+	sturdyc.New[string](100, 10, time.Minute, 5,
+		sturdyc.WithDistributedWriteThrough(),
+	)
+}
+
+func TestPanicsIfDistributedWriteBehindIsUsedWithoutDistributedStorage(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		err := recover()
+		if err == nil {
+			t.Error("expected a panic when trying to use write-behind without a distributed storage")
+		}
+	}()
+	sturdyc.New[string](100, 10, time.Minute, 5,
+		sturdyc.WithDistributedWriteBehind(1, 100, sturdyc.OverflowBlock),
+	)
+}
+
+func TestPanicsIfDistributedWriteBehindQueueIsUsedWithoutWriteBehind(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		err := recover()
+		if err == nil {
+			t.Error("expected a panic when trying to use a write-behind queue without write-behind")
+		}
+	}()
+	sturdyc.New[string](100, 10, time.Minute, 5,
+		sturdyc.WithDistributedStorage(&mockStorage{}),
+		sturdyc.WithDistributedWriteBehindQueue(&memoryWriteBehindQueue{}),
+	)
+}
+
+func TestPanicsIfInvalidationBusIsUsedWithoutDistributedStorage(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		err := recover()
+		if err == nil {
+			t.Error("expected a panic when trying to use an invalidation bus without a distributed storage")
+		}
+	}()
+	sturdyc.New[string](100, 10, time.Minute, 5,
+		sturdyc.WithInvalidationBus(&mockInvalidationBus{}),
+	)
+}
+
+func TestPanicsIfNoStorageTiersAreProvided(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		err := recover()
+		if err == nil {
+			t.Error("expected a panic when trying to use storage tiers without any tiers")
+		}
+	}()
+	sturdyc.New[string](100, 10, time.Minute, 5,
+		sturdyc.WithStorageTiers(),
+	)
+}
+
+func TestPanicsIfTheStatsBucketIsLargerThanTheWindow(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		err := recover()
+		if err == nil {
+			t.Error("expected a panic when the stats bucket is larger than the window")
+		}
+	}()
+	sturdyc.New[string](100, 10, time.Minute, 5,
+		sturdyc.WithStatsWindow(time.Second, time.Minute),
+	)
+}
+
+func TestPanicsIfTheExpvarPrefixIsEmpty(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		err := recover()
+		if err == nil {
+			t.Error("expected a panic when the expvar prefix is empty")
+		}
+	}()
+	sturdyc.New[string](100, 10, time.Minute, 5,
+		sturdyc.WithExpvar(""),
+	)
+}
+
+func TestPanicsIfTheShardSkewThresholdIsLessThanOne(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		err := recover()
+		if err == nil {
+			t.Error("expected a panic when the shard skew threshold is less than 1")
+		}
+	}()
+	sturdyc.New[string](100, 10, time.Minute, 5,
+		sturdyc.WithShardSkewWarning(0.5),
+	)
+}
+
+func TestPanicsIfTheKeyHasherIsNil(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		err := recover()
+		if err == nil {
+			t.Error("expected a panic when the key hasher is nil")
+		}
+	}()
+	sturdyc.New[string](100, 10, time.Minute, 5,
+		sturdyc.WithKeyHasher(nil),
+	)
+}
+
+func TestPanicsIfAdaptiveEvictionsAreUsedWithoutContinuousEvictions(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		err := recover()
+		if err == nil {
+			t.Error("expected a panic when trying to use adaptive evictions without continuous evictions")
+		}
+	}()
+	sturdyc.New[string](100, 10, time.Minute, 5,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithAdaptiveEvictions(time.Second, time.Minute),
+	)
+}
+
+func TestPanicsIfTheDoorkeeperHasNoWindow(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		err := recover()
+		if err == nil {
+			t.Error("expected a panic when the doorkeeper has no window")
+		}
+	}()
+	sturdyc.New[string](100, 10, time.Minute, 5,
+		sturdyc.WithDoorkeeper(1000, 0),
+	)
+}
+
+func TestPanicsIfTheParallelGetManyThresholdIsNegative(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		err := recover()
+		if err == nil {
+			t.Error("expected a panic when the parallel GetMany threshold is negative")
+		}
+	}()
+	sturdyc.New[string](100, 10, time.Minute, 5,
+		sturdyc.WithParallelGetMany(-1),
+	)
+}
+
+func TestPanicsIfTheMissingRecordTTLIsNegative(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		err := recover()
+		if err == nil {
+			t.Error("expected a panic when the missing record TTL is negative")
+		}
+	}()
+	sturdyc.New[string](100, 10, time.Minute, 5,
+		sturdyc.WithMissingRecordTTL(-time.Second),
+	)
+}
+
+func TestPanicsIfTheValidatorHasTheWrongType(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		err := recover()
+		if err == nil {
+			t.Error("expected a panic when the validator has the wrong type")
+		}
+	}()
+	sturdyc.New[string](100, 10, time.Minute, 5,
+		sturdyc.WithValidator(func(string, int) error { return nil }),
+	)
+}
+
+func TestPanicsIfTheTransformHasTheWrongType(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		err := recover()
+		if err == nil {
+			t.Error("expected a panic when the transform has the wrong type")
+		}
+	}()
+	sturdyc.New[string](100, 10, time.Minute, 5,
+		sturdyc.WithTransform(func(_ string, value int) int { return value }),
+	)
+}
+
+func TestPanicsIfTheCostFuncHasTheWrongType(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		err := recover()
+		if err == nil {
+			t.Error("expected a panic when the cost function has the wrong type")
+		}
+	}()
+	sturdyc.New[string](100, 10, time.Minute, 5,
+		sturdyc.WithCostFunc(func(string, int) int { return 0 }),
+	)
+}
+
+func TestPanicsIfThePermutationBuffersAreUsedWithoutRefreshCoalescing(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		err := recover()
+		if err == nil {
+			t.Error("expected a panic when the permutation buffers are used without refresh coalescing")
+		}
+	}()
+	sturdyc.New[string](100, 10, time.Minute, 5,
+		sturdyc.WithEarlyRefreshes(time.Minute, time.Minute, time.Second),
+		sturdyc.WithRefreshCoalescingFn(func(string) (int, time.Duration) { return 10, time.Second }),
+	)
+}
+
+func TestPanicsIfTheTTLJitterIsOutOfRange(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		err := recover()
+		if err == nil {
+			t.Error("expected a panic when the ttl jitter is out of range")
+		}
+	}()
+	sturdyc.New[string](100, 10, time.Minute, 5,
+		sturdyc.WithTTLJitter(1.5),
+	)
+}
@@ -0,0 +1,13 @@
+package sturdyc
+
+// originFetch wraps a fetchFn that calls the underlying data
+// source with the functionality that the cache has been configured with.
+func originFetch[V, T any](c *Client[T], key string, fetchFn FetchFn[V]) FetchFn[V] {
+	return circuitBreakerFetch(c, key, retryFetch(c, transformFetch(c, key, validateFetch(c, key, rateLimitFetch(c, timeoutFetch(c, hedgedFetch(c, fetchFn)))))))
+}
+
+// originBatchFetch wraps a batch fetchFn that calls the underlying data
+// source with the functionality that the cache has been configured with.
+func originBatchFetch[V, T any](c *Client[T], keyFn KeyFn, fetchFn BatchFetchFn[V]) BatchFetchFn[V] {
+	return circuitBreakerBatchFetch(c, keyFn, chunkedBatchFetch(c, retryBatchFetch(c, transformBatchFetch(c, keyFn, validateBatchFetch(c, keyFn, rateLimitBatchFetch(c, timeoutBatchFetch(c, hedgedBatchFetch(c, fetchFn))))))))
+}
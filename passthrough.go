@@ -12,12 +12,14 @@ import (
 //	ctx - The context to be used for the request.
 //	key - The key to be fetched.
 //	fetchFn - Used to retrieve the data from the underlying data source.
+//	opts - Optional overrides of the cache configuration for this call, such as
+//	CallMissingRecordStorage to mark the IDs that the data source is missing.
 //
 // Returns:
 //
 //	The value and an error if one occurred and the key was not found in the cache.
-func (c *Client[T]) Passthrough(ctx context.Context, key string, fetchFn FetchFn[T]) (T, error) {
-	res, err := callAndCache(ctx, c, key, fetchFn)
+func (c *Client[T]) Passthrough(ctx context.Context, key string, fetchFn FetchFn[T], opts ...CallOption) (T, error) {
+	res, err := callAndCache(ctx, c, key, originFetch(c, key, fetchFn), c.newCallOptions(opts))
 	if err == nil {
 		return res, nil
 	}
@@ -26,7 +28,7 @@ func (c *Client[T]) Passthrough(ctx context.Context, key string, fetchFn FetchFn
 		return value, nil
 	}
 
-	return res, err
+	return res, withKey(key, err)
 }
 
 // Passthrough is a convenience function that performs type assertion on the
@@ -38,6 +40,8 @@ func (c *Client[T]) Passthrough(ctx context.Context, key string, fetchFn FetchFn
 //	c - The cache client.
 //	key - The key to be fetched.
 //	fetchFn - Used to retrieve the data from the underlying data source.
+//	opts - Optional overrides of the cache configuration for this call, such as
+//	CallMissingRecordStorage to mark the IDs that the data source is missing.
 //
 // Returns:
 //
@@ -47,8 +51,8 @@ func (c *Client[T]) Passthrough(ctx context.Context, key string, fetchFn FetchFn
 //
 //	V - The type returned by the fetchFn. Must be assignable to T.
 //	T - The type stored in the cache.
-func Passthrough[T, V any](ctx context.Context, c *Client[T], key string, fetchFn FetchFn[V]) (V, error) {
-	value, err := c.Passthrough(ctx, key, wrap[T](fetchFn))
+func Passthrough[T, V any](ctx context.Context, c *Client[T], key string, fetchFn FetchFn[V], opts ...CallOption) (V, error) {
+	value, err := c.Passthrough(ctx, key, wrap[T](fetchFn), opts...)
 	return unwrap[V](value, err)
 }
 
@@ -61,13 +65,15 @@ func Passthrough[T, V any](ctx context.Context, c *Client[T], key string, fetchF
 //	ids - The list of IDs to be fetched.
 //	keyFn - Used to prefix each ID in order to create a unique cache key.
 //	fetchFn - Used to retrieve the data from the underlying data source.
+//	opts - Optional overrides of the cache configuration for this call, such as
+//	CallMissingRecordStorage to mark the IDs that the data source is missing.
 //
 // Returns:
 //
 //	A map of IDs to their corresponding values, and an error if one occurred and
 //	none of the IDs were found in the cache.
-func (c *Client[T]) PassthroughBatch(ctx context.Context, ids []string, keyFn KeyFn, fetchFn BatchFetchFn[T]) (map[string]T, error) {
-	res, err := callAndCacheBatch(ctx, c, callBatchOpts[T, T]{ids, keyFn, fetchFn})
+func (c *Client[T]) PassthroughBatch(ctx context.Context, ids []string, keyFn KeyFn, fetchFn BatchFetchFn[T], opts ...CallOption) (map[string]T, error) {
+	res, err := callAndCacheBatch(ctx, c, callBatchOpts[T, T]{ids, keyFn, originBatchFetch(c, keyFn, fetchFn), c.newCallOptions(opts)})
 	if err == nil {
 		return res, nil
 	}
@@ -77,7 +83,7 @@ func (c *Client[T]) PassthroughBatch(ctx context.Context, ids []string, keyFn Ke
 		return values, nil
 	}
 
-	return res, err
+	return res, withReturnedRecords(len(res), err)
 }
 
 // PassthroughBatch is a convenience function that performs type assertion on the
@@ -90,6 +96,8 @@ func (c *Client[T]) PassthroughBatch(ctx context.Context, ids []string, keyFn Ke
 //	ids - The list of IDs to be fetched.
 //	keyFn - Used to prefix each ID in order to create a unique cache key.
 //	fetchFn - Used to retrieve the data from the underlying data source.
+//	opts - Optional overrides of the cache configuration for this call, such as
+//	CallMissingRecordStorage to mark the IDs that the data source is missing.
 //
 // Returns:
 //
@@ -99,7 +107,7 @@ func (c *Client[T]) PassthroughBatch(ctx context.Context, ids []string, keyFn Ke
 //
 //	V - The type returned by the fetchFn. Must be assignable to T.
 //	T - The type stored in the cache.
-func PassthroughBatch[V, T any](ctx context.Context, c *Client[T], ids []string, keyFn KeyFn, fetchFn BatchFetchFn[V]) (map[string]V, error) {
-	res, err := c.PassthroughBatch(ctx, ids, keyFn, wrapBatch[T](fetchFn))
+func PassthroughBatch[V, T any](ctx context.Context, c *Client[T], ids []string, keyFn KeyFn, fetchFn BatchFetchFn[V], opts ...CallOption) (map[string]V, error) {
+	res, err := c.PassthroughBatch(ctx, ids, keyFn, wrapBatch[T](fetchFn), opts...)
 	return unwrapBatch[V](res, err)
 }
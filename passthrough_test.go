@@ -124,3 +124,44 @@ func TestPassthroughBatch(t *testing.T) {
 		t.Errorf("expected no inflight keys, got %v", c.NumKeysInflight())
 	}
 }
+
+func TestPassthroughBatchCanStoreMissingRecords(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	c := sturdyc.New[string](100, 1, time.Minute, 10,
+		sturdyc.WithNoContinuousEvictions(),
+	)
+
+	fetchObserver := NewFetchObserver(2)
+	fetchObserver.BatchResponse([]string{"1"})
+	keyFn := c.BatchKeyFn("item")
+
+	// Without the call option, the cache doesn't know that "2" is missing.
+	res, err := sturdyc.PassthroughBatch(ctx, c, []string{"1", "2"}, keyFn, fetchObserver.FetchBatch)
+	<-fetchObserver.FetchCompleted
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !cmp.Equal(res, map[string]string{"1": "value1"}) {
+		t.Errorf("expected value1, got %v", res)
+	}
+	if _, ok := c.EntryInfo(keyFn("2")); ok {
+		t.Error("expected the missing ID to not be in the cache")
+	}
+
+	res, err = sturdyc.PassthroughBatch(ctx, c, []string{"1", "2"}, keyFn, fetchObserver.FetchBatch,
+		sturdyc.CallMissingRecordStorage(true),
+	)
+	<-fetchObserver.FetchCompleted
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !cmp.Equal(res, map[string]string{"1": "value1"}) {
+		t.Errorf("expected value1, got %v", res)
+	}
+	info, ok := c.EntryInfo(keyFn("2"))
+	if !ok || !info.IsMissingRecord {
+		t.Errorf("expected the ID to be marked as missing, got %+v", info)
+	}
+}
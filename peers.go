@@ -0,0 +1,287 @@
+package sturdyc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// Peer is another instance of the cache which owns a subset of the keys. The
+// records are exchanged in the same format as the distributed storage uses.
+type Peer interface {
+	DistributedStorageWithDeletions
+}
+
+// PeerPicker determines which instance of the cache that owns a key.
+type PeerPicker interface {
+	// PickPeer returns the peer that owns the key, or false if
+	// the key is owned by the instance that is asking.
+	PickPeer(key string) (Peer, bool)
+}
+
+// peerStorage routes the reads and writes for each key to the peer that owns
+// it. Keys that are owned by this instance are only kept in memory, and are
+// served to the other peers from there.
+type peerStorage struct {
+	picker PeerPicker
+}
+
+// groupByPeer groups the keys by the peer that owns them. The keys
+// that are owned by this instance are left out.
+func (p *peerStorage) groupByPeer(keys []string) map[Peer][]string {
+	keysByPeer := make(map[Peer][]string)
+	for _, key := range keys {
+		if peer, ok := p.picker.PickPeer(key); ok {
+			keysByPeer[peer] = append(keysByPeer[peer], key)
+		}
+	}
+	return keysByPeer
+}
+
+func (p *peerStorage) Get(ctx context.Context, key string) ([]byte, bool) {
+	peer, ok := p.picker.PickPeer(key)
+	if !ok {
+		return nil, false
+	}
+	return peer.Get(ctx, key)
+}
+
+func (p *peerStorage) Set(ctx context.Context, key string, value []byte) {
+	if peer, ok := p.picker.PickPeer(key); ok {
+		peer.Set(ctx, key, value)
+	}
+}
+
+func (p *peerStorage) Delete(ctx context.Context, key string) {
+	if peer, ok := p.picker.PickPeer(key); ok {
+		peer.Delete(ctx, key)
+	}
+}
+
+func (p *peerStorage) GetBatch(ctx context.Context, keys []string) map[string][]byte {
+	records := make(map[string][]byte, len(keys))
+	for peer, peerKeys := range p.groupByPeer(keys) {
+		for key, value := range peer.GetBatch(ctx, peerKeys) {
+			records[key] = value
+		}
+	}
+	return records
+}
+
+func (p *peerStorage) SetBatch(ctx context.Context, records map[string][]byte) {
+	keys := make([]string, 0, len(records))
+	for key := range records {
+		keys = append(keys, key)
+	}
+	for peer, peerKeys := range p.groupByPeer(keys) {
+		peerRecords := make(map[string][]byte, len(peerKeys))
+		for _, key := range peerKeys {
+			peerRecords[key] = records[key]
+		}
+		peer.SetBatch(ctx, peerRecords)
+	}
+}
+
+func (p *peerStorage) DeleteBatch(ctx context.Context, keys []string) {
+	for peer, peerKeys := range p.groupByPeer(keys) {
+		peer.DeleteBatch(ctx, peerKeys)
+	}
+}
+
+// PeerGet returns the record for the key in the format that is exchanged
+// between peers. It's used to serve the keys that this instance owns.
+func (c *Client[T]) PeerGet(key string) ([]byte, bool) {
+	value, exists, markedAsMissing, _ := c.getShard(key).get(key, false)
+	if !exists {
+		return nil, false
+	}
+
+	var recordBytes []byte
+	var err error
+	if markedAsMissing {
+		recordBytes, err = marshalMissingRecord[T](c)
+	} else {
+		recordBytes, err = marshalRecord[T](value, c)
+	}
+	return recordBytes, err == nil
+}
+
+// PeerSet writes a record that was sent by one of the peers to memory.
+func (c *Client[T]) PeerSet(key string, recordBytes []byte) {
+	record, err := unmarshalRecord[T](recordBytes, key, c)
+	if err != nil {
+		return
+	}
+	c.getShard(key).set(key, record.Value, record.IsMissingRecord, 0)
+}
+
+// PeerDelete deletes a key that one of the peers asked us to delete from memory.
+func (c *Client[T]) PeerDelete(key string) {
+	c.getShard(key).delete(key)
+}
+
+// PeerHandler returns an http.Handler that serves the keys that this instance
+// owns to the peers that use NewHTTPPeer.
+func (c *Client[T]) PeerHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.URL.Query().Get("key")
+		switch {
+		case r.Method == http.MethodGet:
+			value, ok := c.PeerGet(key)
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write(value) //nolint:errcheck // The peer treats a partial body as a miss.
+		case r.Method == http.MethodPut:
+			value, err := io.ReadAll(r.Body)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			c.PeerSet(key, value)
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == http.MethodDelete:
+			c.PeerDelete(key)
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == http.MethodPost:
+			c.servePeerBatch(w, r)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+// servePeerBatch handles the batch operations, which carry their keys in a JSON body.
+func (c *Client[T]) servePeerBatch(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Query().Get("op") {
+	case "getBatch":
+		var keys []string
+		if err := json.NewDecoder(r.Body).Decode(&keys); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		records := make(map[string][]byte, len(keys))
+		for _, key := range keys {
+			if value, ok := c.PeerGet(key); ok {
+				records[key] = value
+			}
+		}
+		json.NewEncoder(w).Encode(records) //nolint:errcheck // The peer treats a partial body as a miss.
+	case "setBatch":
+		var records map[string][]byte
+		if err := json.NewDecoder(r.Body).Decode(&records); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		for key, value := range records {
+			c.PeerSet(key, value)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case "deleteBatch":
+		var keys []string
+		if err := json.NewDecoder(r.Body).Decode(&keys); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		for _, key := range keys {
+			c.PeerDelete(key)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
+
+// httpPeer calls the PeerHandler of another instance.
+type httpPeer struct {
+	baseURL string
+	client  *http.Client
+}
+
+// NewHTTPPeer returns a Peer that talks to the PeerHandler which has been
+// mounted at the baseURL of another instance. Failed calls are treated as
+// misses, which makes the cache fall back to the underlying data source.
+func NewHTTPPeer(baseURL string, client *http.Client) Peer {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &httpPeer{baseURL: baseURL, client: client}
+}
+
+func (p *httpPeer) do(ctx context.Context, method string, query url.Values, body any) (*http.Response, error) {
+	var reader io.Reader
+	switch b := body.(type) {
+	case nil:
+	case []byte:
+		reader = bytes.NewReader(b)
+	default:
+		encoded, err := json.Marshal(b)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(encoded)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, p.baseURL+"?"+query.Encode(), reader)
+	if err != nil {
+		return nil, err
+	}
+	return p.client.Do(req)
+}
+
+// send performs a request whose response body isn't needed.
+func (p *httpPeer) send(ctx context.Context, method string, query url.Values, body any) {
+	res, err := p.do(ctx, method, query, body)
+	if err != nil {
+		return
+	}
+	res.Body.Close()
+}
+
+func (p *httpPeer) Get(ctx context.Context, key string) ([]byte, bool) {
+	res, err := p.do(ctx, http.MethodGet, url.Values{"key": {key}}, nil)
+	if err != nil {
+		return nil, false
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, false
+	}
+	value, err := io.ReadAll(res.Body)
+	return value, err == nil
+}
+
+func (p *httpPeer) Set(ctx context.Context, key string, value []byte) {
+	p.send(ctx, http.MethodPut, url.Values{"key": {key}}, value)
+}
+
+func (p *httpPeer) Delete(ctx context.Context, key string) {
+	p.send(ctx, http.MethodDelete, url.Values{"key": {key}}, nil)
+}
+
+func (p *httpPeer) GetBatch(ctx context.Context, keys []string) map[string][]byte {
+	res, err := p.do(ctx, http.MethodPost, url.Values{"op": {"getBatch"}}, keys)
+	if err != nil {
+		return map[string][]byte{}
+	}
+	defer res.Body.Close()
+
+	records := make(map[string][]byte, len(keys))
+	if res.StatusCode != http.StatusOK || json.NewDecoder(res.Body).Decode(&records) != nil {
+		return map[string][]byte{}
+	}
+	return records
+}
+
+func (p *httpPeer) SetBatch(ctx context.Context, records map[string][]byte) {
+	p.send(ctx, http.MethodPost, url.Values{"op": {"setBatch"}}, records)
+}
+
+func (p *httpPeer) DeleteBatch(ctx context.Context, keys []string) {
+	p.send(ctx, http.MethodPost, url.Values{"op": {"deleteBatch"}}, keys)
+}
@@ -0,0 +1,124 @@
+package sturdyc_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/viccon/sturdyc"
+)
+
+// newPeerGroup starts a cache for each of the names, which are
+// connected to each other through their peer handlers.
+func newPeerGroup(t *testing.T, names ...string) map[string]*sturdyc.Client[string] {
+	t.Helper()
+
+	handlers := make(map[string]*http.ServeMux, len(names))
+	peers := make(map[string]sturdyc.Peer, len(names))
+	for _, name := range names {
+		mux := http.NewServeMux()
+		server := httptest.NewServer(mux)
+		t.Cleanup(server.Close)
+		handlers[name] = mux
+		peers[name] = sturdyc.NewHTTPPeer(server.URL, server.Client())
+	}
+
+	clients := make(map[string]*sturdyc.Client[string], len(names))
+	for _, name := range names {
+		c := sturdyc.New[string](1000, 10, time.Hour, 30,
+			sturdyc.WithNoContinuousEvictions(),
+			sturdyc.WithPeers(sturdyc.NewPeerRing(name, 0, peers)),
+		)
+		handlers[name].Handle("/", c.PeerHandler())
+		clients[name] = c
+	}
+	return clients
+}
+
+// keyOwnedBy returns a key that the ring of one instance assigns to the owner.
+func keyOwnedBy(t *testing.T, self, owner string, names ...string) string {
+	t.Helper()
+
+	peers := make(map[string]sturdyc.Peer, len(names))
+	for _, name := range names {
+		peers[name] = sturdyc.NewHTTPPeer(name, nil)
+	}
+	ring := sturdyc.NewPeerRing(self, 0, peers)
+	for i := 0; i < 1000; i++ {
+		key := "key-" + strconv.Itoa(i)
+		if peer, ok := ring.PickPeer(key); ok && peer == peers[owner] {
+			return key
+		}
+	}
+	t.Fatal("expected to find a key that is owned by " + owner)
+	return ""
+}
+
+func TestPeersServeTheKeysThatTheyOwn(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	clients := newPeerGroup(t, "a", "b")
+	key := keyOwnedBy(t, "a", "b", "a", "b")
+
+	fetchObserver := NewFetchObserver(1)
+	fetchObserver.Response(key)
+	res, err := sturdyc.GetOrFetch(ctx, clients["b"], key, fetchObserver.Fetch)
+	<-fetchObserver.FetchCompleted
+	if err != nil || res != "value"+key {
+		t.Fatalf("expected value%s, got %q %v", key, res, err)
+	}
+
+	// The other instance should get the record from the owner rather than the data source.
+	res, err = sturdyc.GetOrFetch(ctx, clients["a"], key, fetchObserver.Fetch)
+	if err != nil || res != "value"+key {
+		t.Fatalf("expected value%s, got %q %v", key, res, err)
+	}
+	fetchObserver.AssertFetchCount(t, 1)
+}
+
+func TestPeersWriteFetchedRecordsToTheOwner(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	clients := newPeerGroup(t, "a", "b")
+	key := keyOwnedBy(t, "a", "b", "a", "b")
+
+	fetchObserver := NewFetchObserver(1)
+	ids := []string{key}
+	fetchObserver.BatchResponse(ids)
+	sturdyc.GetOrFetchBatch(ctx, clients["a"], ids, func(id string) string { return id }, fetchObserver.FetchBatch)
+	<-fetchObserver.FetchCompleted
+
+	// The records are written to the owner asynchronously.
+	time.Sleep(50 * time.Millisecond)
+	if res, ok := clients["b"].Get(key); !ok || res != "value"+key {
+		t.Errorf("expected the owner to have value%s, got %q", key, res)
+	}
+}
+
+func TestPeerRingAssignsEveryKeyToOneOwner(t *testing.T) {
+	t.Parallel()
+
+	names := []string{"a", "b", "c"}
+	peers := map[string]sturdyc.Peer{}
+	for _, name := range names {
+		peers[name] = sturdyc.NewHTTPPeer(name, nil)
+	}
+
+	owned := 0
+	for _, self := range names {
+		ring := sturdyc.NewPeerRing(self, 0, peers)
+		for i := 0; i < 300; i++ {
+			if _, ok := ring.PickPeer(strconv.Itoa(i)); !ok {
+				owned++
+			}
+		}
+	}
+	if owned != 300 {
+		t.Errorf("expected each key to be owned by exactly one instance, got %d", owned)
+	}
+}
@@ -0,0 +1,85 @@
+package sturdyc
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strings"
+)
+
+// preloadBatchSize is the number of keys that Preload reads from the distributed storage at once.
+const preloadBatchSize = 100
+
+// DistributedStorageScanner can be implemented by the distributed storage in
+// order to let client.Preload list the keys that it holds.
+type DistributedStorageScanner interface {
+	// Keys returns the keys that start with the prefix.
+	Keys(ctx context.Context, prefix string) ([]string, error)
+}
+
+// distributedScanner returns the scanner of the storage that was passed to
+// one of the distributed storage options, before it was decorated.
+func (c *Config) distributedScanner() (DistributedStorageScanner, bool) {
+	if storage, ok := c.distributedStorage.(*distributedStorage); ok {
+		scanner, ok := storage.DistributedStorage.(DistributedStorageScanner)
+		return scanner, ok
+	}
+	scanner, ok := c.distributedStorage.(DistributedStorageScanner)
+	return scanner, ok
+}
+
+type preloadedRecord[T any] struct {
+	key    string
+	record distributedRecord[T]
+}
+
+// Preload hydrates the in-memory cache with the records of the distributed
+// storage whose keys start with the prefix. If limit is greater than 0, only
+// the limit most recently written records are loaded. It's meant to be called
+// before the service starts accepting traffic, and requires the storage to
+// implement DistributedStorageScanner. Returns the number of records that were
+// loaded.
+func (c *Client[T]) Preload(ctx context.Context, prefix string, limit int) (int, error) {
+	if c.distributedScannerStorage == nil {
+		return 0, ErrPreloadUnsupported
+	}
+
+	keys, err := c.distributedScannerStorage.Keys(ctx, c.distributedKeyPrefix+prefix)
+	if err != nil {
+		return 0, err
+	}
+
+	records := make([]preloadedRecord[T], 0, len(keys))
+	for start := 0; start < len(keys); start += preloadBatchSize {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+
+		batch := make([]string, 0, preloadBatchSize)
+		for _, key := range keys[start:min(start+preloadBatchSize, len(keys))] {
+			if unprefixedKey, ok := strings.CutPrefix(key, c.distributedKeyPrefix); ok {
+				batch = append(batch, unprefixedKey)
+			}
+		}
+
+		for key, recordBytes := range c.distributedStorage.GetBatch(ctx, batch) {
+			record, unmarshalErr := unmarshalRecord[T](recordBytes, key, c)
+			if unmarshalErr != nil || (record.IsMissingRecord && !c.storeMissingRecords) {
+				continue
+			}
+			records = append(records, preloadedRecord[T]{key: key, record: record})
+		}
+	}
+
+	if limit > 0 && len(records) > limit {
+		slices.SortFunc(records, func(a, b preloadedRecord[T]) int {
+			return cmp.Compare(b.record.CreatedAt.UnixNano(), a.record.CreatedAt.UnixNano())
+		})
+		records = records[:limit]
+	}
+
+	for _, r := range records {
+		c.getShard(r.key).set(r.key, r.record.Value, r.record.IsMissingRecord, 0)
+	}
+	return len(records), nil
+}
@@ -0,0 +1,94 @@
+package sturdyc_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/viccon/sturdyc"
+)
+
+type scannableStorage struct {
+	mockStorage
+}
+
+func (s *scannableStorage) Keys(_ context.Context, prefix string) ([]string, error) {
+	s.Lock()
+	defer s.Unlock()
+	keys := make([]string, 0, len(s.records))
+	for key := range s.records {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+func (s *scannableStorage) GetBatch(_ context.Context, keys []string) map[string][]byte {
+	s.Lock()
+	defer s.Unlock()
+	s.getCount++
+	records := make(map[string][]byte, len(keys))
+	for _, key := range keys {
+		if bytes, ok := s.records[key]; ok {
+			records[key] = bytes
+		}
+	}
+	return records
+}
+
+func TestPreloadHydratesTheCacheFromTheDistributedStorage(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	clock := sturdyc.NewTestClock(time.Now())
+	storage := &scannableStorage{}
+	writer := sturdyc.New[string](100, 1, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithDistributedStorage(storage),
+		sturdyc.WithDistributedWriteThrough(),
+		sturdyc.WithClock(clock),
+	)
+	for _, key := range []string{"user-1", "user-2", "user-3", "order-1"} {
+		writer.Set(key, "value-"+key)
+		clock.Add(time.Second)
+	}
+
+	reader := sturdyc.New[string](100, 1, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithDistributedStorage(storage),
+		sturdyc.WithClock(clock),
+	)
+	loaded, err := reader.Preload(ctx, "user-", 2)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if loaded != 2 || reader.Size() != 2 {
+		t.Fatalf("expected 2 records to be loaded, got %d with a cache size of %d", loaded, reader.Size())
+	}
+	for _, key := range []string{"user-2", "user-3"} {
+		if value, ok := reader.Get(key); !ok || value != "value-"+key {
+			t.Errorf("expected %s to have been preloaded, got %q", key, value)
+		}
+	}
+	if _, ok := reader.Get("user-1"); ok {
+		t.Error("expected the oldest record to be skipped")
+	}
+	if _, ok := reader.Get("order-1"); ok {
+		t.Error("expected records outside of the prefix to be skipped")
+	}
+}
+
+func TestPreloadRequiresAScannableStorage(t *testing.T) {
+	t.Parallel()
+
+	c := sturdyc.New[string](100, 1, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithDistributedStorage(&mockStorage{}),
+	)
+	if _, err := c.Preload(context.Background(), "", 0); !errors.Is(err, sturdyc.ErrPreloadUnsupported) {
+		t.Errorf("expected ErrPreloadUnsupported, got %v", err)
+	}
+}
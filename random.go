@@ -0,0 +1,42 @@
+package sturdyc
+
+import (
+	"math/rand/v2"
+	"sync"
+)
+
+// lockedRand makes a rand.Rand safe for concurrent use.
+type lockedRand struct {
+	mu sync.Mutex
+	r  *rand.Rand
+}
+
+func (l *lockedRand) Float64() float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.r.Float64()
+}
+
+func (l *lockedRand) Int64N(n int64) int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.r.Int64N(n)
+}
+
+// randFloat64 returns a random number in [0.0, 1.0) from the source of the
+// cache, or from the global source if the cache hasn't been given one.
+func (c *Config) randFloat64() float64 {
+	if c.rand == nil {
+		return rand.Float64()
+	}
+	return c.rand.Float64()
+}
+
+// randInt64N returns a random number in [0, n) from the source of the
+// cache, or from the global source if the cache hasn't been given one.
+func (c *Config) randInt64N(n int64) int64 {
+	if c.rand == nil {
+		return rand.Int64N(n)
+	}
+	return c.rand.Int64N(n)
+}
@@ -0,0 +1,47 @@
+package sturdyc_test
+
+import (
+	"math/rand/v2"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/viccon/sturdyc"
+)
+
+func TestRandSourceMakesTheTimingReproducible(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	newClient := func(seed uint64) *sturdyc.Client[string] {
+		return sturdyc.New[string](100, 1, time.Hour, 10,
+			sturdyc.WithNoContinuousEvictions(),
+			sturdyc.WithEarlyRefreshes(time.Minute, time.Minute*10, time.Second),
+			sturdyc.WithTTLJitter(0.5),
+			sturdyc.WithRandSource(rand.NewPCG(seed, seed)),
+			sturdyc.WithClock(sturdyc.NewTestClock(now)),
+		)
+	}
+
+	first, second, third := newClient(1), newClient(1), newClient(2)
+	var differs bool
+	for i := 0; i < 10; i++ {
+		key := strconv.Itoa(i)
+		first.Set(key, "value")
+		second.Set(key, "value")
+		third.Set(key, "value")
+
+		firstInfo, _ := first.EntryInfo(key)
+		secondInfo, _ := second.EntryInfo(key)
+		thirdInfo, _ := third.EntryInfo(key)
+		if !firstInfo.ExpiresAt.Equal(secondInfo.ExpiresAt) || !firstInfo.RefreshAt.Equal(secondInfo.RefreshAt) {
+			t.Errorf("expected the same seed to produce the same timing for key %s, got %+v and %+v", key, firstInfo, secondInfo)
+		}
+		if !firstInfo.ExpiresAt.Equal(thirdInfo.ExpiresAt) || !firstInfo.RefreshAt.Equal(thirdInfo.RefreshAt) {
+			differs = true
+		}
+	}
+	if !differs {
+		t.Error("expected a different seed to produce a different timing")
+	}
+}
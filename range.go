@@ -0,0 +1,39 @@
+package sturdyc
+
+// rangeEntry is a key and value that has been copied out of a shard.
+type rangeEntry[T any] struct {
+	key   string
+	value T
+}
+
+// rangeEntries copies the keys and values of the entries that haven't
+// expired, so that they can be passed to a callback without holding the lock.
+func (s *shard[T]) rangeEntries() []rangeEntry[T] {
+	s.RLock()
+	defer s.RUnlock()
+
+	now := s.clock.Now()
+	entries := make([]rangeEntry[T], 0, len(s.entries))
+	for key, e := range s.entries {
+		if e.isMissingRecord || (!e.pinned && now.After(e.expiresAt)) {
+			continue
+		}
+		entries = append(entries, rangeEntry[T]{key: key, value: e.value})
+	}
+	return entries
+}
+
+// Range calls fn for every value in the cache, until fn returns false. The
+// cache is walked one shard at a time, and fn is called without holding any
+// locks, which means that it's allowed to call the cache. Missing records are
+// skipped, and entries that are written while the cache is being walked may
+// or may not be included.
+func (c *Client[T]) Range(fn func(key string, value T) bool) {
+	for _, shard := range c.getShards() {
+		for _, e := range shard.rangeEntries() {
+			if !fn(e.key, e.value) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,11 @@
+//go:build go1.23
+
+package sturdyc
+
+import "iter"
+
+// All returns an iterator over the keys and values in the cache. It
+// walks the cache in the same way as Range.
+func (c *Client[T]) All() iter.Seq2[string, T] {
+	return c.Range
+}
@@ -0,0 +1,41 @@
+//go:build go1.23
+
+package sturdyc_test
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/viccon/sturdyc"
+)
+
+func TestAllIteratesOverTheCache(t *testing.T) {
+	t.Parallel()
+
+	c := sturdyc.New[int](100, 4, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+	)
+	for i := 0; i < 10; i++ {
+		c.Set(strconv.Itoa(i), i)
+	}
+
+	var sum int
+	for _, value := range c.All() {
+		sum += value
+	}
+	if sum != 45 {
+		t.Errorf("expected the values to sum up to 45, got %d", sum)
+	}
+
+	var count int
+	for range c.All() {
+		count++
+		if count == 3 {
+			break
+		}
+	}
+	if count != 3 {
+		t.Errorf("expected the iteration to stop after 3 values, got %d", count)
+	}
+}
@@ -0,0 +1,47 @@
+package sturdyc_test
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/viccon/sturdyc"
+)
+
+func TestRangeStopsWhenTheCallbackReturnsFalse(t *testing.T) {
+	t.Parallel()
+
+	c := sturdyc.New[int](100, 4, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithMissingRecordStorage(),
+	)
+	for i := 0; i < 20; i++ {
+		c.Set(strconv.Itoa(i), i)
+	}
+	c.StoreMissingRecord("missing")
+
+	seen := make(map[string]int)
+	c.Range(func(key string, value int) bool {
+		seen[key] = value
+		return true
+	})
+	if len(seen) != 20 {
+		t.Errorf("expected 20 values, got %d", len(seen))
+	}
+	for key, value := range seen {
+		if key != strconv.Itoa(value) {
+			t.Errorf("expected key %s to have the value %s, got %d", key, key, value)
+		}
+	}
+
+	var calls int
+	c.Range(func(key string, _ int) bool {
+		calls++
+		// The callback is allowed to call the cache.
+		c.Delete(key)
+		return calls < 5
+	})
+	if calls != 5 || c.Size() != 16 {
+		t.Errorf("expected the walk to stop after 5 values, got %d calls and a size of %d", calls, c.Size())
+	}
+}
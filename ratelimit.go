@@ -0,0 +1,54 @@
+package sturdyc
+
+import (
+	"context"
+	"fmt"
+)
+
+// RateLimiter limits the rate at which the cache calls the underlying data
+// source. It's satisfied by *rate.Limiter from golang.org/x/time/rate.
+type RateLimiter interface {
+	// Wait blocks until the call is allowed to proceed, or returns an
+	// error if the context is cancelled before that happens.
+	Wait(ctx context.Context) error
+}
+
+// rateLimit waits for the rate limiter of the cache, if there is one.
+func (c *Client[T]) rateLimit(ctx context.Context) error {
+	if c.originRateLimiter == nil {
+		return nil
+	}
+	if err := c.originRateLimiter.Wait(ctx); err != nil {
+		return fmt.Errorf("%w: %w", ErrRateLimited, err)
+	}
+	return nil
+}
+
+// rateLimitFetch wraps the fetchFn so that every call to it has to wait for the rate limiter.
+func rateLimitFetch[V, T any](c *Client[T], fetchFn FetchFn[V]) FetchFn[V] {
+	if c.originRateLimiter == nil {
+		return fetchFn
+	}
+
+	return func(ctx context.Context) (V, error) {
+		if err := c.rateLimit(ctx); err != nil {
+			var zero V
+			return zero, err
+		}
+		return fetchFn(ctx)
+	}
+}
+
+// rateLimitBatchFetch wraps the fetchFn so that every call to it has to wait for the rate limiter.
+func rateLimitBatchFetch[V, T any](c *Client[T], fetchFn BatchFetchFn[V]) BatchFetchFn[V] {
+	if c.originRateLimiter == nil {
+		return fetchFn
+	}
+
+	return func(ctx context.Context, ids []string) (map[string]V, error) {
+		if err := c.rateLimit(ctx); err != nil {
+			return map[string]V{}, err
+		}
+		return fetchFn(ctx, ids)
+	}
+}
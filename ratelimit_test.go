@@ -0,0 +1,67 @@
+package sturdyc_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/viccon/sturdyc"
+)
+
+// countingLimiter counts the calls that waited for it, and
+// rejects every call once the budget has been used up.
+type countingLimiter struct {
+	budget int64
+	waits  atomic.Int64
+}
+
+func (l *countingLimiter) Wait(context.Context) error {
+	if l.waits.Add(1) > l.budget {
+		return errors.New("budget exceeded")
+	}
+	return nil
+}
+
+func TestOriginRateLimitAppliesToEveryCall(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	limiter := &countingLimiter{budget: 3}
+	refreshDelay := time.Minute
+	clock := sturdyc.NewTestClock(time.Now())
+	c := sturdyc.New[string](100, 1, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithEarlyRefreshes(refreshDelay, refreshDelay, time.Millisecond*10),
+		sturdyc.WithOriginRateLimit(limiter),
+		sturdyc.WithClock(clock),
+	)
+
+	fetchObserver := NewFetchObserver(3)
+	fetchObserver.Response("1")
+	if _, err := c.GetOrFetch(ctx, "1", fetchObserver.Fetch); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	<-fetchObserver.FetchCompleted
+
+	if _, err := c.Passthrough(ctx, "1", fetchObserver.Fetch); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	<-fetchObserver.FetchCompleted
+
+	// The background refresh has to wait for the limiter as well.
+	clock.Add(refreshDelay + time.Second)
+	c.GetOrFetch(ctx, "1", fetchObserver.Fetch)
+	<-fetchObserver.FetchCompleted
+	if waits := limiter.waits.Load(); waits != 3 {
+		t.Errorf("expected 3 calls to wait for the limiter, got %d", waits)
+	}
+
+	// Once the limiter rejects the calls, the data source isn't called.
+	_, err := c.GetOrFetchBatch(ctx, []string{"2"}, c.BatchKeyFn("item"), fetchObserver.FetchBatch)
+	if !errors.Is(err, sturdyc.ErrRateLimited) {
+		t.Errorf("expected ErrRateLimited, got %v", err)
+	}
+	fetchObserver.AssertFetchCount(t, 3)
+}
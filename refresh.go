@@ -3,52 +3,211 @@ package sturdyc
 import (
 	"context"
 	"errors"
+	"time"
 )
 
-func (c *Client[T]) refresh(key string, fetchFn FetchFn[T]) {
-	response, err := fetchFn(context.Background())
+// Refresh forces the record to be fetched from the underlying data source and
+// written to the cache, regardless of when it's due to be refreshed. If the key
+// is already being fetched, it's going to share the result of that call.
+// Records that have been deleted at the data source are removed from the
+// cache, or marked as missing if WithMissingRecordStorage is used.
+func (c *Client[T]) Refresh(ctx context.Context, key string, fetchFn FetchFn[T]) error {
+	opts := c.newCallOptions(nil)
+	wrappedFetch := distributedWrite(c, key, originFetch(c, key, fetchFn), opts)
+	_, err := callAndCache(ctx, c, key, wrappedFetch, opts)
+	if errors.Is(err, ErrMissingRecord) {
+		return nil
+	}
+	if errors.Is(err, ErrNotFound) {
+		c.deleteLocal(key)
+		return nil
+	}
+	return err
+}
+
+// RefreshBatch is the batch equivalent of Refresh. The IDs are fetched from the
+// underlying data source in chunks if WithMaxBatchSize is used, and IDs that
+// are already being fetched share the result of those calls. A *BatchError is
+// returned if the data source failed for some of the IDs.
+func (c *Client[T]) RefreshBatch(ctx context.Context, ids []string, keyFn KeyFn, fetchFn BatchFetchFn[T]) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	opts := c.newCallOptions(nil)
+	wrappedFetch := distributedBatchWrite(c, keyFn, originBatchFetch(c, keyFn, fetchFn), opts)
+	callBatchOpts := callBatchOpts[T, T]{ids: ids, keyFn: keyFn, fn: wrappedFetch, options: opts}
+	response, err := callAndCacheBatch(ctx, c, callBatchOpts)
+	batchErr, isBatchErr := asBatchError(err)
+	if err != nil && !isBatchErr {
+		return err
+	}
+
+	// The records that weren't returned have been deleted at the data source.
+	if !opts.storeMissingRecords {
+		for _, id := range ids {
+			if _, ok := response[id]; ok {
+				continue
+			}
+			if isBatchErr && batchErr.failed(id) {
+				continue
+			}
+			c.deleteLocal(keyFn(id))
+		}
+	}
+
+	// The records are written to the cache rather than returned.
+	return withReturnedRecords(0, err)
+}
+
+// PauseRefreshes stops the cache from scheduling background refreshes, while
+// it keeps serving the records that it has. The TTLs are left untouched, so
+// records still expire as usual. Pauses can be nested, and every call has to
+// be matched by a call to ResumeRefreshes.
+func (c *Client[T]) PauseRefreshes() {
+	c.refreshesPaused.Add(1)
+}
+
+// ResumeRefreshes resumes the background refreshes that were paused by
+// PauseRefreshes. Records that became due while the refreshes were paused are
+// refreshed the next time they're requested.
+func (c *Client[T]) ResumeRefreshes() {
+	for {
+		paused := c.refreshesPaused.Load()
+		if paused == 0 || c.refreshesPaused.CompareAndSwap(paused, paused-1) {
+			return
+		}
+	}
+}
+
+// PauseRefreshesCtx pauses the background refreshes until the context is done.
+func (c *Client[T]) PauseRefreshesCtx(ctx context.Context) {
+	c.PauseRefreshes()
+	c.safeGo(func() {
+		<-ctx.Done()
+		c.ResumeRefreshes()
+	})
+}
+
+// RefreshesPaused returns true if the background refreshes are paused.
+func (c *Client[T]) RefreshesPaused() bool {
+	return c.refreshesPaused.Load() > 0
+}
+
+// refreshContext returns the context that is used for a background refresh.
+// The context should have been detached from the cancellation of the request
+// that triggered the refresh, but it's allowed to carry its values.
+func (c *Client[T]) refreshContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.refreshTimeout > 0 {
+		return context.WithTimeout(ctx, c.refreshTimeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// logRefresh logs and reports the latency of a refresh. The failures are
+// logged at the info level, as the records are going to be retried.
+func (c *Client[T]) logRefresh(key string, ids int, fetchedAt time.Time, err error) {
+	latency := c.clock.Since(fetchedAt)
+	c.reportRefreshDuration(latency)
+	log := c.logger(LogRefreshes)
+	if err != nil && !errors.Is(err, ErrNotFound) && !errors.Is(err, errOnlyDistributedRecords) {
+		log.Info("sturdyc: refresh failed", "key", key, "ids", ids, "latency", latency, "error", err)
+		return
+	}
+	log.Debug("sturdyc: refreshed", "key", key, "ids", ids, "latency", latency)
+}
+
+func (c *Client[T]) refresh(ctx context.Context, key string, fetchFn FetchFn[T], opts callOptions) {
+	ctx, cancel := c.refreshContext(ctx)
+	defer cancel()
+
+	states := c.refreshStates(key)
+	c.emitRefreshEvent(RefreshStarted, key, nil)
+	fetchedAt := c.clock.Now()
+	response, err := fetchFn(ctx)
+	c.logRefresh(key, 1, fetchedAt, err)
 	if err != nil {
-		if c.storeMissingRecords && errors.Is(err, ErrNotFound) {
-			c.StoreMissingRecord(key)
+		if opts.storeMissingRecords && errors.Is(err, ErrNotFound) {
+			c.storeMissingRecord(key, opts, fetchedAt)
 		}
-		if !c.storeMissingRecords && errors.Is(err, ErrNotFound) {
-			c.Delete(key)
+		if !opts.storeMissingRecords && errors.Is(err, ErrNotFound) {
+			c.deleteLocal(key)
 		}
+		if !errors.Is(err, ErrNotFound) {
+			c.reportRefreshFailure(key, err, states)
+			return
+		}
+		c.reportRefreshSuccess(key, states)
 		return
 	}
-	c.Set(key, response)
+	c.set(key, response, opts)
+	c.reportRefreshSuccess(key, states)
 }
 
-func (c *Client[T]) refreshBatch(ids []string, keyFn KeyFn, fetchFn BatchFetchFn[T]) {
+func (c *Client[T]) refreshBatch(ctx context.Context, ids []string, keyFn KeyFn, fetchFn BatchFetchFn[T], opts callOptions) {
+	ctx, cancel := c.refreshContext(ctx)
+	defer cancel()
+
 	c.reportBatchRefreshSize(len(ids))
-	response, err := fetchFn(context.Background(), ids)
-	if err != nil && !errors.Is(err, errOnlyDistributedRecords) {
+	var states map[string]refreshState
+	if c.hasRefreshHooks() {
+		keys := make([]string, 0, len(ids))
+		for _, id := range ids {
+			keys = append(keys, keyFn(id))
+		}
+		states = c.refreshStates(keys...)
+	}
+
+	c.emitBatchRefreshEvent(RefreshStarted, ids, keyFn)
+	fetchedAt := c.clock.Now()
+	response, err := fetchFn(ctx, ids)
+	if len(ids) > 0 {
+		c.logRefresh(keyFn(ids[0]), len(ids), fetchedAt, err)
+	}
+	batchErr, isBatchErr := asBatchError(err)
+	if err != nil && !errors.Is(err, errOnlyDistributedRecords) && !isBatchErr {
+		for _, id := range ids {
+			c.reportRefreshFailure(keyFn(id), err, states)
+		}
 		return
 	}
 
 	// Check if any of the records have been deleted at the data source.
 	for _, id := range ids {
-		_, okCache, _, _ := c.getWithState(keyFn(id))
+		okCache := c.getShard(keyFn(id)).peek(keyFn(id))
 		_, okResponse := response[id]
 
 		if okResponse {
 			continue
 		}
 
-		if !c.storeMissingRecords && !okResponse && okCache {
-			c.Delete(keyFn(id))
+		// We don't know if the IDs that failed have been deleted or not.
+		if isBatchErr && batchErr.failed(id) {
+			c.reportRefreshFailure(keyFn(id), batchErr.Errors[id], states)
+			continue
+		}
+
+		if !opts.storeMissingRecords && !okResponse && okCache {
+			c.deleteLocal(keyFn(id))
 		}
 
 		// If we're only getting records from the distributed storage, it means that we weren't able to get
 		// the remaining IDs for the batch from the underlying data source. We don't want to store these
 		// as missing records because we don't know if they're missing or not.
-		if c.storeMissingRecords && !okResponse && !errors.Is(err, errOnlyDistributedRecords) {
-			c.StoreMissingRecord(keyFn(id))
+		if opts.storeMissingRecords && !okResponse && !errors.Is(err, errOnlyDistributedRecords) {
+			c.storeMissingRecord(keyFn(id), opts, fetchedAt)
+		}
+
+		if errors.Is(err, errOnlyDistributedRecords) {
+			c.reportRefreshFailure(keyFn(id), err, states)
+			continue
 		}
+		c.reportRefreshSuccess(keyFn(id), states)
 	}
 
 	// Cache the refreshed records.
 	for id, record := range response {
-		c.Set(keyFn(id), record)
+		c.set(keyFn(id), record, opts)
+		c.reportRefreshSuccess(keyFn(id), states)
 	}
 }
@@ -0,0 +1,417 @@
+package sturdyc_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/viccon/sturdyc"
+)
+
+func TestRefreshOverwritesTheRecordImmediately(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	c := sturdyc.New[string](100, 1, time.Hour, 10,
+		sturdyc.WithEarlyRefreshes(time.Minute, time.Minute, time.Second),
+	)
+	c.Set("1", "old")
+
+	err := c.Refresh(ctx, "1", func(context.Context) (string, error) {
+		return "new", nil
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if res, _ := c.Get("1"); res != "new" {
+		t.Errorf("expected the record to be overwritten, got %s", res)
+	}
+}
+
+func TestRefreshSharesTheInFlightCall(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	metricsRecorder := newTestMetricsRecorder(1)
+	c := sturdyc.New[string](100, 1, time.Hour, 10,
+		sturdyc.WithMetrics(metricsRecorder),
+	)
+
+	fetchStarted := make(chan struct{})
+	releaseFetch := make(chan struct{})
+	fetchObserver := NewFetchObserver(2)
+	fetchObserver.Response("1")
+	blockingFetch := func(ctx context.Context) (string, error) {
+		close(fetchStarted)
+		<-releaseFetch
+		return fetchObserver.Fetch(ctx)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		c.Refresh(ctx, "1", blockingFetch)
+	}()
+	<-fetchStarted
+
+	refreshErr := make(chan error)
+	go func() {
+		refreshErr <- c.Refresh(ctx, "1", fetchObserver.Fetch)
+	}()
+	for {
+		metricsRecorder.Lock()
+		coalesced := metricsRecorder.coalesced
+		metricsRecorder.Unlock()
+		if coalesced == 1 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(releaseFetch)
+	wg.Wait()
+
+	if err := <-refreshErr; err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	fetchObserver.AssertFetchCount(t, 1)
+	if res, _ := c.Get("1"); res != "value1" {
+		t.Errorf("expected value1, got %s", res)
+	}
+}
+
+func TestRefreshDeletesRecordsThatNoLongerExist(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	c := sturdyc.New[string](100, 1, time.Hour, 10)
+	c.Set("1", "value1")
+
+	err := c.Refresh(ctx, "1", func(context.Context) (string, error) {
+		return "", sturdyc.ErrNotFound
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if _, ok := c.Get("1"); ok {
+		t.Error("expected the record to be deleted")
+	}
+}
+
+func TestRefreshBypassesTheDistributedStorage(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	storage := &mockStorage{}
+	c := sturdyc.New[string](100, 1, time.Hour, 10,
+		sturdyc.WithDistributedStorage(storage),
+	)
+
+	fetchObserver := NewFetchObserver(1)
+	fetchObserver.Response("1")
+	if err := c.Refresh(ctx, "1", fetchObserver.Fetch); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	<-fetchObserver.FetchCompleted
+
+	// The write to the distributed storage happens in the background.
+	time.Sleep(10 * time.Millisecond)
+	storage.assertGetCount(t, 0)
+	storage.assertSetCount(t, 1)
+	storage.assertRecord(t, "1")
+}
+
+func TestRefreshBatchOverwritesAndDeletesRecords(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	maxBatchSize := 2
+	c := sturdyc.New[string](100, 1, time.Hour, 10,
+		sturdyc.WithMaxBatchSize(maxBatchSize),
+	)
+	keyFn := c.BatchKeyFn("item")
+	ids := []string{"1", "2", "3", "4", "5"}
+	for _, id := range ids {
+		c.Set(keyFn(id), "old")
+	}
+
+	// The fifth record has been deleted at the data source.
+	fetchObserver := NewFetchObserver(3)
+	fetchObserver.BatchResponse(ids[:4])
+	if err := c.RefreshBatch(ctx, ids, keyFn, fetchObserver.FetchBatch); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	fetchObserver.AssertFetchCount(t, 3)
+
+	for _, id := range ids[:4] {
+		if res, _ := c.Get(keyFn(id)); res != "value"+id {
+			t.Errorf("expected value%s, got %s", id, res)
+		}
+	}
+	if _, ok := c.Get(keyFn("5")); ok {
+		t.Error("expected the fifth record to be deleted")
+	}
+}
+
+func TestRefreshBatchKeepsTheRecordsThatFailed(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	c := sturdyc.New[string](100, 1, time.Hour, 10)
+	keyFn := c.BatchKeyFn("item")
+	c.Set(keyFn("1"), "old")
+	c.Set(keyFn("2"), "old")
+
+	fetchErr := errors.New("error")
+	err := c.RefreshBatch(ctx, []string{"1", "2"}, keyFn, func(context.Context, []string) (map[string]string, error) {
+		return map[string]string{"1": "new"}, &sturdyc.BatchError{Errors: map[string]error{"2": fetchErr}}
+	})
+
+	var batchErr *sturdyc.BatchError
+	if !errors.As(err, &batchErr) || !errors.Is(batchErr.Errors["2"], fetchErr) {
+		t.Fatalf("expected a batch error for the second ID, got %v", err)
+	}
+	if res, _ := c.Get(keyFn("1")); res != "new" {
+		t.Errorf("expected the first record to be overwritten, got %s", res)
+	}
+	if res, _ := c.Get(keyFn("2")); res != "old" {
+		t.Errorf("expected the second record to be kept, got %s", res)
+	}
+}
+
+func TestPausedRefreshesAreNotScheduled(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	clock := sturdyc.NewTestClock(time.Now())
+	refreshDelay := time.Second
+	c := sturdyc.New[string](100, 1, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithEarlyRefreshes(refreshDelay, refreshDelay, time.Millisecond),
+		sturdyc.WithClock(clock),
+	)
+
+	fetchObserver := NewFetchObserver(2)
+	fetchObserver.Response("1")
+	c.GetOrFetch(ctx, "1", fetchObserver.Fetch)
+	<-fetchObserver.FetchCompleted
+
+	c.PauseRefreshes()
+	c.PauseRefreshes()
+	clock.Add(refreshDelay + 1)
+	if res, err := c.GetOrFetch(ctx, "1", fetchObserver.Fetch); err != nil || res != "value1" {
+		t.Fatalf("expected the record to be served while paused, got %q %v", res, err)
+	}
+
+	// Every pause has to be resumed.
+	c.ResumeRefreshes()
+	c.GetOrFetch(ctx, "1", fetchObserver.Fetch)
+	time.Sleep(10 * time.Millisecond)
+	fetchObserver.AssertFetchCount(t, 1)
+	if !c.RefreshesPaused() {
+		t.Error("expected the refreshes to still be paused")
+	}
+
+	c.ResumeRefreshes()
+	c.GetOrFetch(ctx, "1", fetchObserver.Fetch)
+	<-fetchObserver.FetchCompleted
+	fetchObserver.AssertFetchCount(t, 2)
+}
+
+func TestRefreshesArePausedUntilTheContextIsDone(t *testing.T) {
+	t.Parallel()
+
+	c := sturdyc.New[string](100, 1, time.Hour, 10,
+		sturdyc.WithEarlyRefreshes(time.Second, time.Second, time.Millisecond),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c.PauseRefreshesCtx(ctx)
+	if !c.RefreshesPaused() {
+		t.Fatal("expected the refreshes to be paused")
+	}
+
+	cancel()
+	for c.RefreshesPaused() {
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestRarelyAccessedKeysAreNotRefreshed(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	clock := sturdyc.NewTestClock(time.Now())
+	refreshDelay := time.Second
+	minAccesses := 3
+	c := sturdyc.New[string](100, 1, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithEarlyRefreshes(refreshDelay, refreshDelay, time.Millisecond),
+		sturdyc.WithRefreshAccessThreshold(minAccesses),
+		sturdyc.WithClock(clock),
+	)
+
+	fetchObserver := NewFetchObserver(2)
+	fetchObserver.Response("1")
+	c.GetOrFetch(ctx, "1", fetchObserver.Fetch)
+	<-fetchObserver.FetchCompleted
+
+	clock.Add(refreshDelay + 1)
+	for i := 1; i < minAccesses; i++ {
+		c.GetOrFetch(ctx, "1", fetchObserver.Fetch)
+	}
+	time.Sleep(10 * time.Millisecond)
+	fetchObserver.AssertFetchCount(t, 1)
+
+	c.GetOrFetch(ctx, "1", fetchObserver.Fetch)
+	<-fetchObserver.FetchCompleted
+	fetchObserver.AssertFetchCount(t, 2)
+}
+
+func TestBatchRefreshesDontCountAsReads(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	clock := sturdyc.NewTestClock(time.Now())
+	refreshDelay := time.Second
+	c := sturdyc.New[string](100, 1, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithEarlyRefreshes(refreshDelay, refreshDelay, time.Millisecond),
+		sturdyc.WithClock(clock),
+	)
+
+	ids := []string{"1", "2"}
+	keyFn := c.BatchKeyFn("item")
+	var fetches atomic.Int32
+	fetchFn := func(_ context.Context, ids []string) (map[string]string, error) {
+		fetches.Add(1)
+		response := make(map[string]string, len(ids))
+		for _, id := range ids {
+			response[id] = "value"
+		}
+		return response, nil
+	}
+	if _, err := c.GetOrFetchBatch(ctx, ids, keyFn, fetchFn); err != nil {
+		t.Fatal(err)
+	}
+
+	clock.Add(refreshDelay + 1)
+	if _, err := c.GetOrFetchBatch(ctx, ids, keyFn, fetchFn); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.WaitForIdle(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if fetches.Load() != 2 {
+		t.Fatalf("expected the records to be refreshed in the background, got %d fetches", fetches.Load())
+	}
+
+	// Only the second call read the records from the cache.
+	var hits int64
+	for _, s := range c.ShardStats() {
+		hits += s.Hits
+	}
+	if hits != int64(len(ids)) {
+		t.Errorf("expected %d hits, got %d", len(ids), hits)
+	}
+}
+
+func TestRecordsAreRefreshedAtAFractionOfTheirTTL(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	clock := sturdyc.NewTestClock(time.Now())
+	ttl := time.Hour
+	c := sturdyc.New[string](100, 1, ttl, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithEarlyRefreshes(time.Second, time.Second, time.Millisecond),
+		sturdyc.WithRefreshAtFraction(0.5, 0),
+		sturdyc.WithClock(clock),
+	)
+
+	fetchObserver := NewFetchObserver(2)
+	fetchObserver.Response("1")
+	c.GetOrFetch(ctx, "1", fetchObserver.Fetch)
+	<-fetchObserver.FetchCompleted
+
+	clock.Add(ttl / 2)
+	c.GetOrFetch(ctx, "1", fetchObserver.Fetch)
+	time.Sleep(10 * time.Millisecond)
+	fetchObserver.AssertFetchCount(t, 1)
+
+	clock.Add(1)
+	c.GetOrFetch(ctx, "1", fetchObserver.Fetch)
+	<-fetchObserver.FetchCompleted
+	fetchObserver.AssertFetchCount(t, 2)
+}
+
+type refreshCtxKey struct{}
+
+func TestRefreshesAreDetachedFromTheRequestContext(t *testing.T) {
+	t.Parallel()
+
+	clock := sturdyc.NewTestClock(time.Now())
+	refreshDelay := time.Second
+	c := sturdyc.New[string](100, 1, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithEarlyRefreshes(refreshDelay, refreshDelay, time.Millisecond),
+		sturdyc.WithClock(clock),
+	)
+	c.Set("1", "value1")
+	clock.Add(refreshDelay + 1)
+
+	type refreshCtx struct {
+		value any
+		err   error
+	}
+	refreshed := make(chan refreshCtx, 1)
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), refreshCtxKey{}, "trace"))
+	c.GetOrFetch(ctx, "1", func(ctx context.Context) (string, error) {
+		// Give the cancellation a chance to propagate.
+		time.Sleep(5 * time.Millisecond)
+		refreshed <- refreshCtx{value: ctx.Value(refreshCtxKey{}), err: ctx.Err()}
+		return "value1", nil
+	})
+	cancel()
+
+	res := <-refreshed
+	if res.value != "trace" {
+		t.Errorf("expected the refresh to keep the values of the context, got %v", res.value)
+	}
+	if res.err != nil {
+		t.Errorf("expected the refresh to not be cancelled, got %v", res.err)
+	}
+}
+
+func TestRefreshTimeout(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	clock := sturdyc.NewTestClock(time.Now())
+	refreshDelay := time.Second
+	c := sturdyc.New[string](100, 1, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithEarlyRefreshes(refreshDelay, refreshDelay, time.Millisecond),
+		sturdyc.WithRefreshTimeout(10*time.Millisecond),
+		sturdyc.WithClock(clock),
+	)
+	c.Set("1", "value1")
+	clock.Add(refreshDelay + 1)
+
+	refreshErr := make(chan error, 1)
+	c.GetOrFetch(ctx, "1", func(ctx context.Context) (string, error) {
+		<-ctx.Done()
+		refreshErr <- ctx.Err()
+		return "", ctx.Err()
+	})
+
+	if err := <-refreshErr; !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the refresh to time out, got %v", err)
+	}
+	if res, _ := c.Get("1"); res != "value1" {
+		t.Errorf("expected the record to be kept, got %s", res)
+	}
+}
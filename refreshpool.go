@@ -0,0 +1,73 @@
+package sturdyc
+
+// OverflowPolicy determines what happens to a refresh when
+// the queue of the refresh worker pool is full.
+type OverflowPolicy int
+
+const (
+	// OverflowDrop drops the refresh. The record is going to be refreshed
+	// the next time it's requested after the refresh retry delay.
+	OverflowDrop OverflowPolicy = iota
+	// OverflowBlock makes the caller wait until there is room in the queue.
+	OverflowBlock
+)
+
+// refreshPool limits the number of background refreshes that
+// are allowed to call the underlying data source at once.
+type refreshPool struct {
+	workers  int
+	queue    chan func()
+	overflow OverflowPolicy
+}
+
+func newRefreshPool(workers, queueSize int, overflow OverflowPolicy) *refreshPool {
+	return &refreshPool{
+		workers:  workers,
+		queue:    make(chan func(), queueSize),
+		overflow: overflow,
+	}
+}
+
+// startRefreshWorkers starts the workers of the refresh pool. They are never
+// going to exit, as the client can still refresh records once it's closed.
+func (c *Client[T]) startRefreshWorkers() {
+	for i := 0; i < c.refreshPool.workers; i++ {
+		go func() {
+			for refresh := range c.refreshPool.queue {
+				c.runRefresh(refresh)
+				c.pendingWork.Add(-1)
+			}
+		}()
+	}
+}
+
+func (c *Client[T]) runRefresh(refresh func()) {
+	defer func() {
+		if err := recover(); err != nil {
+			c.logger(LogRefreshes).Error("sturdyc: panic recovered", "panic", err)
+		}
+	}()
+	refresh()
+}
+
+// scheduleRefresh runs the refresh in a separate goroutine, or
+// hands it to the worker pool if WithRefreshConcurrency is used.
+func (c *Client[T]) scheduleRefresh(refresh func()) {
+	if c.refreshPool == nil {
+		c.trackedGo(refresh)
+		return
+	}
+
+	// The refresh is counted as pending work until a worker has run it.
+	c.pendingWork.Add(1)
+	if c.refreshPool.overflow == OverflowBlock {
+		c.refreshPool.queue <- refresh
+		return
+	}
+
+	select {
+	case c.refreshPool.queue <- refresh:
+	default:
+		c.pendingWork.Add(-1)
+	}
+}
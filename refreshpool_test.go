@@ -0,0 +1,109 @@
+package sturdyc_test
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/viccon/sturdyc"
+)
+
+// concurrencyObserver keeps track of the maximum number of concurrent fetches.
+type concurrencyObserver struct {
+	active    atomic.Int32
+	maxActive atomic.Int32
+	calls     atomic.Int32
+	release   chan struct{}
+}
+
+func (o *concurrencyObserver) Fetch(_ context.Context) (string, error) {
+	o.calls.Add(1)
+	active := o.active.Add(1)
+	defer o.active.Add(-1)
+	for {
+		maxActive := o.maxActive.Load()
+		if active <= maxActive || o.maxActive.CompareAndSwap(maxActive, active) {
+			break
+		}
+	}
+	<-o.release
+	return "value", nil
+}
+
+func seedRefreshPoolCache(t *testing.T, overflow sturdyc.OverflowPolicy, numKeys int) (*sturdyc.Client[string], *sturdyc.TestClock) {
+	t.Helper()
+
+	clock := sturdyc.NewTestClock(time.Now())
+	refreshDelay := time.Second
+	c := sturdyc.New[string](100, 1, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithEarlyRefreshes(refreshDelay, refreshDelay, time.Minute),
+		sturdyc.WithRefreshConcurrency(1, 1, overflow),
+		sturdyc.WithClock(clock),
+	)
+	for i := 0; i < numKeys; i++ {
+		c.Set(strconv.Itoa(i), "value")
+	}
+	clock.Add(refreshDelay + 1)
+	return c, clock
+}
+
+func TestRefreshConcurrencyDropsRefreshesWhenTheQueueIsFull(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	numKeys := 5
+	c, _ := seedRefreshPoolCache(t, sturdyc.OverflowDrop, numKeys)
+	observer := &concurrencyObserver{release: make(chan struct{})}
+
+	// The first refresh is picked up by the worker, the second one is queued,
+	// and the rest are dropped.
+	c.GetOrFetch(ctx, "0", observer.Fetch)
+	for observer.calls.Load() != 1 {
+		time.Sleep(time.Millisecond)
+	}
+	for i := 1; i < numKeys; i++ {
+		c.GetOrFetch(ctx, strconv.Itoa(i), observer.Fetch)
+	}
+	close(observer.release)
+
+	for observer.calls.Load() != 2 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+	if calls := observer.calls.Load(); calls != 2 {
+		t.Errorf("expected 2 refreshes, got %d", calls)
+	}
+}
+
+func TestRefreshConcurrencyBlocksWhenTheQueueIsFull(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	numKeys := 5
+	c, _ := seedRefreshPoolCache(t, sturdyc.OverflowBlock, numKeys)
+	observer := &concurrencyObserver{release: make(chan struct{})}
+
+	var wg sync.WaitGroup
+	for i := 0; i < numKeys; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.GetOrFetch(ctx, strconv.Itoa(i), observer.Fetch)
+		}()
+	}
+	for i := 0; i < numKeys; i++ {
+		observer.release <- struct{}{}
+	}
+	wg.Wait()
+
+	if calls := observer.calls.Load(); calls != int32(numKeys) {
+		t.Errorf("expected %d refreshes, got %d", numKeys, calls)
+	}
+	if maxActive := observer.maxActive.Load(); maxActive != 1 {
+		t.Errorf("expected at most 1 concurrent refresh, got %d", maxActive)
+	}
+}
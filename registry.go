@@ -0,0 +1,78 @@
+package sturdyc
+
+import (
+	"reflect"
+	"sync"
+)
+
+// Registry is a facade over a single client that stores values of several
+// types. Each registered type gets a typed view with its own namespace and
+// call options, such as its TTL or whether it should be refreshed, while
+// the capacity, evictions and metrics of the client are shared.
+type Registry struct {
+	client *Client[any]
+	mu     sync.RWMutex
+	names  map[string]reflect.Type
+	views  map[reflect.Type]any
+	stats  map[string]*Namespace[any]
+}
+
+// NewRegistry returns a registry that stores its values in the client.
+func NewRegistry(c *Client[any]) *Registry {
+	return &Registry{
+		client: c,
+		names:  make(map[string]reflect.Type),
+		views:  make(map[reflect.Type]any),
+		stats:  make(map[string]*Namespace[any]),
+	}
+}
+
+// Register adds the type to the registry, and returns a typed view whose keys
+// are prefixed with the name. The options are used as the defaults for the
+// writes and fetches of the type. It panics if the type or the name has
+// already been registered.
+func Register[V any](r *Registry, name string, opts ...CallOption) *TypedView[V] {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	typ := reflect.TypeFor[V]()
+	if _, ok := r.views[typ]; ok {
+		panic("type " + typ.String() + " has already been registered")
+	}
+	if _, ok := r.names[name]; ok {
+		panic("name " + name + " has already been registered")
+	}
+
+	view := NewTypedView[V](r.client, name, opts...)
+	r.names[name] = typ
+	r.views[typ] = view
+	r.stats[name] = view.Namespace()
+	return view
+}
+
+// Lookup returns the typed view of a type that has been registered.
+func Lookup[V any](r *Registry) (*TypedView[V], bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	view, ok := r.views[reflect.TypeFor[V]()]
+	if !ok {
+		return nil, false
+	}
+	return view.(*TypedView[V]), true
+}
+
+// Stats returns the statistics of each registered type, keyed by its name.
+func (r *Registry) Stats() map[string]NamespaceStats {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	stats := make(map[string]NamespaceStats, len(r.stats))
+	for name, namespace := range r.stats {
+		stats[name] = namespace.Stats()
+	}
+	return stats
+}
+
+// Client returns the client that is shared by every registered type.
+func (r *Registry) Client() *Client[any] {
+	return r.client
+}
@@ -0,0 +1,68 @@
+package sturdyc_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/viccon/sturdyc"
+)
+
+type registryOrder struct {
+	ID string
+}
+
+func TestRegistryAppliesTheConfigurationOfEachType(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	clock := sturdyc.NewTestClock(time.Now())
+	c := sturdyc.New[any](1000, 10, time.Hour, 5,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithClock(clock),
+	)
+	registry := sturdyc.NewRegistry(c)
+	orders := sturdyc.Register[registryOrder](registry, "orders", sturdyc.CallTTL(time.Minute))
+	sturdyc.Register[string](registry, "names")
+
+	if _, err := orders.GetOrFetch(ctx, "1", func(context.Context) (registryOrder, error) {
+		return registryOrder{ID: "1"}, nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	names, ok := sturdyc.Lookup[string](registry)
+	if !ok {
+		t.Fatal("expected the names to be registered")
+	}
+	names.Set("1", "alice")
+
+	clock.Add(time.Minute * 2)
+	if _, ok := orders.Get("1"); ok {
+		t.Error("expected the order to have expired with the TTL of its type")
+	}
+	if name, ok := names.Get("1"); !ok || name != "alice" {
+		t.Errorf("expected the name to use the TTL of the cache, got %q", name)
+	}
+
+	stats := registry.Stats()
+	if stats["orders"].Misses != 2 || stats["names"].Hits != 1 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+	if _, ok := sturdyc.Lookup[int](registry); ok {
+		t.Error("expected int to not be registered")
+	}
+}
+
+func TestRegistryPanicsIfATypeIsRegisteredTwice(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		err := recover()
+		if err == nil {
+			t.Error("expected a panic when a type is registered twice")
+		}
+	}()
+	registry := sturdyc.NewRegistry(sturdyc.New[any](1000, 10, time.Hour, 5))
+	sturdyc.Register[string](registry, "a")
+	sturdyc.Register[string](registry, "b")
+}
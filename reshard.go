@@ -0,0 +1,64 @@
+package sturdyc
+
+// migrate moves the entries of the shard to the shards that are returned by
+// successor, and makes the shard forward every subsequent call to them.
+func (s *shard[T]) migrate(successor func(key string) *shard[T]) {
+	s.Lock()
+	defer s.Unlock()
+
+	// The keys are moved in the order of the eviction index, if there is one.
+	keys := make([]string, 0, len(s.entries))
+	if s.index != nil {
+		keys = s.index.victims(len(s.entries), func(string) bool { return false })
+	} else {
+		for key := range s.entries {
+			keys = append(keys, key)
+		}
+	}
+
+	for _, key := range keys {
+		next := successor(key)
+		next.Lock()
+		next.insertEntry(key, s.entries[key])
+		next.Unlock()
+	}
+	s.entries = make(map[string]*entry[T])
+	s.successor = successor
+}
+
+// Reshard redistributes the entries of the cache across a new number of
+// shards, while keeping the total capacity. The entries are migrated one
+// shard at a time, which means that only the operations on the shard that is
+// being migrated are blocked. Operations on a shard that has been migrated
+// are forwarded to the new shards until every shard has been moved.
+func (c *Client[T]) Reshard(numShards int) {
+	if numShards < 1 {
+		panic("numShards must be greater than 0")
+	}
+
+	c.reshardMu.Lock()
+	defer c.reshardMu.Unlock()
+
+	oldShards := c.getShards()
+	var capacity int
+	for _, s := range oldShards {
+		capacity += s.getCapacity()
+	}
+	if capacity < numShards {
+		panic("numShards must be less than or equal to the capacity")
+	}
+
+	first := oldShards[0]
+	newShards := make([]*shard[T], numShards)
+	for i := range newShards {
+		newShards[i] = newShard[T](capacity/numShards, first.ttl, first.evictionPercentage, c.Config)
+	}
+	successor := func(key string) *shard[T] {
+		return newShards[c.keyHasher(key)%uint64(numShards)]
+	}
+
+	for _, s := range oldShards {
+		s.migrate(successor)
+	}
+	c.shards.Store(&newShards)
+}
@@ -0,0 +1,129 @@
+package sturdyc
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// RetryPolicy determines if, and when, a call to the underlying data source
+// should be retried. It is applied to both foreground fetches and background
+// refreshes. The backoff is also used to determine when a record whose
+// background refresh failed should be scheduled for another refresh.
+type RetryPolicy interface {
+	// MaxAttempts returns the maximum number of times that a fetch function is
+	// invoked for a single call, including the first attempt.
+	MaxAttempts() int
+	// Backoff returns the delay before the given retry attempt. The first
+	// retry is attempt 1.
+	Backoff(attempt int) time.Duration
+	// Retryable reports whether an error should be retried.
+	Retryable(err error) bool
+}
+
+type exponentialRetryPolicy struct {
+	maxAttempts int
+	baseDelay   time.Duration
+	maxDelay    time.Duration
+	retryable   func(err error) bool
+}
+
+// NewRetryPolicy returns a RetryPolicy that doubles the delay for each
+// attempt, starting at baseDelay and capped at maxDelay. If retryable is nil,
+// every error except ErrNotFound and context cancellations are retried.
+//
+// Parameters:
+//
+//	maxAttempts - The maximum number of attempts, including the first one.
+//	baseDelay - The delay before the first retry.
+//	maxDelay - The maximum delay between two attempts.
+//	retryable - Used to classify which errors should be retried.
+//
+// Returns:
+//
+//	A RetryPolicy that can be passed to WithRetryPolicy.
+func NewRetryPolicy(maxAttempts int, baseDelay, maxDelay time.Duration, retryable func(err error) bool) RetryPolicy {
+	if retryable == nil {
+		retryable = isRetryable
+	}
+	return &exponentialRetryPolicy{
+		maxAttempts: maxAttempts,
+		baseDelay:   baseDelay,
+		maxDelay:    maxDelay,
+		retryable:   retryable,
+	}
+}
+
+func (p *exponentialRetryPolicy) MaxAttempts() int {
+	return p.maxAttempts
+}
+
+func (p *exponentialRetryPolicy) Backoff(attempt int) time.Duration {
+	return exponentialBackoff(p.baseDelay, p.maxDelay, attempt-1)
+}
+
+func (p *exponentialRetryPolicy) Retryable(err error) bool {
+	return p.retryable(err)
+}
+
+// isRetryable is the default classification of retryable errors.
+func isRetryable(err error) bool {
+	return !errors.Is(err, ErrNotFound) &&
+		!errors.Is(err, context.Canceled) &&
+		!errors.Is(err, context.DeadlineExceeded)
+}
+
+// wait blocks for the duration, or until the context is done.
+func (c *Config) wait(ctx context.Context, d time.Duration) error {
+	timer, stop := c.clock.NewTimer(d)
+	select {
+	case <-timer:
+		return nil
+	case <-ctx.Done():
+		stop()
+		return ctx.Err()
+	}
+}
+
+// retryFetch wraps the fetchFn so that it is retried according to the retry policy.
+func retryFetch[V, T any](c *Client[T], fetchFn FetchFn[V]) FetchFn[V] {
+	if c.retryPolicy == nil {
+		return fetchFn
+	}
+
+	return func(ctx context.Context) (V, error) {
+		response, err := fetchFn(ctx)
+		for attempt := 1; err != nil && attempt < c.retryPolicy.MaxAttempts(); attempt++ {
+			if !c.retryPolicy.Retryable(err) {
+				break
+			}
+			if waitErr := c.wait(ctx, c.retryPolicy.Backoff(attempt)); waitErr != nil {
+				break
+			}
+			response, err = fetchFn(ctx)
+		}
+		return response, err
+	}
+}
+
+// retryBatchFetch wraps the fetchFn so that it is retried according to the
+// retry policy. Batches that partially succeeded with a BatchError are not retried.
+func retryBatchFetch[V, T any](c *Client[T], fetchFn BatchFetchFn[V]) BatchFetchFn[V] {
+	if c.retryPolicy == nil {
+		return fetchFn
+	}
+
+	return func(ctx context.Context, ids []string) (map[string]V, error) {
+		response, err := fetchFn(ctx, ids)
+		for attempt := 1; err != nil && attempt < c.retryPolicy.MaxAttempts(); attempt++ {
+			if _, isBatchErr := asBatchError(err); isBatchErr || !c.retryPolicy.Retryable(err) {
+				break
+			}
+			if waitErr := c.wait(ctx, c.retryPolicy.Backoff(attempt)); waitErr != nil {
+				break
+			}
+			response, err = fetchFn(ctx, ids)
+		}
+		return response, err
+	}
+}
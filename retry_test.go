@@ -0,0 +1,168 @@
+package sturdyc_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/viccon/sturdyc"
+)
+
+func TestGetOrFetchRetriesAccordingToThePolicy(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	c := sturdyc.New[string](100, 2, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithRetryPolicy(sturdyc.NewRetryPolicy(3, time.Millisecond, 5*time.Millisecond, nil)),
+	)
+
+	var calls atomic.Int32
+	fetchFn := func(_ context.Context) (string, error) {
+		if calls.Add(1) < 3 {
+			return "", errors.New("transient")
+		}
+		return "value", nil
+	}
+
+	res, err := c.GetOrFetch(ctx, "key", fetchFn)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if res != "value" {
+		t.Errorf("expected value, got %s", res)
+	}
+	if got := calls.Load(); got != 3 {
+		t.Errorf("expected 3 calls, got %d", got)
+	}
+}
+
+func TestGetOrFetchGivesUpAfterMaxAttempts(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	c := sturdyc.New[string](100, 2, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithRetryPolicy(sturdyc.NewRetryPolicy(2, time.Millisecond, time.Millisecond, nil)),
+	)
+
+	var calls atomic.Int32
+	errTransient := errors.New("transient")
+	fetchFn := func(_ context.Context) (string, error) {
+		calls.Add(1)
+		return "", errTransient
+	}
+
+	_, err := c.GetOrFetch(ctx, "key", fetchFn)
+	if !errors.Is(err, errTransient) {
+		t.Errorf("expected the transient error, got %v", err)
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("expected 2 calls, got %d", got)
+	}
+}
+
+func TestNonRetryableErrorsAreNotRetried(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	errPermanent := errors.New("permanent")
+	retryable := func(err error) bool {
+		return !errors.Is(err, errPermanent)
+	}
+	c := sturdyc.New[string](100, 2, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithRetryPolicy(sturdyc.NewRetryPolicy(5, time.Millisecond, time.Millisecond, retryable)),
+	)
+
+	var calls atomic.Int32
+	fetchFn := func(_ context.Context) (string, error) {
+		calls.Add(1)
+		return "", errPermanent
+	}
+	batchFetchFn := func(_ context.Context, _ []string) (map[string]string, error) {
+		calls.Add(1)
+		return nil, sturdyc.ErrNotFound
+	}
+
+	c.GetOrFetch(ctx, "key", fetchFn)
+	if got := calls.Load(); got != 1 {
+		t.Errorf("expected 1 call, got %d", got)
+	}
+
+	// The default classification is not used when a custom one has been
+	// provided. Hence, the ErrNotFound should be retried for the batch.
+	c.GetOrFetchBatch(ctx, []string{"1"}, c.BatchKeyFn("item"), batchFetchFn)
+	if got := calls.Load(); got != 6 {
+		t.Errorf("expected 6 calls, got %d", got)
+	}
+}
+
+func TestGetOrFetchBatchRetriesAccordingToThePolicy(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	c := sturdyc.New[string](100, 2, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithRetryPolicy(sturdyc.NewRetryPolicy(2, time.Millisecond, time.Millisecond, nil)),
+	)
+
+	var calls atomic.Int32
+	fetchFn := func(_ context.Context, ids []string) (map[string]string, error) {
+		if calls.Add(1) == 1 {
+			return nil, errors.New("transient")
+		}
+		return map[string]string{ids[0]: "value"}, nil
+	}
+
+	res, err := c.GetOrFetchBatch(ctx, []string{"1"}, c.BatchKeyFn("item"), fetchFn)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if res["1"] != "value" {
+		t.Errorf("expected value, got %s", res["1"])
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("expected 2 calls, got %d", got)
+	}
+}
+
+func TestRetryPolicyBackoffIsUsedToScheduleRefreshes(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	clock := sturdyc.NewTestClock(time.Now())
+	minRefreshDelay := time.Second
+	maxRefreshDelay := time.Second
+	c := sturdyc.New[string](100, 1, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithEarlyRefreshes(minRefreshDelay, maxRefreshDelay, time.Millisecond),
+		sturdyc.WithRetryPolicy(sturdyc.NewRetryPolicy(1, time.Minute, time.Minute, nil)),
+		sturdyc.WithClock(clock),
+	)
+
+	fetchObserver := NewFetchObserver(10)
+	fetchObserver.Response("1")
+	c.GetOrFetch(ctx, "1", fetchObserver.Fetch)
+	<-fetchObserver.FetchCompleted
+
+	fetchObserver.Err(errors.New("error"))
+	clock.Add(maxRefreshDelay + 1)
+	c.GetOrFetch(ctx, "1", fetchObserver.Fetch)
+	<-fetchObserver.FetchCompleted
+	fetchObserver.AssertFetchCount(t, 2)
+
+	// With the default backoff, the next refresh would have happened after a
+	// millisecond. The retry policy should make it wait for a minute instead.
+	clock.Add(time.Second)
+	c.GetOrFetch(ctx, "1", fetchObserver.Fetch)
+	time.Sleep(10 * time.Millisecond)
+	fetchObserver.AssertFetchCount(t, 2)
+
+	clock.Add(time.Minute)
+	c.GetOrFetch(ctx, "1", fetchObserver.Fetch)
+	<-fetchObserver.FetchCompleted
+	fetchObserver.AssertFetchCount(t, 3)
+}
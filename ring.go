@@ -0,0 +1,153 @@
+package sturdyc
+
+import (
+	"slices"
+	"strconv"
+	"sync"
+
+	"github.com/cespare/xxhash/v2"
+)
+
+// defaultRingReplicas is the number of virtual nodes that each
+// peer gets on the ring if no other number has been given.
+const defaultRingReplicas = 50
+
+// PeerRing is a PeerPicker which assigns the keys to the peers using
+// consistent hashing. Each peer is placed on the ring multiple times, as
+// virtual nodes, so that the keys are spread evenly. Adding or removing a peer
+// only moves the keys that the peer gains or loses, which means that the
+// membership can be kept in sync with a service discovery mechanism.
+type PeerRing struct {
+	mu       sync.RWMutex
+	self     string
+	replicas int
+	peers    map[string]Peer
+	hashes   []uint64
+	owners   map[uint64]string
+	onChange []func(names []string)
+}
+
+// NewPeerRing returns a PeerRing for the instance with the given name. The
+// peers are keyed by their names, and this instance should be included with
+// a nil Peer so that it's assigned its share of the keys. Every instance has
+// to be given the same names, and the same number of replicas, in order to
+// agree on which one owns a key. A replicas value of 0 uses the default.
+func NewPeerRing(self string, replicas int, peers map[string]Peer) *PeerRing {
+	if replicas < 1 {
+		replicas = defaultRingReplicas
+	}
+	r := &PeerRing{
+		self:     self,
+		replicas: replicas,
+		peers:    make(map[string]Peer, len(peers)),
+	}
+	for name, peer := range peers {
+		r.peers[name] = peer
+	}
+	r.rebuild()
+	return r
+}
+
+// rebuild places the virtual nodes of every peer on the ring. It
+// should be called with the lock held.
+func (r *PeerRing) rebuild() {
+	r.hashes = make([]uint64, 0, len(r.peers)*r.replicas)
+	r.owners = make(map[uint64]string, len(r.peers)*r.replicas)
+	for name := range r.peers {
+		for i := 0; i < r.replicas; i++ {
+			hash := xxhash.Sum64String(strconv.Itoa(i) + name)
+			r.hashes = append(r.hashes, hash)
+			r.owners[hash] = name
+		}
+	}
+	slices.Sort(r.hashes)
+}
+
+// changed rebuilds the ring, and calls the hooks with the names of the peers.
+// It should be called with the lock held, which is released before the hooks
+// are called.
+func (r *PeerRing) changed() {
+	r.rebuild()
+	names := make([]string, 0, len(r.peers))
+	for name := range r.peers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	hooks := slices.Clone(r.onChange)
+	r.mu.Unlock()
+
+	for _, hook := range hooks {
+		hook(names)
+	}
+}
+
+// AddPeer adds the peer to the ring, or replaces the peer with the same name.
+func (r *PeerRing) AddPeer(name string, peer Peer) {
+	r.mu.Lock()
+	r.peers[name] = peer
+	r.changed()
+}
+
+// RemovePeer removes the peer from the ring. Its keys are
+// assigned to the peers that follow it on the ring.
+func (r *PeerRing) RemovePeer(name string) {
+	r.mu.Lock()
+	if _, ok := r.peers[name]; !ok {
+		r.mu.Unlock()
+		return
+	}
+	delete(r.peers, name)
+	r.changed()
+}
+
+// SetPeers replaces the members of the ring. It's meant to be called with
+// the latest set of instances from a service discovery mechanism.
+func (r *PeerRing) SetPeers(peers map[string]Peer) {
+	r.mu.Lock()
+	r.peers = make(map[string]Peer, len(peers))
+	for name, peer := range peers {
+		r.peers[name] = peer
+	}
+	r.changed()
+}
+
+// OnChange registers a hook that is called with the names
+// of the peers every time the membership of the ring changes.
+func (r *PeerRing) OnChange(hook func(names []string)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.onChange = append(r.onChange, hook)
+}
+
+// Owner returns the name of the peer that owns the key.
+func (r *PeerRing) Owner(key string) (string, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.owner(key)
+}
+
+// owner should be called with the lock held.
+func (r *PeerRing) owner(key string) (string, bool) {
+	if len(r.hashes) == 0 {
+		return "", false
+	}
+
+	hash := xxhash.Sum64String(key)
+	index, _ := slices.BinarySearch(r.hashes, hash)
+	if index == len(r.hashes) {
+		index = 0
+	}
+	return r.owners[r.hashes[index]], true
+}
+
+// PickPeer returns the peer that owns the key, or false if it's owned by this instance.
+func (r *PeerRing) PickPeer(key string) (Peer, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	owner, ok := r.owner(key)
+	if !ok || owner == r.self {
+		return nil, false
+	}
+	return r.peers[owner], true
+}
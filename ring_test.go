@@ -0,0 +1,84 @@
+package sturdyc_test
+
+import (
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/viccon/sturdyc"
+)
+
+func ringOwners(ring *sturdyc.PeerRing, numKeys int) map[string]string {
+	owners := make(map[string]string, numKeys)
+	for i := 0; i < numKeys; i++ {
+		key := strconv.Itoa(i)
+		owners[key], _ = ring.Owner(key)
+	}
+	return owners
+}
+
+func TestPeerRingOnlyMovesTheKeysOfTheChangedPeer(t *testing.T) {
+	t.Parallel()
+
+	peers := map[string]sturdyc.Peer{"a": nil, "b": nil, "c": nil}
+	ring := sturdyc.NewPeerRing("a", 100, peers)
+	before := ringOwners(ring, 1000)
+
+	ring.AddPeer("d", nil)
+	afterAdd := ringOwners(ring, 1000)
+	for key, owner := range afterAdd {
+		if owner != before[key] && owner != "d" {
+			t.Fatalf("expected key %s to stay with %s or move to d, got %s", key, before[key], owner)
+		}
+	}
+
+	ring.RemovePeer("d")
+	afterRemove := ringOwners(ring, 1000)
+	for key, owner := range afterRemove {
+		if owner != before[key] {
+			t.Fatalf("expected key %s to move back to %s, got %s", key, before[key], owner)
+		}
+	}
+}
+
+func TestPeerRingSpreadsTheKeysEvenly(t *testing.T) {
+	t.Parallel()
+
+	peers := map[string]sturdyc.Peer{"a": nil, "b": nil, "c": nil, "d": nil}
+	ring := sturdyc.NewPeerRing("a", 200, peers)
+	counts := make(map[string]int)
+	for _, owner := range ringOwners(ring, 10000) {
+		counts[owner]++
+	}
+	for name, count := range counts {
+		if count < 1500 || count > 3500 {
+			t.Errorf("expected %s to own roughly a quarter of the keys, got %d", name, count)
+		}
+	}
+}
+
+func TestPeerRingCallsTheHooksWhenTheMembershipChanges(t *testing.T) {
+	t.Parallel()
+
+	ring := sturdyc.NewPeerRing("a", 0, map[string]sturdyc.Peer{"a": nil})
+	var calls [][]string
+	ring.OnChange(func(names []string) {
+		calls = append(calls, names)
+	})
+
+	ring.SetPeers(map[string]sturdyc.Peer{"a": nil, "b": nil, "c": nil})
+	ring.RemovePeer("c")
+	ring.RemovePeer("unknown")
+
+	if len(calls) != 2 {
+		t.Fatalf("expected 2 calls, got %d", len(calls))
+	}
+	if !slices.Equal(calls[0], []string{"a", "b", "c"}) || !slices.Equal(calls[1], []string{"a", "b"}) {
+		t.Errorf("unexpected membership updates: %v", calls)
+	}
+	if _, ok := ring.PickPeer("anything"); ok {
+		if owner, _ := ring.Owner("anything"); owner == "a" {
+			t.Error("expected keys that are owned by this instance to not be picked")
+		}
+	}
+}
@@ -3,7 +3,6 @@ package sturdyc
 import (
 	"context"
 	"errors"
-	"fmt"
 )
 
 // safeGo is a helper that prevents panics in any of the goroutines
@@ -12,13 +11,24 @@ func (c *Client[T]) safeGo(fn func()) {
 	go func() {
 		defer func() {
 			if err := recover(); err != nil {
-				c.log.Error(fmt.Sprintf("sturdyc: panic recovered: %v", err))
+				c.logger(LogCache).Error("sturdyc: panic recovered", "panic", err)
 			}
 		}()
 		fn()
 	}()
 }
 
+// trackedGo is the same as safeGo, but the goroutine is counted as pending
+// work until it returns, which is what client.WaitForIdle waits for. It should
+// only be used for tasks that are going to complete on their own.
+func (c *Client[T]) trackedGo(fn func()) {
+	c.pendingWork.Add(1)
+	c.safeGo(func() {
+		defer c.pendingWork.Add(-1)
+		fn()
+	})
+}
+
 func wrap[T, V any](fetchFn FetchFn[V]) FetchFn[T] {
 	return func(ctx context.Context) (T, error) {
 		res, err := fetchFn(ctx)
@@ -52,7 +62,8 @@ func unwrap[V, T any](val T, err error) (V, error) {
 func wrapBatch[T, V any](fetchFn BatchFetchFn[V]) BatchFetchFn[T] {
 	return func(ctx context.Context, ids []string) (map[string]T, error) {
 		resV, err := fetchFn(ctx, ids)
-		if err != nil && !errors.Is(err, errOnlyDistributedRecords) {
+		_, isBatchErr := asBatchError(err)
+		if err != nil && !errors.Is(err, errOnlyDistributedRecords) && !isBatchErr {
 			return map[string]T{}, err
 		}
 
@@ -0,0 +1,91 @@
+package sturdyc
+
+import (
+	"context"
+	"strings"
+)
+
+// ScanKeysMatching returns the keys in the cache that match the pattern. The
+// pattern supports '*', which matches any sequence of bytes, and '?', which
+// matches a single byte. Patterns that only end with a '*' are matched as
+// prefixes, which is the fastest option. The shards are scanned one at a
+// time, and each one is only read locked while its keys are being matched.
+func (c *Client[T]) ScanKeysMatching(pattern string) []string {
+	match := keyMatcher(pattern)
+	var keys []string
+	for _, shard := range c.getShards() {
+		keys = append(keys, shard.keys(match)...)
+	}
+	return keys
+}
+
+// KeysWhere returns the keys in the cache that satisfy the predicate. The
+// predicate is called while the shard of the key is read locked, which means
+// that it must not call the cache itself.
+func (c *Client[T]) KeysWhere(predicate func(key string) bool) []string {
+	var keys []string
+	for _, shard := range c.getShards() {
+		keys = append(keys, shard.keys(predicate)...)
+	}
+	return keys
+}
+
+// ScanKeysChan streams the keys in the cache over the returned channel, which
+// is closed once every key has been sent or the context has been cancelled.
+// Only the keys of one shard are held in memory at a time, which allows huge
+// caches to be enumerated incrementally.
+func (c *Client[T]) ScanKeysChan(ctx context.Context) <-chan string {
+	keys := make(chan string)
+	c.safeGo(func() {
+		defer close(keys)
+		for _, shard := range c.getShards() {
+			for _, key := range shard.keys() {
+				select {
+				case keys <- key:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	})
+	return keys
+}
+
+// keyMatcher returns a function that reports whether a key matches the pattern.
+func keyMatcher(pattern string) func(key string) bool {
+	prefix, isPrefix := strings.CutSuffix(pattern, "*")
+	if !strings.ContainsAny(prefix, "*?") {
+		if isPrefix {
+			return func(key string) bool { return strings.HasPrefix(key, prefix) }
+		}
+		return func(key string) bool { return key == pattern }
+	}
+	return func(key string) bool { return matchGlob(pattern, key) }
+}
+
+// matchGlob reports whether the key matches the pattern. When a mismatch
+// occurs, it backtracks to the most recent '*' and lets it consume one more
+// byte of the key.
+func matchGlob(pattern, key string) bool {
+	p, k := 0, 0
+	starP, starK := -1, 0
+	for k < len(key) {
+		switch {
+		case p < len(pattern) && pattern[p] == '*':
+			starP, starK = p, k
+			p++
+		case p < len(pattern) && (pattern[p] == '?' || pattern[p] == key[k]):
+			p++
+			k++
+		case starP >= 0:
+			starK++
+			p, k = starP+1, starK
+		default:
+			return false
+		}
+	}
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+	return p == len(pattern)
+}
@@ -0,0 +1,95 @@
+package sturdyc_test
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/viccon/sturdyc"
+)
+
+func TestScanKeysMatching(t *testing.T) {
+	t.Parallel()
+
+	c := sturdyc.New[int](100, 4, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+	)
+	for _, key := range []string{"user-1", "user-12", "user-2-admin", "order-1", "order-22"} {
+		c.Set(key, 1)
+	}
+
+	testCases := []struct {
+		pattern  string
+		expected []string
+	}{
+		{pattern: "user-*", expected: []string{"user-1", "user-12", "user-2-admin"}},
+		{pattern: "order-1", expected: []string{"order-1"}},
+		{pattern: "*-1", expected: []string{"order-1", "user-1"}},
+		{pattern: "user-?", expected: []string{"user-1"}},
+		{pattern: "*-??", expected: []string{"order-22", "user-12"}},
+		{pattern: "user-*-admin", expected: []string{"user-2-admin"}},
+		{pattern: "*", expected: []string{"order-1", "order-22", "user-1", "user-12", "user-2-admin"}},
+		{pattern: "product-*", expected: []string{}},
+	}
+	for _, tc := range testCases {
+		keys := c.ScanKeysMatching(tc.pattern)
+		slices.Sort(keys)
+		if !slices.Equal(keys, tc.expected) {
+			t.Errorf("expected %q to match %v, got %v", tc.pattern, tc.expected, keys)
+		}
+	}
+}
+
+func TestKeysWhere(t *testing.T) {
+	t.Parallel()
+
+	clock := sturdyc.NewTestClock(time.Now())
+	c := sturdyc.New[int](100, 4, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithClock(clock),
+	)
+	c.Set("expired-long", 1)
+	clock.Add(2 * time.Hour)
+	for _, key := range []string{"a", "bb", "ccc", "dddd"} {
+		c.Set(key, 1)
+	}
+
+	keys := c.KeysWhere(func(key string) bool { return len(key) > 2 })
+	slices.Sort(keys)
+	if expected := []string{"ccc", "dddd"}; !slices.Equal(keys, expected) {
+		t.Errorf("expected %v, got %v", expected, keys)
+	}
+}
+
+func TestScanKeysChanStreamsEveryKey(t *testing.T) {
+	t.Parallel()
+
+	c := sturdyc.New[int](1000, 8, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+	)
+	for i := 0; i < 500; i++ {
+		c.Set(strconv.Itoa(i), i)
+	}
+
+	seen := make(map[string]bool)
+	for key := range c.ScanKeysChan(context.Background()) {
+		seen[key] = true
+	}
+	if len(seen) != 500 {
+		t.Errorf("expected 500 keys, got %d", len(seen))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	keys := c.ScanKeysChan(ctx)
+	<-keys
+	cancel()
+	var remaining int
+	for range keys {
+		remaining++
+	}
+	if remaining >= 499 {
+		t.Errorf("expected the scan to stop once the context was cancelled, got %d more keys", remaining)
+	}
+}
@@ -1,40 +1,149 @@
 package sturdyc
 
 import (
-	"math/rand/v2"
-	"sync"
+	"cmp"
+	"reflect"
+	"slices"
+	"sync/atomic"
 	"time"
 )
 
+// TTLProvider can be implemented by the values that are stored in the cache
+// in order to limit how long they are cached for. This is useful for values
+// that carry their own expiry, such as tokens. The TTL is capped by the TTL of
+// the cache, and values that return a TTL of 0 or less are not cached at all.
+type TTLProvider interface {
+	GetCacheTTL() time.Duration
+}
+
+// canProvideTTL reports whether values of type T can implement TTLProvider.
+// It spares the shards from converting every value that's written to an
+// interface, which allocates.
+func canProvideTTL[T any]() bool {
+	typ := reflect.TypeFor[T]()
+	return typ.Kind() == reflect.Interface || typ.Implements(reflect.TypeFor[TTLProvider]())
+}
+
 // entry represents a single cache entry.
 type entry[T any] struct {
 	key                 string
 	value               T
+	cachedAt            time.Time
 	expiresAt           time.Time
 	refreshAt           time.Time
 	numOfRefreshRetries int
 	isMissingRecord     bool
+	// pinned entries are never evicted, and are served even after they've expired.
+	pinned bool
+	// accesses is the number of times the entry has been read since it was written.
+	accesses atomic.Int64
+	// previousAccesses is the number of times the key was read before the
+	// entry was written, which keeps refreshes from resetting its frequency.
+	previousAccesses int64
+	// lastAccessedAt is the time, in unix nanoseconds, at which the entry was
+	// last read or written. It's only updated once per accessTimeGranularity.
+	lastAccessedAt atomic.Int64
+}
+
+// accessTimeGranularity limits how often the last access time of an entry is
+// updated, so that frequent reads don't contend over the same cache line.
+const accessTimeGranularity = time.Second
+
+// touch records that the entry was accessed at the given time.
+func (e *entry[T]) touch(now time.Time) {
+	if now.UnixNano()-e.lastAccessedAt.Load() >= int64(accessTimeGranularity) {
+		e.lastAccessedAt.Store(now.UnixNano())
+	}
+}
+
+// frequency returns the number of times the key has been read, including the
+// reads of the entries that it has replaced.
+func (e *entry[T]) frequency() int64 {
+	return e.previousAccesses + e.accesses.Load()
+}
+
+// lastAccessed returns the time at which the entry was last read or written.
+func (e *entry[T]) lastAccessed() time.Time {
+	return time.Unix(0, e.lastAccessedAt.Load())
 }
 
 // shard is a thread-safe data structure that holds a subset of the cache entries.
 type shard[T any] struct {
-	sync.RWMutex
+	stripedRWMutex
 	*Config
 	capacity           int
 	ttl                time.Duration
 	entries            map[string]*entry[T]
 	evictionPercentage int
+	ttlProvider        bool
+	hits               atomic.Int64
+	misses             atomic.Int64
+	// index is nil when the entries are evicted by their expiration time.
+	index evictionIndex
+	// successor is set once the entries of the shard have been moved to a new
+	// set of shards by client.Reshard. It returns the shard that now holds the
+	// key, and is used to forward the calls from goroutines that are still
+	// holding on to this shard.
+	successor func(key string) *shard[T]
 }
 
 // newShard creates a new shard and returns a pointer to it.
 func newShard[T any](capacity int, ttl time.Duration, evictionPercentage int, cfg *Config) *shard[T] {
-	return &shard[T]{
+	s := &shard[T]{
+		stripedRWMutex:     newStripedRWMutex(cfg.lockStripes),
 		Config:             cfg,
 		capacity:           capacity,
 		ttl:                ttl,
 		entries:            make(map[string]*entry[T]),
 		evictionPercentage: evictionPercentage,
+		ttlProvider:        canProvideTTL[T](),
 	}
+	// The index is only used while the shard holds its lock.
+	s.index = cfg.newEvictionIndex(func() int { return s.capacity })
+	return s
+}
+
+// insertEntry writes the entry to the shard. Should be called with a lock.
+func (s *shard[T]) insertEntry(key string, e *entry[T]) {
+	if _, ok := s.entries[key]; !ok && s.index != nil {
+		s.index.add(key)
+	}
+	s.entries[key] = e
+}
+
+// removeEntry removes the entry from the shard. Should be called with a lock.
+func (s *shard[T]) removeEntry(key string) {
+	delete(s.entries, key)
+	if s.index != nil {
+		s.index.remove(key)
+	}
+}
+
+// isPinned reports whether the key belongs to a pinned entry. Should be called with a lock.
+func (s *shard[T]) isPinned(key string) bool {
+	e, ok := s.entries[key]
+	return ok && e.pinned
+}
+
+// evictEntry removes an entry that is being evicted, and reports the reason
+// along with how long ago it was written and its size. Should be called with
+// a lock.
+func (s *shard[T]) evictEntry(e *entry[T], reason EvictionReason, now time.Time) {
+	s.removeEntry(e.key)
+	s.reportEntryEvicted(e, reason, now)
+}
+
+// evictByIndex evicts up to n entries in the order of the eviction
+// index, and returns how many were evicted. Should be called with a lock.
+func (s *shard[T]) evictByIndex(n int) int {
+	now := s.clock.Now()
+	victims := s.index.victims(n, s.isPinned)
+	for _, key := range victims {
+		if e, ok := s.entries[key]; ok {
+			s.evictEntry(e, EvictionCapacity, now)
+		}
+	}
+	return len(victims)
 }
 
 // size returns the number of entries in the shard.
@@ -44,35 +153,151 @@ func (s *shard[T]) size() int {
 	return len(s.entries)
 }
 
-// evictExpired evicts all the expired entries in the shard.
-func (s *shard[T]) evictExpired() {
+// getCapacity returns the number of entries that the shard can hold.
+func (s *shard[T]) getCapacity() int {
+	s.RLock()
+	defer s.RUnlock()
+	return s.capacity
+}
+
+// evictionTime returns the time that determines the order in which the
+// entries are evicted when there is no eviction index. Entries with an
+// earlier time are evicted first.
+func (s *shard[T]) evictionTime(e *entry[T]) time.Time {
+	if s.evictionPolicy == EvictLRU {
+		return e.lastAccessed()
+	}
+	return e.expiresAt
+}
+
+// compareEviction orders the entries by when they should be evicted when
+// there is no eviction index. The least frequently read entries come first
+// for EvictLFU, with ties broken by the time of the last access.
+func (s *shard[T]) compareEviction(a, b *entry[T]) int {
+	if s.evictionPolicy == EvictLFU {
+		if c := cmp.Compare(a.frequency(), b.frequency()); c != 0 {
+			return c
+		}
+		return a.lastAccessed().Compare(b.lastAccessed())
+	}
+	return s.evictionTime(a).Compare(s.evictionTime(b))
+}
+
+// evictInOrder sorts the entries that aren't pinned by compareEviction, and
+// evicts up to n of them. Returns how many were evicted. Should be called
+// with a lock.
+func (s *shard[T]) evictInOrder(n int) int {
+	entries := make([]*entry[T], 0, len(s.entries))
+	for _, e := range s.entries {
+		if !e.pinned {
+			entries = append(entries, e)
+		}
+	}
+	slices.SortFunc(entries, s.compareEviction)
+	now := s.clock.Now()
+	n = min(n, len(entries))
+	for _, e := range entries[:n] {
+		s.evictEntry(e, EvictionCapacity, now)
+	}
+	return n
+}
+
+// evictSampled evicts up to n of the least frequently read entries among a
+// sample of the shard, and returns how many were evicted. This approximates
+// LFU without sorting every entry while the shard holds its write lock. The
+// iteration of a map starts at a random position, which gives us a different
+// sample for every eviction. Should be called with a lock.
+func (s *shard[T]) evictSampled(n int) int {
+	sampleSize := min(max(n*lfuSampleFactor, lfuMinSampleSize), len(s.entries))
+	candidates := make([]*entry[T], 0, sampleSize)
+	for _, e := range s.entries {
+		if len(candidates) == sampleSize {
+			break
+		}
+		if !e.pinned {
+			candidates = append(candidates, e)
+		}
+	}
+	slices.SortFunc(candidates, s.compareEviction)
+	now := s.clock.Now()
+	n = min(n, len(candidates))
+	for _, e := range candidates[:n] {
+		s.evictEntry(e, EvictionCapacity, now)
+	}
+	return n
+}
+
+// resize changes the capacity of the shard. If the shard holds more entries
+// than the new capacity, they are evicted according to the eviction policy.
+func (s *shard[T]) resize(capacity int) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.capacity = capacity
+	overflow := len(s.entries) - capacity
+	if overflow <= 0 {
+		return
+	}
+
+	s.reportForcedEviction()
+	if s.index != nil {
+		s.reportEntriesEvicted(s.evictByIndex(overflow))
+		return
+	}
+	s.reportEntriesEvicted(s.evictInOrder(overflow))
+}
+
+// evictExpired evicts all the expired entries in the shard, and returns how many were evicted.
+func (s *shard[T]) evictExpired() int {
 	s.Lock()
 	defer s.Unlock()
 
 	var entriesEvicted int
+	now := s.clock.Now()
 	for _, e := range s.entries {
-		if s.clock.Now().After(e.expiresAt) {
-			delete(s.entries, e.key)
+		// Entries that can be served if a fetch fails are kept around for a little longer.
+		if !e.pinned && now.After(e.expiresAt.Add(s.staleOnErrorDuration)) {
+			s.evictEntry(e, EvictionExpired, now)
 			entriesEvicted++
 		}
 	}
 	s.reportEntriesEvicted(entriesEvicted)
+	return entriesEvicted
 }
 
-// forceEvict evicts a certain percentage of the entries in the shard
-// based on the expiration time. Should be called with a lock.
+// forceEvict evicts a certain percentage of the entries in the shard based on
+// the eviction policy, which defaults to the expiration time. Should be called
+// with a lock.
 func (s *shard[T]) forceEvict() {
 	s.reportForcedEviction()
-	expirationTimes := make([]time.Time, 0, len(s.entries))
+	if s.index != nil {
+		n := max(len(s.entries)*s.evictionPercentage/100, 1)
+		s.reportEntriesEvicted(s.evictByIndex(n))
+		return
+	}
+	// The frequencies can't be turned into a cutoff time, so a sample of the entries is sorted instead.
+	if s.evictionPolicy == EvictLFU {
+		n := max(len(s.entries)*s.evictionPercentage/100, 1)
+		s.reportEntriesEvicted(s.evictSampled(n))
+		return
+	}
+
+	evictionTimes := make([]time.Time, 0, len(s.entries))
 	for _, e := range s.entries {
-		expirationTimes = append(expirationTimes, e.expiresAt)
+		if !e.pinned {
+			evictionTimes = append(evictionTimes, s.evictionTime(e))
+		}
+	}
+	if len(evictionTimes) == 0 {
+		return
 	}
 
-	cutoff := FindCutoff(expirationTimes, float64(s.evictionPercentage)/100)
+	cutoff := FindCutoff(evictionTimes, float64(s.evictionPercentage)/100)
 	entriesEvicted := 0
-	for key, e := range s.entries {
-		if e.expiresAt.Before(cutoff) {
-			delete(s.entries, key)
+	now := s.clock.Now()
+	for _, e := range s.entries {
+		if !e.pinned && s.evictionTime(e).Before(cutoff) {
+			s.evictEntry(e, EvictionCapacity, now)
 			entriesEvicted++
 		}
 	}
@@ -84,6 +309,7 @@ func (s *shard[T]) forceEvict() {
 // Parameters:
 //
 //	key: The key for which the value is to be retrieved.
+//	allowRefresh: A boolean indicating if the value is allowed to be refreshed.
 //
 // Returns:
 //
@@ -91,52 +317,247 @@ func (s *shard[T]) forceEvict() {
 //	exists: A boolean indicating if the value exists in the shard.
 //	markedAsMissing: A boolean indicating if the key has been marked as a missing record.
 //	refresh: A boolean indicating if the value should be refreshed in the background.
-func (s *shard[T]) get(key string) (val T, exists, markedAsMissing, refresh bool) {
-	s.RLock()
+func (s *shard[T]) get(key string, allowRefresh bool) (val T, exists, markedAsMissing, refresh bool) {
+	stripe := s.rlockStripe()
+	if s.successor != nil {
+		s.runlockStripe(stripe)
+		return s.successor(key).get(key, allowRefresh)
+	}
+
+	item, shouldRefresh := s.read(key, allowRefresh)
+	if item == nil {
+		s.runlockStripe(stripe)
+		return val, false, false, false
+	}
+	if !shouldRefresh {
+		val, markedAsMissing = item.value, item.isMissingRecord
+		s.runlockStripe(stripe)
+		return val, true, markedAsMissing, false
+	}
+	s.runlockStripe(stripe)
+	return s.claimRefresh(key, item)
+}
+
+// read looks up the entry and records the access. It returns nil if the entry
+// doesn't exist or has expired, and whether the entry is due for a refresh.
+// Should be called with a read lock.
+func (s *shard[T]) read(key string, allowRefresh bool) (*entry[T], bool) {
 	item, ok := s.entries[key]
 	if !ok {
-		s.RUnlock()
-		return val, false, false, false
+		s.misses.Add(1)
+		return nil, false
 	}
 
-	if s.clock.Now().After(item.expiresAt) {
-		s.RUnlock()
-		return val, false, false, false
+	if !item.pinned && s.clock.Now().After(item.expiresAt) {
+		s.misses.Add(1)
+		return nil, false
+	}
+	s.hits.Add(1)
+	item.touch(s.clock.Now())
+	if s.index != nil {
+		s.index.access(&item.key)
 	}
 
-	shouldRefresh := s.refreshInBackground && s.clock.Now().After(item.refreshAt)
-	if shouldRefresh {
-		// Release the read lock, and switch to a write lock.
-		s.RUnlock()
-		s.Lock()
+	// Keys that haven't been read often enough are left to expire.
+	accesses := item.accesses.Add(1)
+	frequentlyAccessed := s.minRefreshAccesses < 1 || accesses >= int64(s.minRefreshAccesses)
+	return item, allowRefresh && s.refreshInBackground && s.refreshesPaused.Load() == 0 && frequentlyAccessed && s.clock.Now().After(item.refreshAt)
+}
 
-		// However, during the time it takes to switch locks, another goroutine
-		// might have acquired it and moved the refreshAt. Therefore, we'll have to
-		// check if this operation should still be performed.
-		if !s.clock.Now().After(item.refreshAt) {
+// claimRefresh switches to a write lock and moves the refreshAt of an entry
+// that is due for a refresh, so that no other goroutine refreshes it as well.
+func (s *shard[T]) claimRefresh(key string, item *entry[T]) (val T, exists, markedAsMissing, refresh bool) {
+	s.Lock()
+
+	// During the time it takes to switch locks, another goroutine might have
+	// acquired it and moved the refreshAt. Therefore, we'll have to check if
+	// this operation should still be performed.
+	if !s.clock.Now().After(item.refreshAt) {
+		s.Unlock()
+		return item.value, true, item.isMissingRecord, false
+	}
+
+	// Check if the refreshes of this entry have failed too many times.
+	if s.maxRefreshRetries > 0 && item.numOfRefreshRetries >= s.maxRefreshRetries {
+		if s.refreshesExhaustedPolicy == ExhaustedDelete {
+			s.removeEntry(key)
 			s.Unlock()
-			return item.value, true, item.isMissingRecord, false
+			return val, false, false, false
 		}
+		item.value = val
+		item.isMissingRecord = true
+		item.numOfRefreshRetries = 0
+	}
 
-		// Update the "refreshAt" so no other goroutines attempts to refresh the same entry.
-		nextRefresh := s.retryBaseDelay * (1 << item.numOfRefreshRetries)
-		item.refreshAt = s.clock.Now().Add(nextRefresh)
-		item.numOfRefreshRetries++
+	// Update the "refreshAt" so no other goroutines attempts to refresh the same entry.
+	nextRefresh := s.refreshRetryDelay(item.numOfRefreshRetries)
+	item.refreshAt = s.clock.Now().Add(nextRefresh)
+	item.numOfRefreshRetries++
 
-		s.Unlock()
-		return item.value, true, item.isMissingRecord, shouldRefresh
+	s.Unlock()
+	return item.value, true, item.isMissingRecord, true
+}
+
+// lookup is the result of a read from the cache.
+type lookup[T any] struct {
+	val             T
+	exists          bool
+	markedAsMissing bool
+	refresh         bool
+}
+
+// getMany retrieves the values of multiple keys while only acquiring the read
+// lock once. The result of each key is written to its position in results.
+func (s *shard[T]) getMany(keys []string, positions []int, allowRefresh bool, results []lookup[T]) {
+	stripe := s.rlockStripe()
+	if s.successor != nil {
+		s.runlockStripe(stripe)
+		for i, key := range keys {
+			r := &results[positions[i]]
+			r.val, r.exists, r.markedAsMissing, r.refresh = s.successor(key).get(key, allowRefresh)
+		}
+		return
+	}
+
+	var refreshes []int
+	var refreshItems []*entry[T]
+	for i, key := range keys {
+		item, shouldRefresh := s.read(key, allowRefresh)
+		if item == nil {
+			continue
+		}
+		if shouldRefresh {
+			refreshes = append(refreshes, i)
+			refreshItems = append(refreshItems, item)
+			continue
+		}
+		results[positions[i]] = lookup[T]{val: item.value, exists: true, markedAsMissing: item.isMissingRecord}
+	}
+	s.runlockStripe(stripe)
+
+	for j, i := range refreshes {
+		r := &results[positions[i]]
+		r.val, r.exists, r.markedAsMissing, r.refresh = s.claimRefresh(keys[i], refreshItems[j])
 	}
+}
+
+// peek returns true if the key has an entry that hasn't expired. Unlike get,
+// it doesn't record the access, which makes it suitable for internal lookups
+// that shouldn't affect the statistics or the eviction order.
+func (s *shard[T]) peek(key string) bool {
+	s.RLock()
+	if s.successor != nil {
+		s.RUnlock()
+		return s.successor(key).peek(key)
+	}
+	defer s.RUnlock()
+
+	item, ok := s.entries[key]
+	return ok && (item.pinned || !s.clock.Now().After(item.expiresAt))
+}
+
+// getStale retrieves a value that has expired, but which is still allowed to
+// be served if the underlying data source fails. Missing records are never
+// considered to be stale values.
+func (s *shard[T]) getStale(key string) (val T, ok bool) {
+	s.RLock()
+	if s.successor != nil {
+		s.RUnlock()
+		return s.successor(key).getStale(key)
+	}
+	defer s.RUnlock()
+
+	item, ok := s.entries[key]
+	if !ok || item.isMissingRecord {
+		return val, false
+	}
+
+	if s.clock.Now().After(item.expiresAt.Add(s.staleOnErrorDuration)) {
+		return val, false
+	}
+
+	return item.value, true
+}
+
+// refreshState returns the number of times that a refresh has been scheduled
+// for the entry, and the time at which its current value was written.
+func (s *shard[T]) refreshState(key string) (retries int, cachedAt time.Time, ok bool) {
+	s.RLock()
+	if s.successor != nil {
+		s.RUnlock()
+		return s.successor(key).refreshState(key)
+	}
+	defer s.RUnlock()
+
+	item, ok := s.entries[key]
+	if !ok {
+		return 0, time.Time{}, false
+	}
+	return item.numOfRefreshRetries, item.cachedAt, true
+}
+
+// set writes a key-value pair to the shard and returns a boolean indicating
+// whether an eviction was performed. A ttl of 0 uses the TTL of the shard.
+func (s *shard[T]) set(key string, value T, isMissingRecord bool, ttl time.Duration) bool {
+	return s.setIf(key, value, isMissingRecord, ttl, nil)
+}
+
+// setIf is the same as set, but the value is only written if the condition
+// returns true for the existing entry, which is nil if there isn't one.
+func (s *shard[T]) setIf(key string, value T, isMissingRecord bool, ttl time.Duration, condition func(existing *entry[T]) bool) bool {
+	return s.write(key, value, isMissingRecord, ttl, true, condition)
+}
 
-	s.RUnlock()
-	return item.value, true, item.isMissingRecord, false
+// setWithoutJitter is the same as set, but the TTL is used as is. It's used
+// for records whose remaining TTL has already been decided, such as imports.
+func (s *shard[T]) setWithoutJitter(key string, value T, isMissingRecord bool, ttl time.Duration) bool {
+	return s.write(key, value, isMissingRecord, ttl, false, nil)
 }
 
-// set writes a key-value pair to the shard and returns a
-// boolean indicating whether an eviction was performed.
-func (s *shard[T]) set(key string, value T, isMissingRecord bool) bool {
+// write stores the value if the condition holds, and pads the TTL with
+// jitter unless the caller has asked for it to be used as is.
+func (s *shard[T]) write(key string, value T, isMissingRecord bool, ttl time.Duration, jitter bool, condition func(existing *entry[T]) bool) bool {
+	entryTTL := ttl
+	if entryTTL == 0 {
+		entryTTL = s.ttl
+	}
+	if jitter {
+		entryTTL = s.jitterTTL(entryTTL)
+	}
+
+	// Values that know when they become invalid are never cached for longer than that.
+	if s.ttlProvider && !isMissingRecord {
+		if provider, ok := any(value).(TTLProvider); ok {
+			entryTTL = min(entryTTL, provider.GetCacheTTL())
+		}
+	}
+
 	s.Lock()
+	if s.successor != nil {
+		s.Unlock()
+		// The successor applies the jitter, if any, to the TTL that was passed in.
+		return s.successor(key).write(key, value, isMissingRecord, ttl, jitter, condition)
+	}
+
+	// The promotion of a missing record is reported once the lock has been released.
+	var promoted bool
+	defer func() {
+		if promoted {
+			s.reportMissingRecordPromoted(key)
+		}
+	}()
 	defer s.Unlock()
 
+	if condition != nil && !condition(s.entries[key]) {
+		return false
+	}
+
+	// A value that has already expired replaces the one we have, but isn't stored.
+	if entryTTL <= 0 {
+		s.removeEntry(key)
+		return false
+	}
+
 	// Check we need to perform an eviction first.
 	evict := len(s.entries) >= s.capacity
 
@@ -154,42 +575,107 @@ func (s *shard[T]) set(key string, value T, isMissingRecord bool) bool {
 	newEntry := &entry[T]{
 		key:             key,
 		value:           value,
-		expiresAt:       now.Add(s.ttl),
+		cachedAt:        now,
+		expiresAt:       now.Add(entryTTL),
 		isMissingRecord: isMissingRecord,
 	}
+	newEntry.lastAccessedAt.Store(now.UnixNano())
+	if current, ok := s.entries[key]; ok {
+		newEntry.pinned = current.pinned
+		newEntry.previousAccesses = current.frequency()
+		promoted = current.isMissingRecord && !isMissingRecord
+	}
 
 	if s.refreshInBackground {
-		// If there is a difference between the min- and maxRefreshTime we'll use that to
-		// set a random padding so that the refreshes get spread out evenly over time.
-		var padding time.Duration
-		if s.minRefreshTime != s.maxRefreshTime {
-			padding = time.Duration(rand.Int64N(int64(s.maxRefreshTime - s.minRefreshTime)))
-		}
-		newEntry.refreshAt = now.Add(s.minRefreshTime + padding)
+		newEntry.refreshAt = now.Add(s.refreshDelay(entryTTL))
 		newEntry.numOfRefreshRetries = 0
 	}
 
-	s.entries[key] = newEntry
+	s.insertEntry(key, newEntry)
 	return evict
 }
 
+// jitterTTL pads the TTL with a random fraction of up to ttlJitter of itself,
+// so that entries which are written in the same burst don't expire together.
+func (s *shard[T]) jitterTTL(ttl time.Duration) time.Duration {
+	if s.ttlJitter <= 0 {
+		return ttl
+	}
+	return ttl + time.Duration(s.randFloat64()*s.ttlJitter*float64(ttl))
+}
+
+// refreshDelay returns the duration after which an entry with the given TTL
+// should be refreshed.
+func (s *shard[T]) refreshDelay(ttl time.Duration) time.Duration {
+	if s.refreshAtFraction > 0 {
+		fraction := s.refreshAtFraction
+		if s.refreshAtJitter > 0 {
+			fraction += s.randFloat64() * s.refreshAtJitter
+		}
+		return time.Duration(float64(ttl) * fraction)
+	}
+
+	// If there is a difference between the min- and maxRefreshTime we'll use that to
+	// set a random padding so that the refreshes get spread out evenly over time.
+	var padding time.Duration
+	if s.minRefreshTime != s.maxRefreshTime {
+		padding = time.Duration(s.randInt64N(int64(s.maxRefreshTime - s.minRefreshTime)))
+	}
+	return s.minRefreshTime + padding
+}
+
 // delete removes a key from the shard.
 func (s *shard[T]) delete(key string) {
 	s.Lock()
+	if s.successor != nil {
+		s.Unlock()
+		s.successor(key).delete(key)
+		return
+	}
 	defer s.Unlock()
-	delete(s.entries, key)
+	s.removeEntry(key)
 }
 
-// keys returns all non-expired keys in the shard.
-func (s *shard[T]) keys() []string {
+// setPinned pins or unpins the entry, and reports whether the key exists.
+func (s *shard[T]) setPinned(key string, pinned bool) bool {
+	s.Lock()
+	if s.successor != nil {
+		s.Unlock()
+		return s.successor(key).setPinned(key, pinned)
+	}
+	defer s.Unlock()
+
+	e, ok := s.entries[key]
+	if !ok {
+		return false
+	}
+	e.pinned = pinned
+	return true
+}
+
+// keys returns all non-expired keys in the shard that satisfy every predicate.
+func (s *shard[T]) keys(predicates ...func(key string) bool) []string {
 	s.RLock()
 	defer s.RUnlock()
 	keys := make([]string, 0, len(s.entries))
 	for k, v := range s.entries {
-		if s.clock.Now().After(v.expiresAt) {
+		if !v.pinned && s.clock.Now().After(v.expiresAt) {
+			continue
+		}
+		if !matchesAll(k, predicates) {
 			continue
 		}
 		keys = append(keys, k)
 	}
 	return keys
 }
+
+// matchesAll reports whether the key satisfies every predicate.
+func matchesAll(key string, predicates []func(key string) bool) bool {
+	for _, predicate := range predicates {
+		if !predicate(key) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,72 @@
+package sturdyc
+
+// ShardStats holds the statistics of a single shard.
+type ShardStats struct {
+	// Index is the index of the shard.
+	Index int
+	// Entries is the number of entries in the shard.
+	Entries int
+	// Capacity is the number of entries that the shard can hold.
+	Capacity int
+	// Hits is the number of lookups that found the key in the shard.
+	Hits int64
+	// Misses is the number of lookups that didn't find the key in the shard.
+	Misses int64
+	// HitRate is the share of lookups that were hits. It's 0 without lookups.
+	HitRate float64
+}
+
+// ShardStats returns the statistics of every shard, which can be
+// used to spot shards that are hotter or fuller than the others.
+func (c *Client[T]) ShardStats() []ShardStats {
+	shards := c.getShards()
+	stats := make([]ShardStats, 0, len(shards))
+	for i, s := range shards {
+		hits, misses := s.hits.Load(), s.misses.Load()
+		shardStats := ShardStats{
+			Index:    i,
+			Entries:  s.size(),
+			Capacity: s.getCapacity(),
+			Hits:     hits,
+			Misses:   misses,
+		}
+		if hits+misses > 0 {
+			shardStats.HitRate = float64(hits) / float64(hits+misses)
+		}
+		stats = append(stats, shardStats)
+	}
+	return stats
+}
+
+// shardSkew returns the number of entries in the fullest shard divided by the
+// average number of entries per shard. A perfectly balanced cache has a skew of 1.
+func (c *Client[T]) shardSkew() float64 {
+	shards := c.getShards()
+	var total, largest int
+	for _, s := range shards {
+		size := s.size()
+		total += size
+		largest = max(largest, size)
+	}
+	// The skew isn't meaningful until the shards have had a chance to fill up.
+	if total < len(shards) {
+		return 1
+	}
+	return float64(largest) / (float64(total) / float64(len(shards)))
+}
+
+// checkShardSkew logs a warning when the skew of the shards exceeds the
+// threshold. The warning is only logged again once the skew has recovered.
+func (c *Client[T]) checkShardSkew() {
+	if c.shardSkewThreshold <= 0 {
+		return
+	}
+	skew := c.shardSkew()
+	if skew <= c.shardSkewThreshold {
+		c.shardsSkewed.Store(false)
+		return
+	}
+	if c.shardsSkewed.CompareAndSwap(false, true) {
+		c.logger(LogEvictions).Warn("sturdyc: the fullest shard holds more entries than the average", "skew", skew)
+	}
+}
@@ -0,0 +1,105 @@
+package sturdyc_test
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/viccon/sturdyc"
+)
+
+type warningLogger struct {
+	sturdyc.NoopLogger
+	warnings chan string
+}
+
+func (l *warningLogger) Warn(msg string, _ ...any) {
+	l.warnings <- msg
+}
+
+func TestShardStatsReportsEachShard(t *testing.T) {
+	t.Parallel()
+
+	c := sturdyc.New[string](100, 4, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+	)
+	for i := 0; i < 10; i++ {
+		c.Set(strconv.Itoa(i), "value")
+	}
+	c.Get("1")
+	c.Get("missing")
+
+	stats := c.ShardStats()
+	if len(stats) != 4 {
+		t.Fatalf("expected stats for 4 shards, got %d", len(stats))
+	}
+
+	var entries int
+	var hits, misses int64
+	for i, s := range stats {
+		if s.Index != i {
+			t.Errorf("expected shard %d to have index %d, got %d", i, i, s.Index)
+		}
+		if s.Capacity != 25 {
+			t.Errorf("expected shard %d to have a capacity of 25, got %d", i, s.Capacity)
+		}
+		entries += s.Entries
+		hits += s.Hits
+		misses += s.Misses
+		if s.Hits > 0 && s.Misses == 0 && s.HitRate != 1 {
+			t.Errorf("expected shard %d to have a hit rate of 1, got %v", i, s.HitRate)
+		}
+	}
+	if entries != 10 || hits != 1 || misses != 1 {
+		t.Errorf("expected 10 entries, 1 hit and 1 miss, got %d, %d and %d", entries, hits, misses)
+	}
+}
+
+func TestShardSkewIsLogged(t *testing.T) {
+	t.Parallel()
+
+	clock := sturdyc.NewTestClock(time.Now())
+	logger := &warningLogger{warnings: make(chan string, 1)}
+	c := sturdyc.New[string](100, 4, time.Hour, 10,
+		sturdyc.WithEvictionInterval(time.Second),
+		sturdyc.WithShardSkewWarning(1.5),
+		sturdyc.WithLog(logger),
+		sturdyc.WithClock(clock),
+	)
+	// Five keys can't be spread evenly across four shards, which means
+	// that the fullest shard holds at least 1.6 times the average.
+	for i := 0; i < 5; i++ {
+		c.Set(strconv.Itoa(i), "value")
+	}
+
+	if warning := advanceUntil(clock, logger.warnings); warning == "" {
+		t.Error("expected a warning about the skew of the shards")
+	}
+}
+
+func TestKeyHasherDeterminesTheShard(t *testing.T) {
+	t.Parallel()
+
+	c := sturdyc.New[string](100, 4, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithKeyHasher(func(key string) uint64 {
+			n, _ := strconv.Atoi(key)
+			return uint64(n)
+		}),
+	)
+	for i := 0; i < 8; i++ {
+		c.Set(strconv.Itoa(i*4+1), "value")
+	}
+
+	for _, s := range c.ShardStats() {
+		if s.Index == 1 && s.Entries != 8 {
+			t.Errorf("expected every key to be written to shard 1, got %d entries", s.Entries)
+		}
+		if s.Index != 1 && s.Entries != 0 {
+			t.Errorf("expected shard %d to be empty, got %d entries", s.Index, s.Entries)
+		}
+	}
+	if value, ok := c.Get("5"); !ok || value != "value" {
+		t.Errorf("expected the key to be found, got %q", value)
+	}
+}
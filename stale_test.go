@@ -0,0 +1,171 @@
+package sturdyc_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/viccon/sturdyc"
+)
+
+func TestGetOrFetchServesStaleRecordsOnError(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	ttl := time.Minute
+	maxStaleness := time.Hour
+	clock := sturdyc.NewTestClock(time.Now())
+	c := sturdyc.New[string](100, 2, ttl, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithStaleOnError(maxStaleness),
+		sturdyc.WithClock(clock),
+	)
+
+	fetchObserver := NewFetchObserver(3)
+	fetchObserver.Response("1")
+	c.GetOrFetch(ctx, "1", fetchObserver.Fetch)
+	<-fetchObserver.FetchCompleted
+
+	// The record has expired, and the data source is failing.
+	clock.Add(ttl + 1)
+	fetchObserver.Err(errors.New("unavailable"))
+	var staleErr error
+	onStale := sturdyc.CallOnStale(func(err error) { staleErr = err })
+	res, err := c.GetOrFetch(ctx, "1", fetchObserver.Fetch, onStale)
+	<-fetchObserver.FetchCompleted
+	if err != nil {
+		t.Errorf("expected the stale record to be served without an error, got %v", err)
+	}
+	if res != "value1" {
+		t.Errorf("expected value1, got %s", res)
+	}
+	var keyErr *sturdyc.KeyError
+	if !errors.As(staleErr, &keyErr) || keyErr.Key != "1" {
+		t.Errorf("expected the error of the data source to be reported for key 1, got %v", staleErr)
+	}
+	if _, ok := c.Get("1"); ok {
+		t.Error("expected Get to not return the stale record")
+	}
+
+	// Once the max staleness has passed, the error should be returned.
+	clock.Add(maxStaleness)
+	staleErr = nil
+	_, err = c.GetOrFetch(ctx, "1", fetchObserver.Fetch, onStale)
+	<-fetchObserver.FetchCompleted
+	if err == nil || staleErr != nil {
+		t.Errorf("expected the error from the data source, got %v", err)
+	}
+}
+
+func TestGenericGetOrFetchServesStaleRecordsOnError(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	ttl := time.Minute
+	clock := sturdyc.NewTestClock(time.Now())
+	c := sturdyc.New[any](100, 2, ttl, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithStaleOnError(time.Hour),
+		sturdyc.WithClock(clock),
+	)
+	view := sturdyc.NewTypedView[string](c, "users")
+
+	fetchObserver := NewFetchObserver(4)
+	fetchObserver.Response("1")
+	sturdyc.GetOrFetch(ctx, c, "1", fetchObserver.Fetch)
+	<-fetchObserver.FetchCompleted
+	view.GetOrFetch(ctx, "1", fetchObserver.Fetch)
+	<-fetchObserver.FetchCompleted
+
+	clock.Add(ttl + 1)
+	fetchObserver.Err(errors.New("unavailable"))
+	res, err := sturdyc.GetOrFetch(ctx, c, "1", fetchObserver.Fetch)
+	<-fetchObserver.FetchCompleted
+	if err != nil || res != "value1" {
+		t.Errorf("expected value1 without an error, got %q %v", res, err)
+	}
+
+	res, err = view.GetOrFetch(ctx, "1", fetchObserver.Fetch)
+	<-fetchObserver.FetchCompleted
+	if err != nil || res != "value1" {
+		t.Errorf("expected value1 without an error from the typed view, got %q %v", res, err)
+	}
+}
+
+func TestStaleRecordsAreNotServedForRecordsThatAreNotFound(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	ttl := time.Minute
+	clock := sturdyc.NewTestClock(time.Now())
+	c := sturdyc.New[string](100, 2, ttl, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithStaleOnError(time.Hour),
+		sturdyc.WithClock(clock),
+	)
+
+	c.Set("1", "value1")
+	clock.Add(ttl + 1)
+	_, err := c.GetOrFetch(ctx, "1", func(_ context.Context) (string, error) {
+		return "", sturdyc.ErrNotFound
+	})
+	if !errors.Is(err, sturdyc.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestGetOrFetchBatchServesStaleRecordsOnError(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	ttl := time.Minute
+	clock := sturdyc.NewTestClock(time.Now())
+	c := sturdyc.New[string](100, 2, ttl, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithStaleOnError(time.Hour),
+		sturdyc.WithClock(clock),
+	)
+
+	keyFn := c.BatchKeyFn("item")
+	c.Set(keyFn("1"), "value1")
+	c.Set(keyFn("2"), "value2")
+	clock.Add(ttl + 1)
+
+	failingFetch := func(_ context.Context, _ []string) (map[string]string, error) {
+		return nil, errors.New("unavailable")
+	}
+	res, err := c.GetOrFetchBatch(ctx, []string{"1", "2", "3"}, keyFn, failingFetch)
+	if !errors.Is(err, sturdyc.ErrOnlyCachedRecords) {
+		t.Errorf("expected ErrOnlyCachedRecords, got %v", err)
+	}
+	if len(res) != 2 || res["1"] != "value1" || res["2"] != "value2" {
+		t.Errorf("expected the stale records, got %v", res)
+	}
+}
+
+func TestStaleRecordsAreKeptByTheEvictionJob(t *testing.T) {
+	t.Parallel()
+
+	ttl := time.Minute
+	clock := sturdyc.NewTestClock(time.Now())
+	evictionInterval := time.Second
+	c := sturdyc.New[string](100, 1, ttl, 10,
+		sturdyc.WithStaleOnError(time.Hour),
+		sturdyc.WithEvictionInterval(evictionInterval),
+		sturdyc.WithClock(clock),
+	)
+
+	c.Set("1", "value1")
+	clock.Add(ttl + 1)
+	time.Sleep(10 * time.Millisecond)
+	if c.Size() != 1 {
+		t.Errorf("expected the stale record to still be in the cache, got size %d", c.Size())
+	}
+
+	clock.Add(time.Hour)
+	time.Sleep(10 * time.Millisecond)
+	if c.Size() != 0 {
+		t.Errorf("expected the stale record to have been evicted, got size %d", c.Size())
+	}
+}
@@ -0,0 +1,214 @@
+package sturdyc
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// StatsCounters holds the counters that the cache keeps track of.
+type StatsCounters struct {
+	// Hits is the number of lookups that found the key in the cache.
+	Hits int64 `json:"hits"`
+	// Misses is the number of lookups that didn't find the key in the cache.
+	Misses int64 `json:"misses"`
+	// StaleHits is the number of values that were served while they were
+	// being refreshed, or because the data source failed to refresh them.
+	StaleHits int64 `json:"stale_hits"`
+	// MissingRecordHits is the number of lookups that found a key
+	// which has been marked as missing.
+	MissingRecordHits int64 `json:"missing_record_hits"`
+	// Evictions is the number of entries that have been evicted.
+	Evictions int64 `json:"evictions"`
+	// ForcedEvictions is the number of times that the cache reached its
+	// capacity, and had to evict entries in order to write a new one.
+	ForcedEvictions int64 `json:"forced_evictions"`
+	// RefreshSuccesses is the number of background refreshes that succeeded.
+	RefreshSuccesses int64 `json:"refresh_successes"`
+	// RefreshFailures is the number of background refreshes that failed.
+	RefreshFailures int64 `json:"refresh_failures"`
+}
+
+// Sub returns the difference between the counters and an earlier snapshot of them.
+func (s StatsCounters) Sub(other StatsCounters) StatsCounters {
+	return StatsCounters{
+		Hits:              s.Hits - other.Hits,
+		Misses:            s.Misses - other.Misses,
+		StaleHits:         s.StaleHits - other.StaleHits,
+		MissingRecordHits: s.MissingRecordHits - other.MissingRecordHits,
+		Evictions:         s.Evictions - other.Evictions,
+		ForcedEvictions:   s.ForcedEvictions - other.ForcedEvictions,
+		RefreshSuccesses:  s.RefreshSuccesses - other.RefreshSuccesses,
+		RefreshFailures:   s.RefreshFailures - other.RefreshFailures,
+	}
+}
+
+// CacheStats is a snapshot of the statistics of the cache.
+type CacheStats struct {
+	// Name is the name that the cache was given with WithName.
+	Name string
+	// Size is the number of entries in the cache.
+	Size int
+	// Total holds the counters since the cache was created.
+	Total StatsCounters
+	// Window holds the counters of the sliding window. It's
+	// empty unless WithStatsWindow is used.
+	Window WindowStats
+}
+
+// statsSchemaVersion is incremented whenever a field of the JSON
+// representation of the stats is renamed, removed or changes its meaning.
+// New fields can be added without changing the version.
+const statsSchemaVersion = 1
+
+type statsJSON struct {
+	Version int              `json:"version"`
+	Name    string           `json:"name,omitempty"`
+	Size    int              `json:"size"`
+	Total   StatsCounters    `json:"total"`
+	Window  *windowStatsJSON `json:"window,omitempty"`
+}
+
+type windowStatsJSON struct {
+	DurationSeconds    float64 `json:"duration_seconds"`
+	Hits               int64   `json:"hits"`
+	Misses             int64   `json:"misses"`
+	RefreshSuccesses   int64   `json:"refresh_successes"`
+	RefreshFailures    int64   `json:"refresh_failures"`
+	HitRatio           float64 `json:"hit_ratio"`
+	RefreshFailureRate float64 `json:"refresh_failure_rate"`
+}
+
+// MarshalJSON encodes the stats with a stable schema that is meant to be
+// consumed by scripts and dashboards. The schema is versioned by the
+// "version" field, and the window is omitted unless WithStatsWindow is used.
+func (s CacheStats) MarshalJSON() ([]byte, error) {
+	out := statsJSON{
+		Version: statsSchemaVersion,
+		Name:    s.Name,
+		Size:    s.Size,
+		Total:   s.Total,
+	}
+	if s.Window.Duration > 0 {
+		out.Window = &windowStatsJSON{
+			DurationSeconds:    s.Window.Duration.Seconds(),
+			Hits:               s.Window.Hits,
+			Misses:             s.Window.Misses,
+			RefreshSuccesses:   s.Window.RefreshSuccesses,
+			RefreshFailures:    s.Window.RefreshFailures,
+			HitRatio:           s.Window.HitRatio,
+			RefreshFailureRate: s.Window.RefreshFailureRate,
+		}
+	}
+	return json.Marshal(out)
+}
+
+// cacheStats holds the counters that are returned by client.Stats.
+type cacheStats struct {
+	hits              atomic.Int64
+	misses            atomic.Int64
+	staleHits         atomic.Int64
+	missingRecordHits atomic.Int64
+	evictions         atomic.Int64
+	forcedEvictions   atomic.Int64
+	refreshSuccesses  atomic.Int64
+	refreshFailures   atomic.Int64
+
+	// window is nil unless WithStatsWindow is used.
+	window *statsWindow
+}
+
+func newCacheStats() *cacheStats {
+	return &cacheStats{}
+}
+
+func (s *cacheStats) counters() StatsCounters {
+	return StatsCounters{
+		Hits:              s.hits.Load(),
+		Misses:            s.misses.Load(),
+		StaleHits:         s.staleHits.Load(),
+		MissingRecordHits: s.missingRecordHits.Load(),
+		Evictions:         s.evictions.Load(),
+		ForcedEvictions:   s.forcedEvictions.Load(),
+		RefreshSuccesses:  s.refreshSuccesses.Load(),
+		RefreshFailures:   s.refreshFailures.Load(),
+	}
+}
+
+// recordCacheHits updates the counters that are returned by client.Stats.
+func (c *Client[T]) recordCacheHits(cacheHit, missingRecord, refresh bool) {
+	if c.stats.window != nil {
+		c.stats.window.cacheHit(cacheHit)
+	}
+	if !cacheHit {
+		c.stats.misses.Add(1)
+		return
+	}
+	c.stats.hits.Add(1)
+	if missingRecord {
+		c.stats.missingRecordHits.Add(1)
+	}
+	if refresh {
+		c.stats.staleHits.Add(1)
+	}
+}
+
+// Stats returns a snapshot of the statistics of the cache. The counters are
+// kept regardless of whether a MetricsRecorder has been configured. Calling
+// Stats doesn't change any state, which means that several consumers can read
+// the stats independently. Use client.NewStatsTracker to get the counters
+// since the previous read.
+func (c *Client[T]) Stats() CacheStats {
+	stats := CacheStats{
+		Name:  c.name,
+		Size:  c.Size(),
+		Total: c.stats.counters(),
+	}
+	if c.stats.window != nil {
+		stats.Window = c.stats.window.stats()
+	}
+	return stats
+}
+
+// DumpStats writes the stats of the cache to w as a single line of JSON. The
+// schema is described by CacheStats.MarshalJSON.
+func (c *Client[T]) DumpStats(w io.Writer) error {
+	return json.NewEncoder(w).Encode(c.Stats())
+}
+
+// StatsTracker returns the counters of the cache since its previous read.
+// Each consumer, such as a dashboard, should create its own tracker.
+type StatsTracker struct {
+	stats    *cacheStats
+	mu       sync.Mutex
+	previous StatsCounters
+}
+
+// NewStatsTracker returns a StatsTracker that starts counting from now.
+func (c *Client[T]) NewStatsTracker() *StatsTracker {
+	return &StatsTracker{stats: c.stats, previous: c.stats.counters()}
+}
+
+// SinceLastCall returns the counters since the previous call, or since the
+// tracker was created if it's the first call.
+func (t *StatsTracker) SinceLastCall() StatsCounters {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	total := t.stats.counters()
+	sinceLastCall := total.Sub(t.previous)
+	t.previous = total
+	return sinceLastCall
+}
+
+// recordRefresh updates the counters that are returned by client.Stats.
+func (c *Client[T]) recordRefresh(success bool) {
+	if success {
+		c.stats.refreshSuccesses.Add(1)
+	} else {
+		c.stats.refreshFailures.Add(1)
+	}
+	if c.stats.window != nil {
+		c.stats.window.refresh(success)
+	}
+}
@@ -0,0 +1,266 @@
+package sturdyc_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/viccon/sturdyc"
+)
+
+func TestStatsAreCumulativeAndTrackedSinceLastCall(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	clock := sturdyc.NewTestClock(time.Now())
+	refreshDelay := time.Second
+	c := sturdyc.New[string](100, 1, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithMissingRecordStorage(),
+		sturdyc.WithEarlyRefreshes(refreshDelay, refreshDelay, time.Millisecond),
+		sturdyc.WithClock(clock),
+	)
+	tracker := c.NewStatsTracker()
+	events, unsubscribe := c.SubscribeRefreshEvents(10)
+	defer unsubscribe()
+
+	fetchObserver := NewFetchObserver(10)
+	fetchObserver.Response("1")
+	c.GetOrFetch(ctx, "1", fetchObserver.Fetch)
+	<-fetchObserver.FetchCompleted
+
+	clock.Add(refreshDelay + 1)
+	c.GetOrFetch(ctx, "1", fetchObserver.Fetch)
+	assertRefreshEvents(t, events, sturdyc.RefreshScheduled, sturdyc.RefreshStarted, sturdyc.RefreshSucceeded)
+
+	fetchObserver.Err(errors.New("error"))
+	clock.Add(refreshDelay + 1)
+	c.GetOrFetch(ctx, "1", fetchObserver.Fetch)
+	assertRefreshEvents(t, events, sturdyc.RefreshScheduled, sturdyc.RefreshStarted, sturdyc.RefreshFailed)
+
+	c.StoreMissingRecord("2")
+	c.Get("2")
+
+	stats := c.Stats()
+	want := sturdyc.StatsCounters{
+		Hits:              3,
+		Misses:            1,
+		StaleHits:         2,
+		MissingRecordHits: 1,
+		RefreshSuccesses:  1,
+		RefreshFailures:   1,
+	}
+	if stats.Total != want {
+		t.Errorf("expected the total counters to be %+v, got %+v", want, stats.Total)
+	}
+	if got := tracker.SinceLastCall(); got != want {
+		t.Errorf("expected the counters since the last call to be %+v, got %+v", want, got)
+	}
+	if stats.Size != 2 {
+		t.Errorf("expected a size of 2, got %d", stats.Size)
+	}
+
+	// Reading the stats doesn't affect the trackers.
+	c.Get("2")
+	c.Stats()
+	stats = c.Stats()
+	if stats.Total.Hits != 4 {
+		t.Errorf("expected 4 hits in total, got %d", stats.Total.Hits)
+	}
+	if got := tracker.SinceLastCall(); got != (sturdyc.StatsCounters{Hits: 1, MissingRecordHits: 1}) {
+		t.Errorf("expected a single hit since the last call, got %+v", got)
+	}
+
+	// Each tracker keeps its own previous read.
+	otherTracker := c.NewStatsTracker()
+	c.Get("1")
+	if got := otherTracker.SinceLastCall(); got.Hits != 1 {
+		t.Errorf("expected a single hit for the other tracker, got %+v", got)
+	}
+	if got := tracker.SinceLastCall(); got.Hits != 1 {
+		t.Errorf("expected a single hit for the first tracker, got %+v", got)
+	}
+}
+
+func TestStatsCountEvictions(t *testing.T) {
+	t.Parallel()
+
+	clock := sturdyc.NewTestClock(time.Now())
+	c := sturdyc.New[string](5, 1, time.Hour, 100,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithClock(clock),
+	)
+	// The entry with the latest expiration time is kept when the capacity is exceeded.
+	for i := 0; i < 6; i++ {
+		c.Set(randKey(8), "value")
+		clock.Add(time.Second)
+	}
+
+	stats := c.Stats()
+	if stats.Total.ForcedEvictions != 1 {
+		t.Errorf("expected 1 forced eviction, got %d", stats.Total.ForcedEvictions)
+	}
+	if stats.Total.Evictions != 4 {
+		t.Errorf("expected 4 evicted entries, got %d", stats.Total.Evictions)
+	}
+}
+
+func TestStatsWindowOnlyCountsRecentLookups(t *testing.T) {
+	t.Parallel()
+
+	clock := sturdyc.NewTestClock(time.Now())
+	c := sturdyc.New[string](100, 1, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithStatsWindow(time.Minute, 10*time.Second),
+		sturdyc.WithClock(clock),
+	)
+
+	c.Get("1")
+	c.Get("2")
+	clock.Add(30 * time.Second)
+	c.Set("1", "value")
+	c.Get("1")
+
+	window := c.Stats().Window
+	if window.Duration != time.Minute {
+		t.Errorf("expected a window of 1 minute, got %v", window.Duration)
+	}
+	if window.Hits != 1 || window.Misses != 2 {
+		t.Errorf("expected 1 hit and 2 misses, got %d and %d", window.Hits, window.Misses)
+	}
+
+	clock.Add(45 * time.Second)
+	c.Get("1")
+	c.Get("1")
+	c.Get("2")
+
+	stats := c.Stats()
+	if stats.Window.Hits != 3 || stats.Window.Misses != 1 {
+		t.Errorf("expected 3 hits and 1 miss, got %d and %d", stats.Window.Hits, stats.Window.Misses)
+	}
+	if stats.Window.HitRatio != 0.75 {
+		t.Errorf("expected a hit ratio of 0.75, got %v", stats.Window.HitRatio)
+	}
+	if stats.Total.Hits != 3 || stats.Total.Misses != 3 {
+		t.Errorf("expected the total to include every lookup, got %+v", stats.Total)
+	}
+}
+
+func TestStatsWindowCountsConcurrentLookups(t *testing.T) {
+	t.Parallel()
+
+	c := sturdyc.New[string](100, 1, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithStatsWindow(time.Minute, 10*time.Second),
+		sturdyc.WithClock(sturdyc.NewTestClock(time.Now())),
+	)
+	c.Set("1", "value")
+
+	numGoroutines, lookups := 10, 100
+	var wg sync.WaitGroup
+	wg.Add(numGoroutines)
+	for i := 0; i < numGoroutines; i++ {
+		go func() {
+			defer wg.Done()
+			for j := 0; j < lookups; j++ {
+				c.Get("1")
+			}
+		}()
+	}
+	wg.Wait()
+
+	if hits := c.Stats().Window.Hits; hits != int64(numGoroutines*lookups) {
+		t.Errorf("expected %d hits, got %d", numGoroutines*lookups, hits)
+	}
+}
+
+func TestStatsWindowTracksTheRefreshFailureRate(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	clock := sturdyc.NewTestClock(time.Now())
+	refreshDelay := time.Second
+	c := sturdyc.New[string](100, 1, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithEarlyRefreshes(refreshDelay, refreshDelay, time.Millisecond),
+		sturdyc.WithStatsWindow(time.Minute, 10*time.Second),
+		sturdyc.WithClock(clock),
+	)
+	events, unsubscribe := c.SubscribeRefreshEvents(10)
+	defer unsubscribe()
+
+	fetchObserver := NewFetchObserver(10)
+	fetchObserver.Response("1")
+	c.GetOrFetch(ctx, "1", fetchObserver.Fetch)
+	<-fetchObserver.FetchCompleted
+
+	fetchObserver.Err(errors.New("error"))
+	clock.Add(refreshDelay + 1)
+	c.GetOrFetch(ctx, "1", fetchObserver.Fetch)
+	assertRefreshEvents(t, events, sturdyc.RefreshScheduled, sturdyc.RefreshStarted, sturdyc.RefreshFailed)
+
+	if rate := c.Stats().Window.RefreshFailureRate; rate != 1 {
+		t.Errorf("expected a refresh failure rate of 1, got %v", rate)
+	}
+
+	clock.Add(time.Minute)
+	if rate := c.Stats().Window.RefreshFailureRate; rate != 0 {
+		t.Errorf("expected the failure to have left the window, got a rate of %v", rate)
+	}
+}
+
+func TestStatsAreDumpedAsJSON(t *testing.T) {
+	t.Parallel()
+
+	c := sturdyc.New[string](100, 1, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithName("user-cache"),
+	)
+	c.Set("1", "value")
+	c.Get("1")
+	c.Get("2")
+
+	var buf bytes.Buffer
+	if err := c.DumpStats(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{"version":1,"name":"user-cache","size":1,` +
+		`"total":{"hits":1,"misses":1,"stale_hits":0,"missing_record_hits":0,"evictions":0,"forced_evictions":0,"refresh_successes":0,"refresh_failures":0}}` + "\n"
+	if buf.String() != want {
+		t.Errorf("unexpected JSON:\n got: %s\nwant: %s", buf.String(), want)
+	}
+}
+
+func TestStatsWindowIsIncludedInTheJSON(t *testing.T) {
+	t.Parallel()
+
+	c := sturdyc.New[string](100, 1, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithStatsWindow(time.Minute, 10*time.Second),
+	)
+	c.Set("1", "value")
+	c.Get("1")
+
+	data, err := json.Marshal(c.Stats())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stats struct {
+		Window struct {
+			DurationSeconds float64 `json:"duration_seconds"`
+			Hits            int64   `json:"hits"`
+			HitRatio        float64 `json:"hit_ratio"`
+		} `json:"window"`
+	}
+	if err := json.Unmarshal(data, &stats); err != nil {
+		t.Fatal(err)
+	}
+	if stats.Window.DurationSeconds != 60 || stats.Window.Hits != 1 || stats.Window.HitRatio != 1 {
+		t.Errorf("unexpected window: %s", data)
+	}
+}
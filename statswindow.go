@@ -0,0 +1,119 @@
+package sturdyc
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// WindowStats holds the counters of the sliding window that
+// is configured with WithStatsWindow.
+type WindowStats struct {
+	// Duration is the length of the window.
+	Duration time.Duration
+	// Hits is the number of lookups that found the key in the cache.
+	Hits int64
+	// Misses is the number of lookups that didn't find the key in the cache.
+	Misses int64
+	// RefreshSuccesses is the number of background refreshes that succeeded.
+	RefreshSuccesses int64
+	// RefreshFailures is the number of background refreshes that failed.
+	RefreshFailures int64
+	// HitRatio is the share of lookups that were hits. It's 0 without lookups.
+	HitRatio float64
+	// RefreshFailureRate is the share of background refreshes
+	// that failed. It's 0 without refreshes.
+	RefreshFailureRate float64
+}
+
+// statsBucket holds the counters of the time that is identified by its index.
+// The counters are atomic so that the lookups don't contend over a lock, and
+// the mutex is only held while the bucket is reused for a new index.
+type statsBucket struct {
+	mu               sync.Mutex
+	index            atomic.Int64
+	hits             atomic.Int64
+	misses           atomic.Int64
+	refreshSuccesses atomic.Int64
+	refreshFailures  atomic.Int64
+}
+
+// statsWindow keeps the counters of the last window in buckets, which
+// are reused once the time that they cover has passed.
+type statsWindow struct {
+	clock          Clock
+	bucketDuration time.Duration
+	buckets        []statsBucket
+}
+
+func newStatsWindow(window, bucketDuration time.Duration, clock Clock) *statsWindow {
+	numBuckets := int((window + bucketDuration - 1) / bucketDuration)
+	return &statsWindow{
+		clock:          clock,
+		bucketDuration: bucketDuration,
+		buckets:        make([]statsBucket, numBuckets),
+	}
+}
+
+// bucket returns the bucket of the current time, and resets it if it still
+// holds the counters of an earlier window. The buckets only ever move forward,
+// which keeps a goroutine that read the time before the bucket was reused from
+// resetting it back to an older index.
+func (w *statsWindow) bucket() *statsBucket {
+	index := w.clock.Now().UnixNano() / int64(w.bucketDuration)
+	b := &w.buckets[index%int64(len(w.buckets))]
+	if b.index.Load() < index {
+		b.mu.Lock()
+		if b.index.Load() < index {
+			b.hits.Store(0)
+			b.misses.Store(0)
+			b.refreshSuccesses.Store(0)
+			b.refreshFailures.Store(0)
+			b.index.Store(index)
+		}
+		b.mu.Unlock()
+	}
+	return b
+}
+
+func (w *statsWindow) cacheHit(cacheHit bool) {
+	b := w.bucket()
+	if cacheHit {
+		b.hits.Add(1)
+		return
+	}
+	b.misses.Add(1)
+}
+
+func (w *statsWindow) refresh(success bool) {
+	b := w.bucket()
+	if success {
+		b.refreshSuccesses.Add(1)
+		return
+	}
+	b.refreshFailures.Add(1)
+}
+
+func (w *statsWindow) stats() WindowStats {
+	numBuckets := int64(len(w.buckets))
+	current := w.clock.Now().UnixNano() / int64(w.bucketDuration)
+	stats := WindowStats{Duration: time.Duration(numBuckets) * w.bucketDuration}
+	for i := range w.buckets {
+		b := &w.buckets[i]
+		if index := b.index.Load(); index <= current-numBuckets || index > current {
+			continue
+		}
+		stats.Hits += b.hits.Load()
+		stats.Misses += b.misses.Load()
+		stats.RefreshSuccesses += b.refreshSuccesses.Load()
+		stats.RefreshFailures += b.refreshFailures.Load()
+	}
+
+	if lookups := stats.Hits + stats.Misses; lookups > 0 {
+		stats.HitRatio = float64(stats.Hits) / float64(lookups)
+	}
+	if refreshes := stats.RefreshSuccesses + stats.RefreshFailures; refreshes > 0 {
+		stats.RefreshFailureRate = float64(stats.RefreshFailures) / float64(refreshes)
+	}
+	return stats
+}
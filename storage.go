@@ -0,0 +1,175 @@
+package sturdyc
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// decorateDistributedStorage wraps the distributed storage with
+// the functionality that the cache has been configured with.
+func (c *Config) decorateDistributedStorage() {
+	if c.distributedStorage == nil {
+		return
+	}
+
+	if scanner, ok := c.distributedScanner(); ok {
+		c.distributedScannerStorage = scanner
+	}
+
+	if tiered, ok := c.distributedStorage.(*tieredStorage); ok {
+		tiered.log = c.logger(LogDistributed)
+	}
+
+	if c.latencyRecorder != nil {
+		c.distributedStorage = &timedStorage{c.distributedStorage, c.clock, c.latencyRecorder}
+	}
+
+	if c.distributedCompressor != nil {
+		c.distributedStorage = &compressedStorage{
+			DistributedStorageWithDeletions: c.distributedStorage,
+			compressor:                      c.distributedCompressor,
+			threshold:                       c.distributedCompressionThreshold,
+			log:                             c.logger(LogDistributed),
+		}
+	}
+
+	if c.distributedKeyPrefix != "" {
+		c.distributedStorage = &prefixedStorage{c.distributedStorage, c.distributedKeyPrefix}
+	}
+
+	if c.distributedBreakers != nil {
+		c.distributedStorage = &guardedStorage{
+			DistributedStorageWithDeletions: c.distributedStorage,
+			breakers:                        c.distributedBreakers,
+			timeout:                         c.distributedStorageTimeout,
+			clock:                           c.clock,
+			log:                             c.logger(LogDistributed),
+			metricsRecorder:                 c.metricsRecorder,
+		}
+	}
+
+	if c.distributedWriteWindow > 0 {
+		coalescer := newCoalescedStorage(c.distributedStorage, c.distributedWriteMaxBatchSize, c.logger(LogDistributed))
+		coalescer.run(c.clock, c.distributedWriteWindow)
+		c.distributedStorage = coalescer
+	}
+
+	if c.writeBehindWorkers > 0 {
+		c.writeBehind = newWriteBehindStorage(c.distributedStorage, c)
+		c.writeBehind.start()
+		c.distributedStorage = c.writeBehind
+	}
+}
+
+// timedStorage reports the duration of every call to the distributed storage.
+type timedStorage struct {
+	DistributedStorageWithDeletions
+	clock    Clock
+	recorder LatencyMetricsRecorder
+}
+
+func (t *timedStorage) observe(operation DistributedOperation, start time.Time) {
+	t.recorder.ObserveDistributedDuration(operation, t.clock.Since(start))
+}
+
+func (t *timedStorage) Get(ctx context.Context, key string) ([]byte, bool) {
+	defer t.observe(DistributedGet, t.clock.Now())
+	return t.DistributedStorageWithDeletions.Get(ctx, key)
+}
+
+func (t *timedStorage) Set(ctx context.Context, key string, value []byte) {
+	defer t.observe(DistributedSet, t.clock.Now())
+	t.DistributedStorageWithDeletions.Set(ctx, key, value)
+}
+
+func (t *timedStorage) GetBatch(ctx context.Context, keys []string) map[string][]byte {
+	defer t.observe(DistributedGetBatch, t.clock.Now())
+	return t.DistributedStorageWithDeletions.GetBatch(ctx, keys)
+}
+
+func (t *timedStorage) SetBatch(ctx context.Context, records map[string][]byte) {
+	defer t.observe(DistributedSetBatch, t.clock.Now())
+	t.DistributedStorageWithDeletions.SetBatch(ctx, records)
+}
+
+func (t *timedStorage) Delete(ctx context.Context, key string) {
+	defer t.observe(DistributedDelete, t.clock.Now())
+	t.DistributedStorageWithDeletions.Delete(ctx, key)
+}
+
+func (t *timedStorage) DeleteBatch(ctx context.Context, keys []string) {
+	defer t.observe(DistributedDeleteBatch, t.clock.Now())
+	t.DistributedStorageWithDeletions.DeleteBatch(ctx, keys)
+}
+
+func (t *timedStorage) SetBatchErr(ctx context.Context, records map[string][]byte) error {
+	defer t.observe(DistributedSetBatch, t.clock.Now())
+	return setBatchErr(ctx, t.DistributedStorageWithDeletions, records)
+}
+
+func (t *timedStorage) DeleteBatchErr(ctx context.Context, keys []string) error {
+	defer t.observe(DistributedDeleteBatch, t.clock.Now())
+	return deleteBatchErr(ctx, t.DistributedStorageWithDeletions, keys)
+}
+
+// prefixedStorage namespaces every key that is passed to the distributed storage.
+type prefixedStorage struct {
+	DistributedStorageWithDeletions
+	prefix string
+}
+
+func (p *prefixedStorage) prefixKeys(keys []string) []string {
+	prefixedKeys := make([]string, 0, len(keys))
+	for _, key := range keys {
+		prefixedKeys = append(prefixedKeys, p.prefix+key)
+	}
+	return prefixedKeys
+}
+
+func (p *prefixedStorage) Get(ctx context.Context, key string) ([]byte, bool) {
+	return p.DistributedStorageWithDeletions.Get(ctx, p.prefix+key)
+}
+
+func (p *prefixedStorage) Set(ctx context.Context, key string, value []byte) {
+	p.DistributedStorageWithDeletions.Set(ctx, p.prefix+key, value)
+}
+
+func (p *prefixedStorage) Delete(ctx context.Context, key string) {
+	p.DistributedStorageWithDeletions.Delete(ctx, p.prefix+key)
+}
+
+func (p *prefixedStorage) GetBatch(ctx context.Context, keys []string) map[string][]byte {
+	records := p.DistributedStorageWithDeletions.GetBatch(ctx, p.prefixKeys(keys))
+	unprefixedRecords := make(map[string][]byte, len(records))
+	for key, value := range records {
+		if unprefixedKey, ok := strings.CutPrefix(key, p.prefix); ok {
+			unprefixedRecords[unprefixedKey] = value
+		}
+	}
+	return unprefixedRecords
+}
+
+func (p *prefixedStorage) prefixRecords(records map[string][]byte) map[string][]byte {
+	prefixedRecords := make(map[string][]byte, len(records))
+	for key, value := range records {
+		prefixedRecords[p.prefix+key] = value
+	}
+	return prefixedRecords
+}
+
+func (p *prefixedStorage) SetBatch(ctx context.Context, records map[string][]byte) {
+	p.DistributedStorageWithDeletions.SetBatch(ctx, p.prefixRecords(records))
+}
+
+func (p *prefixedStorage) SetBatchErr(ctx context.Context, records map[string][]byte) error {
+	return setBatchErr(ctx, p.DistributedStorageWithDeletions, p.prefixRecords(records))
+}
+
+func (p *prefixedStorage) DeleteBatch(ctx context.Context, keys []string) {
+	p.DistributedStorageWithDeletions.DeleteBatch(ctx, p.prefixKeys(keys))
+}
+
+func (p *prefixedStorage) DeleteBatchErr(ctx context.Context, keys []string) error {
+	return deleteBatchErr(ctx, p.DistributedStorageWithDeletions, p.prefixKeys(keys))
+}
@@ -0,0 +1,137 @@
+package sturdyc
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// guardedStorage bypasses the distributed storage while it's unhealthy. An
+// operation is considered to have failed if it exceeds the timeout, or panics.
+// While the circuit breaker is open, reads are treated as misses and writes
+// are dropped, which leaves the cache running on the in-memory layer alone.
+type guardedStorage struct {
+	DistributedStorageWithDeletions
+	breakers        *circuitBreakers
+	timeout         time.Duration
+	clock           Clock
+	log             Logger
+	metricsRecorder DistributedMetricsRecorder
+}
+
+// guard performs the operation if the circuit breaker allows it, and
+// reports whether it was performed without exceeding the timeout.
+func (s *guardedStorage) guard(ctx context.Context, operation func(ctx context.Context)) bool {
+	if !s.breakers.allow("", s.clock.Now()) {
+		return false
+	}
+
+	operationCtx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	// The outcome is recorded in a deferred function so that
+	// a panic doesn't leave the circuit breaker half-open.
+	success := false
+	defer func() {
+		opened, recovered := s.breakers.record("", success, s.clock.Now())
+		if opened {
+			s.log.Warn("sturdyc: distributed storage circuit breaker opened", "open_duration", s.breakers.openDuration)
+			s.reportDistributedStorageUnavailable()
+		}
+		if recovered {
+			s.log.Warn("sturdyc: distributed storage circuit breaker closed")
+			s.reportDistributedStorageRecovered()
+		}
+	}()
+
+	operation(operationCtx)
+	// Calls that were cancelled by the caller says nothing about the health of the storage.
+	success = operationCtx.Err() == nil || ctx.Err() != nil
+	return success
+}
+
+// guardErr is the same as guard, but it returns the error of the operation,
+// or an error that describes why the operation wasn't performed successfully.
+func (s *guardedStorage) guardErr(ctx context.Context, operation func(ctx context.Context) error) error {
+	var err error
+	performed := false
+	ok := s.guard(ctx, func(ctx context.Context) {
+		performed = true
+		err = operation(ctx)
+	})
+	switch {
+	case !performed:
+		return ErrCircuitOpen
+	case !ok:
+		return fmt.Errorf("%w: %w", ErrDistributedWriteFailed, context.DeadlineExceeded)
+	}
+	return err
+}
+
+func (s *guardedStorage) reportDistributedStorageUnavailable() {
+	if recorder, ok := s.metricsRecorder.(StorageHealthMetricsRecorder); ok {
+		recorder.DistributedStorageUnavailable()
+	}
+}
+
+func (s *guardedStorage) reportDistributedStorageRecovered() {
+	if recorder, ok := s.metricsRecorder.(StorageHealthMetricsRecorder); ok {
+		recorder.DistributedStorageRecovered()
+	}
+}
+
+func (s *guardedStorage) Get(ctx context.Context, key string) (value []byte, ok bool) {
+	s.guard(ctx, func(ctx context.Context) {
+		value, ok = s.DistributedStorageWithDeletions.Get(ctx, key)
+	})
+	return value, ok
+}
+
+func (s *guardedStorage) Set(ctx context.Context, key string, value []byte) {
+	s.guard(ctx, func(ctx context.Context) {
+		s.DistributedStorageWithDeletions.Set(ctx, key, value)
+	})
+}
+
+func (s *guardedStorage) Delete(ctx context.Context, key string) {
+	s.guard(ctx, func(ctx context.Context) {
+		s.DistributedStorageWithDeletions.Delete(ctx, key)
+	})
+}
+
+func (s *guardedStorage) GetBatch(ctx context.Context, keys []string) map[string][]byte {
+	records := map[string][]byte{}
+	s.guard(ctx, func(ctx context.Context) {
+		if batch := s.DistributedStorageWithDeletions.GetBatch(ctx, keys); batch != nil {
+			records = batch
+		}
+	})
+	return records
+}
+
+func (s *guardedStorage) SetBatch(ctx context.Context, records map[string][]byte) {
+	s.guard(ctx, func(ctx context.Context) {
+		s.DistributedStorageWithDeletions.SetBatch(ctx, records)
+	})
+}
+
+func (s *guardedStorage) DeleteBatch(ctx context.Context, keys []string) {
+	s.guard(ctx, func(ctx context.Context) {
+		s.DistributedStorageWithDeletions.DeleteBatch(ctx, keys)
+	})
+}
+
+// SetBatchErr returns ErrCircuitOpen if the write was dropped because the
+// circuit breaker is open, and ErrDistributedWriteFailed if it timed out.
+func (s *guardedStorage) SetBatchErr(ctx context.Context, records map[string][]byte) error {
+	return s.guardErr(ctx, func(ctx context.Context) error {
+		return setBatchErr(ctx, s.DistributedStorageWithDeletions, records)
+	})
+}
+
+// DeleteBatchErr returns the same errors as SetBatchErr.
+func (s *guardedStorage) DeleteBatchErr(ctx context.Context, keys []string) error {
+	return s.guardErr(ctx, func(ctx context.Context) error {
+		return deleteBatchErr(ctx, s.DistributedStorageWithDeletions, keys)
+	})
+}
@@ -0,0 +1,117 @@
+package sturdyc_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/viccon/sturdyc"
+)
+
+// unavailableStorage blocks every operation until the context
+// is done for as long as the storage is marked as down.
+type unavailableStorage struct {
+	*mockStorage
+	down  atomic.Bool
+	calls atomic.Int32
+}
+
+func (s *unavailableStorage) wait(ctx context.Context) {
+	s.calls.Add(1)
+	if s.down.Load() {
+		<-ctx.Done()
+	}
+}
+
+func (s *unavailableStorage) Get(ctx context.Context, key string) ([]byte, bool) {
+	s.wait(ctx)
+	return s.mockStorage.Get(ctx, key)
+}
+
+func (s *unavailableStorage) Set(ctx context.Context, key string, value []byte) {
+	s.wait(ctx)
+	s.mockStorage.Set(ctx, key, value)
+}
+
+func (s *unavailableStorage) GetBatch(ctx context.Context, keys []string) map[string][]byte {
+	s.wait(ctx)
+	return s.mockStorage.GetBatch(ctx, keys)
+}
+
+func (s *unavailableStorage) SetBatch(ctx context.Context, records map[string][]byte) {
+	s.wait(ctx)
+	s.mockStorage.SetBatch(ctx, records)
+}
+
+type storageHealthRecorder struct {
+	*TestMetricsRecorder
+	unavailable atomic.Int32
+	recovered   atomic.Int32
+}
+
+func (r *storageHealthRecorder) DistributedCacheHit()           {}
+func (r *storageHealthRecorder) DistributedCacheMiss()          {}
+func (r *storageHealthRecorder) DistributedRefresh()            {}
+func (r *storageHealthRecorder) DistributedMissingRecord()      {}
+func (r *storageHealthRecorder) DistributedFallback()           {}
+func (r *storageHealthRecorder) DistributedStorageUnavailable() { r.unavailable.Add(1) }
+func (r *storageHealthRecorder) DistributedStorageRecovered()   { r.recovered.Add(1) }
+
+func TestDistributedStorageCircuitBreaker(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	clock := sturdyc.NewTestClock(time.Now())
+	storage := &unavailableStorage{mockStorage: &mockStorage{}}
+	storage.down.Store(true)
+	metrics := &storageHealthRecorder{TestMetricsRecorder: newTestMetricsRecorder(10)}
+	c := sturdyc.New[string](1000, 10, time.Hour, 30,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithClock(clock),
+		sturdyc.WithDistributedStorage(storage),
+		sturdyc.WithDistributedMetrics(metrics),
+		sturdyc.WithDistributedStorageCircuitBreaker(2, time.Minute, 10*time.Millisecond),
+	)
+	fetchObserver := NewFetchObserver(3)
+
+	// The read times out, and so does the write that happens after the fetch.
+	fetchObserver.Response("1")
+	res, err := sturdyc.GetOrFetch(ctx, c, "1", fetchObserver.Fetch)
+	<-fetchObserver.FetchCompleted
+	if err != nil || res != "value1" {
+		t.Fatalf("expected value1, got %q %v", res, err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if metrics.unavailable.Load() != 1 {
+		t.Fatalf("expected the circuit breaker to have opened, got %d", metrics.unavailable.Load())
+	}
+
+	// While the circuit breaker is open, the storage shouldn't be called at all.
+	callsBefore := storage.calls.Load()
+	fetchObserver.Response("2")
+	res, err = sturdyc.GetOrFetch(ctx, c, "2", fetchObserver.Fetch)
+	<-fetchObserver.FetchCompleted
+	if err != nil || res != "value2" {
+		t.Fatalf("expected value2, got %q %v", res, err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if calls := storage.calls.Load(); calls != callsBefore {
+		t.Errorf("expected the storage to be bypassed, got %d new calls", calls-callsBefore)
+	}
+
+	// Once the open duration has passed, a probe should close the circuit breaker.
+	storage.down.Store(false)
+	clock.Add(time.Minute)
+	fetchObserver.Response("3")
+	res, err = sturdyc.GetOrFetch(ctx, c, "3", fetchObserver.Fetch)
+	<-fetchObserver.FetchCompleted
+	if err != nil || res != "value3" {
+		t.Fatalf("expected value3, got %q %v", res, err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if metrics.recovered.Load() != 1 {
+		t.Errorf("expected the circuit breaker to have closed, got %d", metrics.recovered.Load())
+	}
+	storage.assertRecord(t, "3")
+}
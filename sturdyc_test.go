@@ -8,8 +8,10 @@ import (
 	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/viccon/sturdyc"
 )
 
 var letters = []rune("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ")
@@ -31,14 +33,21 @@ type TestMetricsRecorder struct {
 	evictions       int
 	forcedEvictions int
 	evictedEntries  int
+	coalesced       int
+	promoted        int
 	shards          map[int]int
 	batchSizes      []int
+	bufferSizes     map[string]int
+	bufferFlushes   map[sturdyc.BufferFlushReason]int
+	timeInBuffer    []time.Duration
 }
 
 func newTestMetricsRecorder(numShards int) *TestMetricsRecorder {
 	return &TestMetricsRecorder{
-		shards:     make(map[int]int, numShards),
-		batchSizes: make([]int, 0),
+		shards:        make(map[int]int, numShards),
+		batchSizes:    make([]int, 0),
+		bufferSizes:   make(map[string]int),
+		bufferFlushes: make(map[sturdyc.BufferFlushReason]int),
 	}
 }
 
@@ -74,6 +83,19 @@ func (r *TestMetricsRecorder) CacheBatchRefreshSize(n int) {
 	r.batchSizes = append(r.batchSizes, n)
 }
 
+func (r *TestMetricsRecorder) RefreshBufferSize(permutation string, size int) {
+	r.Lock()
+	defer r.Unlock()
+	r.bufferSizes[permutation] = size
+}
+
+func (r *TestMetricsRecorder) RefreshBufferFlushed(reason sturdyc.BufferFlushReason, timeInBuffer time.Duration) {
+	r.Lock()
+	defer r.Unlock()
+	r.bufferFlushes[reason]++
+	r.timeInBuffer = append(r.timeInBuffer, timeInBuffer)
+}
+
 func (r *TestMetricsRecorder) Eviction() {
 	r.Lock()
 	defer r.Unlock()
@@ -92,6 +114,18 @@ func (r *TestMetricsRecorder) EntriesEvicted(n int) {
 	r.evictedEntries += n
 }
 
+func (r *TestMetricsRecorder) FetchCoalesced() {
+	r.Lock()
+	defer r.Unlock()
+	r.coalesced++
+}
+
+func (r *TestMetricsRecorder) MissingRecordPromoted() {
+	r.Lock()
+	defer r.Unlock()
+	r.promoted++
+}
+
 func (r *TestMetricsRecorder) ShardIndex(index int) {
 	r.Lock()
 	defer r.Unlock()
@@ -0,0 +1,174 @@
+package sturdyc
+
+import (
+	"context"
+)
+
+// TierWritePolicy determines how the records are written to a tier.
+type TierWritePolicy int
+
+const (
+	// TierWriteThrough writes the records to the tier before the write returns.
+	TierWriteThrough TierWritePolicy = iota
+	// TierWriteAsync writes the records to the tier in a separate goroutine.
+	TierWriteAsync
+	// TierReadOnly never writes any records to the tier.
+	TierReadOnly
+)
+
+// Tier is one of the layers that sits between the in-memory cache and the
+// underlying data source when WithStorageTiers is used.
+type Tier struct {
+	Storage     DistributedStorageWithDeletions
+	WritePolicy TierWritePolicy
+	// Promote makes the records that are found in one of the tiers below this
+	// one get written to this tier as well. Promotions to read-only tiers are
+	// performed synchronously.
+	Promote bool
+}
+
+// tieredStorage reads from the tiers in order, and writes to each
+// one of them according to its write policy.
+type tieredStorage struct {
+	tiers []Tier
+	log   Logger
+}
+
+// goSafe runs the fn in a separate goroutine, and recovers from any panics.
+func (t *tieredStorage) goSafe(fn func()) {
+	go func() {
+		defer func() {
+			if err := recover(); err != nil {
+				t.log.Error("sturdyc: panic recovered", "panic", err)
+			}
+		}()
+		fn()
+	}()
+}
+
+// write performs the write against every tier according to its write policy.
+func (t *tieredStorage) write(tiers []Tier, promotion bool, write func(storage DistributedStorageWithDeletions)) {
+	for _, tier := range tiers {
+		if (promotion && !tier.Promote) || (!promotion && tier.WritePolicy == TierReadOnly) {
+			continue
+		}
+
+		if tier.WritePolicy == TierWriteAsync {
+			storage := tier.Storage
+			t.goSafe(func() {
+				write(storage)
+			})
+			continue
+		}
+		write(tier.Storage)
+	}
+}
+
+func (t *tieredStorage) Get(ctx context.Context, key string) ([]byte, bool) {
+	for i, tier := range t.tiers {
+		value, ok := tier.Storage.Get(ctx, key)
+		if !ok {
+			continue
+		}
+		t.write(t.tiers[:i], true, func(storage DistributedStorageWithDeletions) {
+			storage.Set(context.Background(), key, value)
+		})
+		return value, true
+	}
+	return nil, false
+}
+
+func (t *tieredStorage) GetBatch(ctx context.Context, keys []string) map[string][]byte {
+	records := make(map[string][]byte, len(keys))
+	remainingKeys := keys
+	for i, tier := range t.tiers {
+		if len(remainingKeys) == 0 {
+			break
+		}
+
+		found := tier.Storage.GetBatch(ctx, remainingKeys)
+		promotedRecords := make(map[string][]byte, len(found))
+		missingKeys := make([]string, 0, len(remainingKeys))
+		for _, key := range remainingKeys {
+			value, ok := found[key]
+			if !ok {
+				missingKeys = append(missingKeys, key)
+				continue
+			}
+			records[key] = value
+			promotedRecords[key] = value
+		}
+		remainingKeys = missingKeys
+
+		if len(promotedRecords) > 0 {
+			t.write(t.tiers[:i], true, func(storage DistributedStorageWithDeletions) {
+				storage.SetBatch(context.Background(), promotedRecords)
+			})
+		}
+	}
+	return records
+}
+
+func (t *tieredStorage) Set(ctx context.Context, key string, value []byte) {
+	t.write(t.tiers, false, func(storage DistributedStorageWithDeletions) {
+		storage.Set(ctx, key, value)
+	})
+}
+
+func (t *tieredStorage) SetBatch(ctx context.Context, records map[string][]byte) {
+	t.write(t.tiers, false, func(storage DistributedStorageWithDeletions) {
+		storage.SetBatch(ctx, records)
+	})
+}
+
+// Delete removes the key from every tier, including the read-only ones, as
+// they would otherwise serve the deleted record back to the tiers above.
+func (t *tieredStorage) Delete(ctx context.Context, key string) {
+	for _, tier := range t.tiers {
+		tier.Storage.Delete(ctx, key)
+	}
+}
+
+func (t *tieredStorage) DeleteBatch(ctx context.Context, keys []string) {
+	for _, tier := range t.tiers {
+		tier.Storage.DeleteBatch(ctx, keys)
+	}
+}
+
+// clientStorage allows another client to be used as one of the tiers.
+type clientStorage struct {
+	client *Client[[]byte]
+}
+
+// NewClientStorage returns a storage that reads from, and writes to, the
+// in-memory cache of the client. This allows a client to be used as one of
+// the tiers of WithStorageTiers, e.g. a larger cache with a longer TTL.
+func NewClientStorage(client *Client[[]byte]) DistributedStorageWithDeletions {
+	return &clientStorage{client: client}
+}
+
+func (s *clientStorage) Get(_ context.Context, key string) ([]byte, bool) {
+	return s.client.Get(key)
+}
+
+func (s *clientStorage) Set(ctx context.Context, key string, value []byte) {
+	s.client.SetCtx(ctx, key, value)
+}
+
+func (s *clientStorage) GetBatch(_ context.Context, keys []string) map[string][]byte {
+	return s.client.GetMany(keys)
+}
+
+func (s *clientStorage) SetBatch(_ context.Context, records map[string][]byte) {
+	s.client.SetMany(records)
+}
+
+func (s *clientStorage) Delete(_ context.Context, key string) {
+	s.client.Delete(key)
+}
+
+func (s *clientStorage) DeleteBatch(_ context.Context, keys []string) {
+	for _, key := range keys {
+		s.client.Delete(key)
+	}
+}
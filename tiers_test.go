@@ -0,0 +1,73 @@
+package sturdyc_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/viccon/sturdyc"
+)
+
+func TestStorageTiersPromoteRecordsOnHits(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	secondTier := sturdyc.New[[]byte](1000, 10, time.Hour, 30, sturdyc.WithNoContinuousEvictions())
+	thirdTier := &mockStorage{}
+	c := sturdyc.New[string](1000, 10, time.Minute, 30,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithStorageTiers(
+			sturdyc.Tier{Storage: sturdyc.NewClientStorage(secondTier), WritePolicy: sturdyc.TierWriteThrough, Promote: true},
+			sturdyc.Tier{Storage: thirdTier, WritePolicy: sturdyc.TierWriteThrough},
+		),
+	)
+	fetchObserver := NewFetchObserver(1)
+
+	fetchObserver.Response("1")
+	sturdyc.GetOrFetch(ctx, c, "1", fetchObserver.Fetch)
+	<-fetchObserver.FetchCompleted
+
+	// The record is written to every tier asynchronously.
+	time.Sleep(50 * time.Millisecond)
+	if _, ok := secondTier.Get("1"); !ok {
+		t.Fatal("expected the record to have been written to the second tier")
+	}
+	thirdTier.assertRecord(t, "1")
+
+	// A hit in the third tier should promote the record to the second tier.
+	c.Delete("1")
+	secondTier.Delete("1")
+	res, err := sturdyc.GetOrFetch(ctx, c, "1", fetchObserver.Fetch)
+	if err != nil || res != "value1" {
+		t.Fatalf("expected value1, got %q %v", res, err)
+	}
+	if _, ok := secondTier.Get("1"); !ok {
+		t.Error("expected the record to have been promoted to the second tier")
+	}
+	fetchObserver.AssertFetchCount(t, 1)
+}
+
+func TestStorageTiersRespectTheWritePolicies(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	readOnlyTier, asyncTier := &mockStorage{}, &mockStorage{}
+	c := sturdyc.New[string](1000, 10, time.Minute, 30,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithStorageTiers(
+			sturdyc.Tier{Storage: readOnlyTier, WritePolicy: sturdyc.TierReadOnly},
+			sturdyc.Tier{Storage: asyncTier, WritePolicy: sturdyc.TierWriteAsync},
+		),
+	)
+	fetchObserver := NewFetchObserver(1)
+
+	ids := []string{"1", "2"}
+	keyFn := c.BatchKeyFn("item")
+	fetchObserver.BatchResponse(ids)
+	sturdyc.GetOrFetchBatch(ctx, c, ids, keyFn, fetchObserver.FetchBatch)
+	<-fetchObserver.FetchCompleted
+
+	time.Sleep(50 * time.Millisecond)
+	readOnlyTier.assertSetCount(t, 0)
+	asyncTier.assertRecords(t, ids, keyFn)
+}
@@ -0,0 +1,62 @@
+package sturdyc
+
+import (
+	"context"
+	"fmt"
+)
+
+// callWithTimeout calls the fn with a context that is cancelled once the fetch
+// timeout has passed. The caller is released when that happens, even if the fn
+// doesn't respect the cancellation of the context.
+func callWithTimeout[V, T any](ctx context.Context, c *Client[T], fn func(ctx context.Context) (V, error)) (V, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan callResult[V], 1)
+	go func() {
+		defer func() {
+			if err := recover(); err != nil {
+				results <- callResult[V]{err: fmt.Errorf("sturdyc: panic recovered: %v", err)}
+			}
+		}()
+		val, err := fn(ctx)
+		results <- callResult[V]{val: val, err: err}
+	}()
+
+	timer, stop := c.clock.NewTimer(c.fetchTimeout)
+	defer stop()
+
+	var zero V
+	select {
+	case res := <-results:
+		return res.val, res.err
+	case <-timer:
+		return zero, fmt.Errorf("%w after %v", ErrFetchTimeout, c.fetchTimeout)
+	case <-ctx.Done():
+		return zero, ctx.Err()
+	}
+}
+
+// timeoutFetch wraps the fetchFn so that every call to it is subject to the fetch timeout.
+func timeoutFetch[V, T any](c *Client[T], fetchFn FetchFn[V]) FetchFn[V] {
+	if c.fetchTimeout == 0 {
+		return fetchFn
+	}
+
+	return func(ctx context.Context) (V, error) {
+		return callWithTimeout(ctx, c, fetchFn)
+	}
+}
+
+// timeoutBatchFetch wraps the fetchFn so that every call to it is subject to the fetch timeout.
+func timeoutBatchFetch[V, T any](c *Client[T], fetchFn BatchFetchFn[V]) BatchFetchFn[V] {
+	if c.fetchTimeout == 0 {
+		return fetchFn
+	}
+
+	return func(ctx context.Context, ids []string) (map[string]V, error) {
+		return callWithTimeout(ctx, c, func(ctx context.Context) (map[string]V, error) {
+			return fetchFn(ctx, ids)
+		})
+	}
+}
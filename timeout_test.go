@@ -0,0 +1,83 @@
+package sturdyc_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/viccon/sturdyc"
+)
+
+// advanceUntil keeps moving the clock forward until the
+// channel receives a value, and then returns that value.
+func advanceUntil[V any](clock *sturdyc.TestClock, ch <-chan V) V {
+	for {
+		select {
+		case v := <-ch:
+			return v
+		case <-time.After(time.Millisecond):
+			clock.Add(time.Second)
+		}
+	}
+}
+
+func TestFetchTimeoutReleasesTheCaller(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	clock := sturdyc.NewTestClock(time.Now())
+	c := sturdyc.New[string](100, 1, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithFetchTimeout(time.Second),
+		sturdyc.WithClock(clock),
+	)
+
+	cancelled := make(chan struct{})
+	blockingFetch := func(ctx context.Context) (string, error) {
+		<-ctx.Done()
+		close(cancelled)
+		select {}
+	}
+
+	errs := make(chan error)
+	go func() {
+		_, err := c.GetOrFetch(ctx, "1", blockingFetch)
+		errs <- err
+	}()
+
+	if err := advanceUntil(clock, errs); !errors.Is(err, sturdyc.ErrFetchTimeout) {
+		t.Errorf("expected ErrFetchTimeout, got %v", err)
+	}
+	<-cancelled
+	if c.NumKeysInflight() != 0 {
+		t.Errorf("expected no keys to be in flight, got %d", c.NumKeysInflight())
+	}
+}
+
+func TestFetchTimeoutAppliesToBatches(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	clock := sturdyc.NewTestClock(time.Now())
+	c := sturdyc.New[string](100, 1, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithFetchTimeout(time.Second),
+		sturdyc.WithClock(clock),
+	)
+
+	blockingFetch := func(ctx context.Context, _ []string) (map[string]string, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+
+	errs := make(chan error)
+	go func() {
+		_, err := c.GetOrFetchBatch(ctx, []string{"1", "2"}, c.BatchKeyFn("item"), blockingFetch)
+		errs <- err
+	}()
+
+	if err := advanceUntil(clock, errs); !errors.Is(err, sturdyc.ErrFetchTimeout) {
+		t.Errorf("expected ErrFetchTimeout, got %v", err)
+	}
+}
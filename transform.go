@@ -0,0 +1,56 @@
+package sturdyc
+
+import "context"
+
+// transform applies the transform of the cache to the value.
+func transform[V, T any](c *Client[T], key string, value V) (V, error) {
+	v, ok := any(value).(T)
+	if !ok {
+		// Values of the wrong type are reported by the type assertions of the fetch.
+		return value, nil
+	}
+	transformed, ok := any(c.transform(key, v)).(V)
+	if !ok {
+		return value, ErrInvalidType
+	}
+	return transformed, nil
+}
+
+// transformFetch wraps the fetchFn so that the transform of the cache is
+// applied to the values it returns before they are written to the cache.
+func transformFetch[V, T any](c *Client[T], key string, fetchFn FetchFn[V]) FetchFn[V] {
+	if c.transform == nil {
+		return fetchFn
+	}
+
+	return func(ctx context.Context) (V, error) {
+		response, err := fetchFn(ctx)
+		if err != nil {
+			return response, err
+		}
+		return transform(c, key, response)
+	}
+}
+
+// transformBatchFetch wraps the fetchFn so that the transform of the cache is
+// applied to the records it returns before they are written to the cache. The
+// records are either all transformed or none of them are returned, and the map
+// of the fetchFn is left as it was.
+func transformBatchFetch[V, T any](c *Client[T], keyFn KeyFn, fetchFn BatchFetchFn[V]) BatchFetchFn[V] {
+	if c.transform == nil {
+		return fetchFn
+	}
+
+	return func(ctx context.Context, ids []string) (map[string]V, error) {
+		response, err := fetchFn(ctx, ids)
+		transformedResponse := make(map[string]V, len(response))
+		for id, record := range response {
+			transformed, transformErr := transform(c, keyFn(id), record)
+			if transformErr != nil {
+				return map[string]V{}, transformErr
+			}
+			transformedResponse[id] = transformed
+		}
+		return transformedResponse, err
+	}
+}
@@ -0,0 +1,75 @@
+package sturdyc_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/viccon/sturdyc"
+)
+
+func TestTransformIsAppliedBeforeTheValuesAreCached(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	c := sturdyc.New[string](100, 1, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithTransform(func(_ string, value string) string {
+			return strings.TrimSpace(value)
+		}),
+	)
+
+	res, err := c.GetOrFetch(ctx, "key", func(context.Context) (string, error) { return "  value  ", nil })
+	if err != nil || res != "value" {
+		t.Fatalf("expected the transformed value to be returned, got %q: %v", res, err)
+	}
+	if cached, ok := c.Get("key"); !ok || cached != "value" {
+		t.Errorf("expected the transformed value to be cached, got %q", cached)
+	}
+
+	keyFn := c.BatchKeyFn("item")
+	batch, err := c.GetOrFetchBatch(ctx, []string{"1"}, keyFn, func(context.Context, []string) (map[string]string, error) {
+		return map[string]string{"1": " batch "}, nil
+	})
+	if err != nil || batch["1"] != "batch" {
+		t.Fatalf("expected the transformed record to be returned, got %v: %v", batch, err)
+	}
+	if cached, ok := c.Get(keyFn("1")); !ok || cached != "batch" {
+		t.Errorf("expected the transformed record to be cached, got %q", cached)
+	}
+}
+
+func TestBatchIsNotTransformedIfOneOfTheRecordsFails(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	c := sturdyc.New[any](100, 1, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithTransform(func(key string, value any) any {
+			if strings.HasSuffix(key, "2") {
+				return 2
+			}
+			return strings.ToUpper(value.(string))
+		}),
+	)
+
+	keyFn := c.BatchKeyFn("item")
+	fetched := map[string]string{"1": "a", "2": "b", "3": "c"}
+	batch, err := sturdyc.GetOrFetchBatch(ctx, c, []string{"1", "2", "3"}, keyFn, func(context.Context, []string) (map[string]string, error) {
+		return fetched, nil
+	})
+	if !errors.Is(err, sturdyc.ErrInvalidType) {
+		t.Fatalf("expected ErrInvalidType, got %v", err)
+	}
+	if len(batch) != 0 {
+		t.Errorf("expected none of the records to be returned, got %v", batch)
+	}
+	if fetched["1"] != "a" || fetched["3"] != "c" {
+		t.Errorf("expected the fetched records to be left as they were, got %v", fetched)
+	}
+	if c.Size() != 0 {
+		t.Errorf("expected none of the records to be cached, got %d", c.Size())
+	}
+}
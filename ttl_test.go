@@ -0,0 +1,176 @@
+package sturdyc_test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/viccon/sturdyc"
+)
+
+type token struct {
+	value     string
+	expiresAt time.Time
+	clock     *sturdyc.TestClock
+}
+
+func (t token) GetCacheTTL() time.Duration {
+	return t.expiresAt.Sub(t.clock.Now())
+}
+
+func TestValuesCanLimitTheirOwnTTL(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	clock := sturdyc.NewTestClock(time.Now())
+	c := sturdyc.New[token](100, 1, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithClock(clock),
+	)
+
+	res, err := c.GetOrFetch(ctx, "short", func(context.Context) (token, error) {
+		return token{value: "short", expiresAt: clock.Now().Add(time.Minute), clock: clock}, nil
+	})
+	if err != nil || res.value != "short" {
+		t.Fatalf("expected the token to be returned, got %v %v", res, err)
+	}
+
+	// The TTL of the cache is used as an upper bound.
+	c.Set("long", token{value: "long", expiresAt: clock.Now().Add(2 * time.Hour), clock: clock})
+
+	clock.Add(time.Minute + 1)
+	if _, ok := c.Get("short"); ok {
+		t.Error("expected the token to have expired")
+	}
+	if _, ok := c.Get("long"); !ok {
+		t.Error("expected the long lived token to still be cached")
+	}
+
+	clock.Add(time.Hour)
+	if _, ok := c.Get("long"); ok {
+		t.Error("expected the long lived token to have expired with the TTL of the cache")
+	}
+}
+
+func TestExpiredValuesAreNotCached(t *testing.T) {
+	t.Parallel()
+
+	clock := sturdyc.NewTestClock(time.Now())
+	c := sturdyc.New[token](100, 1, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithClock(clock),
+	)
+
+	c.Set("1", token{value: "valid", expiresAt: clock.Now().Add(time.Minute), clock: clock})
+	c.Set("1", token{value: "expired", expiresAt: clock.Now(), clock: clock})
+	if _, ok := c.Get("1"); ok {
+		t.Error("expected the expired token to replace the valid one without being cached")
+	}
+	if c.Size() != 0 {
+		t.Errorf("expected the cache to be empty, got %d", c.Size())
+	}
+}
+
+func TestMissingRecordsCanHaveTheirOwnTTL(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	clock := sturdyc.NewTestClock(time.Now())
+	c := sturdyc.New[string](100, 1, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithMissingRecordStorage(),
+		sturdyc.WithMissingRecordTTL(time.Minute),
+		sturdyc.WithClock(clock),
+	)
+
+	var fetches int
+	fetchFn := func(context.Context) (string, error) {
+		fetches++
+		return "", sturdyc.ErrNotFound
+	}
+	c.GetOrFetch(ctx, "short", fetchFn)
+	c.GetOrFetch(ctx, "shorter", fetchFn, sturdyc.CallMissingRecordTTL(time.Second))
+	c.Set("value", "value")
+
+	clock.Add(time.Second * 2)
+	c.GetOrFetch(ctx, "short", fetchFn)
+	c.GetOrFetch(ctx, "shorter", fetchFn)
+	if fetches != 3 {
+		t.Errorf("expected only the record with the call TTL to have expired, got %d fetches", fetches)
+	}
+
+	clock.Add(time.Minute)
+	c.GetOrFetch(ctx, "short", fetchFn)
+	if fetches != 4 {
+		t.Errorf("expected the missing record to have expired, got %d fetches", fetches)
+	}
+	if _, ok := c.Get("value"); !ok {
+		t.Error("expected the value to use the TTL of the cache")
+	}
+}
+
+func TestTTLJitterSpreadsTheExpiry(t *testing.T) {
+	t.Parallel()
+
+	ttl := time.Hour
+	clock := sturdyc.NewTestClock(time.Now())
+	c := sturdyc.New[string](1000, 1, ttl, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithTTLJitter(0.5),
+		sturdyc.WithClock(clock),
+	)
+
+	numKeys := 100
+	for i := 0; i < numKeys; i++ {
+		c.Set(strconv.Itoa(i), "value")
+	}
+
+	// No record should expire before the TTL, or after the TTL and the jitter.
+	clock.Add(ttl - 1)
+	if size := c.Size(); size != numKeys {
+		t.Fatalf("expected %d records, got %d", numKeys, size)
+	}
+
+	clock.Add(ttl / 4)
+	remaining := 0
+	for i := 0; i < numKeys; i++ {
+		if _, ok := c.Get(strconv.Itoa(i)); ok {
+			remaining++
+		}
+	}
+	if remaining == 0 || remaining == numKeys {
+		t.Errorf("expected the expiry of the records to be spread out, got %d of %d remaining", remaining, numKeys)
+	}
+
+	clock.Add(ttl / 4)
+	for i := 0; i < numKeys; i++ {
+		if _, ok := c.Get(strconv.Itoa(i)); ok {
+			t.Fatalf("expected key %d to have expired", i)
+		}
+	}
+}
+
+func TestMissingRecordsAreStoredWhenTheClockHasNotMoved(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	clock := sturdyc.NewTestClock(time.Now())
+	c := sturdyc.New[string](100, 1, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithMissingRecordStorage(),
+		sturdyc.WithClock(clock),
+	)
+
+	// The record is written at the same time as the refresh starts.
+	c.Set("1", "value")
+	err := c.Refresh(ctx, "1", func(context.Context) (string, error) {
+		return "", sturdyc.ErrNotFound
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := c.Get("1"); ok {
+		t.Error("expected the record to have been marked as missing")
+	}
+}
@@ -0,0 +1,57 @@
+package sturdyc
+
+import "context"
+
+// TypedView is a type-safe view of a namespace within a cache that stores
+// values of any type. It allows values of several types to share the
+// capacity, shards and background goroutines of a single client. The type
+// of each value is checked when it's read, and values of any other type are
+// treated as if they weren't in the cache.
+type TypedView[V any] struct {
+	namespace *Namespace[any]
+}
+
+// NewTypedView returns a view of the client that prefixes its keys with the
+// namespace. The options are used as the defaults for the writes and fetches
+// of the view. Two views that use the same namespace share the same keys.
+func NewTypedView[V any](c *Client[any], namespace string, opts ...CallOption) *TypedView[V] {
+	return &TypedView[V]{namespace: c.Namespace(namespace, opts...)}
+}
+
+// Namespace returns the namespace of the view, which can be used to clear,
+// scan and retrieve the statistics of the keys.
+func (v *TypedView[V]) Namespace() *Namespace[any] {
+	return v.namespace
+}
+
+// Get retrieves a single value. The value is reported as missing if it
+// was written with a different type.
+func (v *TypedView[V]) Get(key string) (V, bool) {
+	value, _ := v.namespace.client.Get(v.namespace.Key(key))
+	typed, ok := value.(V)
+	v.namespace.record(ok)
+	return typed, ok
+}
+
+// Set writes a single value. Returns true if it triggered an eviction.
+func (v *TypedView[V]) Set(key string, value V) bool {
+	return v.namespace.Set(key, value)
+}
+
+// Delete removes a single value.
+func (v *TypedView[V]) Delete(key string) {
+	v.namespace.Delete(key)
+}
+
+// GetOrFetch is the same as client.GetOrFetch, but it returns ErrInvalidType
+// if the value in the cache was written with a different type.
+func (v *TypedView[V]) GetOrFetch(ctx context.Context, key string, fetchFn FetchFn[V], opts ...CallOption) (V, error) {
+	return unwrap[V](namespaceGetOrFetch(ctx, v.namespace, key, fetchFn, opts))
+}
+
+// GetOrFetchBatch is the same as client.GetOrFetchBatch, but it returns
+// ErrInvalidType if any of the values in the cache were written with a
+// different type.
+func (v *TypedView[V]) GetOrFetchBatch(ctx context.Context, ids []string, keyFn KeyFn, fetchFn BatchFetchFn[V], opts ...CallOption) (map[string]V, error) {
+	return unwrapBatch[V](namespaceGetOrFetchBatch(ctx, v.namespace, ids, keyFn, fetchFn, opts))
+}
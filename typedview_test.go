@@ -0,0 +1,75 @@
+package sturdyc_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/viccon/sturdyc"
+)
+
+type typedViewUser struct {
+	Name string
+}
+
+func TestTypedViewsShareTheClient(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	c := sturdyc.New[any](1000, 10, time.Hour, 5, sturdyc.WithNoContinuousEvictions())
+	users := sturdyc.NewTypedView[typedViewUser](c, "users")
+	counts := sturdyc.NewTypedView[int](c, "counts")
+
+	users.Set("1", typedViewUser{Name: "alice"})
+	counts.Set("1", 42)
+	if user, ok := users.Get("1"); !ok || user.Name != "alice" {
+		t.Errorf("expected alice, got %+v", user)
+	}
+	if count, ok := counts.Get("1"); !ok || count != 42 {
+		t.Errorf("expected 42, got %d", count)
+	}
+	if c.Size() != 2 {
+		t.Errorf("expected both views to write to the same client, got size %d", c.Size())
+	}
+
+	count, err := counts.GetOrFetch(ctx, "2", func(context.Context) (int, error) { return 7, nil })
+	if err != nil || count != 7 {
+		t.Errorf("expected 7, got %d: %v", count, err)
+	}
+
+	batch, err := users.GetOrFetchBatch(ctx, []string{"1", "2"}, c.BatchKeyFn("user"), func(_ context.Context, ids []string) (map[string]typedViewUser, error) {
+		records := make(map[string]typedViewUser, len(ids))
+		for _, id := range ids {
+			records[id] = typedViewUser{Name: id}
+		}
+		return records, nil
+	})
+	if err != nil || len(batch) != 2 || batch["2"].Name != "2" {
+		t.Errorf("unexpected batch: %v, %v", batch, err)
+	}
+}
+
+func TestTypedViewChecksTheTypeOfTheValues(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	c := sturdyc.New[any](1000, 10, time.Hour, 5, sturdyc.WithNoContinuousEvictions())
+	strings := sturdyc.NewTypedView[string](c, "shared")
+	ints := sturdyc.NewTypedView[int](c, "shared")
+
+	strings.Set("1", "value")
+	if _, ok := ints.Get("1"); ok {
+		t.Error("expected a value of a different type to be reported as missing")
+	}
+
+	_, err := ints.GetOrFetch(ctx, "1", func(context.Context) (int, error) { return 1, nil })
+	if !errors.Is(err, sturdyc.ErrInvalidType) {
+		t.Errorf("expected ErrInvalidType, got %v", err)
+	}
+
+	// GetOrFetch found the value in the cache, even though it was of a different type.
+	if stats := ints.Namespace().Stats(); stats.Hits != 1 || stats.Misses != 1 {
+		t.Errorf("expected one hit and one miss, got %+v", stats)
+	}
+}
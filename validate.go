@@ -0,0 +1,75 @@
+package sturdyc
+
+import (
+	"context"
+	"fmt"
+	"maps"
+)
+
+// validate returns an error if the validator of the cache rejects the value.
+func (c *Client[T]) validate(key string, value any) error {
+	if c.validator == nil {
+		return nil
+	}
+	// Values of the wrong type are reported by the type assertions of the fetch.
+	v, ok := value.(T)
+	if !ok {
+		return nil
+	}
+	if err := c.validator(key, v); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidValue, err)
+	}
+	return nil
+}
+
+// validateFetch wraps the fetchFn so that the values it returns are rejected
+// if they don't pass the validator of the cache.
+func validateFetch[V, T any](c *Client[T], key string, fetchFn FetchFn[V]) FetchFn[V] {
+	if c.validator == nil {
+		return fetchFn
+	}
+
+	return func(ctx context.Context) (V, error) {
+		response, err := fetchFn(ctx)
+		if err != nil {
+			return response, err
+		}
+		if err := c.validate(key, response); err != nil {
+			var zero V
+			return zero, err
+		}
+		return response, nil
+	}
+}
+
+// validateBatchFetch wraps the fetchFn so that the records which don't pass
+// the validator of the cache are removed from the response, and reported as
+// failed IDs in a BatchError.
+func validateBatchFetch[V, T any](c *Client[T], keyFn KeyFn, fetchFn BatchFetchFn[V]) BatchFetchFn[V] {
+	if c.validator == nil {
+		return fetchFn
+	}
+
+	return func(ctx context.Context, ids []string) (map[string]V, error) {
+		response, err := fetchFn(ctx, ids)
+		idErrors := make(map[string]error)
+		for id, record := range response {
+			if validationErr := c.validate(keyFn(id), record); validationErr != nil {
+				idErrors[id] = validationErr
+				delete(response, id)
+			}
+		}
+		if len(idErrors) == 0 {
+			return response, err
+		}
+
+		if err == nil {
+			return response, &BatchError{Errors: idErrors}
+		}
+		if batchErr, ok := asBatchError(err); ok {
+			maps.Copy(idErrors, batchErr.Errors)
+			return response, &BatchError{Errors: idErrors}
+		}
+		return response, err
+	}
+}
@@ -0,0 +1,65 @@
+package sturdyc_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/viccon/sturdyc"
+)
+
+func rejectEmptyValues(_ string, value string) error {
+	if value == "" {
+		return errors.New("empty value")
+	}
+	return nil
+}
+
+func TestValidatorRejectsValuesBeforeTheyAreCached(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	c := sturdyc.New[string](100, 1, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithValidator(rejectEmptyValues),
+	)
+
+	_, err := c.GetOrFetch(ctx, "key", func(context.Context) (string, error) { return "", nil })
+	if !errors.Is(err, sturdyc.ErrInvalidValue) {
+		t.Fatalf("expected ErrInvalidValue, got %v", err)
+	}
+	if _, ok := c.Get("key"); ok {
+		t.Error("expected the invalid value to not be cached")
+	}
+
+	res, err := c.GetOrFetch(ctx, "key", func(context.Context) (string, error) { return "value", nil })
+	if err != nil || res != "value" {
+		t.Errorf("expected the valid value to be returned, got %q: %v", res, err)
+	}
+}
+
+func TestValidatorRejectsIndividualRecordsOfABatch(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	c := sturdyc.New[string](100, 1, time.Hour, 10,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithValidator(rejectEmptyValues),
+	)
+	keyFn := c.BatchKeyFn("item")
+
+	res, err := c.GetOrFetchBatch(ctx, []string{"1", "2"}, keyFn, func(context.Context, []string) (map[string]string, error) {
+		return map[string]string{"1": "value", "2": ""}, nil
+	})
+	var batchErr *sturdyc.BatchError
+	if !errors.As(err, &batchErr) || !errors.Is(batchErr.Errors["2"], sturdyc.ErrInvalidValue) {
+		t.Fatalf("expected the invalid ID to be reported, got %v", err)
+	}
+	if len(res) != 1 || res["1"] != "value" {
+		t.Errorf("expected the valid record to be returned, got %v", res)
+	}
+	if _, ok := c.Get(keyFn("2")); ok {
+		t.Error("expected the invalid record to not be cached")
+	}
+}
@@ -0,0 +1,87 @@
+package sturdyc
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// defaultWarmBatchSize is the number of IDs that Warm fetches at once
+// if no other batch size has been given.
+const defaultWarmBatchSize = 100
+
+// WarmOption configures a call to Warm.
+type WarmOption func(*warmOptions)
+
+type warmOptions struct {
+	batchSize int
+	interval  time.Duration
+	progress  func(warmed, total int)
+	callOpts  []CallOption
+}
+
+// WarmBatchSize sets the number of IDs that are fetched at once.
+func WarmBatchSize(size int) WarmOption {
+	return func(o *warmOptions) {
+		if size > 0 {
+			o.batchSize = size
+		}
+	}
+}
+
+// WarmInterval limits the rate at which the underlying data source is called
+// by waiting for the interval between the batches.
+func WarmInterval(interval time.Duration) WarmOption {
+	return func(o *warmOptions) {
+		o.interval = max(interval, 0)
+	}
+}
+
+// WarmProgress registers a function that is called after each batch with the
+// number of IDs that have been processed so far, and the total number of IDs.
+func WarmProgress(progress func(warmed, total int)) WarmOption {
+	return func(o *warmOptions) {
+		o.progress = progress
+	}
+}
+
+// WarmCallOptions sets the options that are passed along to GetOrFetchBatch.
+func WarmCallOptions(opts ...CallOption) WarmOption {
+	return func(o *warmOptions) {
+		o.callOpts = opts
+	}
+}
+
+// Warm populates the cache with the IDs before it starts serving traffic. The
+// IDs are fetched in batches using GetOrFetchBatch, which means that IDs that
+// are already cached are skipped. A failing batch doesn't stop the rest of the
+// IDs from being fetched, and the errors of the batches that failed are joined
+// together and returned once every batch has been attempted. Warm returns
+// early with the error of the context if it's done.
+func (c *Client[T]) Warm(ctx context.Context, ids []string, keyFn KeyFn, fetchFn BatchFetchFn[T], opts ...WarmOption) error {
+	options := warmOptions{batchSize: defaultWarmBatchSize}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	var errs []error
+	for start := 0; start < len(ids); start += options.batchSize {
+		if start > 0 && options.interval > 0 {
+			if err := c.wait(ctx, options.interval); err != nil {
+				return err
+			}
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		end := min(start+options.batchSize, len(ids))
+		if _, err := c.GetOrFetchBatch(ctx, ids[start:end], keyFn, fetchFn, options.callOpts...); err != nil {
+			errs = append(errs, err)
+		}
+		if options.progress != nil {
+			options.progress(end, len(ids))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,112 @@
+package sturdyc_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/viccon/sturdyc"
+)
+
+func warmIDs(n int) []string {
+	ids := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		ids = append(ids, strconv.Itoa(i))
+	}
+	return ids
+}
+
+func TestWarmFetchesTheIDsInBatches(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	clock := sturdyc.NewTestClock(time.Now())
+	c := sturdyc.New[string](1000, 10, time.Hour, 30,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithClock(clock),
+	)
+
+	var fetches atomic.Int32
+	fetchFn := func(_ context.Context, ids []string) (map[string]string, error) {
+		fetches.Add(1)
+		response := make(map[string]string, len(ids))
+		for _, id := range ids {
+			response[id] = "value" + id
+		}
+		return response, nil
+	}
+
+	var progress []int
+	done := make(chan error)
+	go func() {
+		done <- c.Warm(ctx, warmIDs(25), c.BatchKeyFn("item"), fetchFn,
+			sturdyc.WarmBatchSize(10),
+			sturdyc.WarmInterval(time.Minute),
+			sturdyc.WarmProgress(func(warmed, total int) {
+				if total != 25 {
+					t.Errorf("expected a total of 25, got %d", total)
+				}
+				progress = append(progress, warmed)
+			}),
+		)
+	}()
+
+	if err := advanceUntil(clock, done); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if fetches.Load() != 3 {
+		t.Errorf("expected 3 fetches, got %d", fetches.Load())
+	}
+	if !slices.Equal(progress, []int{10, 20, 25}) {
+		t.Errorf("unexpected progress: %v", progress)
+	}
+	if c.Size() != 25 {
+		t.Errorf("expected 25 records to have been cached, got %d", c.Size())
+	}
+}
+
+func TestWarmContinuesAfterAFailedBatch(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	c := sturdyc.New[string](1000, 10, time.Hour, 30, sturdyc.WithNoContinuousEvictions())
+	errBatch := errors.New("batch failed")
+	fetchFn := func(_ context.Context, ids []string) (map[string]string, error) {
+		if ids[0] == "0" {
+			return nil, errBatch
+		}
+		response := make(map[string]string, len(ids))
+		for _, id := range ids {
+			response[id] = "value" + id
+		}
+		return response, nil
+	}
+
+	err := c.Warm(ctx, warmIDs(20), c.BatchKeyFn("item"), fetchFn, sturdyc.WarmBatchSize(10))
+	if !errors.Is(err, errBatch) {
+		t.Errorf("expected the error of the failed batch, got %v", err)
+	}
+	if c.Size() != 10 {
+		t.Errorf("expected the second batch to have been cached, got %d records", c.Size())
+	}
+}
+
+func TestWarmStopsWhenTheContextIsDone(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	c := sturdyc.New[string](1000, 10, time.Hour, 30, sturdyc.WithNoContinuousEvictions())
+	fetchFn := func(_ context.Context, _ []string) (map[string]string, error) {
+		t.Error("expected no fetches")
+		return nil, nil
+	}
+
+	if err := c.Warm(ctx, warmIDs(5), c.BatchKeyFn("item"), fetchFn); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
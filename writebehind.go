@@ -0,0 +1,360 @@
+package sturdyc
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	xxhash "github.com/cespare/xxhash/v2"
+)
+
+// writeBehindMaxBatchSize is the maximum number of queued operations
+// that a worker merges into a single flush.
+const writeBehindMaxBatchSize = 100
+
+// writeBehindOperation is a write, or deletion, that has been queued for the
+// distributed storage. The ID is assigned by the WriteBehindQueue, if one is used.
+type writeBehindOperation struct {
+	id      string
+	key     string
+	value   []byte
+	deleted bool
+}
+
+// WriteBehindOperation is a write, or deletion, that has been pushed to the
+// WriteBehindQueue.
+type WriteBehindOperation struct {
+	// ID is the ID that was returned by Push. It's only set by Unacked.
+	ID      string
+	Key     string
+	Value   []byte
+	Deleted bool
+}
+
+// WriteBehindQueue can be passed to WithDistributedWriteBehindQueue in order
+// to persist the operations of the write-behind queue. The operations are
+// pushed to it before they are queued in memory, and acknowledged once they
+// have been flushed. The operations that were never acknowledged, e.g. because
+// the process crashed, are flushed by the next client that uses the queue.
+type WriteBehindQueue interface {
+	// Push persists the operation, and returns an ID that is
+	// passed to Ack once the operation has been flushed.
+	Push(ctx context.Context, operation WriteBehindOperation) (string, error)
+	// Ack removes the operations from the queue.
+	Ack(ctx context.Context, ids []string) error
+	// Unacked returns the operations that have been pushed, but not
+	// acknowledged, in the order they were pushed.
+	Unacked(ctx context.Context) ([]WriteBehindOperation, error)
+}
+
+// writeBehindStorage queues the writes and deletions that the cache performs
+// against the distributed storage, and lets a pool of workers flush them in
+// batches. Every worker has a queue of its own, and the operations are
+// assigned to the queues by the hash of their key. That way, the operations
+// for a key are flushed in the order they were made, and a write can't be
+// flushed after a later deletion of the same key by another worker. Reads go
+// straight to the distributed storage, which means that they don't see the
+// operations that are still in the queues.
+type writeBehindStorage struct {
+	DistributedStorageWithDeletions
+	*Config
+	queues   []chan writeBehindOperation
+	overflow OverflowPolicy
+	durable  WriteBehindQueue
+
+	mu      sync.Mutex
+	pending int
+	drained []chan struct{}
+}
+
+func newWriteBehindStorage(storage DistributedStorageWithDeletions, cfg *Config) *writeBehindStorage {
+	// The size of the queue is split between the workers.
+	queueSize := (cfg.writeBehindQueueSize + cfg.writeBehindWorkers - 1) / cfg.writeBehindWorkers
+	queues := make([]chan writeBehindOperation, cfg.writeBehindWorkers)
+	for i := range queues {
+		queues[i] = make(chan writeBehindOperation, queueSize)
+	}
+	return &writeBehindStorage{
+		DistributedStorageWithDeletions: storage,
+		Config:                          cfg,
+		queues:                          queues,
+		overflow:                        cfg.writeBehindOverflow,
+		durable:                         cfg.writeBehindQueue,
+	}
+}
+
+// start starts one worker per queue, and queues the operations that were
+// never acknowledged by the durable queue. The workers are never going to
+// exit, as the client can still write to the storage once it's closed.
+func (s *writeBehindStorage) start() {
+	for _, queue := range s.queues {
+		go func(queue chan writeBehindOperation) {
+			for operation := range queue {
+				operations := []writeBehindOperation{operation}
+				operations = append(operations, dequeue(queue, writeBehindMaxBatchSize-1)...)
+				s.flush(operations)
+				s.ack(operations)
+				s.done(len(operations))
+			}
+		}(queue)
+	}
+	s.replay()
+}
+
+// replay queues the operations that were never acknowledged by the durable
+// queue. They have already been persisted, so they are never dropped.
+func (s *writeBehindStorage) replay() {
+	if s.durable == nil {
+		return
+	}
+
+	unacked, err := s.durable.Unacked(context.Background())
+	if err != nil {
+		s.logger(LogDistributed).Error("sturdyc: error reading the write-behind queue", "error", err)
+		return
+	}
+	for _, operation := range unacked {
+		s.dispatch(writeBehindOperation{
+			id:      operation.ID,
+			key:     operation.Key,
+			value:   operation.Value,
+			deleted: operation.Deleted,
+		}, true)
+	}
+}
+
+// push persists the operation in the durable queue. An operation that can't be
+// persisted is still queued in memory, but it's lost if the process crashes.
+func (s *writeBehindStorage) push(ctx context.Context, operation writeBehindOperation) writeBehindOperation {
+	if s.durable == nil {
+		return operation
+	}
+
+	id, err := s.durable.Push(ctx, WriteBehindOperation{Key: operation.key, Value: operation.value, Deleted: operation.deleted})
+	if err != nil {
+		s.logger(LogDistributed).Error("sturdyc: error persisting write-behind operation", "key", operation.key, "error", err)
+		return operation
+	}
+	operation.id = id
+	return operation
+}
+
+// ack removes the operations that have been flushed, or dropped, from the durable queue.
+func (s *writeBehindStorage) ack(operations []writeBehindOperation) {
+	if s.durable == nil {
+		return
+	}
+
+	ids := make([]string, 0, len(operations))
+	for _, operation := range operations {
+		if operation.id != "" {
+			ids = append(ids, operation.id)
+		}
+	}
+	if len(ids) == 0 {
+		return
+	}
+	if err := s.durable.Ack(context.Background(), ids); err != nil {
+		s.logger(LogDistributed).Error("sturdyc: error acknowledging write-behind operations", "error", err)
+	}
+}
+
+// queue returns the queue of the worker that flushes the operations for the key.
+func (s *writeBehindStorage) queue(key string) chan writeBehindOperation {
+	return s.queues[xxhash.Sum64String(key)%uint64(len(s.queues))]
+}
+
+// dequeue takes up to n operations from the queue without blocking.
+func dequeue(queue chan writeBehindOperation, n int) []writeBehindOperation {
+	operations := make([]writeBehindOperation, 0, n)
+	for len(operations) < n {
+		select {
+		case operation := <-queue:
+			operations = append(operations, operation)
+		default:
+			return operations
+		}
+	}
+	return operations
+}
+
+// enqueue persists the operations, and adds them to
+// the queue according to the overflow policy.
+func (s *writeBehindStorage) enqueue(ctx context.Context, operations ...writeBehindOperation) {
+	for _, operation := range operations {
+		s.dispatch(s.push(ctx, operation), s.overflow == OverflowBlock)
+	}
+}
+
+// dispatch adds the operation to the queue of its worker. If block is false,
+// and the queue is full, the operation is dropped.
+func (s *writeBehindStorage) dispatch(operation writeBehindOperation, block bool) {
+	s.mu.Lock()
+	s.pending++
+	s.mu.Unlock()
+
+	queue := s.queue(operation.key)
+	if block {
+		queue <- operation
+		return
+	}
+
+	select {
+	case queue <- operation:
+	default:
+		s.ack([]writeBehindOperation{operation})
+		s.done(1)
+	}
+}
+
+// done marks the operations as flushed, and releases the callers
+// that are waiting for the queue to drain once it's empty.
+func (s *writeBehindStorage) done(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pending -= n
+	if s.pending > 0 {
+		return
+	}
+	for _, drained := range s.drained {
+		close(drained)
+	}
+	s.drained = nil
+}
+
+// drain blocks until every queued operation has been flushed, or the context is done.
+func (s *writeBehindStorage) drain(ctx context.Context) error {
+	s.mu.Lock()
+	if s.pending == 0 {
+		s.mu.Unlock()
+		return nil
+	}
+	drained := make(chan struct{})
+	s.drained = append(s.drained, drained)
+	s.mu.Unlock()
+
+	select {
+	case <-drained:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// flush merges the operations, where later operations for the same key
+// take precedence, and writes them to the distributed storage.
+func (s *writeBehindStorage) flush(operations []writeBehindOperation) {
+	writes := make(map[string][]byte)
+	deletes := make(map[string]struct{})
+	for _, operation := range operations {
+		if operation.deleted {
+			delete(writes, operation.key)
+			deletes[operation.key] = struct{}{}
+			continue
+		}
+		delete(deletes, operation.key)
+		writes[operation.key] = operation.value
+	}
+
+	if len(deletes) > 0 {
+		keys := make([]string, 0, len(deletes))
+		for key := range deletes {
+			keys = append(keys, key)
+		}
+		s.retry(func(ctx context.Context) error {
+			return deleteBatchErr(ctx, s.DistributedStorageWithDeletions, keys)
+		})
+	}
+
+	if len(writes) > 0 {
+		s.retry(func(ctx context.Context) error {
+			return setBatchErr(ctx, s.DistributedStorageWithDeletions, writes)
+		})
+	}
+}
+
+// retry performs the write according to the retry policy of the write-behind queue.
+func (s *writeBehindStorage) retry(write func(ctx context.Context) error) {
+	err := s.attempt(write)
+	if s.writeBehindRetryPolicy == nil {
+		return
+	}
+
+	for attempt := 1; err != nil && attempt < s.writeBehindRetryPolicy.MaxAttempts(); attempt++ {
+		if !s.writeBehindRetryPolicy.Retryable(err) {
+			break
+		}
+		if waitErr := s.wait(context.Background(), s.writeBehindRetryPolicy.Backoff(attempt)); waitErr != nil {
+			break
+		}
+		err = s.attempt(write)
+	}
+}
+
+// attempt performs the write, and reports whether it failed, timed out, or panicked.
+func (s *writeBehindStorage) attempt(write func(ctx context.Context) error) (err error) {
+	ctx, cancel := context.Background(), context.CancelFunc(func() {})
+	if s.writeBehindTimeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, s.writeBehindTimeout)
+	}
+	defer cancel()
+
+	defer func() {
+		if r := recover(); r != nil {
+			s.logger(LogDistributed).Error("sturdyc: panic recovered", "panic", r)
+			err = fmt.Errorf("%w: %v", ErrDistributedWriteFailed, r)
+		}
+	}()
+
+	if writeErr := write(ctx); writeErr != nil {
+		return fmt.Errorf("%w: %w", ErrDistributedWriteFailed, writeErr)
+	}
+	if ctx.Err() != nil {
+		return fmt.Errorf("%w: %w", ErrDistributedWriteFailed, ctx.Err())
+	}
+	return nil
+}
+
+func (s *writeBehindStorage) Set(ctx context.Context, key string, value []byte) {
+	s.enqueue(ctx, writeBehindOperation{key: key, value: value})
+}
+
+func (s *writeBehindStorage) SetBatch(ctx context.Context, records map[string][]byte) {
+	operations := make([]writeBehindOperation, 0, len(records))
+	for key, value := range records {
+		operations = append(operations, writeBehindOperation{key: key, value: value})
+	}
+	s.enqueue(ctx, operations...)
+}
+
+func (s *writeBehindStorage) Delete(ctx context.Context, key string) {
+	s.enqueue(ctx, writeBehindOperation{key: key, deleted: true})
+}
+
+func (s *writeBehindStorage) DeleteBatch(ctx context.Context, keys []string) {
+	operations := make([]writeBehindOperation, 0, len(keys))
+	for _, key := range keys {
+		operations = append(operations, writeBehindOperation{key: key, deleted: true})
+	}
+	s.enqueue(ctx, operations...)
+}
+
+// Drain blocks until every write that has been queued for the distributed
+// storage by WithDistributedWriteBehind has been flushed, or until the context
+// is done. The refresh buffers are flushed first, and the background work is
+// awaited, so that the records they refresh are written as well. It should be
+// called before the application shuts down.
+func (c *Client[T]) Drain(ctx context.Context) error {
+	c.FlushRefreshBuffers()
+	if c.writeBehind == nil {
+		return nil
+	}
+
+	// The flushed refreshes are written by goroutines that may not have
+	// queued their writes yet, so we have to wait for them to return.
+	err := c.waitUntil(ctx, func() bool { return c.pendingWork.Load() == 0 })
+	if err != nil {
+		return err
+	}
+	return c.writeBehind.drain(ctx)
+}
@@ -0,0 +1,379 @@
+package sturdyc_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/viccon/sturdyc"
+)
+
+// flakyStorage panics for the first failures calls to SetBatch.
+type flakyStorage struct {
+	*mockStorage
+	failures atomic.Int32
+	attempts atomic.Int32
+}
+
+func (s *flakyStorage) SetBatch(ctx context.Context, records map[string][]byte) {
+	s.attempts.Add(1)
+	if s.failures.Add(-1) >= 0 {
+		panic("storage unavailable")
+	}
+	s.mockStorage.SetBatch(ctx, records)
+}
+
+// failingStorage returns an error from the first failures calls to SetBatchErr.
+type failingStorage struct {
+	*mockStorage
+	failures atomic.Int32
+	attempts atomic.Int32
+}
+
+func (s *failingStorage) SetBatchErr(ctx context.Context, records map[string][]byte) error {
+	s.attempts.Add(1)
+	if s.failures.Add(-1) >= 0 {
+		return errors.New("storage unavailable")
+	}
+	s.mockStorage.SetBatch(ctx, records)
+	return nil
+}
+
+func (s *failingStorage) DeleteBatchErr(ctx context.Context, keys []string) error {
+	s.mockStorage.DeleteBatch(ctx, keys)
+	return nil
+}
+
+// blockingStorage blocks every call to SetBatch until it's released.
+type blockingStorage struct {
+	*mockStorage
+	release chan struct{}
+}
+
+func (s *blockingStorage) SetBatch(ctx context.Context, records map[string][]byte) {
+	<-s.release
+	s.mockStorage.SetBatch(ctx, records)
+}
+
+// slowStorage delays every call to SetBatch.
+type slowStorage struct {
+	*mockStorage
+}
+
+func (s *slowStorage) SetBatch(ctx context.Context, records map[string][]byte) {
+	time.Sleep(10 * time.Millisecond)
+	s.mockStorage.SetBatch(ctx, records)
+}
+
+// memoryWriteBehindQueue is a WriteBehindQueue that keeps the operations in memory.
+type memoryWriteBehindQueue struct {
+	sync.Mutex
+	nextID     int
+	operations []sturdyc.WriteBehindOperation
+}
+
+func (q *memoryWriteBehindQueue) Push(_ context.Context, operation sturdyc.WriteBehindOperation) (string, error) {
+	q.Lock()
+	defer q.Unlock()
+	q.nextID++
+	operation.ID = strconv.Itoa(q.nextID)
+	q.operations = append(q.operations, operation)
+	return operation.ID, nil
+}
+
+func (q *memoryWriteBehindQueue) Ack(_ context.Context, ids []string) error {
+	q.Lock()
+	defer q.Unlock()
+	acked := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		acked[id] = true
+	}
+	operations := q.operations[:0]
+	for _, operation := range q.operations {
+		if !acked[operation.ID] {
+			operations = append(operations, operation)
+		}
+	}
+	q.operations = operations
+	return nil
+}
+
+func (q *memoryWriteBehindQueue) Unacked(_ context.Context) ([]sturdyc.WriteBehindOperation, error) {
+	q.Lock()
+	defer q.Unlock()
+	return append([]sturdyc.WriteBehindOperation(nil), q.operations...), nil
+}
+
+func (q *memoryWriteBehindQueue) size() int {
+	q.Lock()
+	defer q.Unlock()
+	return len(q.operations)
+}
+
+func TestDistributedWriteBehindIsFlushedByDrain(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	distributedStorage := &mockStorage{}
+	c := sturdyc.New[string](1000, 10, time.Hour, 30,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithDistributedStorageEarlyRefreshes(distributedStorage, time.Hour),
+		sturdyc.WithDistributedWriteThrough(),
+		sturdyc.WithDistributedWriteBehind(2, 100, sturdyc.OverflowBlock),
+	)
+
+	keyFn := c.BatchKeyFn("item")
+	c.SetManyKeyFn(map[string]string{"1": "value1", "2": "value2", "3": "value3"}, keyFn)
+	c.Set("4", "value4")
+	c.Delete("4")
+
+	if err := c.Drain(ctx); err != nil {
+		t.Fatalf("expected the queue to be drained, got %v", err)
+	}
+	distributedStorage.assertRecords(t, []string{"1", "2", "3"}, keyFn)
+	if distributedStorage.size() != 3 {
+		t.Errorf("expected the deletion to have been flushed, got %d records", distributedStorage.size())
+	}
+}
+
+func TestDistributedWriteBehindRetriesFailedBatches(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	distributedStorage := &flakyStorage{mockStorage: &mockStorage{}}
+	distributedStorage.failures.Store(2)
+	c := sturdyc.New[string](1000, 10, time.Hour, 30,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithLog(&sturdyc.NoopLogger{}),
+		sturdyc.WithDistributedStorage(distributedStorage),
+		sturdyc.WithDistributedWriteThrough(),
+		sturdyc.WithDistributedWriteBehind(1, 100, sturdyc.OverflowBlock),
+		sturdyc.WithDistributedWriteBehindRetries(time.Second, sturdyc.NewRetryPolicy(3, time.Millisecond, time.Millisecond, nil)),
+	)
+
+	c.SetMany(map[string]string{"1": "value1", "2": "value2"})
+	if err := c.Drain(ctx); err != nil {
+		t.Fatalf("expected the queue to be drained, got %v", err)
+	}
+	if attempts := distributedStorage.attempts.Load(); attempts != 3 {
+		t.Errorf("expected 3 attempts, got %d", attempts)
+	}
+	distributedStorage.assertRecord(t, "1")
+	distributedStorage.assertRecord(t, "2")
+}
+
+func TestDistributedWriteBehindRetriesBatchesThatReturnAnError(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	distributedStorage := &failingStorage{mockStorage: &mockStorage{}}
+	distributedStorage.failures.Store(2)
+	c := sturdyc.New[string](1000, 10, time.Hour, 30,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithDistributedStorage(distributedStorage),
+		sturdyc.WithDistributedKeyPrefix("service"),
+		sturdyc.WithDistributedWriteThrough(),
+		sturdyc.WithDistributedWriteBehind(1, 100, sturdyc.OverflowBlock),
+		sturdyc.WithDistributedWriteBehindRetries(time.Second, sturdyc.NewRetryPolicy(3, time.Millisecond, time.Millisecond, nil)),
+	)
+
+	c.SetMany(map[string]string{"1": "value1", "2": "value2"})
+	if err := c.Drain(ctx); err != nil {
+		t.Fatalf("expected the queue to be drained, got %v", err)
+	}
+	if attempts := distributedStorage.attempts.Load(); attempts != 3 {
+		t.Errorf("expected 3 attempts, got %d", attempts)
+	}
+	distributedStorage.assertRecord(t, "service1")
+	distributedStorage.assertRecord(t, "service2")
+}
+
+func TestDistributedWriteBehindDropsWritesWhenTheQueueIsFull(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	distributedStorage := &blockingStorage{mockStorage: &mockStorage{}, release: make(chan struct{})}
+	c := sturdyc.New[string](1000, 10, time.Hour, 30,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithDistributedStorage(distributedStorage),
+		sturdyc.WithDistributedWriteThrough(),
+		sturdyc.WithDistributedWriteBehind(1, 1, sturdyc.OverflowDrop),
+	)
+
+	// The first write occupies the worker, and the second one fills the queue.
+	c.Set("1", "value1")
+	time.Sleep(10 * time.Millisecond)
+	c.Set("2", "value2")
+	c.Set("3", "value3")
+
+	close(distributedStorage.release)
+	if err := c.Drain(ctx); err != nil {
+		t.Fatalf("expected the queue to be drained, got %v", err)
+	}
+	distributedStorage.assertRecord(t, "1")
+	distributedStorage.assertRecord(t, "2")
+	if distributedStorage.size() != 2 {
+		t.Errorf("expected the last write to have been dropped, got %d records", distributedStorage.size())
+	}
+}
+
+func TestDrainReturnsWhenTheContextIsDone(t *testing.T) {
+	t.Parallel()
+
+	distributedStorage := &blockingStorage{mockStorage: &mockStorage{}, release: make(chan struct{})}
+	defer close(distributedStorage.release)
+	c := sturdyc.New[string](1000, 10, time.Hour, 30,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithDistributedStorage(distributedStorage),
+		sturdyc.WithDistributedWriteThrough(),
+		sturdyc.WithDistributedWriteBehind(1, 10, sturdyc.OverflowBlock),
+	)
+
+	c.Set("1", "value1")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := c.Drain(ctx); err == nil {
+		t.Error("expected an error when the queue couldn't be drained in time")
+	}
+}
+
+func TestDistributedWriteBehindFlushesTheOperationsForAKeyInOrder(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	distributedStorage := &slowStorage{mockStorage: &mockStorage{}}
+	c := sturdyc.New[string](1000, 10, time.Hour, 30,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithDistributedStorageEarlyRefreshes(distributedStorage, time.Hour),
+		sturdyc.WithDistributedWriteThrough(),
+		sturdyc.WithDistributedWriteBehind(8, 1000, sturdyc.OverflowBlock),
+	)
+
+	// The writes are slower than the deletions, which would let a deletion
+	// overtake an earlier write if they were flushed by different workers.
+	for i := 0; i < 5; i++ {
+		key := strconv.Itoa(i)
+		c.Set(key, "value")
+		time.Sleep(time.Millisecond)
+		c.Delete(key)
+	}
+
+	if err := c.Drain(ctx); err != nil {
+		t.Fatalf("expected the queue to be drained, got %v", err)
+	}
+	if distributedStorage.size() != 0 {
+		t.Errorf("expected every key to have been deleted, got %d records", distributedStorage.size())
+	}
+}
+
+func TestDistributedWriteBehindQueueIsAcknowledgedOnceFlushed(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	distributedStorage := &blockingStorage{mockStorage: &mockStorage{}, release: make(chan struct{})}
+	queue := &memoryWriteBehindQueue{}
+	c := sturdyc.New[string](1000, 10, time.Hour, 30,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithDistributedStorage(distributedStorage),
+		sturdyc.WithDistributedWriteThrough(),
+		sturdyc.WithDistributedWriteBehind(1, 100, sturdyc.OverflowBlock),
+		sturdyc.WithDistributedWriteBehindQueue(queue),
+	)
+
+	c.Set("1", "value1")
+	c.Set("2", "value2")
+	if queue.size() != 2 {
+		t.Errorf("expected the writes to have been persisted, got %d operations", queue.size())
+	}
+
+	close(distributedStorage.release)
+	if err := c.Drain(ctx); err != nil {
+		t.Fatalf("expected the queue to be drained, got %v", err)
+	}
+	if queue.size() != 0 {
+		t.Errorf("expected the writes to have been acknowledged, got %d operations", queue.size())
+	}
+	distributedStorage.assertRecord(t, "1")
+	distributedStorage.assertRecord(t, "2")
+}
+
+func TestDistributedWriteBehindQueueIsReplayedByTheNextClient(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	distributedStorage := &mockStorage{}
+	queue := &memoryWriteBehindQueue{}
+	// The operations were persisted by a client that crashed before they were flushed.
+	queue.Push(ctx, sturdyc.WriteBehindOperation{Key: "1", Value: []byte("value1")})
+	queue.Push(ctx, sturdyc.WriteBehindOperation{Key: "2", Value: []byte("value2")})
+	queue.Push(ctx, sturdyc.WriteBehindOperation{Key: "2", Deleted: true})
+
+	c := sturdyc.New[string](1000, 10, time.Hour, 30,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithDistributedStorageEarlyRefreshes(distributedStorage, time.Hour),
+		sturdyc.WithDistributedWriteBehind(4, 100, sturdyc.OverflowDrop),
+		sturdyc.WithDistributedWriteBehindQueue(queue),
+	)
+
+	if err := c.Drain(ctx); err != nil {
+		t.Fatalf("expected the queue to be drained, got %v", err)
+	}
+	if queue.size() != 0 {
+		t.Errorf("expected the replayed operations to have been acknowledged, got %d operations", queue.size())
+	}
+	distributedStorage.assertRecord(t, "1")
+	if distributedStorage.size() != 1 {
+		t.Errorf("expected the deletion to have been replayed, got %d records", distributedStorage.size())
+	}
+}
+
+func TestDrainWaitsForTheRefreshesThatWereFlushedFromTheBuffers(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	refreshDelay := time.Minute
+	clock := sturdyc.NewTestClock(time.Now())
+	distributedStorage := &mockStorage{}
+	c := sturdyc.New[string](1000, 10, time.Hour, 30,
+		sturdyc.WithNoContinuousEvictions(),
+		sturdyc.WithEarlyRefreshes(refreshDelay, refreshDelay, time.Millisecond*10),
+		sturdyc.WithRefreshCoalescing(10, time.Hour),
+		sturdyc.WithClock(clock),
+		sturdyc.WithDistributedStorageEarlyRefreshes(distributedStorage, time.Second),
+		sturdyc.WithDistributedWriteBehind(1, 100, sturdyc.OverflowBlock),
+	)
+
+	var calls atomic.Int32
+	fetchFn := func(_ context.Context, ids []string) (map[string]string, error) {
+		value := "value"
+		if calls.Add(1) > 1 {
+			// The refresh is slower than the flush of the buffers.
+			time.Sleep(20 * time.Millisecond)
+			value = "refreshed"
+		}
+		response := make(map[string]string, len(ids))
+		for _, id := range ids {
+			response[id] = value
+		}
+		return response, nil
+	}
+
+	keyFn := c.BatchKeyFn("item")
+	sturdyc.GetOrFetchBatch(ctx, c, []string{"1"}, keyFn, fetchFn)
+	clock.Add(refreshDelay + time.Second)
+	sturdyc.GetOrFetchBatch(ctx, c, []string{"1"}, keyFn, fetchFn)
+	time.Sleep(10 * time.Millisecond)
+
+	if err := c.Drain(ctx); err != nil {
+		t.Fatalf("expected the queue to be drained, got %v", err)
+	}
+	record, ok := distributedStorage.Get(ctx, keyFn("1"))
+	if !ok || !bytes.Contains(record, []byte("refreshed")) {
+		t.Errorf("expected the refreshed record to have been written, got %s", record)
+	}
+}